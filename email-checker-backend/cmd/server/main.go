@@ -1,57 +1,146 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"email-intelligence/internal/config"
 	"email-intelligence/internal/engine"
 	"email-intelligence/internal/handlers"
+	"email-intelligence/internal/jobs"
+	"email-intelligence/internal/logging"
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/store"
+	"email-intelligence/internal/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// shutdownTimeout is how long in-flight requests get to finish after SIGTERM
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	logging.Setup(cfg.LogLevel)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("❌ Invalid configuration: %v", err)
+	}
+	
+	// Tracing is a no-op unless an OTLP endpoint is configured
+	shutdownTracing := tracing.Init(tracing.Options{
+		Endpoint:    cfg.OTLPEndpoint,
+		Headers:     cfg.OTLPHeaders,
+		ServiceName: cfg.OTelServiceName,
+	})
 	
 	// Initialize Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	
 	// Add middleware
-	router.Use(gin.Logger())
+	router.Use(logging.Middleware())
 	router.Use(gin.Recovery())
+	router.Use(tracing.Middleware())
 	
 	// CORS configuration
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "X-Rate-Limit", "X-Processing-Time"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Caller-ID", "X-API-Key", "If-None-Match", "traceparent", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Rate-Limit-Limit", "X-Rate-Limit-Remaining", "X-Rate-Limit-Reset", "Retry-After", "X-Processing-Time", "ETag", "traceparent", "X-Request-ID"},
 		AllowCredentials: false,
 		MaxAge:           86400,
 	}))
 	
 	// Initialize engine and handlers
 	eng := engine.New(cfg)
-	h := handlers.New(eng)
+	analyze := func(ctx context.Context, email string, deepAnalysis bool) (*models.EmailIntelligence, error) {
+		return eng.AnalyzeEmail(ctx, email, engine.Options{DeepAnalysis: deepAnalysis, RetryDeferred: true})
+	}
+	
+	// Result storage is optional; without a database results are not persisted
+	var resultStore store.ResultStore = store.NopStore{}
+	var pg *store.PostgresStore
+	if cfg.DatabaseURL != "" {
+		var err error
+		pg, err = store.NewPostgresStore(cfg.DatabaseURL, cfg.ResultBufferSize)
+		if err != nil {
+			log.Fatalf("❌ Failed to open result store: %v", err)
+		}
+		resultStore = pg
+	}
+	jobManager := jobs.NewManager(analyze, jobs.Options{
+		Workers:           cfg.JobWorkers,
+		DomainConcurrency: cfg.JobDomainLimit,
+		TTL:               cfg.JobTTL,
+		CallbackSecret:    cfg.JobCallbackSecret,
+		PublicURL:         cfg.PublicURL,
+		// Job results are persisted like any other analysis
+		OnResult: func(job *jobs.Job, intelligence *models.EmailIntelligence) {
+			resultStore.Save(store.NewRecord(intelligence, job.CallerID, cfg.StoreFullResults))
+		},
+	})
+	h := handlers.New(eng, jobManager, resultStore, cfg)
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(handlers.NotFound)
+	router.NoMethod(handlers.MethodNotAllowed)
+	
+	// Prometheus scrapes the conventional path; /api/v1/metrics keeps the JSON summary
+	router.GET("/metrics", h.PrometheusMetrics)
 	
-	// API Routes
+	// API Routes; admin routes require ADMIN_API_KEY
+	admin := handlers.RequireAdmin(cfg.AdminAPIKey)
 	v1 := router.Group("/api/v1")
 	{
 		v1.POST("/analyze", h.AnalyzeEmail)
+		v1.POST("/validate-syntax", h.ValidateSyntax)
+		v1.GET("/analyze/stream", h.AnalyzeEmailStream)
 		v1.POST("/bulk-analyze", h.BulkAnalyze)
+		v1.POST("/bulk-analyze-csv", h.BulkAnalyzeCSV)
+		v1.POST("/bulk-analyze-text", h.BulkAnalyzeText)
+		v1.POST("/feedback", h.Feedback)
+		v1.POST("/diff", h.DiffResults)
+		v1.GET("/dkim", h.DKIMSelector)
+		v1.GET("/domain-analyze/:domain", h.DomainAnalyze)
+		v1.GET("/results", admin, h.RecentResults)
 		v1.GET("/health", h.Health)
+		v1.GET("/ready", h.Ready)
 		v1.GET("/metrics", h.Metrics)
-		v1.GET("/scoring-weights", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"algorithm": "Enterprise Email Intelligence Scoring",
-				"version":   "2.0.0",
-				"weights":   cfg.ScoringWeights,
-				"total":     100,
-			})
-		})
+		v1.GET("/stats", admin, h.Stats)
+		v1.POST("/jobs", h.CreateJob)
+		v1.POST("/jobs/upload", h.UploadJob)
+		v1.GET("/jobs/:id", h.JobStatus)
+		v1.GET("/jobs/:id/report", h.JobReport)
+		v1.GET("/jobs/:id/results", h.JobResults)
+		v1.GET("/scoring-weights", h.ScoringWeights)
+		v1.PUT("/scoring-weights", admin, h.UpdateScoringWeights)
+		v1.GET("/openapi.json", h.OpenAPI)
+	}
+	
+	// Startup self-test; /ready reports 503 until it passes. Runs cut short by
+	// DNS failures are retried in the background with backoff.
+	if cfg.SelfTest {
+		go func() {
+			report := eng.RunSelfTestWithRetries(context.Background(), engine.DefaultSelfTestFixtures, cfg.SelfTestTimeout)
+			if report.Passed {
+				log.Printf("✅ Startup self-test passed in %dms (attempt %d)", report.DurationMs, report.Attempts)
+				return
+			}
+			for _, result := range report.Results {
+				if !result.Passed {
+					log.Printf("❌ Startup self-test %s (%s): %s", result.Name, result.Email, strings.Join(result.Deviations, "; "))
+				}
+			}
+		}()
 	}
 	
 	// Start server
@@ -61,7 +150,41 @@ func main() {
 	log.Printf("🔥 DKIM: 30+ selectors searched in parallel")
 	log.Printf("🌐 SMTP: Multiple MX servers & ports tested concurrently")
 	
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	
+	// Stop on SIGINT/SIGTERM: let in-flight requests finish, then flush the
+	// result store so queued records are written before exiting
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	failed := false
+	select {
+	case err := <-serveErr:
+		log.Printf("❌ Failed to start server: %v", err)
+		failed = true
+	case <-signals.Done():
+		log.Printf("🛑 Shutting down, waiting up to %s for in-flight requests", shutdownTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("❌ Graceful shutdown incomplete: %v", err)
+		}
+		cancel()
+		if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("❌ Server error: %v", err)
+		}
+	}
+	
+	eng.Close()
+	if pg != nil {
+		if err := pg.Close(); err != nil {
+			log.Printf("❌ Failed to close result store: %v", err)
+		}
+	}
+	shutdownTracing(context.Background())
+	if failed {
+		os.Exit(1)
 	}
 }
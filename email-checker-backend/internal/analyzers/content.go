@@ -4,21 +4,25 @@ import (
 	"strings"
 
 	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
 )
 
 // ContentGenerator generates user-friendly content
-type ContentGenerator struct{}
+type ContentGenerator struct {
+	typos *TypoCorrector
+}
 
-// NewContentGenerator creates a new content generator
-func NewContentGenerator() *ContentGenerator {
-	return &ContentGenerator{}
+// NewContentGenerator creates a new content generator. popularDomains are the
+// typo correction targets, most popular first; nil uses DefaultPopularDomains.
+func NewContentGenerator(popularDomains []string) *ContentGenerator {
+	return &ContentGenerator{typos: NewTypoCorrector(popularDomains)}
 }
 
 // Generate generates user-friendly content
 func (g *ContentGenerator) Generate(intelligence *models.EmailIntelligence) {
 	intelligence.Suggestions = g.generateSuggestions(intelligence)
 	intelligence.Warnings = g.generateWarnings(intelligence)
-	intelligence.AlternativeEmails = g.generateAlternatives(intelligence.Email)
+	intelligence.AlternativeEmails = g.Alternatives(intelligence.Email)
 	intelligence.ExplanationText = g.generateExplanation(intelligence)
 }
 
@@ -29,7 +33,7 @@ func (g *ContentGenerator) generateSuggestions(intelligence *models.EmailIntelli
 		suggestions = append(suggestions, "Consider using a different email address")
 	}
 	
-	if intelligence.DomainIntelligence.IsDisposable.Status == "fail" {
+	if intelligence.DomainIntelligence.DisposableLevel == validators.DisposableConfirmed {
 		suggestions = append(suggestions, "Use a permanent email address for better deliverability")
 	}
 	
@@ -37,6 +41,10 @@ func (g *ContentGenerator) generateSuggestions(intelligence *models.EmailIntelli
 		suggestions = append(suggestions, "Domain should implement email security records (SPF, DKIM, DMARC)")
 	}
 	
+	if intelligence.SMTPValidation.Deferred {
+		suggestions = append(suggestions, "Retry verification later; the mail server temporarily deferred the check")
+	}
+	
 	return suggestions
 }
 
@@ -48,31 +56,26 @@ func (g *ContentGenerator) generateWarnings(intelligence *models.EmailIntelligen
 			warnings = append(warnings, factor.Description)
 		}
 	}
+	warnings = append(warnings, intelligence.DNSValidation.Warnings...)
+	warnings = append(warnings, intelligence.SecurityAnalysis.SPFWarnings...)
+	if len(intelligence.DegradedChecks) > 0 {
+		warnings = append(warnings, "Some DNS lookups failed ("+strings.Join(intelligence.DegradedChecks, ", ")+"); those checks were not scored, so retry later for a complete result")
+	}
 	
 	return warnings
 }
 
-func (g *ContentGenerator) generateAlternatives(email string) []string {
+// Alternatives returns email with its domain replaced by each likely intended
+// popular domain, when the domain looks mistyped
+func (g *ContentGenerator) Alternatives(email string) []string {
 	alternatives := []string{}
 	
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
+	localPart, domain, ok := validators.SplitAddress(email)
+	if !ok {
 		return alternatives
 	}
 	
-	localPart, domain := parts[0], parts[1]
-	
-	typoCorrections := map[string]string{
-		"gmai.com":    "gmail.com",
-		"gamil.com":   "gmail.com",
-		"gmial.com":   "gmail.com",
-		"yahooo.com":  "yahoo.com",
-		"yaho.com":    "yahoo.com",
-		"hotmial.com": "hotmail.com",
-		"outlok.com":  "outlook.com",
-	}
-	
-	if correction, exists := typoCorrections[domain]; exists {
+	for _, correction := range g.typos.Suggest(domain) {
 		alternatives = append(alternatives, localPart+"@"+correction)
 	}
 	
@@ -80,8 +83,14 @@ func (g *ContentGenerator) generateAlternatives(email string) []string {
 }
 
 func (g *ContentGenerator) generateExplanation(intelligence *models.EmailIntelligence) string {
-	score := intelligence.ValidationScore
-	
+	explanation := g.scoreExplanation(intelligence.ValidationScore)
+	if intelligence.SMTPValidation.Deferred {
+		explanation += " The mail server deferred verification, which usually means greylisting: the mailbox is likely valid, and a retry later is recommended to confirm it."
+	}
+	return explanation
+}
+
+func (g *ContentGenerator) scoreExplanation(score int) string {
 	if score >= 85 {
 		return "This email address has excellent validation scores across all checks and is highly likely to be deliverable."
 	} else if score >= 70 {
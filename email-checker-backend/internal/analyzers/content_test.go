@@ -0,0 +1,15 @@
+package analyzers
+
+import "testing"
+
+func TestAlternativesMalformedAddress(t *testing.T) {
+	g := NewContentGenerator([]string{"gmail.com"})
+	for _, email := range []string{"", "@", "a@", "@b", "a@b@c"} {
+		if alternatives := g.Alternatives(email); len(alternatives) != 0 {
+			t.Errorf("Alternatives(%q) = %v, want none", email, alternatives)
+		}
+	}
+	if alternatives := g.Alternatives("jane@gmai.com"); len(alternatives) == 0 {
+		t.Error("Alternatives(jane@gmai.com) suggested nothing")
+	}
+}
@@ -0,0 +1,178 @@
+package analyzers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// Thresholds below which numeric movements are treated as noise
+const (
+	scoreChangeThreshold      = 10
+	reputationChangeThreshold = 10
+)
+
+// DiffAnalyzer compares two analysis runs of the same list
+type DiffAnalyzer struct{}
+
+// NewDiffAnalyzer creates a new diff analyzer
+func NewDiffAnalyzer() *DiffAnalyzer {
+	return &DiffAnalyzer{}
+}
+
+// Compare matches results by normalized address and reports meaningful changes:
+// validity and risk category flips, score or reputation moves of at least the
+// thresholds above, and status changes of MX, SPF, DKIM, DMARC, disposable,
+// catch-all and blacklist checks.
+func (a *DiffAnalyzer) Compare(previous, current []models.EmailIntelligence) models.ResultDiff {
+	diff := models.ResultDiff{
+		Changes: []models.EmailDiff{},
+		Added:   []string{},
+		Removed: []string{},
+	}
+
+	before := indexResults(previous)
+	after := indexResults(current)
+
+	for email, next := range after {
+		prev, ok := before[email]
+		if !ok {
+			diff.Added = append(diff.Added, email)
+			continue
+		}
+
+		diff.Summary.Compared++
+		changes := compareResults(prev, next)
+		if len(changes) == 0 {
+			diff.Summary.Unchanged++
+			continue
+		}
+
+		diff.Summary.Changed++
+		if !prev.IsValid && next.IsValid {
+			diff.Summary.BecameValid++
+		} else if prev.IsValid && !next.IsValid {
+			diff.Summary.BecameInvalid++
+		}
+
+		domain := ""
+		if at := strings.LastIndex(email, "@"); at != -1 {
+			domain = email[at+1:]
+		}
+		diff.Changes = append(diff.Changes, models.EmailDiff{
+			Email:   email,
+			Domain:  domain,
+			Changes: changes,
+		})
+	}
+
+	for email := range before {
+		if _, ok := after[email]; !ok {
+			diff.Removed = append(diff.Removed, email)
+		}
+	}
+
+	// Map iteration order is random; keep output stable for callers
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].Email < diff.Changes[j].Email })
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	diff.Summary.Added = len(diff.Added)
+	diff.Summary.Removed = len(diff.Removed)
+
+	return diff
+}
+
+// indexResults keys results by lowercased address; later duplicates win
+func indexResults(results []models.EmailIntelligence) map[string]*models.EmailIntelligence {
+	index := make(map[string]*models.EmailIntelligence, len(results))
+	for i := range results {
+		email := strings.TrimSpace(strings.ToLower(results[i].Email))
+		if email == "" {
+			continue
+		}
+		index[email] = &results[i]
+	}
+	return index
+}
+
+// compareResults returns the meaningful field changes between two results
+func compareResults(prev, next *models.EmailIntelligence) []models.FieldChange {
+	changes := []models.FieldChange{}
+
+	if prev.IsValid != next.IsValid {
+		changes = append(changes, models.FieldChange{Field: "is_valid", Previous: prev.IsValid, Current: next.IsValid})
+	}
+	if prev.RiskCategory != next.RiskCategory {
+		changes = append(changes, models.FieldChange{Field: "risk_category", Previous: prev.RiskCategory, Current: next.RiskCategory})
+	}
+	if absInt(next.ValidationScore-prev.ValidationScore) >= scoreChangeThreshold {
+		changes = append(changes, models.FieldChange{Field: "validation_score", Previous: prev.ValidationScore, Current: next.ValidationScore})
+	}
+
+	prevReputation := prev.DomainIntelligence.ReputationScore
+	nextReputation := next.DomainIntelligence.ReputationScore
+	if absInt(nextReputation-prevReputation) >= reputationChangeThreshold {
+		changes = append(changes, models.FieldChange{Field: "reputation_score", Previous: prevReputation, Current: nextReputation})
+	}
+
+	statuses := []struct {
+		field      string
+		prev, next string
+	}{
+		{"mx_records", prev.DNSValidation.MXRecords.Status, next.DNSValidation.MXRecords.Status},
+		{"spf_record", prev.SecurityAnalysis.SPFRecord.Status, next.SecurityAnalysis.SPFRecord.Status},
+		{"dkim_record", prev.SecurityAnalysis.DKIMRecord.Status, next.SecurityAnalysis.DKIMRecord.Status},
+		{"dmarc_record", prev.SecurityAnalysis.DMARCRecord.Status, next.SecurityAnalysis.DMARCRecord.Status},
+		{"is_disposable", prev.DomainIntelligence.IsDisposable.Status, next.DomainIntelligence.IsDisposable.Status},
+		{"disposable_level", prev.DomainIntelligence.DisposableLevel, next.DomainIntelligence.DisposableLevel},
+		{"is_catch_all", prev.DomainIntelligence.IsCatchAll.Status, next.DomainIntelligence.IsCatchAll.Status},
+		{"is_blacklisted", prev.DomainIntelligence.IsBlacklisted.Status, next.DomainIntelligence.IsBlacklisted.Status},
+	}
+	for _, status := range statuses {
+		if status.prev != status.next {
+			changes = append(changes, models.FieldChange{Field: status.field, Previous: status.prev, Current: status.next})
+		}
+	}
+
+	return changes
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ContentHash returns a stable hash of a result's content for ETag-style
+// comparison. Fields that change on every run without the verdict changing
+// (timestamps, timings, the SMTP banner, the source address a pool rotated to
+// and the position in a bulk request) are left out, so re-validating an unchanged address yields the same hash.
+func ContentHash(intelligence *models.EmailIntelligence) string {
+	stable := *intelligence
+	stable.Timestamp = time.Time{}
+	stable.ProcessingTime = 0
+	stable.OriginalIndex = nil
+	stable.DNSValidation.ResponseTime = 0
+	stable.SMTPValidation.ResponseTime = 0
+	stable.SMTPValidation.ServerResponse = ""
+	stable.SMTPValidation.SourceIP = ""
+	stable.SMTPValidation.MXResults = make([]models.MXTestResult, len(intelligence.SMTPValidation.MXResults))
+	for i, result := range intelligence.SMTPValidation.MXResults {
+		result.ResponseTime = 0
+		stable.SMTPValidation.MXResults[i] = result
+	}
+
+	// Maps are encoded with sorted keys, so the encoding is deterministic
+	encoded, err := json.Marshal(stable)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16])
+}
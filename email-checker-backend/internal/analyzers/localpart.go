@@ -0,0 +1,238 @@
+package analyzers
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"email-intelligence/internal/models"
+)
+
+// Local part verdicts
+const (
+	LocalPartHuman      = "human"
+	LocalPartSuspicious = "suspicious"
+	LocalPartRandom     = "random"
+)
+
+// LocalPartThresholds tunes how aggressively local parts are judged random
+type LocalPartThresholds struct {
+	HighDigitRatio   float64 // digit share above which a local part looks generated
+	TrailingDigits   int     // length of a trailing number that looks auto-incremented
+	MixedTransitions int     // letter<->digit switches typical of random strings
+	ConsonantRun     int     // consecutive consonants rarely found in names
+	HighEntropy      float64 // bits per character for long, non-name-like parts
+	SuspiciousBelow  int     // humanness score under which the part is suspicious
+	RandomBelow      int     // humanness score under which the part is random
+}
+
+// DefaultLocalPartThresholds are tuned to leave ordinary names and
+// name+year addresses alone while catching obvious generated strings
+func DefaultLocalPartThresholds() LocalPartThresholds {
+	return LocalPartThresholds{
+		HighDigitRatio:   0.5,
+		TrailingDigits:   5,
+		MixedTransitions: 4,
+		ConsonantRun:     5,
+		HighEntropy:      3.0,
+		SuspiciousBelow:  70,
+		RandomBelow:      45,
+	}
+}
+
+// LocalPartAnalyzer scores how human-chosen the part before "@" looks
+type LocalPartAnalyzer struct {
+	thresholds LocalPartThresholds
+}
+
+// NewLocalPartAnalyzer creates a new local part analyzer
+func NewLocalPartAnalyzer(thresholds LocalPartThresholds) *LocalPartAnalyzer {
+	return &LocalPartAnalyzer{thresholds: thresholds}
+}
+
+// Analyze returns a 0-100 humanness score for the local part along with the
+// signals that lowered it
+func (a *LocalPartAnalyzer) Analyze(email string) models.LocalPartQuality {
+	local := strings.ToLower(email)
+	if at := strings.LastIndex(local, "@"); at != -1 {
+		local = local[:at]
+	}
+	// Sub-addressing tags (user+tag) are chosen by the user, not generated
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+
+	quality := models.LocalPartQuality{
+		Score:   100,
+		Signals: []string{},
+	}
+	if local == "" {
+		return quality
+	}
+
+	t := a.thresholds
+	quality.Entropy = shannonEntropy(local)
+	quality.DigitRatio = digitRatio(local)
+	quality.NameLike = isNameLike(local)
+	quality.LongestRandomRun = longestMixedRun(local)
+
+	if quality.DigitRatio > t.HighDigitRatio {
+		quality.Score -= 30
+		quality.Signals = append(quality.Signals, "mostly_digits")
+	} else if quality.DigitRatio > t.HighDigitRatio/2 {
+		quality.Score -= 15
+		quality.Signals = append(quality.Signals, "many_digits")
+	}
+
+	if trailingDigits(local) >= t.TrailingDigits {
+		quality.Score -= 20
+		quality.Signals = append(quality.Signals, "long_trailing_number")
+	}
+
+	if letterDigitTransitions(local) >= t.MixedTransitions {
+		quality.Score -= 30
+		quality.Signals = append(quality.Signals, "mixed_letters_digits")
+	}
+
+	if longestConsonantRun(local) >= t.ConsonantRun {
+		quality.Score -= 20
+		quality.Signals = append(quality.Signals, "unpronounceable")
+	}
+
+	if quality.Entropy >= t.HighEntropy && len(local) >= 8 && !quality.NameLike {
+		quality.Score -= 15
+		quality.Signals = append(quality.Signals, "high_entropy")
+	}
+
+	if quality.NameLike {
+		quality.Score += 10
+	}
+	quality.Score = int(math.Max(0, math.Min(100, float64(quality.Score))))
+
+	switch {
+	case quality.Score < t.RandomBelow:
+		quality.Verdict = LocalPartRandom
+	case quality.Score < t.SuspiciousBelow:
+		quality.Verdict = LocalPartSuspicious
+	default:
+		quality.Verdict = LocalPartHuman
+	}
+
+	return quality
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func digitRatio(s string) float64 {
+	digits, total := 0, 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(digits) / float64(total)
+}
+
+func trailingDigits(s string) int {
+	count := 0
+	for i := len(s) - 1; i >= 0 && s[i] >= '0' && s[i] <= '9'; i-- {
+		count++
+	}
+	return count
+}
+
+func letterDigitTransitions(s string) int {
+	transitions := 0
+	prev := 0 // 1 letter, 2 digit
+	for _, r := range s {
+		kind := 0
+		if unicode.IsLetter(r) {
+			kind = 1
+		} else if unicode.IsDigit(r) {
+			kind = 2
+		}
+		if kind != 0 && prev != 0 && kind != prev {
+			transitions++
+		}
+		if kind != 0 {
+			prev = kind
+		}
+	}
+	return transitions
+}
+
+func longestConsonantRun(s string) int {
+	longest, run := 0, 0
+	for _, r := range s {
+		if unicode.IsLetter(r) && !strings.ContainsRune("aeiouy", r) {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// longestMixedRun is the longest separator-free segment that mixes letters and digits
+func longestMixedRun(s string) int {
+	longest := 0
+	for _, segment := range strings.FieldsFunc(s, isLocalSeparator) {
+		if letterDigitTransitions(segment) > 0 && len(segment) > longest {
+			longest = len(segment)
+		}
+	}
+	return longest
+}
+
+// isNameLike reports whether every alphabetic segment has a plausible vowel
+// ratio, e.g. "john.smith" or "mary_k" but not "xkcdqzt"
+func isNameLike(s string) bool {
+	segments := strings.FieldsFunc(s, isLocalSeparator)
+	named := 0
+	for _, segment := range segments {
+		letters := strings.TrimRightFunc(segment, unicode.IsDigit)
+		if letters == "" {
+			continue
+		}
+		vowels := 0
+		for _, r := range letters {
+			if !unicode.IsLetter(r) {
+				return false
+			}
+			if strings.ContainsRune("aeiouy", r) {
+				vowels++
+			}
+		}
+		ratio := float64(vowels) / float64(len(letters))
+		if len(letters) > 1 && (ratio < 0.2 || ratio > 0.7) {
+			return false
+		}
+		named++
+	}
+	return named > 0
+}
+
+func isLocalSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == '-'
+}
@@ -0,0 +1,63 @@
+package analyzers
+
+import (
+	"slices"
+	"testing"
+
+	"email-intelligence/internal/models"
+)
+
+func TestLocalPartAnalyze(t *testing.T) {
+	a := NewLocalPartAnalyzer(DefaultLocalPartThresholds())
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"john.smith@example.com", LocalPartHuman},
+		{"mary_k@example.com", LocalPartHuman},
+		{"christopher@example.com", LocalPartHuman},
+		{"jane.doe1987@example.com", LocalPartHuman},
+		{"matthew.schmidt@example.com", LocalPartHuman},
+		// Sub-address tags are ignored
+		{"alice+a8f3k2j9x7@example.com", LocalPartHuman},
+
+		{"user12938471@example.com", LocalPartSuspicious},
+		{"12938471@example.com", LocalPartSuspicious},
+		{"qwrtpsdf@example.com", LocalPartSuspicious},
+		{"a8f3k2j9@gmail.com", LocalPartRandom},
+	}
+	for _, tt := range tests {
+		if quality := a.Analyze(tt.email); quality.Verdict != tt.want {
+			t.Errorf("Analyze(%q) = %s (score %d, signals %v), want %s", tt.email, quality.Verdict, quality.Score, quality.Signals, tt.want)
+		}
+	}
+}
+
+func TestLocalPartThresholdsAreTunable(t *testing.T) {
+	thresholds := DefaultLocalPartThresholds()
+	thresholds.SuspiciousBelow = 50
+	thresholds.RandomBelow = 10
+	a := NewLocalPartAnalyzer(thresholds)
+	if quality := a.Analyze("user12938471@example.com"); quality.Verdict != LocalPartHuman {
+		t.Errorf("Analyze = %s (score %d) with lenient thresholds, want human", quality.Verdict, quality.Score)
+	}
+}
+
+func TestRandomLocalPartRaisesRisk(t *testing.T) {
+	human := &models.EmailIntelligence{LocalPartQuality: models.LocalPartQuality{Score: 100, Verdict: LocalPartHuman}}
+	random := &models.EmailIntelligence{LocalPartQuality: models.LocalPartQuality{Score: 40, Verdict: LocalPartRandom}}
+
+	hasFactor := func(intelligence *models.EmailIntelligence) bool {
+		return slices.ContainsFunc(NewRiskAnalyzer().Analyze(intelligence).RiskFactors, func(f models.RiskFactor) bool {
+			return f.Factor == "Randomized Local Part"
+		})
+	}
+	if hasFactor(human) || !hasFactor(random) {
+		t.Errorf("risk factor: human %t, random %t; want only random", hasFactor(human), hasFactor(random))
+	}
+
+	ml := NewMLAnalyzer()
+	if humanSpam, randomSpam := ml.Predict(human).SpamProbability, ml.Predict(random).SpamProbability; randomSpam <= humanSpam {
+		t.Errorf("spam probability: random %.2f, human %.2f; want random higher", randomSpam, humanSpam)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
 )
 
 // MLAnalyzer performs machine learning predictions
@@ -18,17 +19,37 @@ func NewMLAnalyzer() *MLAnalyzer {
 // Predict generates ML predictions
 func (a *MLAnalyzer) Predict(intelligence *models.EmailIntelligence) models.MLPredictions {
 	features := map[string]float64{
-		"syntax_score":      float64(intelligence.SyntaxValidation.Score) / 10.0,
+		"syntax_score":      scoreShare(intelligence.SyntaxValidation),
 		"mx_score":          float64(intelligence.DNSValidation.MXRecords.Score) / 20.0,
 		"security_score":    float64(intelligence.SecurityAnalysis.SecurityScore) / 20.0,
-		"smtp_score":        float64(intelligence.SMTPValidation.Reachable.Score) / 20.0,
-		"is_disposable":     boolToFloat(intelligence.DomainIntelligence.IsDisposable.Status == "fail"),
+		"smtp_score":        scoreShare(intelligence.SMTPValidation.Reachable),
+		"is_disposable":     disposableFeature(intelligence.DomainIntelligence.DisposableLevel),
 		"is_free_provider":  boolToFloat(intelligence.DomainIntelligence.IsFreeProvider.Status == "pass"),
 		"is_corporate":      boolToFloat(intelligence.DomainIntelligence.IsCorporate.Status == "pass"),
-		"domain_age":        float64(intelligence.DomainIntelligence.DomainAge) / 365.0,
 		"reputation_score":  float64(intelligence.DomainIntelligence.ReputationScore) / 100.0,
 	}
 	
+	// Registration age in years, capped so very old domains do not dominate
+	if age := intelligence.DomainIntelligence.DomainAge; age != validators.UnknownDomainAge {
+		features["domain_age"] = math.Min(float64(age)/365.0, 3.0)
+	}
+	
+	// Only trust domain outcome rates once enough feedback has been collected
+	if feedback := intelligence.DomainIntelligence.Feedback; feedback.Total >= validators.MinFeedbackSamples {
+		features["domain_bounce_rate"] = feedback.BounceRate
+		features["domain_complaint_rate"] = feedback.ComplaintRate
+	}
+	
+	// Generated-looking local parts correlate with bot and throwaway signups
+	if intelligence.LocalPartQuality.Verdict != "" && intelligence.LocalPartQuality.Verdict != LocalPartHuman {
+		features["random_local_part"] = 1.0 - float64(intelligence.LocalPartQuality.Score)/100.0
+	}
+	
+	// Mail sent through reputable infrastructure with DMARC is a positive signal
+	if intelligence.SecurityAnalysis.ReputableSender {
+		features["reputable_sender"] = 1.0
+	}
+	
 	spamProbability := a.calculateSpamProbability(features)
 	bounceProbability := a.calculateBounceProbability(features)
 	deliverabilityScore := 1.0 - math.Max(spamProbability, bounceProbability)
@@ -47,11 +68,14 @@ func (a *MLAnalyzer) Predict(intelligence *models.EmailIntelligence) models.MLPr
 
 func (a *MLAnalyzer) calculateSpamProbability(features map[string]float64) float64 {
 	weights := map[string]float64{
-		"is_disposable":    0.8,
-		"is_free_provider": 0.2,
-		"security_score":   -0.3,
-		"reputation_score": -0.4,
-		"domain_age":       -0.2,
+		"is_disposable":         0.8,
+		"is_free_provider":      0.2,
+		"security_score":        -0.3,
+		"reputation_score":      -0.4,
+		"domain_age":            -0.2,
+		"domain_complaint_rate": 2.0,
+		"reputable_sender":      -0.3,
+		"random_local_part":     0.6,
 	}
 	
 	score := 0.0
@@ -66,10 +90,12 @@ func (a *MLAnalyzer) calculateSpamProbability(features map[string]float64) float
 
 func (a *MLAnalyzer) calculateBounceProbability(features map[string]float64) float64 {
 	weights := map[string]float64{
-		"mx_score":       -0.4,
-		"smtp_score":     -0.5,
-		"syntax_score":   -0.3,
-		"is_disposable":  0.6,
+		"mx_score":           -0.4,
+		"smtp_score":         -0.5,
+		"syntax_score":       -0.3,
+		"is_disposable":      0.6,
+		"domain_bounce_rate": 1.5,
+		"reputable_sender":   -0.2,
 	}
 	
 	score := 0.0
@@ -106,6 +132,14 @@ func (a *MLAnalyzer) generateExplanation(features map[string]float64, spamProb,
 		explanations = append(explanations, "Strong security records reduce spam likelihood")
 	}
 	
+	if features["random_local_part"] > 0 {
+		explanations = append(explanations, "Machine-generated looking address increases spam risk")
+	}
+	
+	if features["reputable_sender"] > 0 {
+		explanations = append(explanations, "Sends through a reputable email provider with DMARC")
+	}
+	
 	if features["smtp_score"] > 0.8 {
 		explanations = append(explanations, "SMTP reachability indicates good deliverability")
 	}
@@ -117,9 +151,29 @@ func (a *MLAnalyzer) generateExplanation(features map[string]float64, spamProb,
 	return strings.Join(explanations, "; ")
 }
 
+// scoreShare is the share of its weight a check earned, so features do not
+// depend on the scoring weights the validators were built with
+func scoreShare(result models.ValidationResult) float64 {
+	if result.Weight <= 0 {
+		return 0
+	}
+	return float64(result.Score) / float64(result.Weight)
+}
+
 func boolToFloat(b bool) float64 {
 	if b {
 		return 1.0
 	}
 	return 0.0
 }
+
+// disposableFeature weighs a keyword-only suspicion at half a confirmed match
+func disposableFeature(level string) float64 {
+	switch level {
+	case validators.DisposableConfirmed:
+		return 1.0
+	case validators.DisposableSuspected:
+		return 0.5
+	}
+	return 0.0
+}
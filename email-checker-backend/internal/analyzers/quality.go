@@ -1,50 +1,109 @@
 package analyzers
 
-import "email-intelligence/internal/models"
+import (
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+// QualityOptions tunes how verdicts are turned into confidence and tiers
+type QualityOptions struct {
+	// Free-provider addresses are only forced to valid/"Safe" when no
+	// high-severity risk factor or randomized local part is present; otherwise
+	// they are judged like any other address (the provider still earns its
+	// reputation bonus)
+	StrictFreeProviders bool
+	// A mailbox confirmed by RCPT TO reaches High/Medium confidence at lower
+	// scores than one whose SMTP verdict was assumed
+	RCPTConfidenceBoost bool
+	// Only RCPT-verified mailboxes may be rated Premium
+	PremiumRequiresRCPT bool
+	// Free providers are never forced to valid/"Safe"; their addresses are
+	// judged on the evidence like any other
+	StrictMode bool
+	// Scores at which confidence levels and quality tiers are reached
+	Thresholds models.QualityThresholds
+}
 
 // QualityAnalyzer determines quality metrics
-type QualityAnalyzer struct{}
+type QualityAnalyzer struct {
+	opts QualityOptions
+}
 
 // NewQualityAnalyzer creates a new quality analyzer
-func NewQualityAnalyzer() *QualityAnalyzer {
-	return &QualityAnalyzer{}
+func NewQualityAnalyzer(opts QualityOptions) *QualityAnalyzer {
+	return &QualityAnalyzer{opts: opts}
 }
 
-// Determine determines quality metrics
-func (a *QualityAnalyzer) Determine(intelligence *models.EmailIntelligence) {
+// freeProviderTrusted reports whether the free-provider override may apply
+func (a *QualityAnalyzer) freeProviderTrusted(intelligence *models.EmailIntelligence) bool {
+	if intelligence.DomainIntelligence.IsFreeProvider.Status != "pass" || a.opts.StrictMode {
+		return false
+	}
+	if intelligence.DomainIntelligence.MailboxPlausibility.Status == "fail" {
+		return false
+	}
+	if !a.opts.StrictFreeProviders {
+		return true
+	}
+	if intelligence.LocalPartQuality.Verdict == LocalPartRandom {
+		return false
+	}
+	for _, factor := range intelligence.RiskAnalysis.RiskFactors {
+		if factor.Severity == "High" {
+			return false
+		}
+	}
+	return true
+}
+
+// Determine determines quality metrics using the profile's verdict thresholds
+func (a *QualityAnalyzer) Determine(intelligence *models.EmailIntelligence, profile models.ScoringProfile) {
 	score := intelligence.ValidationScore
 	
 	hasValidSyntax := intelligence.SyntaxValidation.Status == "pass"
 	hasMXRecords := intelligence.DNSValidation.MXRecords.Status == "pass"
 	isFreeProvider := intelligence.DomainIntelligence.IsFreeProvider.Status == "pass"
-	isDisposable := intelligence.DomainIntelligence.IsDisposable.Status == "fail" && intelligence.DomainIntelligence.IsDisposable.Score == 0
+	isDisposable := intelligence.DomainIntelligence.DisposableLevel == validators.DisposableConfirmed
+	trustedFreeProvider := a.freeProviderTrusted(intelligence)
 	
-	intelligence.IsValid = hasValidSyntax && (hasMXRecords || isFreeProvider) && !isDisposable && score >= 50
+	intelligence.IsValid = hasValidSyntax && (hasMXRecords || isFreeProvider) && !isDisposable && score >= profile.ValidScore
 	
-	if isFreeProvider && hasValidSyntax && hasMXRecords {
+	if trustedFreeProvider && hasValidSyntax && hasMXRecords {
 		intelligence.IsValid = true
 		intelligence.RiskCategory = "Safe"
 	}
 	
-	// Confidence level
-	if score >= 85 {
+	// Confidence level; a mailbox the server actually confirmed needs less score
+	rcptVerified := intelligence.SMTPValidation.VerificationMethod == validators.VerificationRCPT &&
+		intelligence.SMTPValidation.MailboxStatus == validators.MailboxActive
+	thresholds := a.opts.Thresholds
+	highConfidence, mediumConfidence := thresholds.HighConfidence, thresholds.MediumConfidence
+	if rcptVerified && a.opts.RCPTConfidenceBoost {
+		highConfidence, mediumConfidence = thresholds.VerifiedHighConfidence, thresholds.VerifiedMediumConfidence
+	}
+	if score >= highConfidence {
 		intelligence.ConfidenceLevel = "High"
-	} else if score >= 60 {
+	} else if score >= mediumConfidence {
 		intelligence.ConfidenceLevel = "Medium"
 	} else {
 		intelligence.ConfidenceLevel = "Low"
 	}
 	
+	// A deferred SMTP check is "likely valid, retry later", not a confirmation
+	if intelligence.SMTPValidation.Deferred && intelligence.ConfidenceLevel == "High" {
+		intelligence.ConfidenceLevel = "Medium"
+	}
+	
 	// Risk category
 	riskScore := intelligence.RiskAnalysis.RiskScore
 	
-	if isFreeProvider && score >= 60 {
+	if trustedFreeProvider && score >= thresholds.SafeFreeProvider {
 		intelligence.RiskCategory = "Safe"
 	} else if isDisposable {
 		intelligence.RiskCategory = "High Risk"
-	} else if riskScore >= 50 {
+	} else if riskScore >= profile.HighRiskScore {
 		intelligence.RiskCategory = "High Risk"
-	} else if riskScore >= 25 {
+	} else if riskScore >= profile.HighRiskScore/2 {
 		intelligence.RiskCategory = "Medium Risk"
 	} else if intelligence.IsValid {
 		intelligence.RiskCategory = "Safe"
@@ -52,16 +111,123 @@ func (a *QualityAnalyzer) Determine(intelligence *models.EmailIntelligence) {
 		intelligence.RiskCategory = "Invalid"
 	}
 	
+	// A free provider with risk signals is never waved through as "Safe"
+	if isFreeProvider && !trustedFreeProvider && intelligence.RiskCategory == "Safe" {
+		intelligence.RiskCategory = "Medium Risk"
+	}
+	
+	intelligence.PrimaryFailureReason = a.FailureReason(intelligence, profile)
+	
 	// Quality tier
-	if score >= 90 {
+	if score >= thresholds.Premium && (rcptVerified || !a.opts.PremiumRequiresRCPT) {
 		intelligence.QualityTier = "Premium"
-	} else if score >= 75 {
+	} else if score >= thresholds.Excellent {
 		intelligence.QualityTier = "Excellent"
-	} else if score >= 60 {
+	} else if score >= thresholds.Good {
 		intelligence.QualityTier = "Good"
-	} else if score >= 40 {
+	} else if score >= thresholds.Fair {
 		intelligence.QualityTier = "Fair"
 	} else {
 		intelligence.QualityTier = "Poor"
 	}
+	
+	intelligence.DeliverabilityVerdict = a.Verdict(intelligence)
+}
+
+// Deliverability verdicts
+const (
+	VerdictDeliverable   = "deliverable"
+	VerdictRisky         = "risky"
+	VerdictUndeliverable = "undeliverable"
+	VerdictUnknown       = "unknown"
+)
+
+// Bounce probabilities above which a valid address is only risky and an
+// invalid one is undeliverable
+const (
+	riskyBounceProbability         = 0.3
+	undeliverableBounceProbability = 0.7
+)
+
+// Verdict reconciles validity, risk, tier and the ML predictions into one
+// deliverability verdict. The first rule that applies wins:
+//
+//  1. Bad syntax, a domain with no mail servers (not a free provider) or a
+//     mailbox the server rejected as nonexistent or disabled: undeliverable.
+//  2. A confirmed disposable domain: risky, whatever the score.
+//  3. A failed or timed out lookup of the domain or its MX records: unknown,
+//     since the missing evidence may be transient.
+//  4. A valid address is deliverable, unless it is High Risk or its bounce
+//     probability reaches 0.3, which makes it risky.
+//  5. An invalid address is undeliverable once its bounce probability
+//     reaches 0.7, and risky below that.
+func (a *QualityAnalyzer) Verdict(intelligence *models.EmailIntelligence) string {
+	isFreeProvider := intelligence.DomainIntelligence.IsFreeProvider.Status == "pass"
+	mailbox := intelligence.SMTPValidation.MailboxStatus
+	bounce := intelligence.MLPredictions.BounceProbability
+	
+	switch {
+	case intelligence.SyntaxValidation.Status != "pass":
+		return VerdictUndeliverable
+	case intelligence.DNSValidation.MXRecords.Status == "fail" && !isFreeProvider:
+		return VerdictUndeliverable
+	case mailbox == validators.MailboxNonexistent || mailbox == validators.MailboxDisabled:
+		return VerdictUndeliverable
+	case intelligence.DomainIntelligence.DisposableLevel == validators.DisposableConfirmed:
+		return VerdictRisky
+	case validators.Degraded(intelligence.DNSValidation.DomainExists) || validators.Degraded(intelligence.DNSValidation.MXRecords):
+		return VerdictUnknown
+	case intelligence.IsValid:
+		if intelligence.RiskCategory == "High Risk" || bounce >= riskyBounceProbability {
+			return VerdictRisky
+		}
+		return VerdictDeliverable
+	case bounce >= undeliverableBounceProbability:
+		return VerdictUndeliverable
+	}
+	return VerdictRisky
+}
+
+// Failure reason codes, in priority order
+const (
+	FailureInvalidSyntax   = "invalid_syntax"
+	FailureDNSError        = "dns_error"
+	FailureNoMX            = "no_mx"
+	FailureDisposable      = "disposable"
+	FailureLowScore        = "low_score"
+	FailureSMTPUnreachable = "smtp_unreachable"
+	FailureOther           = "other"
+)
+
+// FailureReason picks the one reason to show for an invalid address, checking
+// in priority order: bad syntax, failed MX lookup, no MX records, disposable
+// domain, low score, SMTP unreachable. Valid addresses have no failure reason.
+// "No MX records" needs an answer saying so; an MX check that failed, timed
+// out or never finished is reported as a DNS error.
+func (a *QualityAnalyzer) FailureReason(intelligence *models.EmailIntelligence, profile models.ScoringProfile) *models.FailureReason {
+	if intelligence.IsValid {
+		return nil
+	}
+	
+	switch {
+	case intelligence.SyntaxValidation.Status != "pass":
+		return &models.FailureReason{Code: FailureInvalidSyntax, Message: "The email address is not correctly formatted."}
+	case mxUnanswered(intelligence.DNSValidation.MXRecords) && intelligence.DomainIntelligence.IsFreeProvider.Status != "pass":
+		return &models.FailureReason{Code: FailureDNSError, Message: "The domain's mail servers could not be looked up; try again later."}
+	case intelligence.DNSValidation.MXRecords.RawSignal == validators.SignalNoMXRecords && intelligence.DomainIntelligence.IsFreeProvider.Status != "pass":
+		return &models.FailureReason{Code: FailureNoMX, Message: "The domain has no mail servers and cannot receive email."}
+	case intelligence.DomainIntelligence.DisposableLevel == validators.DisposableConfirmed:
+		return &models.FailureReason{Code: FailureDisposable, Message: "The address belongs to a disposable email service."}
+	case intelligence.ValidationScore < profile.ValidScore:
+		return &models.FailureReason{Code: FailureLowScore, Message: "The address scored too low to be considered deliverable."}
+	case intelligence.SMTPValidation.Reachable.Status == "fail":
+		return &models.FailureReason{Code: FailureSMTPUnreachable, Message: "The mail server rejected or could not verify this mailbox."}
+	}
+	return &models.FailureReason{Code: FailureOther, Message: "The address did not pass validation."}
+}
+
+// mxUnanswered reports an MX check without an answer either way: the lookup
+// failed or timed out, or the DNS stage was cut short before it ran
+func mxUnanswered(mx models.ValidationResult) bool {
+	return validators.Degraded(mx) || mx.Status != "pass" && mx.RawSignal != validators.SignalNoMXRecords
 }
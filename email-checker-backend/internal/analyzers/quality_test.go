@@ -0,0 +1,57 @@
+package analyzers
+
+import (
+	"testing"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+func TestFailureReasonMX(t *testing.T) {
+	tests := []struct {
+		name string
+		mx   models.ValidationResult
+		want string
+	}{
+		{"empty MX answer", models.ValidationResult{Status: "fail", RawSignal: validators.SignalNoMXRecords}, FailureNoMX},
+		{"lookup failed", models.ValidationResult{Status: "unknown", RawSignal: validators.SignalDNSError}, FailureDNSError},
+		{"lookup timed out", models.ValidationResult{Status: "unknown", RawSignal: validators.SignalLookupTimeout}, FailureDNSError},
+		{"DNS stage cut short", models.ValidationResult{}, FailureDNSError},
+		{"unknown for another reason", models.ValidationResult{Status: "unknown", RawSignal: "analysis_timeout"}, FailureDNSError},
+		{"MX found", models.ValidationResult{Status: "pass", RawSignal: "2_mx_records"}, FailureLowScore},
+	}
+	a := NewQualityAnalyzer(QualityOptions{})
+	profile := models.ScoringProfile{ValidScore: 70}
+	for _, tt := range tests {
+		intelligence := &models.EmailIntelligence{ValidationScore: 20}
+		intelligence.SyntaxValidation.Status = "pass"
+		intelligence.DNSValidation.MXRecords = tt.mx
+		reason := a.FailureReason(intelligence, profile)
+		if reason == nil || reason.Code != tt.want {
+			t.Errorf("%s: reason = %+v, want %s", tt.name, reason, tt.want)
+		}
+	}
+}
+
+func TestFailureReasonOrder(t *testing.T) {
+	a := NewQualityAnalyzer(QualityOptions{})
+	profile := models.ScoringProfile{ValidScore: 70}
+
+	if reason := a.FailureReason(&models.EmailIntelligence{IsValid: true}, profile); reason != nil {
+		t.Errorf("valid address got reason %+v", reason)
+	}
+
+	intelligence := &models.EmailIntelligence{ValidationScore: 20}
+	intelligence.SyntaxValidation.Status = "fail"
+	intelligence.DNSValidation.MXRecords = models.ValidationResult{Status: "fail", RawSignal: validators.SignalNoMXRecords}
+	if reason := a.FailureReason(intelligence, profile); reason.Code != FailureInvalidSyntax {
+		t.Errorf("reason = %s, want syntax first", reason.Code)
+	}
+
+	// Free providers are not reported as lacking mail servers
+	intelligence.SyntaxValidation.Status = "pass"
+	intelligence.DomainIntelligence.IsFreeProvider.Status = "pass"
+	if reason := a.FailureReason(intelligence, profile); reason.Code != FailureLowScore {
+		t.Errorf("reason = %s, want %s", reason.Code, FailureLowScore)
+	}
+}
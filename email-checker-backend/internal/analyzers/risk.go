@@ -1,6 +1,11 @@
 package analyzers
 
-import "email-intelligence/internal/models"
+import (
+	"strings"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
 
 // RiskAnalyzer analyzes risk factors
 type RiskAnalyzer struct{}
@@ -16,13 +21,30 @@ func (a *RiskAnalyzer) Analyze(intelligence *models.EmailIntelligence) models.Ri
 		RiskFactors: []models.RiskFactor{},
 	}
 	
-	if intelligence.DomainIntelligence.IsDisposable.Status == "fail" && intelligence.DomainIntelligence.IsDisposable.Score == 0 {
+	if intelligence.DomainIntelligence.DisposableLevel == validators.DisposableConfirmed {
 		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
 			Factor:      "Disposable Email",
 			Severity:    "High",
 			Impact:      30,
 			Description: "Email address uses a temporary/disposable email service",
 		})
+	} else if intelligence.DomainIntelligence.DisposableLevel == validators.DisposableSuspected {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Suspected Disposable",
+			Severity:    "Low",
+			Impact:      10,
+			Description: "Domain name contains a disposable-service keyword (" + intelligence.DomainIntelligence.IsDisposable.RawSignal + ") but is not a known disposable provider",
+		})
+	}
+	
+	// Deliberate impersonation, unlike a typo of a popular domain
+	if intelligence.DomainIntelligence.HomoglyphRisk.Status == "fail" {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Lookalike Domain",
+			Severity:    "High",
+			Impact:      30,
+			Description: intelligence.DomainIntelligence.HomoglyphRisk.Reason,
+		})
 	}
 	
 	if intelligence.DNSValidation.MXRecords.Status == "fail" {
@@ -34,6 +56,34 @@ func (a *RiskAnalyzer) Analyze(intelligence *models.EmailIntelligence) models.Ri
 		})
 	}
 	
+	// Providers that cannot be probed are judged by their naming rules instead
+	if intelligence.DomainIntelligence.MailboxPlausibility.Status == "fail" {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Implausible Mailbox",
+			Severity:    "High",
+			Impact:      30,
+			Description: intelligence.DomainIntelligence.MailboxPlausibility.Reason,
+		})
+	}
+	
+	if intelligence.DNSValidation.WildcardDNS {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Wildcard DNS",
+			Severity:    "Low",
+			Impact:      5,
+			Description: "Domain resolves every hostname, so DNS existence is a weak signal",
+		})
+	}
+	
+	if intelligence.DNSValidation.SuspiciousNameservers {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Parked or Free DNS",
+			Severity:    "Medium",
+			Impact:      10,
+			Description: "Domain is served by a free DNS or domain-parking nameserver",
+		})
+	}
+	
 	if intelligence.SecurityAnalysis.SecurityScore < 10 {
 		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
 			Factor:      "Poor Security",
@@ -43,7 +93,68 @@ func (a *RiskAnalyzer) Analyze(intelligence *models.EmailIntelligence) models.Ri
 		})
 	}
 	
-	if intelligence.SMTPValidation.Reachable.Status == "fail" && intelligence.DomainIntelligence.IsFreeProvider.Status != "pass" {
+	if intelligence.SecurityAnalysis.AbnormalTXT {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Abnormal TXT Records",
+			Severity:    "Medium",
+			Impact:      10,
+			Description: "Domain publishes oversized or excessive TXT records",
+		})
+	}
+	
+	if intelligence.LocalPartQuality.Verdict == LocalPartRandom {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Randomized Local Part",
+			Severity:    "Medium",
+			Impact:      15,
+			Description: "Address looks machine-generated, which is common for bot signups",
+		})
+	}
+	
+	if intelligence.DomainIntelligence.IsRoleAccount.Status == "fail" {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Role Account",
+			Severity:    "Medium",
+			Impact:      10,
+			Description: intelligence.DomainIntelligence.IsRoleAccount.Reason + "; a poor target for transactional mail",
+		})
+	}
+	
+	switch intelligence.SpamTrapRisk.Level {
+	case SpamTrapHigh:
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Spam Trap Risk",
+			Severity:    "High",
+			Impact:      30,
+			Description: "Address matches several spam trap patterns (" + strings.Join(intelligence.SpamTrapRisk.Signals, ", ") + ")",
+		})
+	case SpamTrapMedium:
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Spam Trap Risk",
+			Severity:    "Medium",
+			Impact:      15,
+			Description: "Address shares traits with spam traps (" + strings.Join(intelligence.SpamTrapRisk.Signals, ", ") + ")",
+		})
+	}
+	
+	smtp := intelligence.SMTPValidation
+	if len(smtp.Capabilities) > 0 && !smtp.TLSSupported {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "No STARTTLS",
+			Severity:    "Low",
+			Impact:      5,
+			Description: "Mail server does not advertise STARTTLS, so mail may travel unencrypted",
+		})
+	}
+	
+	if smtp.MailboxStatus == "nonexistent" || smtp.MailboxStatus == "disabled" {
+		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
+			Factor:      "Mailbox Unavailable",
+			Severity:    "High",
+			Impact:      40,
+			Description: "Mail server rejected the mailbox as " + smtp.MailboxStatus,
+		})
+	} else if intelligence.SMTPValidation.Reachable.Status == "fail" && intelligence.DomainIntelligence.IsFreeProvider.Status != "pass" {
 		analysis.RiskFactors = append(analysis.RiskFactors, models.RiskFactor{
 			Factor:      "SMTP Unreachable",
 			Severity:    "Medium",
@@ -78,10 +189,26 @@ func (a *RiskAnalyzer) generateRecommendations(riskFactors []models.RiskFactor)
 		switch factor.Factor {
 		case "Disposable Email":
 			recommendations = append(recommendations, "Use a permanent email address for better deliverability")
+		case "Suspected Disposable":
+			recommendations = append(recommendations, "Review the domain manually; keyword matches are often legitimate businesses")
 		case "No MX Records":
 			recommendations = append(recommendations, "Verify domain configuration and MX records")
+		case "Parked or Free DNS":
+			recommendations = append(recommendations, "Confirm the domain is actively used for email and not parked")
 		case "Poor Security":
 			recommendations = append(recommendations, "Implement SPF, DKIM, and DMARC records")
+		case "Wildcard DNS":
+			recommendations = append(recommendations, "Rely on MX and SMTP evidence rather than domain resolution")
+		case "Abnormal TXT Records":
+			recommendations = append(recommendations, "Treat the domain with caution; legitimate senders rarely publish bloated TXT records")
+		case "Randomized Local Part":
+			recommendations = append(recommendations, "Confirm the signup with a verification email before trusting it")
+		case "Spam Trap Risk":
+			recommendations = append(recommendations, "Do not mail this address unless it opted in recently with confirmed double opt-in")
+		case "No STARTTLS":
+			recommendations = append(recommendations, "Enable STARTTLS on the receiving mail server")
+		case "Mailbox Unavailable":
+			recommendations = append(recommendations, "Remove this address; the mailbox cannot receive mail")
 		case "SMTP Unreachable":
 			recommendations = append(recommendations, "Check mail server configuration and connectivity")
 		}
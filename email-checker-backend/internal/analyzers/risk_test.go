@@ -0,0 +1,37 @@
+package analyzers
+
+import (
+	"testing"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+func TestDisposableLevelRisk(t *testing.T) {
+	tests := []struct {
+		level       string
+		wantFactor  string
+		wantFeature float64
+	}{
+		{validators.DisposableConfirmed, "Disposable Email", 1},
+		{validators.DisposableSuspected, "Suspected Disposable", 0.5},
+		{validators.DisposableNone, "", 0},
+	}
+	for _, tt := range tests {
+		intelligence := &models.EmailIntelligence{}
+		intelligence.DomainIntelligence.DisposableLevel = tt.level
+
+		factor := ""
+		for _, f := range NewRiskAnalyzer().Analyze(intelligence).RiskFactors {
+			if f.Factor == "Disposable Email" || f.Factor == "Suspected Disposable" {
+				factor = f.Factor
+			}
+		}
+		if factor != tt.wantFactor {
+			t.Errorf("%s: risk factor %q, want %q", tt.level, factor, tt.wantFactor)
+		}
+		if feature := disposableFeature(tt.level); feature != tt.wantFeature {
+			t.Errorf("%s: ML feature %v, want %v", tt.level, feature, tt.wantFeature)
+		}
+	}
+}
@@ -5,25 +5,52 @@ import (
 	"strings"
 
 	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
 )
 
+// reputationPoints is what the reputation category is scored out of before
+// rescaling: a tenth of the 0-100 reputation score
+const reputationPoints = 10
+
 // ScoreAnalyzer calculates validation scores
 type ScoreAnalyzer struct {
-	weights models.ScoringWeights
+	weights models.ScoringWeights // the scale each category is scored out of
+	strict  bool                  // no free-provider overrides
 }
 
-// NewScoreAnalyzer creates a new score analyzer
-func NewScoreAnalyzer(weights models.ScoringWeights) *ScoreAnalyzer {
-	return &ScoreAnalyzer{weights: weights}
+// NewScoreAnalyzer creates a new score analyzer for validators built with
+// weights. The DNS and security validators score out of fixed points
+// whatever the weights, so their categories keep that scale. In strict mode
+// free-provider addresses earn only the points their checks found.
+func NewScoreAnalyzer(weights models.ScoringWeights, strict bool) *ScoreAnalyzer {
+	weights.MXRecords = validators.MXPoints
+	weights.SecurityRecords = validators.SecurityPoints
+	weights.DomainReputation = reputationPoints
+	return &ScoreAnalyzer{weights: weights, strict: strict}
 }
 
-// Calculate calculates the enterprise score
-func (a *ScoreAnalyzer) Calculate(intelligence *models.EmailIntelligence) models.ScoreBreakdown {
+// Calculate calculates the enterprise score. Each category is scored out of
+// the scale its validator uses; the points are rescaled to weights, so a
+// profile can make a category count for more or less.
+func (a *ScoreAnalyzer) Calculate(intelligence *models.EmailIntelligence, weights models.ScoringWeights) models.ScoreBreakdown {
 	breakdown := models.ScoreBreakdown{
 		MaxPossible: 100,
+		CategoryMax: models.CategoryMaximums{
+			Syntax:     weights.SyntaxFormat,
+			MX:         weights.MXRecords,
+			Security:   weights.SecurityRecords,
+			SMTP:       weights.SMTPReachability,
+			Disposable: weights.DisposableCheck,
+			Reputation: weights.DomainReputation,
+			CatchAll:   weights.CatchAllRisk,
+		},
+		OverridesApplied: []string{},
 	}
 	
-	isFreeProvider := intelligence.DomainIntelligence.IsFreeProvider.Status == "pass"
+	// Strict mode turns off the free-provider overrides below, and so does a
+	// local part the provider would never have issued
+	isFreeProvider := intelligence.DomainIntelligence.IsFreeProvider.Status == "pass" && !a.strict &&
+		intelligence.DomainIntelligence.MailboxPlausibility.Status != "fail"
 	
 	// Syntax Score (10 points)
 	breakdown.SyntaxScore = intelligence.SyntaxValidation.Score
@@ -36,8 +63,9 @@ func (a *ScoreAnalyzer) Calculate(intelligence *models.EmailIntelligence) models
 	
 	// SMTP Score (20 points) - Full credit for trusted providers
 	breakdown.SMTPScore = intelligence.SMTPValidation.Reachable.Score
-	if isFreeProvider && breakdown.SMTPScore < 20 {
-		breakdown.SMTPScore = 20
+	if isFreeProvider && breakdown.SMTPScore < a.weights.SMTPReachability {
+		breakdown.SMTPScore = a.weights.SMTPReachability
+		breakdown.OverridesApplied = append(breakdown.OverridesApplied, "free_provider_smtp_full_credit")
 	}
 	
 	// Disposable Score (10 points)
@@ -47,21 +75,40 @@ func (a *ScoreAnalyzer) Calculate(intelligence *models.EmailIntelligence) models
 	reputationScore := intelligence.DomainIntelligence.ReputationScore
 	if isFreeProvider && reputationScore < 75 {
 		reputationScore = 85
+		breakdown.OverridesApplied = append(breakdown.OverridesApplied, "free_provider_reputation_85")
 	}
 	breakdown.ReputationScore = reputationScore / 10
 	
 	// Catch-all Score (10 points)
 	breakdown.CatchAllScore = intelligence.DomainIntelligence.IsCatchAll.Score
 	if isFreeProvider {
-		breakdown.CatchAllScore = 10
+		breakdown.CatchAllScore = a.weights.CatchAllRisk
+		breakdown.OverridesApplied = append(breakdown.OverridesApplied, "free_provider_catch_all_full_credit")
 	}
 	
+	// Rescale from the validators' scale to the requested weights
+	breakdown.SyntaxScore = rescale(breakdown.SyntaxScore, a.weights.SyntaxFormat, weights.SyntaxFormat)
+	breakdown.MXScore = rescale(breakdown.MXScore, a.weights.MXRecords, weights.MXRecords)
+	breakdown.SecurityScore = rescale(breakdown.SecurityScore, a.weights.SecurityRecords, weights.SecurityRecords)
+	breakdown.SMTPScore = rescale(breakdown.SMTPScore, a.weights.SMTPReachability, weights.SMTPReachability)
+	breakdown.DisposableScore = rescale(breakdown.DisposableScore, a.weights.DisposableCheck, weights.DisposableCheck)
+	breakdown.ReputationScore = rescale(breakdown.ReputationScore, a.weights.DomainReputation, weights.DomainReputation)
+	breakdown.CatchAllScore = rescale(breakdown.CatchAllScore, a.weights.CatchAllRisk, weights.CatchAllRisk)
+	
 	// Calculate total
 	breakdown.TotalScore = breakdown.SyntaxScore + breakdown.MXScore + breakdown.SecurityScore +
 		breakdown.SMTPScore + breakdown.DisposableScore + breakdown.ReputationScore + breakdown.CatchAllScore
 	
+	// Checks that could not be looked up are left out rather than scored as
+	// missing, so a DNS outage does not read as a badly configured domain
+	if unscored := a.unscoredPoints(intelligence, breakdown, weights); unscored > 0 && unscored < 100 {
+		breakdown.TotalScore = rescale(breakdown.TotalScore, 100-unscored, 100)
+		breakdown.OverridesApplied = append(breakdown.OverridesApplied, fmt.Sprintf("degraded_checks_excluded_%d", unscored))
+	}
+	
 	if breakdown.TotalScore > 100 {
 		breakdown.TotalScore = 100
+		breakdown.OverridesApplied = append(breakdown.OverridesApplied, "total_capped_at_100")
 	}
 	
 	breakdown.Explanation = a.generateExplanation(breakdown)
@@ -69,6 +116,35 @@ func (a *ScoreAnalyzer) Calculate(intelligence *models.EmailIntelligence) models
 	return breakdown
 }
 
+// unscoredPoints is how many of the 100 points belong to checks whose lookups
+// failed, in the requested weights. Without MX hosts the SMTP check never
+// ran, so it is left out with them unless an override already scored it.
+func (a *ScoreAnalyzer) unscoredPoints(intelligence *models.EmailIntelligence, breakdown models.ScoreBreakdown, weights models.ScoringWeights) int {
+	unscored := 0
+	if validators.Degraded(intelligence.DNSValidation.MXRecords) {
+		unscored += weights.MXRecords
+		if breakdown.SMTPScore == 0 {
+			unscored += weights.SMTPReachability
+		}
+	}
+	security := intelligence.SecurityAnalysis
+	securityPoints := 0
+	for _, record := range []models.ValidationResult{security.SPFRecord, security.DMARCRecord, security.DKIMRecord} {
+		if validators.Degraded(record) {
+			securityPoints += record.Weight
+		}
+	}
+	return unscored + rescale(securityPoints, a.weights.SecurityRecords, weights.SecurityRecords)
+}
+
+// rescale converts points earned out of from into points out of to, rounding to nearest
+func rescale(points, from, to int) int {
+	if from <= 0 {
+		return 0
+	}
+	return (points*to + from/2) / from
+}
+
 func (a *ScoreAnalyzer) generateExplanation(breakdown models.ScoreBreakdown) string {
 	explanations := []string{}
 	
@@ -84,8 +160,10 @@ func (a *ScoreAnalyzer) generateExplanation(breakdown models.ScoreBreakdown) str
 	if breakdown.SMTPScore > 0 {
 		explanations = append(explanations, fmt.Sprintf("SMTP reachable (+%d)", breakdown.SMTPScore))
 	}
-	if breakdown.DisposableScore > 0 {
+	if breakdown.DisposableScore >= breakdown.CategoryMax.Disposable && breakdown.DisposableScore > 0 {
 		explanations = append(explanations, fmt.Sprintf("Not disposable (+%d)", breakdown.DisposableScore))
+	} else if breakdown.DisposableScore > 0 {
+		explanations = append(explanations, fmt.Sprintf("Possibly disposable (+%d)", breakdown.DisposableScore))
 	}
 	
 	if len(explanations) == 0 {
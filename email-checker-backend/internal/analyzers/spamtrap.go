@@ -0,0 +1,140 @@
+package analyzers
+
+import (
+	"strings"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+// Spam trap risk levels
+const (
+	SpamTrapNone   = "none"
+	SpamTrapLow    = "low"
+	SpamTrapMedium = "medium"
+	SpamTrapHigh   = "high"
+)
+
+// DefaultHoneypotPatterns are local part fragments used by published
+// honeypots and trap addresses
+var DefaultHoneypotPatterns = []string{
+	"spamtrap", "spam-trap", "spam.trap", "spam_trap",
+	"honeypot", "honey-pot", "honey.pot",
+	"blackhole", "bitbucket", "devnull",
+}
+
+// SpamTrapThresholds tunes the spam trap heuristic
+type SpamTrapThresholds struct {
+	OldDomainDays int     // domain age after which heavy bouncing suggests a recycled domain
+	BounceRate    float64 // domain bounce rate typical of abandoned mailboxes
+	ComplaintRate float64 // domain complaint rate typical of trap-heavy lists
+	MediumAt      int     // risk score at which the level becomes medium
+	HighAt        int     // risk score at which the level becomes high
+}
+
+// DefaultSpamTrapThresholds flags only combinations of signals as medium or
+// high so that an ordinary info@ address stays low
+func DefaultSpamTrapThresholds() SpamTrapThresholds {
+	return SpamTrapThresholds{
+		OldDomainDays: 5 * 365,
+		BounceRate:    0.2,
+		ComplaintRate: 0.02,
+		MediumAt:      30,
+		HighAt:        60,
+	}
+}
+
+// SpamTrapAnalyzer combines role, domain and feedback signals into an
+// estimate of spam trap risk. It cannot know real trap lists; it only
+// surfaces the patterns traps tend to share.
+type SpamTrapAnalyzer struct {
+	thresholds SpamTrapThresholds
+	patterns   []string
+}
+
+// NewSpamTrapAnalyzer creates a spam trap analyzer. patterns are matched
+// case-insensitively against the local part; nil uses DefaultHoneypotPatterns.
+func NewSpamTrapAnalyzer(thresholds SpamTrapThresholds, patterns []string) *SpamTrapAnalyzer {
+	if patterns == nil {
+		patterns = DefaultHoneypotPatterns
+	}
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return &SpamTrapAnalyzer{thresholds: thresholds, patterns: normalized}
+}
+
+// Analyze scores the spam trap risk of an analyzed address. DNS, security and
+// domain results must already be filled in.
+func (a *SpamTrapAnalyzer) Analyze(intelligence *models.EmailIntelligence) models.SpamTrapRisk {
+	risk := models.SpamTrapRisk{
+		Level:   SpamTrapNone,
+		Signals: []string{},
+	}
+	t := a.thresholds
+
+	local := strings.ToLower(intelligence.Email)
+	if at := strings.LastIndex(local, "@"); at != -1 {
+		local = local[:at]
+	}
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+
+	for _, pattern := range a.patterns {
+		if strings.Contains(local, pattern) {
+			risk.Score += 60
+			risk.Signals = append(risk.Signals, "honeypot_pattern")
+			break
+		}
+	}
+
+	// Role mailboxes never opt in themselves; at a domain that rejects
+	// unauthenticated mail they are a classic pristine trap
+	if intelligence.DomainIntelligence.IsRoleAccount.Status == "fail" {
+		risk.Score += 10
+		risk.Signals = append(risk.Signals, "role_address")
+		if intelligence.SecurityAnalysis.DMARCPolicy == "reject" {
+			risk.Score += 20
+			risk.Signals = append(risk.Signals, "role_at_dmarc_reject")
+		}
+	}
+
+	// Old domains whose mail now bounces heavily were likely abandoned and
+	// reactivated, the typical source of recycled traps
+	domain := intelligence.DomainIntelligence
+	if feedback := domain.Feedback; feedback.Total >= validators.MinFeedbackSamples {
+		if feedback.BounceRate >= t.BounceRate && domain.DomainAge >= t.OldDomainDays {
+			risk.Score += 30
+			risk.Signals = append(risk.Signals, "recycled_domain")
+		}
+		if feedback.ComplaintRate >= t.ComplaintRate {
+			risk.Score += 20
+			risk.Signals = append(risk.Signals, "high_complaint_rate")
+		}
+	}
+
+	// Parked domains that still accept mail are often run as trap networks
+	if intelligence.DNSValidation.SuspiciousNameservers && intelligence.DNSValidation.MXRecords.Status == "pass" {
+		risk.Score += 15
+		risk.Signals = append(risk.Signals, "parked_domain_accepting_mail")
+	}
+
+	if risk.Score > 100 {
+		risk.Score = 100
+	}
+
+	switch {
+	case risk.Score >= t.HighAt:
+		risk.Level = SpamTrapHigh
+	case risk.Score >= t.MediumAt:
+		risk.Level = SpamTrapMedium
+	case risk.Score > 0:
+		risk.Level = SpamTrapLow
+	}
+
+	return risk
+}
@@ -0,0 +1,188 @@
+package analyzers
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DefaultPopularDomains are the mailbox providers most addresses belong to,
+// most popular first. Typos are corrected toward them, and an address at one
+// of them is never corrected.
+var DefaultPopularDomains = []string{
+	"gmail.com", "yahoo.com", "hotmail.com", "outlook.com", "icloud.com",
+	"aol.com", "live.com", "msn.com", "me.com", "mail.com",
+	"googlemail.com", "protonmail.com", "proton.me", "yandex.com", "zoho.com",
+	"gmx.com", "gmx.de", "gmx.net", "web.de", "mail.ru",
+	"yahoo.co.uk", "yahoo.co.in", "hotmail.co.uk", "outlook.de", "comcast.net",
+}
+
+// KnownProviderDomains are real mailbox providers that are not worth offering
+// as corrections but are a letter or two from one that is, so they must never
+// be corrected themselves
+var KnownProviderDomains = []string{
+	"ymail.com", "email.com", "yahoo.co.jp", "yahoo.ca", "yahoo.fr",
+	"protonmail.ch", "pm.me", "outlook.fr", "outlook.it", "hotmail.fr",
+	"hotmail.it", "live.co.uk", "gmx.at", "gmx.ch", "yandex.ru",
+}
+
+// maxSuggestions caps the "did you mean" alternatives for one address
+const maxSuggestions = 3
+
+// TypoCorrector suggests popular domains a mistyped domain was probably meant
+// to be
+type TypoCorrector struct {
+	domains  []string
+	known    map[string]bool
+	regional map[string]bool // labels popular under more than one suffix, like yahoo
+}
+
+// NewTypoCorrector creates a typo corrector. domains are in order of
+// popularity, which breaks ties between equally close suggestions; nil uses
+// DefaultPopularDomains.
+func NewTypoCorrector(domains []string) *TypoCorrector {
+	if domains == nil {
+		domains = DefaultPopularDomains
+	}
+	c := &TypoCorrector{known: make(map[string]bool, len(domains)+len(KnownProviderDomains))}
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" && !c.known[domain] {
+			c.known[domain] = true
+			c.domains = append(c.domains, domain)
+		}
+	}
+	for _, domain := range KnownProviderDomains {
+		c.known[domain] = true
+	}
+
+	c.regional = make(map[string]bool)
+	suffixes := make(map[string]string)
+	for _, domain := range c.domains {
+		label, suffix, _ := splitSuffix(domain)
+		if seen, ok := suffixes[label]; ok && seen != suffix {
+			c.regional[label] = true
+		}
+		suffixes[label] = suffix
+	}
+	return c
+}
+
+// Suggest returns the popular domains closest to domain, most popular first.
+// Only the nearest within two edits are kept, so "gmaill.com" offers gmail.com
+// and not also mail.com; short domains allow just one edit.
+//
+// A real suffix other than the popular domain's was most likely meant, so
+// only the labels before the suffixes are compared, and a label popular under
+// several suffixes, like yahoo.fr's, marks a regional domain that is left
+// alone. A label matching a provider with a single suffix ("gmail.co") and a
+// misspelled suffix (".con") are compared as the whole domain.
+func (c *TypoCorrector) Suggest(domain string) []string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if domain == "" || c.known[domain] {
+		return nil
+	}
+	label, suffix, listed := splitSuffix(domain)
+	if listed && c.regional[label] {
+		return nil
+	}
+
+	type candidate struct {
+		domain      string
+		distance    int
+		crossSuffix bool // only the labels were compared
+		rank        int
+	}
+	candidates := []candidate{}
+	for rank, popular := range c.domains {
+		a, b := domain, popular
+		popularLabel, popularSuffix, _ := splitSuffix(popular)
+		crossSuffix := listed && suffix != popularSuffix && label != popularLabel
+		if crossSuffix {
+			a, b = label, popularLabel
+		}
+		maxDistance := typoDistance(a)
+		if distance := editDistance(a, b, maxDistance); distance <= maxDistance {
+			candidates = append(candidates, candidate{domain: popular, distance: distance, crossSuffix: crossSuffix, rank: rank})
+		}
+	}
+	// At the same distance, a domain under the suffix that was typed wins
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		if candidates[i].crossSuffix != candidates[j].crossSuffix {
+			return !candidates[i].crossSuffix
+		}
+		return candidates[i].rank < candidates[j].rank
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, candidate := range candidates {
+		if len(suggestions) == maxSuggestions || candidate.distance > candidates[0].distance ||
+			candidate.crossSuffix != candidates[0].crossSuffix {
+			break
+		}
+		suggestions = append(suggestions, candidate.domain)
+	}
+	return suggestions
+}
+
+// typoDistance is the number of edits allowed when correcting s: two, or one
+// for short strings, where two edits reach too many unrelated names
+func typoDistance(s string) int {
+	if len(s) <= 7 {
+		return 1
+	}
+	return 2
+}
+
+// splitSuffix splits domain into the label before its public suffix and the
+// suffix, e.g. "yahoo" and "co.uk". listed is false when the suffix is not in
+// the public suffix list, such as a mistyped ".con".
+func splitSuffix(domain string) (label, suffix string, listed bool) {
+	suffix, icann := publicsuffix.PublicSuffix(domain)
+	listed = icann && suffix != domain
+	label = strings.TrimSuffix(strings.TrimSuffix(domain, suffix), ".")
+	if dot := strings.LastIndexByte(label, '.'); dot != -1 {
+		label = label[dot+1:]
+	}
+	return label, suffix, listed
+}
+
+// editDistance is the Levenshtein distance between a and b, with a swap of
+// two adjacent characters ("gmial") counted as one edit. Anything above limit
+// is reported as limit+1 without finishing the table.
+func editDistance(a, b string, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+	// Three rows: the previous two (for transpositions) and the current one
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
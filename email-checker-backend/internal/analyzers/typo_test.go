@@ -0,0 +1,94 @@
+package analyzers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestCorrectsTypos(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   []string
+	}{
+		// Keyboard slips: a neighbouring key hit instead
+		{"gmsil.com", []string{"gmail.com"}},
+		{"hotnail.com", []string{"hotmail.com"}},
+		{"yajoo.com", []string{"yahoo.com"}},
+		{"outlool.com", []string{"outlook.com"}},
+		{"icloud.xom", []string{"icloud.com"}},
+
+		// Swapped, doubled and dropped letters
+		{"gmial.com", []string{"gmail.com"}},
+		{"gmaill.com", []string{"gmail.com"}},
+		{"yahooo.com", []string{"yahoo.com"}},
+		{"hotmal.com", []string{"hotmail.com"}},
+
+		// Mistyped suffixes
+		{"gmail.con", []string{"gmail.com"}},
+		{"gmail.co", []string{"gmail.com"}},
+		{"gmail.cm", []string{"gmail.com"}},
+		{"protonmail.co", []string{"protonmail.com"}},
+
+		// A typo in the label under another real suffix
+		{"hotmial.fr", []string{"hotmail.com", "hotmail.co.uk"}},
+
+		{"GMIAL.COM.", []string{"gmail.com"}},
+	}
+	c := NewTypoCorrector(nil)
+	for _, tt := range tests {
+		if got := c.Suggest(tt.domain); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestLeavesRealDomainsAlone(t *testing.T) {
+	domains := []string{
+		// Popular and known providers
+		"gmail.com", "yahoo.co.uk", "ymail.com", "email.com", "protonmail.ch",
+		"yahoo.co.jp", "yahoo.ca", "outlook.fr", "outlook.it",
+
+		// Regional domains of providers popular under several suffixes
+		"yahoo.fr", "yahoo.de", "hotmail.de", "gmx.fr", "gmx.org", "mail.de",
+
+		// Unrelated domains
+		"example.com", "acme.io", "live.fr", "zoho.in", "aol.co.uk", "",
+	}
+	c := NewTypoCorrector(nil)
+	for _, domain := range domains {
+		if got := c.Suggest(domain); len(got) != 0 {
+			t.Errorf("Suggest(%q) = %v, want no suggestion", domain, got)
+		}
+	}
+}
+
+func TestSuggestCustomDomains(t *testing.T) {
+	c := NewTypoCorrector([]string{"example.com", "Example.com", "corp.example"})
+	if got := c.Suggest("exmaple.com"); !reflect.DeepEqual(got, []string{"example.com"}) {
+		t.Errorf("Suggest(exmaple.com) = %v", got)
+	}
+	// Known providers are never corrected, whatever the configured list
+	if got := c.Suggest("ymail.com"); len(got) != 0 {
+		t.Errorf("Suggest(ymail.com) = %v", got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"gmail", "gmail", 2, 0},
+		{"gmial", "gmail", 2, 1}, // transposition
+		{"gmsil", "gmail", 2, 1},
+		{"gml", "gmail", 2, 2},
+		{"hotmail", "gmail", 2, 3}, // over the limit: limit+1
+		{"a", "abcdef", 2, 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
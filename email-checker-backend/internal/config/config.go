@@ -1,32 +1,150 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"email-intelligence/internal/models"
+	"email-intelligence/internal/resultcache"
 )
 
 // Config holds application configuration
 type Config struct {
-	Port             string
-	CORSOrigins      []string
-	SMTPTimeout      time.Duration
-	DNSTimeout       time.Duration
-	WorkerPoolSize   int
-	CacheDuration    time.Duration
-	ScoringWeights   models.ScoringWeights
+	Port                string
+	CORSOrigins         []string
+	SMTPTimeout         time.Duration
+	SMTPStartTLS        bool
+	SMTPCacheTTL        time.Duration
+	SMTPBlockThreshold  int           // consecutive refusals/421s before an MX host is paused
+	SMTPBlockCooldown   time.Duration // how long a paused MX host is left alone
+	SMTPSourceIPs       []string      // local IPs or interface names SMTP probes rotate through
+	SMTPMaxConnsPerHost int           // simultaneous SMTP connections to one MX host
+	SMTPRetries         int           // extra SMTP rounds after a 4xx deferral in async jobs; 0 disables retries
+	SMTPRetryBackoff    time.Duration // wait before the first retry, doubled for each further one
+	SMTPRetryBudget     time.Duration // longest an async job spends on one address's SMTP check when retrying
+	DNSTimeout          time.Duration
+	DNSCacheTTL         time.Duration
+	DNSTransport        string            // plain, dot (DNS over TLS), doh (DNS over HTTPS) or doh-json (DoH JSON API)
+	DNSServer           string            // resolver for DNSTransport; empty uses resolv.conf or a public encrypted resolver
+	DNSSECResolver      string            // validating resolver for DNSSEC checks; empty uses the DNS transport
+	SecurityTimeout     time.Duration     // budget for SPF/DMARC/DKIM lookups per domain
+	DKIMConcurrency     int               // DKIM selector lookups in flight per domain
+	DKIMSelectors       []string          // searched in addition to the built-in selector list
+	ESPIncludes         map[string]string // SPF include domain -> reputable sending provider
+	WorkerPoolSize      int
+	CacheDuration       time.Duration
+	JobWorkers          int
+	JobDomainLimit      int
+	BulkDomainLimit     int           // analyses in flight per domain in /bulk-analyze after the first
+	BulkSMTPSpacing     time.Duration // minimum gap between deep analyses of one domain in /bulk-analyze
+	BulkTextMaxBytes    int64         // largest body /bulk-analyze-text accepts
+	JobTTL              time.Duration
+	JobMaxUpload        int64
+	JobCallbackSecret   string // HMAC key signing job completion callbacks; empty disables callback_url
+	PublicURL           string // base URL clients reach the API at, e.g. https://api.example.com
+	ScoringWeights      models.ScoringWeights
+	ScoringProfiles     map[string]models.ScoringProfile
+	CanonicalRules      map[string]models.CanonicalRule
+	HighValueDomains    []string // always analyzed deeply (domains or parent suffixes)
+	CheapDomains        []string // never analyzed deeply
+	InternalDomains     []string // internal/test domains reported as "Internal" instead of scored
+	TLDReputation       map[string]int
+	QualityThresholds   models.QualityThresholds
+	StrictFreeProviders bool     // free providers lose the automatic "Safe" verdict when other risk signals are present
+	StrictMode          bool     // no trusted/free-provider shortcuts: such addresses score only on DNS and SMTP evidence
+	RCPTConfidenceBoost bool     // RCPT-verified mailboxes reach higher confidence at lower scores
+	PremiumRequiresRCPT bool     // reserve the Premium tier for RCPT-verified mailboxes
+	ValidScore          int      // minimum score for an address to be valid under the default profile
+	DisposableDomains   []string // extra disposable domains on top of the built-in list
+	DisposablePatterns  []string // wildcard patterns such as *.tempmail.* whose matches are disposable
+	DisposableFuzzy     bool     // also mark domains containing disposable keywords as suspected; false disables the heuristic
+	DisposableCacheSize int      // recent disposable verdicts kept in memory
+	SpamTrapPatterns    []string // extra honeypot local part fragments on top of the built-in list
+	RolePatterns        []string // extra role account local parts (e.g. "careers") on top of the built-in list
+	BrandDomains        []string // extra brand domains whose lookalikes are flagged, on top of the built-in list
+	PopularDomains      []string // extra typo correction targets, after the built-in providers in popularity
+	ProbeHeloName       string   // EHLO name for SMTP probes; strict servers reject names without matching forward/reverse DNS
+	ProbeMailFrom       string   // envelope sender for SMTP probes; empty uses verify@ProbeHeloName
+	ProbeUserAgent      string   // User-Agent for outbound HTTP integrations
+	ProbeContact        string   // abuse contact (email or URL) advertised by outbound probes
+	DatabaseURL         string   // Postgres DSN for result storage; empty disables it
+	ResultBufferSize    int      // results queued for the database before new ones are dropped
+	StoreFullResults    bool     // store the complete result JSON, not just the summary
+	OTLPEndpoint        string   // OTLP/HTTP traces URL; empty disables tracing
+	OTLPHeaders         map[string]string
+	OTelServiceName     string
+	SelfTest            bool          // analyze known fixtures at startup; /ready fails until they pass
+	SelfTestTimeout     time.Duration // budget for each run of the startup self-test
+	BlocklistZones      []string      // DNSBL zones queried for the domain's mail servers; "none" disables
+	DisposableSource    string        // file path or http(s) URL of a newline-delimited disposable domain list
+	DisposableRefresh   time.Duration // reload interval for DisposableSource; zero loads it once
+	RDAPBaseURL         string        // RDAP service for domain registration dates; "none" disables
+	RDAPTimeout         time.Duration // budget for one RDAP lookup
+	DomainAgeTTL        time.Duration // how long a domain's registration date is reused
+	CacheBackend        string        // memory (per process) or redis (shared between replicas)
+	RedisURL            string        // redis:// or rediss:// URL for the redis cache backend
+	RateLimitWindow     time.Duration // period over which RateLimitBurst analyses of one address are allowed
+	RateLimitBurst      int           // uncached analyses of one address allowed per RateLimitWindow
+	LogLevel            string        // debug, info, warn or error
+	DomainCacheTTL      time.Duration // how long addresses at a domain share its DNS, security and domain results
+	StatsMaxDomains     int           // domains /stats keeps counts for; the least recently seen are dropped beyond it
+	FeedbackMaxDomains  int           // domains delivery feedback is kept for; the least recently reported are dropped beyond it
+	FeedbackTTL         time.Duration // how long a domain's feedback counts last without a new outcome; zero keeps them until dropped
+	FeedbackRateWindow  time.Duration // period over which FeedbackRateBurst outcomes from one client are accepted
+	FeedbackRateBurst   int           // feedback outcomes one client may report per FeedbackRateWindow
+	AnalysisTimeout     time.Duration // budget for one analysis's network stages; unfinished stages are reported as unknown. Zero disables it
+	VirusTotalAPIKey    string        // enables VirusTotal domain reputation; empty keeps the heuristic only
+	VirusTotalRate      int           // VirusTotal requests per minute allowed by the key
+	ReputationTimeout   time.Duration // budget for one reputation lookup, including any wait for the rate limit
+	ReputationTTL       time.Duration // how long a domain's scanner verdict is reused
+	AdminAPIKey         string        // required by the admin endpoints; empty disables them
+
+	profilesErr   error // SCORING_PROFILES could not be parsed
+	canonicalErr  error // CANONICAL_RULES could not be parsed
+	thresholdsErr error // QUALITY_THRESHOLDS could not be parsed
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
-	return &Config{
-		Port:           getEnv("PORT", "8080"),
-		CORSOrigins:    getCORSOrigins(),
-		SMTPTimeout:    3 * time.Second,
-		DNSTimeout:     2 * time.Second,
-		WorkerPoolSize: 100,
-		CacheDuration:  15 * time.Minute,
+	cfg := &Config{
+		Port:               getEnv("PORT", "8080"),
+		CORSOrigins:        getCORSOrigins(),
+		SMTPTimeout:        3 * time.Second,
+		SMTPStartTLS:       getEnv("SMTP_STARTTLS", "false") == "true",
+		SMTPCacheTTL:       10 * time.Minute,
+		SMTPBlockThreshold: getIntEnv("SMTP_BLOCK_THRESHOLD", 3),
+		SMTPBlockCooldown:  getDurationEnv("SMTP_BLOCK_COOLDOWN", 5*time.Minute),
+		SMTPSourceIPs:      splitAndTrim(getEnv("SMTP_SOURCE_IPS", ""), ","),
+		SMTPRetries:        getIntEnv("SMTP_RETRIES", 0),
+		SMTPRetryBackoff:   getDurationEnv("SMTP_RETRY_BACKOFF", time.Minute),
+		SMTPRetryBudget:    getDurationEnv("SMTP_RETRY_BUDGET", 5*time.Minute),
+		DNSTimeout:         2 * time.Second,
+		DNSCacheTTL:        5 * time.Minute,
+		DNSTransport:       getDNSTransport(),
+		DNSServer:          getEnv("DNS_SERVER", ""),
+		DNSSECResolver:     getEnv("DNSSEC_RESOLVER", ""),
+		SecurityTimeout:    getDurationEnv("SECURITY_TIMEOUT", 3*time.Second),
+		DKIMConcurrency:    getIntEnv("DKIM_CONCURRENCY", 8),
+		DKIMSelectors:      splitAndTrim(getEnv("DKIM_SELECTORS", ""), ","),
+		ESPIncludes:        getESPIncludes(),
+		WorkerPoolSize:     100,
+		CacheDuration:      15 * time.Minute,
+		JobWorkers:         20,
+		JobDomainLimit:     2,
+		BulkDomainLimit:    getIntEnv("BULK_DOMAIN_LIMIT", 4),
+		BulkSMTPSpacing:    getDurationEnv("BULK_SMTP_SPACING", 200*time.Millisecond),
+		BulkTextMaxBytes:   int64(getIntEnv("BULK_TEXT_MAX_BYTES", 1<<20)),
+		JobTTL:             getDurationEnv("JOB_TTL", 24*time.Hour),
+		JobMaxUpload:       50 << 20,
+		JobCallbackSecret:  getEnv("JOB_CALLBACK_SECRET", ""),
+		PublicURL:          getEnv("PUBLIC_URL", ""),
 		ScoringWeights: models.ScoringWeights{
 			SyntaxFormat:     10,
 			MXRecords:        20,
@@ -36,7 +154,446 @@ func Load() *Config {
 			DomainReputation: 10,
 			CatchAllRisk:     10,
 		},
+		HighValueDomains:    splitAndTrim(getEnv("HIGH_VALUE_DOMAINS", ""), ","),
+		CheapDomains:        splitAndTrim(getEnv("CHEAP_DOMAINS", ""), ","),
+		InternalDomains:     splitAndTrim(getEnv("INTERNAL_DOMAINS", ""), ","),
+		TLDReputation:       getTLDReputation(),
+		StrictFreeProviders: getEnv("STRICT_FREE_PROVIDERS", "true") == "true",
+		StrictMode:          getEnv("STRICT_MODE", "false") == "true",
+		RCPTConfidenceBoost: getEnv("RCPT_CONFIDENCE_BOOST", "true") == "true",
+		PremiumRequiresRCPT: getEnv("PREMIUM_REQUIRES_RCPT", "false") == "true",
+		ValidScore:          getIntEnv("VALID_SCORE", 50),
+		DisposableDomains:   splitAndTrim(getEnv("DISPOSABLE_DOMAINS", ""), ","),
+		DisposablePatterns:  splitAndTrim(getEnv("DISPOSABLE_PATTERNS", ""), ","),
+		DisposableFuzzy:     getEnv("DISPOSABLE_FUZZY", "true") == "true",
+		DisposableCacheSize: 10000,
+		SpamTrapPatterns:    splitAndTrim(getEnv("SPAM_TRAP_PATTERNS", ""), ","),
+		RolePatterns:        splitAndTrim(getEnv("ROLE_ACCOUNT_PATTERNS", ""), ","),
+		BrandDomains:        splitAndTrim(getEnv("BRAND_DOMAINS", ""), ","),
+		PopularDomains:      splitAndTrim(getEnv("POPULAR_DOMAINS", ""), ","),
+		ProbeHeloName:       getEnv("SMTP_HELO_HOST", getEnv("PROBE_HELO_NAME", "emailintel.local")),
+		ProbeMailFrom:       getEnv("SMTP_MAIL_FROM", getEnv("PROBE_MAIL_FROM", "")),
+		SMTPMaxConnsPerHost: getIntEnv("SMTP_MAX_CONNS_PER_HOST", 2),
+		ProbeUserAgent:      getEnv("PROBE_USER_AGENT", "EmailIntelligence/2.0"),
+		ProbeContact:        getEnv("PROBE_CONTACT", ""),
+		DatabaseURL:         getEnv("DATABASE_URL", ""),
+		ResultBufferSize:    getIntEnv("RESULT_BUFFER_SIZE", 1000),
+		StoreFullResults:    getEnv("STORE_FULL_RESULTS", "false") == "true",
+		OTLPEndpoint:        getOTLPEndpoint(),
+		OTLPHeaders:         getOTLPHeaders(),
+		OTelServiceName:     getEnv("OTEL_SERVICE_NAME", "email-intelligence"),
+		SelfTest:            getEnv("SELF_TEST", "false") == "true",
+		SelfTestTimeout:     getDurationEnv("SELF_TEST_TIMEOUT", 15*time.Second),
+		BlocklistZones:      getBlocklistZones(),
+		DisposableSource:    getEnv("DISPOSABLE_LIST_SOURCE", ""),
+		DisposableRefresh:   getDurationEnv("DISPOSABLE_LIST_REFRESH", 0),
+		RDAPBaseURL:         getRDAPBaseURL(),
+		RDAPTimeout:         getDurationEnv("RDAP_TIMEOUT", 3*time.Second),
+		DomainAgeTTL:        getDurationEnv("DOMAIN_AGE_TTL", 24*time.Hour),
+		CacheBackend:        strings.ToLower(getEnv("CACHE_BACKEND", "memory")),
+		RedisURL:            getEnv("REDIS_URL", ""),
+		RateLimitWindow:     getDurationEnv("RATE_LIMIT_WINDOW", time.Second),
+		RateLimitBurst:      getIntEnv("RATE_LIMIT_BURST", 1),
+		LogLevel:            getEnv("LOG_LEVEL", "info"),
+		DomainCacheTTL:      getDurationEnv("DOMAIN_CACHE_TTL", 5*time.Minute),
+		StatsMaxDomains:     getIntEnv("STATS_MAX_DOMAINS", 10000),
+		FeedbackMaxDomains:  getIntEnv("FEEDBACK_MAX_DOMAINS", 10000),
+		FeedbackTTL:         getDurationEnv("FEEDBACK_TTL", 30*24*time.Hour),
+		FeedbackRateWindow:  getDurationEnv("FEEDBACK_RATE_WINDOW", time.Minute),
+		FeedbackRateBurst:   getIntEnv("FEEDBACK_RATE_BURST", 60),
+		AnalysisTimeout:     getDurationEnv("ANALYSIS_TIMEOUT", 0),
+		VirusTotalAPIKey:    getEnv("VIRUSTOTAL_API_KEY", ""),
+		VirusTotalRate:      getIntEnv("VIRUSTOTAL_RATE_LIMIT", 4),
+		ReputationTimeout:   getDurationEnv("REPUTATION_TIMEOUT", 3*time.Second),
+		ReputationTTL:       getDurationEnv("REPUTATION_TTL", 24*time.Hour),
+		AdminAPIKey:         getEnv("ADMIN_API_KEY", ""),
+	}
+	cfg.ScoringProfiles, cfg.profilesErr = getScoringProfiles(cfg.ScoringWeights)
+	cfg.CanonicalRules, cfg.canonicalErr = getCanonicalRules()
+	cfg.QualityThresholds, cfg.thresholdsErr = getQualityThresholds()
+	return cfg
+}
+
+// Validate reports configuration that cannot be used, such as scoring
+// profiles whose weights do not add up to 100
+func (c *Config) Validate() error {
+	if c.profilesErr != nil {
+		return fmt.Errorf("SCORING_PROFILES: %w", c.profilesErr)
+	}
+	if c.canonicalErr != nil {
+		return fmt.Errorf("CANONICAL_RULES: %w", c.canonicalErr)
+	}
+	if c.thresholdsErr != nil {
+		return fmt.Errorf("QUALITY_THRESHOLDS: %w", c.thresholdsErr)
+	}
+	if err := validateThresholds(c.QualityThresholds); err != nil {
+		return fmt.Errorf("QUALITY_THRESHOLDS: %w", err)
+	}
+	if c.ValidScore < 0 || c.ValidScore > 100 {
+		return fmt.Errorf("VALID_SCORE must be between 0 and 100")
+	}
+	for _, pattern := range c.DisposablePatterns {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return fmt.Errorf("DISPOSABLE_PATTERNS: %q: %w", pattern, err)
+		}
+	}
+	if c.BulkTextMaxBytes < 1 {
+		return fmt.Errorf("BULK_TEXT_MAX_BYTES must be positive")
+	}
+	if c.FeedbackRateWindow <= 0 || c.FeedbackRateBurst < 1 {
+		return fmt.Errorf("FEEDBACK_RATE_WINDOW and FEEDBACK_RATE_BURST must be positive")
+	}
+	for name, profile := range c.ScoringProfiles {
+		if err := validateProfile(profile); err != nil {
+			return fmt.Errorf("scoring profile %q: %w", name, err)
+		}
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("LOG_LEVEL: unknown level %q, want debug, info, warn or error", c.LogLevel)
+	}
+	switch c.CacheBackend {
+	case "memory":
+	case "redis":
+		if c.RedisURL == "" {
+			return fmt.Errorf("CACHE_BACKEND=redis requires REDIS_URL")
+		}
+		if _, err := resultcache.NewRedis(c.RedisURL); err != nil {
+			return fmt.Errorf("REDIS_URL: %w", err)
+		}
+	default:
+		return fmt.Errorf("CACHE_BACKEND: unknown backend %q, want memory or redis", c.CacheBackend)
+	}
+	return nil
+}
+
+// DefaultProfile is the profile used when a request does not name one
+func (c *Config) DefaultProfile() models.ScoringProfile {
+	return models.ScoringProfile{
+		Name:          DefaultProfileName,
+		Weights:       c.ScoringWeights,
+		ValidScore:    c.ValidScore,
+		HighRiskScore: 50,
+	}
+}
+
+// defaultTLDReputation seeds the domain reputation score (0-100) by public
+// suffix. Mainstream TLDs start neutral at 50, restricted TLDs start higher,
+// and TLDs with historically heavy abuse (free or very cheap registrations)
+// start lower. Unlisted suffixes use 50.
+var defaultTLDReputation = map[string]int{
+	"com": 50, "org": 50, "net": 50, "io": 50, "co": 50,
+	"co.uk": 50, "de": 50, "fr": 50, "ca": 50, "com.au": 50, "in": 50, "jp": 50,
+	"edu": 65, "gov": 70, "mil": 70, "ac.uk": 65, "gov.uk": 70,
+	"xyz": 35, "online": 35, "site": 35, "rest": 35, "info": 40, "biz": 40,
+	"top": 30, "click": 30, "work": 30, "buzz": 30, "icu": 30, "cam": 30,
+	"loan": 25, "win": 25, "bid": 25,
+	"tk": 20, "ml": 20, "ga": 20, "cf": 20, "gq": 20,
+}
+
+// defaultESPIncludes maps SPF include domains to the reputable sending
+// providers they belong to. Subdomains of an entry match too.
+var defaultESPIncludes = map[string]string{
+	"sendgrid.net":               "SendGrid",
+	"mailgun.org":                "Mailgun",
+	"amazonses.com":              "Amazon SES",
+	"spf.mtasv.net":              "Postmark",
+	"sparkpostmail.com":          "SparkPost",
+	"servers.mcsv.net":           "Mailchimp",
+	"spf.mandrillapp.com":        "Mandrill",
+	"_spf.google.com":            "Google Workspace",
+	"spf.protection.outlook.com": "Microsoft 365",
+	"zoho.com":                   "Zoho",
+}
+
+// getESPIncludes applies ESP_INCLUDES overrides ("mail.example.net:Example ESP") to the defaults
+func getESPIncludes() map[string]string {
+	includes := make(map[string]string, len(defaultESPIncludes))
+	for domain, provider := range defaultESPIncludes {
+		includes[domain] = provider
+	}
+	
+	for _, entry := range splitAndTrim(getEnv("ESP_INCLUDES", ""), ",") {
+		parts := splitAndTrim(entry, ":")
+		if len(parts) != 2 {
+			continue
+		}
+		includes[strings.ToLower(strings.TrimPrefix(parts[0], "."))] = parts[1]
+	}
+	
+	return includes
+}
+
+// defaultCanonicalRules describe how major mailbox providers treat local
+// parts. Providers not listed keep their local part case in storage and only
+// have case folded for duplicate matching.
+var defaultCanonicalRules = map[string]models.CanonicalRule{
+	"gmail.com":      {CaseInsensitive: true, IgnoreDots: true, TagSeparators: "+"},
+	"googlemail.com": {CaseInsensitive: true, IgnoreDots: true, TagSeparators: "+", Domain: "gmail.com"},
+	"outlook.com":    {CaseInsensitive: true, TagSeparators: "+"},
+	"hotmail.com":    {CaseInsensitive: true, TagSeparators: "+"},
+	"live.com":       {CaseInsensitive: true, TagSeparators: "+"},
+	"msn.com":        {CaseInsensitive: true, TagSeparators: "+"},
+	"yahoo.com":      {CaseInsensitive: true, TagSeparators: "-"},
+	"icloud.com":     {CaseInsensitive: true, TagSeparators: "+"},
+	"me.com":         {CaseInsensitive: true, TagSeparators: "+", Domain: "icloud.com"},
+	"mac.com":        {CaseInsensitive: true, TagSeparators: "+", Domain: "icloud.com"},
+	"fastmail.com":   {CaseInsensitive: true, TagSeparators: "+"},
+	"proton.me":      {CaseInsensitive: true, IgnoreDots: true, TagSeparators: "+"},
+	"protonmail.com": {CaseInsensitive: true, IgnoreDots: true, TagSeparators: "+", Domain: "proton.me"},
+}
+
+// getCanonicalRules merges CANONICAL_RULES (a JSON object of domain -> rule)
+// over the built-in provider rules
+func getCanonicalRules() (map[string]models.CanonicalRule, error) {
+	rules := make(map[string]models.CanonicalRule, len(defaultCanonicalRules))
+	for domain, rule := range defaultCanonicalRules {
+		rules[domain] = rule
+	}
+	
+	raw := getEnv("CANONICAL_RULES", "")
+	if raw == "" {
+		return rules, nil
+	}
+	var custom map[string]models.CanonicalRule
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return rules, err
+	}
+	for domain, rule := range custom {
+		rules[strings.ToLower(strings.TrimSpace(domain))] = rule
+	}
+	return rules, nil
+}
+
+// getQualityThresholds applies QUALITY_THRESHOLDS (a JSON object with any of
+// the models.QualityThresholds fields) over the built-in cutoffs
+func getQualityThresholds() (models.QualityThresholds, error) {
+	thresholds := defaultQualityThresholds
+	raw := getEnv("QUALITY_THRESHOLDS", "")
+	if raw == "" {
+		return thresholds, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &thresholds); err != nil {
+		return defaultQualityThresholds, err
+	}
+	return thresholds, nil
+}
+
+// defaultQualityThresholds are the built-in confidence and tier cutoffs
+var defaultQualityThresholds = models.QualityThresholds{
+	HighConfidence:           85,
+	MediumConfidence:         60,
+	VerifiedHighConfidence:   75,
+	VerifiedMediumConfidence: 50,
+	SafeFreeProvider:         60,
+	Premium:                  90,
+	Excellent:                75,
+	Good:                     60,
+	Fair:                     40,
+}
+
+// validateThresholds checks that cutoffs are within 0-100 and that each
+// level needs at least the score of the one below it
+func validateThresholds(t models.QualityThresholds) error {
+	for _, cutoff := range []int{t.HighConfidence, t.MediumConfidence, t.VerifiedHighConfidence, t.VerifiedMediumConfidence, t.SafeFreeProvider, t.Premium, t.Excellent, t.Good, t.Fair} {
+		if cutoff < 0 || cutoff > 100 {
+			return fmt.Errorf("thresholds must be between 0 and 100")
+		}
+	}
+	if t.HighConfidence < t.MediumConfidence || t.VerifiedHighConfidence < t.VerifiedMediumConfidence {
+		return fmt.Errorf("high confidence thresholds must not be below medium ones")
+	}
+	if t.Premium < t.Excellent || t.Excellent < t.Good || t.Good < t.Fair {
+		return fmt.Errorf("tier thresholds must descend from premium to fair")
+	}
+	return nil
+}
+
+// DefaultProfileName identifies the built-in weights and thresholds
+const DefaultProfileName = "default"
+
+// defaultScoringProfiles are the built-in named profiles; weights sum to 100
+var defaultScoringProfiles = map[string]models.ScoringProfile{
+	// Demands stronger evidence before calling an address valid
+	"strict": {
+		Weights:       models.ScoringWeights{SyntaxFormat: 10, MXRecords: 20, SecurityRecords: 20, SMTPReachability: 25, DisposableCheck: 10, DomainReputation: 10, CatchAllRisk: 5},
+		ValidScore:    70,
+		HighRiskScore: 40,
+	},
+	// Accepts most deliverable-looking addresses
+	"lenient": {
+		Weights:       models.ScoringWeights{SyntaxFormat: 10, MXRecords: 20, SecurityRecords: 20, SMTPReachability: 20, DisposableCheck: 10, DomainReputation: 10, CatchAllRisk: 10},
+		ValidScore:    40,
+		HighRiskScore: 65,
+	},
+	// Weighs whether mail will actually arrive
+	"deliverability": {
+		Weights:       models.ScoringWeights{SyntaxFormat: 5, MXRecords: 25, SecurityRecords: 10, SMTPReachability: 35, DisposableCheck: 10, DomainReputation: 5, CatchAllRisk: 10},
+		ValidScore:    60,
+		HighRiskScore: 50,
+	},
+	// Weighs signals of throwaway or abusive signups
+	"fraud": {
+		Weights:       models.ScoringWeights{SyntaxFormat: 10, MXRecords: 10, SecurityRecords: 20, SMTPReachability: 10, DisposableCheck: 20, DomainReputation: 20, CatchAllRisk: 10},
+		ValidScore:    55,
+		HighRiskScore: 35,
+	},
+}
+
+// getScoringProfiles merges SCORING_PROFILES (a JSON object of name -> profile)
+// over the built-in profiles. The default profile cannot be redefined.
+func getScoringProfiles(defaultWeights models.ScoringWeights) (map[string]models.ScoringProfile, error) {
+	profiles := make(map[string]models.ScoringProfile, len(defaultScoringProfiles))
+	for name, profile := range defaultScoringProfiles {
+		profile.Name = name
+		profiles[name] = profile
+	}
+	
+	raw := getEnv("SCORING_PROFILES", "")
+	if raw == "" {
+		return profiles, nil
+	}
+	var custom map[string]models.ScoringProfile
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return profiles, err
+	}
+	for name, profile := range custom {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == DefaultProfileName {
+			return profiles, fmt.Errorf("invalid profile name %q", name)
+		}
+		profile.Name = name
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// ValidateWeights reports weights that are negative or do not sum to 100
+func ValidateWeights(w models.ScoringWeights) error {
+	weights := []int{w.SyntaxFormat, w.MXRecords, w.SecurityRecords, w.SMTPReachability, w.DisposableCheck, w.DomainReputation, w.CatchAllRisk}
+	total := 0
+	for _, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("weights must not be negative")
+		}
+		total += weight
+	}
+	if total != 100 {
+		return fmt.Errorf("weights sum to %d, want 100", total)
+	}
+	return nil
+}
+
+// validateProfile checks that weights are non-negative and sum to 100 and
+// that thresholds are within 0-100
+func validateProfile(profile models.ScoringProfile) error {
+	if err := ValidateWeights(profile.Weights); err != nil {
+		return err
+	}
+	if profile.ValidScore < 0 || profile.ValidScore > 100 || profile.HighRiskScore < 1 || profile.HighRiskScore > 100 {
+		return fmt.Errorf("valid_score must be 0-100 and high_risk_score 1-100")
+	}
+	return nil
+}
+
+// getTLDReputation applies TLD_REPUTATION overrides ("tk:10,xyz:45") to the defaults
+func getTLDReputation() map[string]int {
+	baselines := make(map[string]int, len(defaultTLDReputation))
+	for tld, score := range defaultTLDReputation {
+		baselines[tld] = score
+	}
+	
+	for _, entry := range splitAndTrim(getEnv("TLD_REPUTATION", ""), ",") {
+		parts := splitAndTrim(entry, ":")
+		if len(parts) != 2 {
+			continue
+		}
+		if score, err := strconv.Atoi(parts[1]); err == nil && score >= 0 && score <= 100 {
+			baselines[strings.ToLower(strings.TrimPrefix(parts[0], "."))] = score
+		}
+	}
+	
+	return baselines
+}
+
+// getOTLPEndpoint reads the standard OpenTelemetry exporter variables. The
+// signal-specific OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is used as-is, while the
+// base OTEL_EXPORTER_OTLP_ENDPOINT gets the /v1/traces path appended. Tracing
+// is off when neither is set, when OTEL_SDK_DISABLED is true, or when
+// OTEL_TRACES_EXPORTER is "none". Spans are sent as OTLP/HTTP JSON.
+func getOTLPEndpoint() string {
+	if getEnv("OTEL_SDK_DISABLED", "false") == "true" || getEnv("OTEL_TRACES_EXPORTER", "otlp") == "none" {
+		return ""
+	}
+	if endpoint := getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", ""); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
 	}
+	return ""
+}
+
+// getOTLPHeaders parses OTEL_EXPORTER_OTLP_HEADERS and the traces-specific
+// variant ("api-key=secret,x-tenant=acme"); values may be URL-encoded
+func getOTLPHeaders() map[string]string {
+	headers := map[string]string{}
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		for _, entry := range splitAndTrim(getEnv(key, ""), ",") {
+			name, value, ok := strings.Cut(entry, "=")
+			if !ok || strings.TrimSpace(name) == "" {
+				continue
+			}
+			if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+				value = decoded
+			}
+			headers[strings.TrimSpace(name)] = value
+		}
+	}
+	return headers
+}
+
+// getRDAPBaseURL reads RDAP_BASE_URL; "none" disables domain age lookups
+func getRDAPBaseURL() string {
+	value := getEnv("RDAP_BASE_URL", "https://rdap.org")
+	if strings.EqualFold(value, "none") {
+		return ""
+	}
+	return value
+}
+
+// defaultBlocklistZones are the DNS blocklists queried unless BLOCKLIST_ZONES is set
+var defaultBlocklistZones = []string{"zen.spamhaus.org", "bl.spamcop.net", "b.barracudacentral.org"}
+
+// getBlocklistZones reads BLOCKLIST_ZONES (comma separated); unset uses the
+// default lists and "none" disables blocklist lookups
+func getBlocklistZones() []string {
+	value := getEnv("BLOCKLIST_ZONES", "")
+	if value == "" {
+		return append([]string{}, defaultBlocklistZones...)
+	}
+	if strings.EqualFold(value, "none") {
+		return []string{}
+	}
+	zones := []string{}
+	for _, zone := range splitAndTrim(value, ",") {
+		zones = append(zones, strings.ToLower(strings.Trim(zone, ".")))
+	}
+	return zones
+}
+
+// getDNSTransport reads DNS_TRANSPORT or, when unset, DNS_RESOLVER. There
+// "doh" means the DoH JSON API, which needs nothing but outbound HTTPS; set
+// DNS_TRANSPORT=doh for wire-format DoH.
+func getDNSTransport() string {
+	if transport := os.Getenv("DNS_TRANSPORT"); transport != "" {
+		return strings.ToLower(transport)
+	}
+	resolver := strings.ToLower(getEnv("DNS_RESOLVER", "plain"))
+	if resolver == "doh" {
+		return "doh-json"
+	}
+	return resolver
 }
 
 func getEnv(key, defaultValue string) string {
@@ -46,6 +603,22 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getDurationEnv parses a duration such as "1500ms" or "3s", falling back on bad input
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getIntEnv parses a positive integer, falling back on bad input
+func getIntEnv(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
 func getCORSOrigins() []string {
 	origins := getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,https://email-intelligence-platform.vercel.app")
 	result := []string{}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRedisURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{"redis://localhost:6379/0", ""},
+		{"rediss://:secret@cache.internal:6380", ""},
+		{"", "requires REDIS_URL"},
+		{"localhost:6379", "REDIS_URL"},
+		{"redis://localhost:6379/cache", "REDIS_URL"},
+		{"redis://", "REDIS_URL"},
+	}
+	for _, tt := range tests {
+		cfg := Load()
+		cfg.CacheBackend = "redis"
+		cfg.RedisURL = tt.url
+		err := cfg.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("REDIS_URL=%q: %v", tt.url, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("REDIS_URL=%q: err = %v, want %q", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDNSTransportFromEnv(t *testing.T) {
+	tests := []struct {
+		transport, resolver string
+		want                string
+	}{
+		{"", "", "plain"},
+		{"", "doh", "doh-json"},
+		{"", "DoH", "doh-json"},
+		{"", "dot", "dot"},
+		{"doh", "", "doh"},
+		{"doh", "plain", "doh"}, // DNS_TRANSPORT wins
+	}
+	for _, tt := range tests {
+		t.Setenv("DNS_TRANSPORT", tt.transport)
+		t.Setenv("DNS_RESOLVER", tt.resolver)
+		if got := Load().DNSTransport; got != tt.want {
+			t.Errorf("DNS_TRANSPORT=%q DNS_RESOLVER=%q: transport %q, want %q", tt.transport, tt.resolver, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalRules(t *testing.T) {
+	t.Setenv("CANONICAL_RULES", `{"Example.com": {"case_insensitive": true, "tag_separators": "-"}, "outlook.com": {"ignore_dots": true}}`)
+	cfg := Load()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rule := cfg.CanonicalRules["googlemail.com"]; !rule.IgnoreDots || rule.Domain != "gmail.com" {
+		t.Errorf("googlemail.com rule = %+v, want dots ignored and aliased to gmail.com", rule)
+	}
+	if rule := cfg.CanonicalRules["example.com"]; !rule.CaseInsensitive || rule.TagSeparators != "-" {
+		t.Errorf("example.com rule = %+v, want the configured rule", rule)
+	}
+	// A configured rule replaces the built-in one
+	if rule := cfg.CanonicalRules["outlook.com"]; !rule.IgnoreDots || rule.TagSeparators != "" {
+		t.Errorf("outlook.com rule = %+v, want the configured rule", rule)
+	}
+
+	t.Setenv("CANONICAL_RULES", `{"example.com": true}`)
+	if err := Load().Validate(); err == nil || !strings.Contains(err.Error(), "CANONICAL_RULES") {
+		t.Errorf("err = %v, want a CANONICAL_RULES error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"email-intelligence/internal/models"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// domainChecks are the results that depend only on the domain, so every
+// address at a domain can share them
+type domainChecks struct {
+	DNS      models.DNSValidationResult
+	Security models.SecurityAnalysisResult
+	Domain   models.DomainIntelligenceResult // with MX fingerprint, blocklists and age applied
+
+	Unfinished []string // stages still running when the context ended, e.g. the analysis budget
+}
+
+// domainCache keeps domainChecks per domain and runs the checks once for all
+// concurrent analyses of the same domain, so a bulk run of many addresses at
+// one company costs one round of DNS and security lookups
+type domainCache struct {
+	items *cache.Cache
+	group singleflight.Group
+	ttl   time.Duration
+}
+
+func newDomainCache(ttl time.Duration) *domainCache {
+	return &domainCache{items: cache.New(ttl, ttl*2), ttl: ttl}
+}
+
+// get returns the cached checks for domain or runs check. Only complete runs
+// that found mail servers are kept: a lookup that timed out or was cancelled
+// must not stand in for the domain until the TTL runs out.
+func (c *domainCache) get(ctx context.Context, domain string, check func(context.Context, string) domainChecks) (domainChecks, bool) {
+	domain = strings.ToLower(domain)
+	if cached, found := c.items.Get(domain); found {
+		return cached.(domainChecks), true
+	}
+
+	value, _, shared := c.group.Do(domain, func() (interface{}, error) {
+		checks := check(ctx, domain)
+		if ctx.Err() != nil {
+			return sharedChecks{checks: checks, cancelled: true}, nil
+		}
+		if checks.DNS.MXRecords.Status == "pass" {
+			c.items.Set(domain, checks, c.ttl)
+		}
+		return sharedChecks{checks: checks}, nil
+	})
+	result := value.(sharedChecks)
+	// The caller that ran the checks went away mid-flight; its cut-short
+	// lookups say nothing about the domain to callers still waiting
+	if result.cancelled && ctx.Err() == nil {
+		return check(ctx, domain), false
+	}
+	return result.checks, shared
+}
+
+// sharedChecks is the outcome of one run of the domain checks
+type sharedChecks struct {
+	checks    domainChecks
+	cancelled bool
+}
+
+// forget drops the cached checks for domain, e.g. after feedback changed its
+// reputation
+func (c *domainCache) forget(domain string) {
+	c.items.Delete(strings.ToLower(domain))
+}
@@ -2,117 +2,749 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"email-intelligence/internal/analyzers"
 	"email-intelligence/internal/config"
+	"email-intelligence/internal/httpclient"
+	"email-intelligence/internal/logging"
+	"email-intelligence/internal/metrics"
 	"email-intelligence/internal/models"
+	"email-intelligence/internal/resultcache"
+	"email-intelligence/internal/stats"
+	"email-intelligence/internal/tracing"
 	"email-intelligence/internal/validators"
-
-	"github.com/patrickmn/go-cache"
 )
 
 // Engine is the main email intelligence engine
 type Engine struct {
 	config            *config.Config
-	cache             *cache.Cache
-	syntaxValidator   *validators.SyntaxValidator
+	cache             resultcache.Cache
+	resolver          validators.Resolver
 	dnsValidator      *validators.DNSValidator
 	securityValidator *validators.SecurityValidator
-	smtpValidator     *validators.SMTPValidator
-	domainValidator   *validators.DomainValidator
-	scoreAnalyzer     *analyzers.ScoreAnalyzer
+	blocklists        *validators.BlocklistChecker
+	domainAge         *validators.DomainAgeLookup
+	reputation        validators.ReputationProvider // nil without a provider configured
 	riskAnalyzer      *analyzers.RiskAnalyzer
 	mlAnalyzer        *analyzers.MLAnalyzer
 	qualityAnalyzer   *analyzers.QualityAnalyzer
 	contentGenerator  *analyzers.ContentGenerator
-	rateLimiter       map[string]time.Time
-	rateLimitMutex    sync.RWMutex
+	localPartAnalyzer *analyzers.LocalPartAnalyzer
+	localValidator    *validators.LocalPartValidator
+	spamTrapAnalyzer  *analyzers.SpamTrapAnalyzer
+	canonicalizer     *validators.Canonicalizer
+	feedback          *validators.FeedbackStore
+	rateLimiter       *rateLimiter
+	feedbackLimiter   *rateLimiter // outcomes reported per client
+	stats             *stats.Aggregator
+	scorers           *scorers // replaced by SetScoringWeights
+	scorersMutex      sync.RWMutex
+	selfTest          selfTestState
+	stop              context.CancelFunc // ends the background loops
+	loops             sync.WaitGroup
+}
+
+// scorers are the parts that score against the default profile's weights:
+// the validators stamp them into their results, the score analyzer rescales
+// from them, and the domain cache keeps results scored with them. They are
+// replaced together, so an analysis scores with the set it started with.
+type scorers struct {
+	weights      models.ScoringWeights
+	syntax       *validators.SyntaxValidator
+	smtp         *validators.SMTPValidator
+	domain       *validators.DomainValidator
+	score        *analyzers.ScoreAnalyzer
+	domainChecks *domainCache
+}
+
+// withWeights builds the scorers for weights, sharing the SMTP validator's
+// host state and the domain validator's lists with s
+func (s *scorers) withWeights(weights models.ScoringWeights, strict bool, domainCacheTTL time.Duration) *scorers {
+	return &scorers{
+		weights:      weights,
+		syntax:       validators.NewSyntaxValidator(weights),
+		smtp:         s.smtp.WithWeights(weights),
+		domain:       s.domain.WithWeights(weights),
+		score:        analyzers.NewScoreAnalyzer(weights, strict),
+		domainChecks: newDomainCache(domainCacheTTL),
+	}
 }
 
 // New creates a new email intelligence engine
 func New(cfg *config.Config) *Engine {
-	return &Engine{
+	// Outbound HTTP for the disposable list, RDAP and DoH JSON lookups
+	client := httpclient.New(httpclient.Options{
+		MaxRetries: 2,
+		UserAgent:  cfg.ProbeUserAgent,
+		Contact:    cfg.ProbeContact,
+	})
+	
+	transport := validators.DNSTransport{Protocol: cfg.DNSTransport, Server: cfg.DNSServer, HTTPClient: client}
+	if !validators.ValidDNSProtocol(transport.Protocol) {
+		log.Printf("unknown DNS_TRANSPORT %q, using plain DNS", transport.Protocol)
+		transport.Protocol = validators.DNSPlain
+	}
+	dnssecTransport := transport
+	if cfg.DNSSECResolver != "" {
+		dnssecTransport = validators.DNSTransport{Protocol: validators.DNSPlain, Server: cfg.DNSSECResolver}
+	}
+	
+	// Shared resolver so DNS and security lookups reuse each other's answers
+	resolver := validators.NewCachingResolver(validators.NewNetResolver(transport), cfg.DNSCacheTTL, cfg.DNSTimeout)
+	
+	var disposableKeywords []string
+	if cfg.DisposableFuzzy {
+		disposableKeywords = validators.DefaultDisposableKeywords
+	}
+	feedback := validators.NewFeedbackStore(cfg.FeedbackMaxDomains, cfg.FeedbackTTL)
+	disposable := validators.NewDisposableIndex(
+		append(append([]string{}, validators.DefaultDisposableDomains...), cfg.DisposableDomains...),
+		cfg.DisposablePatterns,
+		disposableKeywords,
+		cfg.DisposableCacheSize,
+	)
+	
+	if cfg.DisposableSource != "" {
+		loadDisposableList(context.Background(), disposable, client, cfg.DisposableSource)
+	}
+	
+	sourceAddrs, err := validators.ResolveSourceAddrs(cfg.SMTPSourceIPs)
+	if err != nil {
+		log.Printf("SMTP_SOURCE_IPS: %v", err)
+	}
+	
+	var spamTrapPatterns []string
+	if len(cfg.SpamTrapPatterns) > 0 {
+		spamTrapPatterns = append(append([]string{}, analyzers.DefaultHoneypotPatterns...), cfg.SpamTrapPatterns...)
+	}
+	var rolePatterns []string
+	if len(cfg.RolePatterns) > 0 {
+		rolePatterns = append(append([]string{}, validators.DefaultRolePatterns...), cfg.RolePatterns...)
+	}
+	var brandDomains []string
+	if len(cfg.BrandDomains) > 0 {
+		brandDomains = append(append([]string{}, validators.DefaultBrandDomains...), cfg.BrandDomains...)
+	}
+	var popularDomains []string
+	if len(cfg.PopularDomains) > 0 {
+		popularDomains = append(append([]string{}, analyzers.DefaultPopularDomains...), cfg.PopularDomains...)
+	}
+	
+	// Domain reputation stays heuristic unless a scanner API key is configured
+	var reputation validators.ReputationProvider
+	if cfg.VirusTotalAPIKey != "" {
+		reputation = validators.NewVirusTotal(client, validators.VirusTotalOptions{
+			APIKey:            cfg.VirusTotalAPIKey,
+			Timeout:           cfg.ReputationTimeout,
+			TTL:               cfg.ReputationTTL,
+			RequestsPerMinute: cfg.VirusTotalRate,
+		})
+	}
+	
+	cache := newResultCache(cfg)
+	metrics.NewGaugeFunc("email_intelligence_cache_items", "Results held in the result cache.", func() float64 {
+		return float64(cache.Len())
+	})
+	
+	// Background loops run until Close
+	ctx, stop := context.WithCancel(context.Background())
+	e := &Engine{
 		config:            cfg,
-		cache:             cache.New(cfg.CacheDuration, cfg.CacheDuration*2),
-		syntaxValidator:   validators.NewSyntaxValidator(cfg.ScoringWeights),
-		dnsValidator:      validators.NewDNSValidator(cfg.DNSTimeout),
-		securityValidator: validators.NewSecurityValidator(cfg.DNSTimeout),
-		smtpValidator:     validators.NewSMTPValidator(cfg.SMTPTimeout, cfg.ScoringWeights),
-		domainValidator:   validators.NewDomainValidator(cfg.ScoringWeights),
-		scoreAnalyzer:     analyzers.NewScoreAnalyzer(cfg.ScoringWeights),
+		cache:             cache,
+		resolver:          resolver,
+		dnsValidator:      validators.NewDNSValidator(
+			resolver,
+			validators.NewDNSSECChecker(dnssecTransport, cfg.DNSTimeout, cfg.DNSCacheTTL),
+			cfg.DNSTimeout,
+			cfg.DNSCacheTTL,
+		),
+		securityValidator: validators.NewSecurityValidator(resolver, validators.SecurityOptions{
+			Timeout:         cfg.SecurityTimeout,
+			DKIMConcurrency: cfg.DKIMConcurrency,
+			DKIMSelectors:   cfg.DKIMSelectors,
+			ESPIncludes:     cfg.ESPIncludes,
+			Strict:          cfg.StrictMode,
+		}),
+		scorers:           &scorers{
+			weights:      cfg.ScoringWeights,
+			syntax:       validators.NewSyntaxValidator(cfg.ScoringWeights),
+			smtp:         validators.NewSMTPValidator(validators.SMTPOptions{
+				Timeout:         cfg.SMTPTimeout,
+				StartTLS:        cfg.SMTPStartTLS,
+				CacheTTL:        cfg.SMTPCacheTTL,
+				BlockThreshold:  cfg.SMTPBlockThreshold,
+				BlockCooldown:   cfg.SMTPBlockCooldown,
+				SourceAddrs:     sourceAddrs,
+				HeloName:        cfg.ProbeHeloName,
+				MailFrom:        cfg.ProbeMailFrom,
+				Strict:          cfg.StrictMode,
+				MaxConnsPerHost: cfg.SMTPMaxConnsPerHost,
+				Retries:         cfg.SMTPRetries,
+				RetryBackoff:    cfg.SMTPRetryBackoff,
+				RetryBudget:     cfg.SMTPRetryBudget,
+			}, cfg.ScoringWeights),
+			domain:       validators.NewDomainValidator(cfg.ScoringWeights, cfg.TLDReputation, disposable, feedback, brandDomains),
+			score:        analyzers.NewScoreAnalyzer(cfg.ScoringWeights, cfg.StrictMode),
+			domainChecks: newDomainCache(cfg.DomainCacheTTL),
+		},
+		blocklists:        validators.NewBlocklistChecker(resolver, cfg.BlocklistZones, cfg.DNSTimeout),
+		domainAge:         validators.NewDomainAgeLookup(client, cfg.RDAPBaseURL, cfg.RDAPTimeout, cfg.DomainAgeTTL),
+		reputation:        reputation,
 		riskAnalyzer:      analyzers.NewRiskAnalyzer(),
 		mlAnalyzer:        analyzers.NewMLAnalyzer(),
-		qualityAnalyzer:   analyzers.NewQualityAnalyzer(),
-		contentGenerator:  analyzers.NewContentGenerator(),
-		rateLimiter:       make(map[string]time.Time),
+		qualityAnalyzer:   analyzers.NewQualityAnalyzer(analyzers.QualityOptions{
+			StrictFreeProviders: cfg.StrictFreeProviders,
+			RCPTConfidenceBoost: cfg.RCPTConfidenceBoost,
+			PremiumRequiresRCPT: cfg.PremiumRequiresRCPT,
+			StrictMode:          cfg.StrictMode,
+			Thresholds:          cfg.QualityThresholds,
+		}),
+		contentGenerator:  analyzers.NewContentGenerator(popularDomains),
+		localPartAnalyzer: analyzers.NewLocalPartAnalyzer(analyzers.DefaultLocalPartThresholds()),
+		localValidator:    validators.NewLocalPartValidator(rolePatterns),
+		spamTrapAnalyzer:  analyzers.NewSpamTrapAnalyzer(analyzers.DefaultSpamTrapThresholds(), spamTrapPatterns),
+		canonicalizer:     validators.NewCanonicalizer(cfg.CanonicalRules),
+		feedback:          feedback,
+		rateLimiter:       newRateLimiter(cfg.RateLimitWindow, cfg.RateLimitBurst),
+		feedbackLimiter:   newRateLimiter(cfg.FeedbackRateWindow, cfg.FeedbackRateBurst),
+		stats:             stats.NewAggregator(cfg.StatsMaxDomains),
+		stop:              stop,
+	}
+	if cfg.DisposableSource != "" && cfg.DisposableRefresh > 0 {
+		e.runLoop(func() { refreshDisposableList(ctx, disposable, client, cfg.DisposableSource, cfg.DisposableRefresh) })
+	}
+	e.runLoop(func() { e.rateLimiter.sweepLoop(ctx) })
+	e.runLoop(func() { e.feedbackLimiter.sweepLoop(ctx) })
+	return e
+}
+
+// runLoop runs loop in the background; Close waits for it to return
+func (e *Engine) runLoop(loop func()) {
+	e.loops.Add(1)
+	go func() {
+		defer e.loops.Done()
+		loop()
+	}()
+}
+
+// Close stops the background list refresh and rate-limit sweeps and waits
+// for them to exit. Analyses keep working, but the disposable list is no
+// longer refreshed and idle rate-limit buckets are no longer dropped.
+func (e *Engine) Close() {
+	e.stop()
+	e.loops.Wait()
+}
+
+// newResultCache picks the result cache backend. Config.Validate rejects an
+// unusable Redis URL at startup; should one get here, the in-process cache is
+// used instead.
+func newResultCache(cfg *config.Config) resultcache.Cache {
+	if cfg.CacheBackend == "redis" {
+		redis, err := resultcache.NewRedis(cfg.RedisURL)
+		if err == nil {
+			return redis
+		}
+		log.Printf("REDIS_URL: %v, using the in-memory cache", err)
+	}
+	return resultcache.NewMemory(cfg.CacheDuration)
+}
+
+// loadDisposableList replaces the external disposable list from source. On
+// failure the previously loaded list stays in use.
+func loadDisposableList(ctx context.Context, index *validators.DisposableIndex, client *httpclient.Client, source string) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	domains, err := validators.LoadDisposableList(ctx, client, source)
+	if err != nil {
+		log.Printf("DISPOSABLE_LIST_SOURCE: %v", err)
+		return
+	}
+	index.SetExternalList(domains)
+	log.Printf("Loaded %d disposable domains from %s", len(domains), source)
+}
+
+// refreshDisposableList reloads the external disposable list every interval
+// until ctx is done
+func refreshDisposableList(ctx context.Context, index *validators.DisposableIndex, client *httpclient.Client, source string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			loadDisposableList(ctx, index, client, source)
+		}
+	}
+}
+
+// ErrRateLimited matches the RateLimitError returned when the same address is
+// analyzed too often
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Options are per-request analysis settings
+type Options struct {
+	DeepAnalysis    bool                    // run SMTP verification
+	CheckSubmission bool                    // probe the submission port (587) for AUTH and STARTTLS
+	Profile         string                  // named scoring profile; empty uses the default
+	Weights         *models.WeightOverrides // per-request changes to the profile's weights; nil keeps them
+	DKIMSelector    string                  // look up only this DKIM selector instead of searching the known ones
+	Debug           bool                    // attach the raw DNS answers and SMTP dialogue behind the verdict
+	MinValidScore   *int                    // replaces the profile's valid score for this request; nil keeps it
+	RetryDeferred   bool                    // wait and retry SMTP checks the server deferred (SMTP_RETRIES); for async jobs
+}
+
+// ErrUnknownProfile is returned when Options.Profile names no configured profile
+var ErrUnknownProfile = errors.New("unknown scoring profile")
+
+// ErrInvalidWeights is returned when Options.Weights leave the profile with
+// negative weights or weights that do not sum to 100
+var ErrInvalidWeights = errors.New("invalid scoring weights")
+
+// ErrInvalidValidScore is returned when Options.MinValidScore is outside 0-100
+var ErrInvalidValidScore = errors.New("min_valid_score must be between 0 and 100")
+
+// ErrInvalidSelector is returned when Options.DKIMSelector is not a usable
+// selector name
+var ErrInvalidSelector = errors.New("invalid DKIM selector")
+
+// Profile resolves a scoring profile by name; an empty name is the default profile
+func (e *Engine) Profile(name string) (models.ScoringProfile, bool) {
+	return e.profile(name, e.currentScorers())
+}
+
+// profile resolves a scoring profile, taking the default profile's weights from s
+func (e *Engine) profile(name string, s *scorers) (models.ScoringProfile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == config.DefaultProfileName {
+		profile := e.config.DefaultProfile()
+		profile.Weights = s.weights
+		return profile, true
+	}
+	profile, ok := e.config.ScoringProfiles[name]
+	return profile, ok
+}
+
+// Stats returns the recent analysis volume and the top domains seen, at most
+// top of each kind
+func (e *Engine) Stats(top int) models.AnalysisStats {
+	return e.stats.Snapshot(top)
+}
+
+// ScoringWeights returns the weights of the default scoring profile
+func (e *Engine) ScoringWeights() models.ScoringWeights {
+	return e.currentScorers().weights
+}
+
+// currentScorers returns the scorers for the default profile's weights
+func (e *Engine) currentScorers() *scorers {
+	e.scorersMutex.RLock()
+	defer e.scorersMutex.RUnlock()
+	return e.scorers
+}
+
+// SetScoringWeights replaces the weights of the default scoring profile.
+// The validators and the score analyzer are rebuilt for them, so the weights
+// reported in results match the scores; analyses already running finish with
+// the old set. Cached results scored with the old weights are dropped.
+func (e *Engine) SetScoringWeights(weights models.ScoringWeights) error {
+	if err := config.ValidateWeights(weights); err != nil {
+		return err
 	}
+	e.scorersMutex.Lock()
+	e.scorers = e.scorers.withWeights(weights, e.config.StrictMode, e.config.DomainCacheTTL)
+	e.scorersMutex.Unlock()
+	e.cache.Flush()
+	return nil
 }
 
 // AnalyzeEmail performs complete email intelligence analysis
-func (e *Engine) AnalyzeEmail(ctx context.Context, email string, deepAnalysis bool) (*models.EmailIntelligence, error) {
+func (e *Engine) AnalyzeEmail(ctx context.Context, email string, opts Options) (*models.EmailIntelligence, error) {
+	startTime := time.Now()
+	intelligence, err := e.analyze(ctx, email, opts, nil)
+	e.observeAnalysis(ctx, startTime, intelligence, err)
+	return intelligence, err
+}
+
+// AnalyzeEmailProgressive performs the same analysis as AnalyzeEmail but calls
+// onFast with a preliminary verdict (syntax, DNS, security, domain) before the
+// slow SMTP stage starts. onFast is not called when there is no slow stage to
+// wait for. The returned result is the final verdict.
+func (e *Engine) AnalyzeEmailProgressive(ctx context.Context, email string, opts Options, onFast func(*models.EmailIntelligence)) (*models.EmailIntelligence, error) {
 	startTime := time.Now()
+	intelligence, err := e.analyze(ctx, email, opts, onFast)
+	e.observeAnalysis(ctx, startTime, intelligence, err)
+	return intelligence, err
+}
+
+// observeAnalysis records one finished analysis in the Prometheus metrics,
+// the per-domain statistics and the request log; only the domain of the
+// address is logged
+func (e *Engine) observeAnalysis(ctx context.Context, startTime time.Time, intelligence *models.EmailIntelligence, err error) {
+	elapsed := time.Since(startTime)
+	metrics.Analyses.Inc()
+	metrics.AnalysisDuration.Observe(elapsed.Seconds())
+	if err != nil {
+		e.stats.Record(nil)
+		metrics.AnalysisErrors.Inc()
+		logging.FromContext(ctx).Debug("analysis failed", "error", err.Error())
+		return
+	}
+	
+	domain := ""
+	if at := strings.LastIndex(intelligence.Email, "@"); at != -1 {
+		domain = intelligence.Email[at+1:]
+	}
+	e.stats.Record(intelligence)
+	logging.RecordAnalysis(ctx, domain, intelligence.ValidationScore)
+	logging.FromContext(ctx).Debug("analysis",
+		"domain", domain,
+		"score", intelligence.ValidationScore,
+		"duration_ms", elapsed.Milliseconds(),
+	)
+}
+
+func (e *Engine) analyze(ctx context.Context, email string, opts Options, onFast func(*models.EmailIntelligence)) (*models.EmailIntelligence, error) {
+	startTime := time.Now()
+	ctx, span := tracing.Start(ctx, "engine.AnalyzeEmail")
+	defer span.End()
+	deepAnalysis := opts.DeepAnalysis
+	scorers := e.currentScorers()
+	profile, ok := e.profile(opts.Profile, scorers)
+	if !ok {
+		err := fmt.Errorf("%w %q", ErrUnknownProfile, opts.Profile)
+		span.RecordError(err)
+		return nil, err
+	}
+	custom := false
+	if opts.Weights != nil {
+		profile.Weights = opts.Weights.Apply(profile.Weights)
+		if err := config.ValidateWeights(profile.Weights); err != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidWeights, err)
+			span.RecordError(err)
+			return nil, err
+		}
+		custom = true
+	}
+	if opts.MinValidScore != nil {
+		if *opts.MinValidScore < 0 || *opts.MinValidScore > 100 {
+			span.RecordError(ErrInvalidValidScore)
+			return nil, ErrInvalidValidScore
+		}
+		profile.ValidScore = *opts.MinValidScore
+		custom = true
+	}
+	if custom {
+		profile.Name += "+custom"
+	}
+	opts.DKIMSelector = strings.TrimSpace(opts.DKIMSelector)
+	if opts.DKIMSelector != "" && !validators.ValidSelector(opts.DKIMSelector) {
+		err := fmt.Errorf("%w %q", ErrInvalidSelector, opts.DKIMSelector)
+		span.RecordError(err)
+		return nil, err
+	}
+	opts.Profile = profile.Name
+	span.SetAttribute("scoring.profile", profile.Name)
+	key := cacheKey(email, opts, profile.Weights)
+	
+	// Computed from the input as given: the storage form keeps local part case
+	// that the shared lowercase cache key folds away
+	storageEmail, canonicalEmail, _ := e.canonicalizer.Canonicalize(email)
 	
 	// Check cache first
-	if cached, found := e.cache.Get(email); found {
-		if intelligence, ok := cached.(*models.EmailIntelligence); ok {
-			return intelligence, nil
+	if intelligence, found := e.cache.Get(key); found {
+		span.SetAttribute("cache.hit", "true")
+		metrics.CacheHits.Inc()
+		logging.RecordCache(ctx, true)
+		if intelligence.StorageCanonicalEmail != storageEmail {
+			variant := *intelligence
+			variant.StorageCanonicalEmail = storageEmail
+			return &variant, nil
 		}
+		return intelligence, nil
 	}
 	
+	metrics.CacheMisses.Inc()
+	logging.RecordCache(ctx, false)
+	
 	// Rate limiting check
-	if !e.checkRateLimit(email) {
-		return nil, fmt.Errorf("rate limit exceeded")
+	if quota, ok := e.rateLimiter.allow(email); !ok {
+		span.RecordError(ErrRateLimited)
+		return nil, &RateLimitError{Quota: quota}
 	}
 	
 	email = strings.TrimSpace(strings.ToLower(email))
 	
 	intelligence := &models.EmailIntelligence{
-		Email:      email,
-		Timestamp:  time.Now(),
-		APIVersion: "2.0.0",
+		Email:                 email,
+		StorageCanonicalEmail: storageEmail,
+		CanonicalEmail:        canonicalEmail,
+		DegradedChecks:        []string{},
+		Timestamp:             time.Now(),
+		APIVersion:            "2.0.0",
+		ScoringProfile:        profile.Name,
 	}
+	// Replaced by the SMTP validator's verdict when it runs
+	intelligence.SMTPValidation.VerificationMethod = validators.VerificationSkipped
 	
 	// 1. Syntax Validation (immediate)
-	intelligence.SyntaxValidation = e.syntaxValidator.Validate(email)
+	_, syntaxSpan := tracing.Start(ctx, "validate.syntax")
+	intelligence.SyntaxValidation = scorers.syntax.Validate(email)
+	syntaxSpan.End()
 	
 	if intelligence.SyntaxValidation.Status != "pass" {
 		intelligence.IsValid = false
 		intelligence.ValidationScore = 0
 		intelligence.RiskCategory = "Invalid"
 		intelligence.ConfidenceLevel = "High"
+		intelligence.PrimaryFailureReason = e.qualityAnalyzer.FailureReason(intelligence, profile)
+		intelligence.DeliverabilityVerdict = analyzers.VerdictUndeliverable
 		intelligence.ProcessingTime = time.Since(startTime).Milliseconds()
 		return intelligence, nil
 	}
 	
+	// DNS and SMTP need the ASCII form of internationalized domains
+	if ascii, err := validators.ASCIIAddress(email); err == nil {
+		email = ascii
+	}
+	intelligence.NormalizedEmail = validators.UnicodeAddress(email)
+	
 	// Extract domain
-	parts := strings.Split(email, "@")
-	domain := parts[1]
+	localPart, domain, ok := validators.SplitAddress(email)
+	if !ok {
+		intelligence.IsValid = false
+		intelligence.RiskCategory = "Invalid"
+		intelligence.ConfidenceLevel = "High"
+		intelligence.PrimaryFailureReason = e.qualityAnalyzer.FailureReason(intelligence, profile)
+		intelligence.DeliverabilityVerdict = analyzers.VerdictUndeliverable
+		intelligence.ProcessingTime = time.Since(startTime).Milliseconds()
+		return intelligence, nil
+	}
+	
+	// Internal and reserved test domains are recognized, not scored
+	if validators.IsReservedDomain(domain) || matchesDomainList(domain, e.config.InternalDomains) {
+		intelligence.IsValid = false
+		intelligence.RiskCategory = "Internal"
+		intelligence.ConfidenceLevel = "High"
+		intelligence.DeliverabilityVerdict = analyzers.VerdictUnknown
+		intelligence.ExplanationText = "This address belongs to an internal or reserved test domain and was not scored."
+		intelligence.ProcessingTime = time.Since(startTime).Milliseconds()
+		return intelligence, nil
+	}
+	
+	// Domain policy decides how much effort this address deserves
+	deepAnalysis, intelligence.AnalysisPolicy = e.analysisDepth(domain, deepAnalysis)
+	intelligence.AnalysisDepth = "standard"
+	if deepAnalysis {
+		intelligence.AnalysisDepth = "deep"
+	}
+	span.SetAttribute("email.domain", domain)
+	span.SetAttribute("analysis.depth", intelligence.AnalysisDepth)
+	
+	// Humanness of the local part (cheap, no network)
+	intelligence.LocalPartQuality = e.localPartAnalyzer.Analyze(email)
+	
+	// Nobody is waiting for the network checks of a cancelled request
+	if err := ctx.Err(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	
+	// The time budget cuts the network stages short; ctx alone still decides
+	// whether anyone is waiting for the result. Jobs that retry deferred SMTP
+	// checks wait for the retries under SMTP_RETRY_BUDGET instead, but their
+	// other stages keep the budget.
+	stageCtx := ctx
+	if e.config.AnalysisTimeout > 0 {
+		var cancel context.CancelFunc
+		stageCtx, cancel = context.WithTimeout(ctx, e.config.AnalysisTimeout)
+		defer cancel()
+	}
+	smtpStageCtx := stageCtx
+	if opts.RetryDeferred {
+		smtpStageCtx = ctx
+	}
+	var unfinished []string
+	
+	// BIMI is reported for deep analysis only, so it runs beside the domain
+	// checks rather than inside them and their shared cache
+	bimi := make(chan validators.BIMIRecord, 1)
+	bimiCut := false
+	if deepAnalysis {
+		go func() {
+			ctx, span := tracing.Start(stageCtx, "validate.bimi")
+			defer span.End()
+			record := e.securityValidator.CheckBIMI(ctx, domain)
+			bimiCut = ctx.Err() != nil
+			bimi <- record
+		}()
+	}
+	
+	// 2-4. Domain-level checks, shared by every address at the domain. A
+	// DKIM selector hint changes the security result, so it is not shared.
+	var checks domainChecks
+	cached := false
+	if opts.DKIMSelector != "" {
+		checks = e.checkDomain(stageCtx, scorers, domain, opts.DKIMSelector)
+	} else {
+		checks, cached = scorers.domainChecks.get(stageCtx, domain, func(ctx context.Context, domain string) domainChecks {
+			return e.checkDomain(ctx, scorers, domain, "")
+		})
+	}
+	span.SetAttribute("domain_checks.shared", strconv.FormatBool(cached))
+	intelligence.DNSValidation = checks.DNS
+	intelligence.SecurityAnalysis = checks.Security
+	intelligence.DomainIntelligence = checks.Domain
+	unfinished = append(unfinished, checks.Unfinished...)
+	scorers.domain.ApplyMailboxPlausibility(&intelligence.DomainIntelligence, localPart)
+	scorers.domain.ApplyRoleAccount(&intelligence.DomainIntelligence, e.localValidator.CheckRoleAccount(localPart))
+	if deepAnalysis {
+		e.securityValidator.ApplyBIMI(&intelligence.SecurityAnalysis, <-bimi)
+		if bimiCut {
+			unfinished = append(unfinished, "bimi")
+		}
+	}
+	
+	if err := ctx.Err(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 	
-	// 2-4. Parallel validation pipeline
+	var wg sync.WaitGroup
+	
+	// 5. SMTP Validation (if deep analysis and MX records exist)
+	hasMX := intelligence.DNSValidation.MXRecords.Status == "pass"
+	if onFast != nil && hasMX && (deepAnalysis || opts.CheckSubmission) {
+		// Score a copy so the caller can show the fast checks while SMTP runs
+		preliminary := *intelligence
+		e.finalize(ctx, scorers, &preliminary, startTime, profile)
+		onFast(&preliminary)
+	}
+	submissionCut := false
+	if opts.CheckSubmission && hasMX {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, span := tracing.Start(stageCtx, "validate.submission")
+			defer span.End()
+			capabilities := scorers.smtp.CheckSubmission(ctx, intelligence.DNSValidation.MXDetails)
+			intelligence.SubmissionCapabilities = &capabilities
+			submissionCut = ctx.Err() != nil
+		}()
+	}
+	if deepAnalysis && hasMX {
+		smtpCtx, smtpSpan := tracing.Start(smtpStageCtx, "validate.smtp")
+		validate := scorers.smtp.Validate
+		if opts.RetryDeferred {
+			validate = scorers.smtp.ValidateWithRetries
+		}
+		intelligence.SMTPValidation = validate(smtpCtx, email, intelligence.DNSValidation.MXDetails)
+		// Only a mailbox answer that arrived in time is worth keeping
+		if smtpStageCtx.Err() != nil && intelligence.SMTPValidation.VerificationMethod != validators.VerificationRCPT {
+			intelligence.SMTPValidation.Reachable = models.ValidationResult{
+				Status:    "unknown",
+				Reason:    "The analysis time budget ran out before the mail server answered",
+				RawSignal: "analysis_timeout",
+				Score:     0,
+				Weight:    profile.Weights.SMTPReachability,
+			}
+			unfinished = append(unfinished, "smtp")
+		}
+		smtpSpan.SetAttribute("smtp.verification_method", intelligence.SMTPValidation.VerificationMethod)
+		smtpSpan.End()
+		
+		// The mailbox session also asks about a random address, so this is
+		// usually answered from cache without another connection
+		catchAllCtx, catchAllSpan := tracing.Start(smtpStageCtx, "validate.catch_all")
+		catchAll := scorers.smtp.CheckCatchAll(catchAllCtx, domain, intelligence.DNSValidation.MXDetails)
+		scorers.domain.ApplyCatchAll(&intelligence.DomainIntelligence, catchAll)
+		catchAllSpan.End()
+		if smtpStageCtx.Err() != nil && catchAll.Status == "unknown" {
+			unfinished = append(unfinished, "catch_all")
+		}
+	}
+	wg.Wait()
+	if submissionCut {
+		unfinished = append(unfinished, "submission")
+	}
+	
+	// Whatever finished within the budget is reported; the rest stays unknown
+	if len(unfinished) > 0 && ctx.Err() == nil {
+		sort.Strings(unfinished)
+		intelligence.TimedOut = true
+		intelligence.UnfinishedStages = unfinished
+	}
+	
+	e.finalize(ctx, scorers, intelligence, startTime, profile)
+	if opts.Debug {
+		intelligence.Debug = debugInfo(intelligence)
+	}
+	
+	// Cache result, unless a lookup failed or timed out and a retry may well do better
+	if len(intelligence.DegradedChecks) == 0 && !intelligence.TimedOut {
+		e.cache.Set(cacheAddress(email), key, intelligence, e.config.CacheDuration)
+	}
+	
+	return intelligence, nil
+}
+
+// checkDomain runs the DNS, security and domain intelligence checks of a
+// domain in parallel, scored with scorers. A non-empty dkimSelector replaces
+// the DKIM selector search.
+func (e *Engine) checkDomain(ctx context.Context, scorers *scorers, domain, dkimSelector string) domainChecks {
+	var checks domainChecks
+	// Parallel validation pipeline
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	
-	// DNS Validation (parallel)
+	// A stage that returns after ctx ended was cut short, not answered
+	finished := func(stage string) {
+		if ctx.Err() != nil {
+			mu.Lock()
+			checks.Unfinished = append(checks.Unfinished, stage)
+			mu.Unlock()
+		}
+	}
+	
+	// DNS Validation (parallel), then DNS blocklists for the mail server
+	// addresses it resolved
+	var blocklistHits []string
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		result := e.dnsValidator.Validate(ctx, domain)
+		dnsCtx, span := tracing.Start(ctx, "validate.dns")
+		result := e.dnsValidator.Validate(dnsCtx, domain)
+		span.End()
+		finished("dns")
 		mu.Lock()
-		intelligence.DNSValidation = result
+		checks.DNS = result
 		mu.Unlock()
+		
+		blocklistCtx, span := tracing.Start(ctx, "validate.blocklist")
+		blocklistHits = e.blocklists.Check(blocklistCtx, domain, result.MXDetails)
+		span.End()
+		finished("blocklist")
 	}()
 	
 	// Security Analysis (parallel - SPF, DMARC, DKIM all parallel inside)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		result := e.securityValidator.Validate(ctx, domain)
+		ctx, span := tracing.Start(ctx, "validate.security")
+		defer span.End()
+		result := e.securityValidator.Validate(ctx, domain, dkimSelector)
+		finished("security")
 		mu.Lock()
-		intelligence.SecurityAnalysis = result
+		checks.Security = result
 		mu.Unlock()
 	}()
 	
@@ -120,56 +752,295 @@ func (e *Engine) AnalyzeEmail(ctx context.Context, email string, deepAnalysis bo
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		result := e.domainValidator.Validate(domain)
+		_, span := tracing.Start(ctx, "validate.domain")
+		defer span.End()
+		result := scorers.domain.Validate(domain)
 		mu.Lock()
-		intelligence.DomainIntelligence = result
+		checks.Domain = result
 		mu.Unlock()
 	}()
 	
+	// Security scanner verdict (parallel, when a provider is configured)
+	reputation := struct {
+		score   int
+		verdict string
+		err     error
+	}{}
+	if e.reputation != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, span := tracing.Start(ctx, "validate.reputation")
+			defer span.End()
+			registrable, _ := validators.SplitRegistrable(domain)
+			reputation.score, reputation.verdict, reputation.err = e.reputation.DomainReputation(ctx, registrable)
+			finished("reputation")
+		}()
+	}
+	
+	// Registration age (parallel, RDAP for the registrable domain)
+	domainAge := validators.UnknownDomainAge
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctx, span := tracing.Start(ctx, "validate.domain_age")
+		defer span.End()
+		registrable, _ := validators.SplitRegistrable(domain)
+		domainAge = e.domainAge.Age(ctx, registrable)
+		finished("domain_age")
+	}()
+	
 	// Wait for parallel operations
 	wg.Wait()
 	
-	// 5. SMTP Validation (if deep analysis and MX records exist)
-	if deepAnalysis && intelligence.DNSValidation.MXRecords.Status == "pass" {
-		intelligence.SMTPValidation = e.smtpValidator.Validate(ctx, email, intelligence.DNSValidation.MXDetails)
+	// Custom domains fronting a disposable service are only recognizable by MX
+	scorers.domain.ApplyMXFingerprint(&checks.Domain, checks.DNS.MXDetails)
+	scorers.domain.ApplyBlocklists(&checks.Domain, blocklistHits, e.blocklists.Enabled())
+	scorers.domain.ApplyDomainAge(&checks.Domain, domainAge)
+	if e.reputation != nil {
+		scorers.domain.ApplyReputation(&checks.Domain, reputation.score, reputation.verdict, reputation.err)
 	}
 	
+	return checks
+}
+
+// finalize runs scoring, risk, ML, quality and content generation over the
+// validation results gathered so far
+func (e *Engine) finalize(ctx context.Context, scorers *scorers, intelligence *models.EmailIntelligence, startTime time.Time, profile models.ScoringProfile) {
+	intelligence.DegradedChecks = degradedChecks(intelligence)
+	
 	// 6. Calculate Enterprise Score
-	intelligence.ScoreBreakdown = e.scoreAnalyzer.Calculate(intelligence)
+	_, span := tracing.Start(ctx, "analyze.scoring")
+	intelligence.ScoreBreakdown = scorers.score.Calculate(intelligence, profile.Weights)
 	intelligence.ValidationScore = intelligence.ScoreBreakdown.TotalScore
+	span.End()
 	
-	// 7. Risk Analysis
+	// 7. Risk Analysis (spam trap heuristics feed into it)
+	_, span = tracing.Start(ctx, "analyze.risk")
+	intelligence.SpamTrapRisk = e.spamTrapAnalyzer.Analyze(intelligence)
 	intelligence.RiskAnalysis = e.riskAnalyzer.Analyze(intelligence)
+	span.End()
 	
 	// 8. ML Predictions
+	_, span = tracing.Start(ctx, "analyze.ml")
 	intelligence.MLPredictions = e.mlAnalyzer.Predict(intelligence)
+	span.End()
 	
 	// 9. Determine Quality Metrics
-	e.qualityAnalyzer.Determine(intelligence)
+	e.qualityAnalyzer.Determine(intelligence, profile)
 	
 	// 10. Generate User-Friendly Content
 	e.contentGenerator.Generate(intelligence)
 	
 	intelligence.ProcessingTime = time.Since(startTime).Milliseconds()
+}
+
+// degradedChecks lists the checks whose lookups failed or timed out, so their
+// "unknown" results reflect the DNS outage, not the domain
+func degradedChecks(intelligence *models.EmailIntelligence) []string {
+	checks := []struct {
+		name   string
+		result models.ValidationResult
+	}{
+		{"dns.domain_exists", intelligence.DNSValidation.DomainExists},
+		{"dns.mx", intelligence.DNSValidation.MXRecords},
+		{"security.spf", intelligence.SecurityAnalysis.SPFRecord},
+		{"security.dmarc", intelligence.SecurityAnalysis.DMARCRecord},
+		{"security.dkim", intelligence.SecurityAnalysis.DKIMRecord},
+		{"security.bimi", intelligence.SecurityAnalysis.BIMIRecord},
+	}
+	degraded := []string{}
+	for _, check := range checks {
+		if validators.Degraded(check.result) {
+			degraded = append(degraded, check.name)
+		}
+	}
+	return degraded
+}
+
+// ValidateSyntax checks only the format of email and suggests corrections for
+// a mistyped domain. It does no network I/O and bypasses the result cache and
+// the rate limiter, so forms can call it as the user types.
+func (e *Engine) ValidateSyntax(email string) models.SyntaxCheckResult {
+	email = strings.TrimSpace(strings.ToLower(email))
+	syntax := e.currentScorers().syntax.Validate(email)
+	return models.SyntaxCheckResult{
+		Valid:       syntax.Status == "pass",
+		Reason:      syntax.Reason,
+		Suggestions: e.contentGenerator.Alternatives(email),
+	}
+}
+
+// debugInfo gathers the raw evidence the validators kept behind a verdict
+func debugInfo(intelligence *models.EmailIntelligence) *models.DebugInfo {
+	debug := &models.DebugInfo{
+		TXTRecords:     intelligence.SecurityAnalysis.TXTLookups,
+		MXRecords:      []models.DebugMXRecord{},
+		DKIMSelectors:  intelligence.SecurityAnalysis.DKIMSelectors,
+		SMTPTranscript: intelligence.SMTPValidation.Transcript,
+	}
+	for _, mx := range intelligence.DNSValidation.MXDetails {
+		addresses := append(append([]string{}, mx.IPv4...), mx.IPv6...)
+		debug.MXRecords = append(debug.MXRecords, models.DebugMXRecord{Host: mx.Host, Priority: mx.Priority, Addresses: addresses})
+	}
+	if debug.TXTRecords == nil {
+		debug.TXTRecords = []models.TXTLookup{}
+	}
+	if debug.DKIMSelectors == nil {
+		debug.DKIMSelectors = []models.DKIMSelectorResult{}
+	}
+	if debug.SMTPTranscript == nil {
+		debug.SMTPTranscript = []models.SMTPExchange{}
+	}
+	return debug
+}
+
+// CheckDKIMSelector validates one explicitly named DKIM selector for a domain
+func (e *Engine) CheckDKIMSelector(ctx context.Context, domain, selector string) models.DKIMSelectorResult {
+	domain = strings.TrimSuffix(strings.TrimSpace(strings.ToLower(domain)), ".")
+	return e.securityValidator.CheckDKIMSelector(ctx, domain, strings.TrimSpace(selector))
+}
+
+// AnalyzeDomain runs the domain-level checks for a bare domain, in ASCII
+// form, without a mailbox. Results are shared with address analyses through
+// the domain cache, so pre-qualifying a list of domains warms it too.
+func (e *Engine) AnalyzeDomain(ctx context.Context, domain string) *models.DomainAnalysis {
+	startTime := time.Now()
+	ctx, span := tracing.Start(ctx, "engine.AnalyzeDomain")
+	defer span.End()
+	domain = strings.TrimSuffix(strings.TrimSpace(strings.ToLower(domain)), ".")
+	span.SetAttribute("email.domain", domain)
 	
-	// Cache result
-	e.cache.Set(email, intelligence, cache.DefaultExpiration)
+	scorers := e.currentScorers()
+	checks, cached := scorers.domainChecks.get(ctx, domain, func(ctx context.Context, domain string) domainChecks {
+		return e.checkDomain(ctx, scorers, domain, "")
+	})
+	span.SetAttribute("domain_checks.shared", strconv.FormatBool(cached))
 	
-	return intelligence, nil
+	intelligence := checks.Domain
+	return &models.DomainAnalysis{
+		Domain:           domain,
+		DNSValidation:    checks.DNS,
+		SecurityAnalysis: checks.Security,
+		DomainIntelligence: models.DomainReputation{
+			RegistrableDomain: intelligence.RegistrableDomain,
+			Subdomain:         intelligence.Subdomain,
+			IsDisposable:      intelligence.IsDisposable,
+			DisposableLevel:   intelligence.DisposableLevel,
+			IsFreeProvider:    intelligence.IsFreeProvider,
+			IsCorporate:       intelligence.IsCorporate,
+			IsCatchAll:        intelligence.IsCatchAll,
+			IsBlacklisted:     intelligence.IsBlacklisted,
+			HomoglyphRisk:     intelligence.HomoglyphRisk,
+			ScannerReputation: intelligence.ScannerReputation,
+			BlocklistHits:     intelligence.BlocklistHits,
+			DomainAge:         intelligence.DomainAge,
+			ReputationScore:   intelligence.ReputationScore,
+			RiskIndicators:    intelligence.RiskIndicators,
+			Feedback:          intelligence.Feedback,
+		},
+		DegradedChecks: degradedChecks(&models.EmailIntelligence{DNSValidation: checks.DNS, SecurityAnalysis: checks.Security}),
+		Cached:         cached,
+		ProcessingTime: time.Since(startTime).Milliseconds(),
+		Timestamp:      time.Now(),
+	}
 }
 
-// checkRateLimit checks if email is rate limited
-func (e *Engine) checkRateLimit(email string) bool {
-	e.rateLimitMutex.Lock()
-	defer e.rateLimitMutex.Unlock()
+// Feedback outcomes reported by callers after sending mail
+const (
+	FeedbackDelivered = validators.OutcomeDelivered
+	FeedbackBounced   = validators.OutcomeBounced
+	FeedbackComplaint = validators.OutcomeComplaint
+)
+
+// RecordFeedback applies a real-world delivery outcome for an address. A bounce
+// overrides any optimistic cached verdict so the next analysis re-verifies, and
+// every outcome feeds the per-domain rates used in reputation scoring.
+// Outcomes are unverified, so each client may only report a limited number per
+// window; beyond it a *RateLimitError is returned and nothing is recorded.
+func (e *Engine) RecordFeedback(client, email, outcome string) error {
+	email = strings.TrimSpace(strings.ToLower(email))
 	
-	now := time.Now()
-	if lastRequest, exists := e.rateLimiter[email]; exists {
-		if now.Sub(lastRequest) < time.Second {
-			return false
-		}
+	switch outcome {
+	case FeedbackBounced, FeedbackDelivered, FeedbackComplaint:
+	default:
+		return fmt.Errorf("unknown feedback outcome %q", outcome)
+	}
+	if quota, ok := e.feedbackLimiter.allow(client); !ok {
+		return &RateLimitError{Quota: quota}
+	}
+	if outcome == FeedbackBounced {
+		e.currentScorers().smtp.InvalidateMailbox(email)
+		e.invalidateCachedResults(email)
+	}
+	
+	if _, domain, ok := validators.SplitAddress(email); ok {
+		e.feedback.Record(domain, outcome)
+		// The cached domain intelligence carries the feedback rates
+		e.currentScorers().domainChecks.forget(domain)
 	}
 	
-	e.rateLimiter[email] = now
-	return true
+	return nil
+}
+
+// cacheAddress is the form of email cached results are indexed under
+func cacheAddress(email string) string {
+	return strings.TrimSpace(strings.ToLower(email))
+}
+
+// cacheKey identifies a cached result by address and the options that shape it
+func cacheKey(email string, opts Options, weights models.ScoringWeights) string {
+	key := fmt.Sprintf("%s|deep=%t|submission=%t|profile=%s",
+		cacheAddress(email), opts.DeepAnalysis, opts.CheckSubmission, opts.Profile)
+	// Custom weights share a profile name, so they must be part of the key
+	if opts.Weights != nil {
+		key += fmt.Sprintf("|weights=%+v", weights)
+	}
+	if opts.MinValidScore != nil {
+		key += fmt.Sprintf("|valid=%d", *opts.MinValidScore)
+	}
+	if opts.DKIMSelector != "" {
+		key += "|dkim=" + strings.ToLower(opts.DKIMSelector)
+	}
+	if opts.Debug {
+		key += "|debug"
+	}
+	// A retried check may settle what a single round left deferred
+	if opts.RetryDeferred {
+		key += "|retry"
+	}
+	return key
+}
+
+// invalidateCachedResults drops every cached result for an address
+func (e *Engine) invalidateCachedResults(email string) {
+	e.cache.DeleteAddress(cacheAddress(email))
+}
+
+// analysisDepth applies the high-value and cheap domain policies to the requested depth
+func (e *Engine) analysisDepth(domain string, requested bool) (bool, string) {
+	if matchesDomainList(domain, e.config.HighValueDomains) {
+		return true, "high_value_domain"
+	}
+	if matchesDomainList(domain, e.config.CheapDomains) {
+		return false, "cheap_domain"
+	}
+	return requested, "requested"
+}
+
+// matchesDomainList reports whether domain equals or is a subdomain of any entry
+func matchesDomainList(domain string, list []string) bool {
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimPrefix(entry, "."))
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// RateQuota returns the rate limit state of an address as passed to
+// AnalyzeEmail, without using any of it
+func (e *Engine) RateQuota(email string) RateQuota {
+	return e.rateLimiter.peek(email)
 }
@@ -0,0 +1,458 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/config"
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	cfg := config.Load()
+	cfg.DisposableSource = ""
+	cfg.RateLimitBurst = 1000
+	e := New(cfg)
+	t.Cleanup(e.Close)
+	return e
+}
+
+func TestCloseStopsBackgroundLoops(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "burner.io")
+	}))
+	defer server.Close()
+	cfg := config.Load()
+	cfg.DisposableSource = server.URL
+	cfg.DisposableRefresh = time.Millisecond
+	e := New(cfg)
+
+	closed := make(chan struct{})
+	go func() {
+		e.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop the refresh and sweep loops")
+	}
+}
+
+func TestSetScoringWeightsRebuildsScorers(t *testing.T) {
+	e := newTestEngine(t)
+	weights := models.ScoringWeights{
+		SyntaxFormat:     20,
+		MXRecords:        20,
+		SecurityRecords:  10,
+		SMTPReachability: 20,
+		DisposableCheck:  10,
+		DomainReputation: 10,
+		CatchAllRisk:     10,
+	}
+	if err := e.SetScoringWeights(weights); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.ScoringWeights(); got != weights {
+		t.Errorf("ScoringWeights() = %+v, want %+v", got, weights)
+	}
+
+	// Results report the new weights, not the ones the validators were first built with
+	result, err := e.AnalyzeEmail(context.Background(), "not-an-address", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SyntaxValidation.Weight != weights.SyntaxFormat {
+		t.Errorf("syntax weight = %d, want %d", result.SyntaxValidation.Weight, weights.SyntaxFormat)
+	}
+	scorers := e.currentScorers()
+	if got := scorers.domain.Validate("example.org").IsDisposable.Weight; got != weights.DisposableCheck {
+		t.Errorf("disposable weight = %d, want %d", got, weights.DisposableCheck)
+	}
+	if profile, _ := e.Profile(""); profile.Weights != weights {
+		t.Errorf("default profile weights = %+v, want %+v", profile.Weights, weights)
+	}
+}
+
+func TestSetScoringWeightsKeepsRunningAnalysesConsistent(t *testing.T) {
+	e := newTestEngine(t)
+	before := e.currentScorers()
+	weights := before.weights
+	weights.SyntaxFormat, weights.MXRecords = weights.SyntaxFormat+5, weights.MXRecords-5
+	if err := e.SetScoringWeights(weights); err != nil {
+		t.Fatal(err)
+	}
+
+	// An analysis holding the old set still scores and caches with it
+	if before.weights == weights || before.syntax.Validate("bad").Weight == weights.SyntaxFormat {
+		t.Error("the scorers in use were changed in place")
+	}
+	if e.currentScorers().domainChecks == before.domainChecks {
+		t.Error("domain results scored with the old weights are still shared")
+	}
+}
+
+func TestSetScoringWeightsRejectsInvalidWeights(t *testing.T) {
+	e := newTestEngine(t)
+	want := e.ScoringWeights()
+	if err := e.SetScoringWeights(models.ScoringWeights{SyntaxFormat: 50}); err == nil {
+		t.Fatal("weights summing to 50 were accepted")
+	}
+	if got := e.ScoringWeights(); got != want {
+		t.Errorf("weights changed to %+v after a rejected update", got)
+	}
+}
+
+func TestSetScoringWeightsRescalesEveryCategory(t *testing.T) {
+	e := newTestEngine(t)
+	e.config.StrictMode = false
+	weights := models.ScoringWeights{
+		SyntaxFormat:     10,
+		MXRecords:        30,
+		SecurityRecords:  10,
+		SMTPReachability: 15,
+		DisposableCheck:  20,
+		DomainReputation: 5,
+		CatchAllRisk:     10,
+	}
+	if err := e.SetScoringWeights(weights); err != nil {
+		t.Fatal(err)
+	}
+	scorers := e.currentScorers()
+	profile, _ := e.Profile("")
+
+	// Full marks from every check, each on the scale its validator uses
+	intelligence := &models.EmailIntelligence{
+		SyntaxValidation:   scorers.syntax.Validate("jane@example.com"),
+		DNSValidation:      models.DNSValidationResult{MXRecords: models.ValidationResult{Status: "pass", Score: validators.MXPoints, Weight: validators.MXPoints}},
+		SecurityAnalysis:   models.SecurityAnalysisResult{SecurityScore: validators.SecurityPoints},
+		SMTPValidation:     models.SMTPValidationResult{Reachable: models.ValidationResult{Status: "pass", Score: weights.SMTPReachability, Weight: weights.SMTPReachability}},
+		DomainIntelligence: models.DomainIntelligenceResult{
+			IsDisposable:    models.ValidationResult{Status: "pass", Score: weights.DisposableCheck, Weight: weights.DisposableCheck},
+			IsCatchAll:      models.ValidationResult{Status: "pass", Score: weights.CatchAllRisk, Weight: weights.CatchAllRisk},
+			ReputationScore: 100,
+		},
+	}
+	breakdown := scorers.score.Calculate(intelligence, profile.Weights)
+	got := models.CategoryMaximums{
+		Syntax:     breakdown.SyntaxScore,
+		MX:         breakdown.MXScore,
+		Security:   breakdown.SecurityScore,
+		SMTP:       breakdown.SMTPScore,
+		Disposable: breakdown.DisposableScore,
+		Reputation: breakdown.ReputationScore,
+		CatchAll:   breakdown.CatchAllScore,
+	}
+	if got != breakdown.CategoryMax || breakdown.TotalScore != 100 {
+		t.Errorf("full marks scored %+v (total %d), want %+v", got, breakdown.TotalScore, breakdown.CategoryMax)
+	}
+
+	// The free-provider overrides top out at the new maximums too
+	intelligence.DomainIntelligence.IsFreeProvider = models.ValidationResult{Status: "pass"}
+	intelligence.SMTPValidation.Reachable.Score = 0
+	intelligence.DomainIntelligence.IsCatchAll.Score = 0
+	breakdown = scorers.score.Calculate(intelligence, profile.Weights)
+	if breakdown.SMTPScore != weights.SMTPReachability || breakdown.CatchAllScore != weights.CatchAllRisk {
+		t.Errorf("free provider scored SMTP %d, catch-all %d; want %d, %d",
+			breakdown.SMTPScore, breakdown.CatchAllScore, weights.SMTPReachability, weights.CatchAllRisk)
+	}
+}
+
+func TestRecordFeedbackRateLimitsClients(t *testing.T) {
+	cfg := config.Load()
+	cfg.DisposableSource = ""
+	cfg.FeedbackRateBurst = 3
+	cfg.FeedbackRateWindow = time.Hour
+	e := New(cfg)
+	t.Cleanup(e.Close)
+
+	for i := 0; i < 3; i++ {
+		if err := e.RecordFeedback("192.0.2.1", "jane@example.com", FeedbackBounced); err != nil {
+			t.Fatalf("outcome %d: %v", i, err)
+		}
+	}
+	err := e.RecordFeedback("192.0.2.1", "jane@example.com", FeedbackBounced)
+	var limited *RateLimitError
+	if !errors.As(err, &limited) || limited.Quota.RetryAfter <= 0 {
+		t.Fatalf("err = %v, want a rate limit with a retry time", err)
+	}
+	// Refused outcomes are not counted
+	if got := e.feedback.Stats("example.com").Total; got != 3 {
+		t.Errorf("total = %d, want 3", got)
+	}
+
+	// Other clients have their own quota, and bad input does not use it up
+	if err := e.RecordFeedback("192.0.2.2", "jane@example.com", "opened"); err == nil || errors.Is(err, ErrRateLimited) {
+		t.Errorf("unknown outcome: err = %v", err)
+	}
+	if err := e.RecordFeedback("192.0.2.2", "jane@example.com", FeedbackDelivered); err != nil {
+		t.Errorf("second client: %v", err)
+	}
+}
+
+// slowTXTResolver answers address, MX and NS lookups at once but holds TXT
+// lookups until their context ends, like a domain whose DNS times out on
+// policy records
+type slowTXTResolver struct{}
+
+func (slowTXTResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if strings.HasPrefix(host, "wildcard-") {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func (slowTXTResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if network == "ip6" {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IP{net.ParseIP("192.0.2.1")}, nil
+}
+
+func (slowTXTResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return []*net.MX{{Host: "mx." + name + ".", Pref: 10}}, nil
+}
+
+func (slowTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (slowTXTResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	return []*net.NS{{Host: "ns1." + name + "."}}, nil
+}
+
+// newSlowSecurityEngine is an engine whose TXT lookups never answer within its 100ms analysis budget
+func newSlowSecurityEngine(t *testing.T) *Engine {
+	t.Helper()
+	cfg := config.Load()
+	cfg.DisposableSource = ""
+	cfg.RateLimitBurst = 1000
+	cfg.BlocklistZones = nil
+	cfg.RDAPBaseURL = ""
+	cfg.AnalysisTimeout = 100 * time.Millisecond
+	cfg.SecurityTimeout = time.Minute
+	e := New(cfg)
+	t.Cleanup(e.Close)
+	e.dnsValidator = validators.NewDNSValidator(slowTXTResolver{}, nil, time.Second, time.Minute)
+	e.securityValidator = validators.NewSecurityValidator(slowTXTResolver{}, validators.SecurityOptions{Timeout: cfg.SecurityTimeout, DKIMConcurrency: 4})
+	return e
+}
+
+func TestAnalysisTimeoutReturnsPartialResult(t *testing.T) {
+	// Jobs retrying deferred SMTP checks keep the budget for every other stage
+	for _, opts := range []Options{{}, {RetryDeferred: true}} {
+		t.Run(fmt.Sprintf("retry deferred %t", opts.RetryDeferred), func(t *testing.T) {
+			e := newSlowSecurityEngine(t)
+			start := time.Now()
+			result, err := e.AnalyzeEmail(context.Background(), "jane@slow-dns.net", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("analysis took %s with a %s budget", elapsed, e.config.AnalysisTimeout)
+			}
+
+			// The stages that answered are kept
+			if result.DNSValidation.MXRecords.Status != "pass" {
+				t.Errorf("MX = %+v, want the answered lookup kept", result.DNSValidation.MXRecords)
+			}
+			// The rest are reported as unfinished and degraded, not as missing records
+			if !result.TimedOut || !slices.Contains(result.UnfinishedStages, "security") {
+				t.Errorf("timed out %t, unfinished %v; want security unfinished", result.TimedOut, result.UnfinishedStages)
+			}
+			if slices.Contains(result.UnfinishedStages, "dns") {
+				t.Errorf("unfinished %v, but DNS answered in time", result.UnfinishedStages)
+			}
+			for _, check := range []string{"security.spf", "security.dmarc", "security.dkim"} {
+				if !slices.Contains(result.DegradedChecks, check) {
+					t.Errorf("degraded checks %v, want %s", result.DegradedChecks, check)
+				}
+			}
+			if result.SecurityAnalysis.SPFRecord.Status != "unknown" {
+				t.Errorf("SPF = %+v, want unknown", result.SecurityAnalysis.SPFRecord)
+			}
+
+			// A cut-short result is not cached, so the next request tries again
+			if e.cache.Len() != 0 {
+				t.Errorf("%d results cached", e.cache.Len())
+			}
+		})
+	}
+}
+
+func TestAnalyzeEmailMalformedAddress(t *testing.T) {
+	e := newTestEngine(t)
+	for _, email := range []string{"@", "a@", "@b", "a@b@c"} {
+		intelligence, err := e.AnalyzeEmail(context.Background(), email, Options{})
+		if err != nil {
+			t.Errorf("%q: %v", email, err)
+			continue
+		}
+		if intelligence.IsValid || intelligence.SyntaxValidation.Status != "fail" {
+			t.Errorf("%q: valid %t, syntax %q; want an invalid address", email, intelligence.IsValid, intelligence.SyntaxValidation.Status)
+		}
+	}
+}
+
+func TestAnalyzeEmailCanonicalForms(t *testing.T) {
+	e := newTestEngine(t)
+
+	// The reserved domain short-circuits analysis after the forms are computed
+	intelligence, err := e.AnalyzeEmail(context.Background(), " John.Doe+promo@Example.com", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intelligence.StorageCanonicalEmail != "John.Doe+promo@example.com" || intelligence.CanonicalEmail != "john.doe+promo@example.com" {
+		t.Errorf("storage %q, canonical %q", intelligence.StorageCanonicalEmail, intelligence.CanonicalEmail)
+	}
+
+	// A cached result shared by differently cased inputs reports each input's storage form
+	profile, _ := e.Profile("")
+	cached := &models.EmailIntelligence{
+		Email:                 "jane.doe@company.org",
+		StorageCanonicalEmail: "jane.doe@company.org",
+		CanonicalEmail:        "jane.doe@company.org",
+	}
+	e.cache.Set("jane.doe@company.org", cacheKey("jane.doe@company.org", Options{Profile: profile.Name}, profile.Weights), cached, time.Hour)
+
+	intelligence, err = e.AnalyzeEmail(context.Background(), "Jane.Doe@Company.org", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intelligence.StorageCanonicalEmail != "Jane.Doe@company.org" || intelligence.CanonicalEmail != "jane.doe@company.org" {
+		t.Errorf("cache hit: storage %q, canonical %q", intelligence.StorageCanonicalEmail, intelligence.CanonicalEmail)
+	}
+	if cached.StorageCanonicalEmail != "jane.doe@company.org" {
+		t.Errorf("cached result changed to %q", cached.StorageCanonicalEmail)
+	}
+	if intelligence, _ = e.AnalyzeEmail(context.Background(), "jane.doe@company.org", Options{}); intelligence != cached {
+		t.Error("matching storage form did not return the cached result itself")
+	}
+}
+
+func TestDisposableFuzzyConfig(t *testing.T) {
+	for _, fuzzy := range []bool{true, false} {
+		cfg := config.Load()
+		cfg.DisposableSource = ""
+		cfg.DisposableFuzzy = fuzzy
+		e := New(cfg)
+		t.Cleanup(e.Close)
+
+		want := validators.DisposableNone
+		if fuzzy {
+			want = validators.DisposableSuspected
+		}
+		if level := e.currentScorers().domain.Validate("fakenews.com").DisposableLevel; level != want {
+			t.Errorf("DisposableFuzzy=%t: fakenews.com is %q, want %q", fuzzy, level, want)
+		}
+		if level := e.currentScorers().domain.Validate("mailinator.com").DisposableLevel; level != validators.DisposableConfirmed {
+			t.Errorf("DisposableFuzzy=%t: mailinator.com is %q, want confirmed", fuzzy, level)
+		}
+	}
+}
+
+// countingResolver answers every lookup at once and counts the queries
+type countingResolver struct {
+	slowTXTResolver
+	mu      sync.Mutex
+	queries int
+}
+
+func (r *countingResolver) count() {
+	r.mu.Lock()
+	r.queries++
+	r.mu.Unlock()
+}
+
+func (r *countingResolver) total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queries
+}
+
+func (r *countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.count()
+	return r.slowTXTResolver.LookupHost(ctx, host)
+}
+
+func (r *countingResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	r.count()
+	return r.slowTXTResolver.LookupIP(ctx, network, host)
+}
+
+func (r *countingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.count()
+	return r.slowTXTResolver.LookupMX(ctx, name)
+}
+
+func (r *countingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.count()
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *countingResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	r.count()
+	return r.slowTXTResolver.LookupNS(ctx, name)
+}
+
+func TestDomainChecksSharedAcrossAddresses(t *testing.T) {
+	newCountingEngine := func() (*Engine, *countingResolver) {
+		cfg := config.Load()
+		cfg.DisposableSource = ""
+		cfg.RateLimitBurst = 1000
+		cfg.BlocklistZones = nil
+		cfg.RDAPBaseURL = ""
+		e := New(cfg)
+		t.Cleanup(e.Close)
+		resolver := &countingResolver{}
+		e.dnsValidator = validators.NewDNSValidator(resolver, nil, time.Second, time.Minute)
+		e.securityValidator = validators.NewSecurityValidator(resolver, validators.SecurityOptions{Timeout: time.Second, DKIMConcurrency: 4})
+		return e, resolver
+	}
+
+	// One address sets the cost of a domain's lookups
+	e, resolver := newCountingEngine()
+	if _, err := e.AnalyzeEmail(context.Background(), "first@company-mail.net", Options{}); err != nil {
+		t.Fatal(err)
+	}
+	perDomain := resolver.total()
+	if perDomain == 0 {
+		t.Fatal("no lookups counted")
+	}
+
+	// 100 addresses at the domain, half of them at once, cost the same
+	e, resolver = newCountingEngine()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e.AnalyzeEmail(context.Background(), fmt.Sprintf("user%d@company-mail.net", i), Options{})
+		}(i)
+	}
+	wg.Wait()
+	for i := 50; i < 100; i++ {
+		result, err := e.AnalyzeEmail(context.Background(), fmt.Sprintf("user%d@Company-Mail.net", i), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.DNSValidation.MXRecords.Status != "pass" {
+			t.Fatalf("MX = %+v", result.DNSValidation.MXRecords)
+		}
+	}
+	if queries := resolver.total(); queries != perDomain {
+		t.Errorf("100 addresses made %d DNS queries, want the %d of one address", queries, perDomain)
+	}
+}
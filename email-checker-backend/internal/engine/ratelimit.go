@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateQuota is the state of one address's rate limit
+type RateQuota struct {
+	Limit      int           // analyses allowed per window
+	Remaining  int           // analyses that may start right now
+	Reset      time.Duration // until the quota is full again
+	RetryAfter time.Duration // until the next analysis may start; zero while Remaining > 0
+}
+
+// RateLimitError is returned by AnalyzeEmail when an address has used up its
+// quota. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Quota RateQuota
+}
+
+func (e *RateLimitError) Error() string { return ErrRateLimited.Error() }
+
+func (e *RateLimitError) Unwrap() error { return ErrRateLimited }
+
+// rateLimiter is a token bucket per address: each bucket holds up to burst
+// tokens and refills burst tokens per window. A bucket left alone for a whole
+// window is full, which is the same as having none, so sweepLoop drops those
+// and memory stays proportional to recently active addresses.
+type rateLimiter struct {
+	window  time.Duration
+	burst   int
+	buckets map[string]*tokenBucket
+	mu      sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter(window time.Duration, burst int) *rateLimiter {
+	return &rateLimiter{
+		window:  window,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token for key when one is available
+func (l *rateLimiter) allow(key string) (RateQuota, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = bucket
+	}
+	l.refill(bucket, now)
+
+	if bucket.tokens < 1 {
+		return l.quota(bucket), false
+	}
+	bucket.tokens--
+	return l.quota(bucket), true
+}
+
+// peek returns key's quota without taking a token
+func (l *rateLimiter) peek(key string) RateQuota {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		return RateQuota{Limit: l.burst, Remaining: l.burst}
+	}
+	current := *bucket
+	l.refill(&current, time.Now())
+	return l.quota(&current)
+}
+
+func (l *rateLimiter) refill(bucket *tokenBucket, now time.Time) {
+	elapsed := now.Sub(bucket.updated)
+	bucket.tokens += float64(l.burst) * elapsed.Seconds() / l.window.Seconds()
+	if bucket.tokens > float64(l.burst) {
+		bucket.tokens = float64(l.burst)
+	}
+	bucket.updated = now
+}
+
+func (l *rateLimiter) quota(bucket *tokenBucket) RateQuota {
+	perToken := l.window / time.Duration(l.burst)
+	quota := RateQuota{
+		Limit:     l.burst,
+		Remaining: int(bucket.tokens),
+		Reset:     time.Duration((float64(l.burst) - bucket.tokens) * float64(perToken)),
+	}
+	if bucket.tokens < 1 {
+		quota.RetryAfter = time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+	return quota
+}
+
+// sweepLoop drops buckets that have refilled completely until ctx is done
+func (l *rateLimiter) sweepLoop(ctx context.Context) {
+	interval := l.window
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		l.mu.Lock()
+		now := time.Now()
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.updated) >= l.window {
+				delete(l.buckets, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+// SelfTestFixture is an address with a known outcome, analyzed at startup to
+// confirm the deployment can reach DNS before it serves traffic
+type SelfTestFixture struct {
+	Name       string
+	Email      string
+	MXFound    bool   // the domain must publish MX records
+	NXDomain   bool   // the resolver must answer that the domain does not exist
+	Disposable string // expected disposable level; empty skips the check
+}
+
+// DefaultSelfTestFixtures cover a resolvable provider, a domain that does not
+// exist and a listed disposable domain. A broken egress path fails the first
+// and last; a resolver that hides NXDOMAIN fails the second.
+var DefaultSelfTestFixtures = []SelfTestFixture{
+	{Name: "valid_provider", Email: "postmaster@gmail.com", MXFound: true, Disposable: "none"},
+	{Name: "nxdomain", Email: "postmaster@selftest-nxdomain-4c9e1f7a.com", NXDomain: true},
+	{Name: "disposable", Email: "postmaster@mailinator.com", MXFound: true, Disposable: "confirmed"},
+}
+
+// SelfTestResult is the outcome of one fixture
+type SelfTestResult struct {
+	Name       string   `json:"name"`
+	Email      string   `json:"email"`
+	Passed     bool     `json:"passed"`
+	Deviations []string `json:"deviations,omitempty"`
+	Transient  bool     `json:"transient,omitempty"` // lookups failed rather than answered differently; a later run may pass
+}
+
+// SelfTestReport summarizes a self-test run
+type SelfTestReport struct {
+	Passed     bool             `json:"passed"`
+	Attempts   int              `json:"attempts"`
+	StartedAt  time.Time        `json:"started_at"`
+	DurationMs int64            `json:"duration_ms"`
+	Results    []SelfTestResult `json:"results"`
+}
+
+// retryable reports whether every failure in the report is transient, so
+// running the self-test again may change the outcome
+func (r SelfTestReport) retryable() bool {
+	if r.Passed {
+		return false
+	}
+	for _, result := range r.Results {
+		if !result.Passed && !result.Transient {
+			return false
+		}
+	}
+	return true
+}
+
+// selfTestState holds the last report; nil until a run has finished
+type selfTestState struct {
+	report *SelfTestReport
+	mu     sync.RWMutex
+}
+
+// Wait between self-test runs that failed on lookups: doubled after each run
+// up to the maximum
+const (
+	selfTestBackoff    = 2 * time.Second
+	selfTestMaxBackoff = time.Minute
+)
+
+// RunSelfTest analyzes the fixtures without SMTP probes, compares the results
+// with their expectations and keeps the report for Readiness
+func (e *Engine) RunSelfTest(ctx context.Context, fixtures []SelfTestFixture) SelfTestReport {
+	report := e.runSelfTest(ctx, fixtures)
+	report.Attempts = 1
+	e.setSelfTestReport(report)
+	return report
+}
+
+// RunSelfTestWithRetries runs the self-test until it passes, fails in a way
+// another run will not change, or ctx ends. Runs that failed only because
+// lookups timed out or the resolver was unreachable are retried with
+// exponential backoff, each within timeout. Readiness reports the latest run.
+func (e *Engine) RunSelfTestWithRetries(ctx context.Context, fixtures []SelfTestFixture, timeout time.Duration) SelfTestReport {
+	return retrySelfTest(ctx, selfTestBackoff, selfTestMaxBackoff, func(ctx context.Context) SelfTestReport {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return e.runSelfTest(ctx, fixtures)
+	}, e.setSelfTestReport)
+}
+
+// retrySelfTest calls run until its report is not retryable, waiting backoff
+// before the second run and twice as long before each further one, up to
+// maxBackoff. Every report is handed to keep; the last one is returned.
+func retrySelfTest(ctx context.Context, backoff, maxBackoff time.Duration, run func(context.Context) SelfTestReport, keep func(SelfTestReport)) SelfTestReport {
+	for attempt := 1; ; attempt++ {
+		report := run(ctx)
+		report.Attempts = attempt
+		keep(report)
+		if !report.retryable() || ctx.Err() != nil {
+			return report
+		}
+		log.Printf("Startup self-test run %d failed on DNS lookups, retrying in %s", attempt, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return report
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (e *Engine) setSelfTestReport(report SelfTestReport) {
+	e.selfTest.mu.Lock()
+	e.selfTest.report = &report
+	e.selfTest.mu.Unlock()
+}
+
+// runSelfTest analyzes the fixtures once
+func (e *Engine) runSelfTest(ctx context.Context, fixtures []SelfTestFixture) SelfTestReport {
+	report := SelfTestReport{Passed: true, StartedAt: time.Now()}
+	results := make([]SelfTestResult, len(fixtures))
+	var wg sync.WaitGroup
+	for i, fixture := range fixtures {
+		wg.Add(1)
+		go func(i int, fixture SelfTestFixture) {
+			defer wg.Done()
+			result := SelfTestResult{Name: fixture.Name, Email: fixture.Email}
+			intelligence, err := e.analyze(ctx, fixture.Email, Options{}, nil)
+			if err != nil {
+				result.Deviations = []string{fmt.Sprintf("analysis failed: %v", err)}
+				result.Transient = errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRateLimited)
+			} else {
+				result.Deviations, result.Transient = e.compare(ctx, fixture, intelligence)
+			}
+			result.Passed = len(result.Deviations) == 0
+			results[i] = result
+		}(i, fixture)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if !result.Passed {
+			report.Passed = false
+		}
+	}
+	report.Results = results
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	return report
+}
+
+// compare lists how an analysis deviates from the fixture's expectations.
+// transient is set when a deviation comes from a lookup that failed or timed
+// out rather than one that answered.
+func (e *Engine) compare(ctx context.Context, f SelfTestFixture, intelligence *models.EmailIntelligence) (deviations []string, transient bool) {
+	dns := intelligence.DNSValidation
+	if f.MXFound && dns.MXRecords.Status != "pass" {
+		deviations = append(deviations, fmt.Sprintf("expected MX records, got %s (%s)", dns.MXRecords.Status, dns.MXRecords.Reason))
+		transient = transient || validators.Degraded(dns.MXRecords)
+	}
+	if f.NXDomain {
+		if dns.DomainExists.Status != "fail" {
+			deviations = append(deviations, fmt.Sprintf("expected NXDOMAIN, got %s (%s)", dns.DomainExists.Status, dns.DomainExists.RawSignal))
+			transient = transient || validators.Degraded(dns.DomainExists)
+		}
+		if _, domain, ok := validators.SplitAddress(f.Email); ok {
+			if deviation, failed := e.nxdomainDeviation(ctx, domain); deviation != "" {
+				deviations = append(deviations, deviation)
+				transient = transient || failed
+			}
+		}
+	}
+	if f.Disposable != "" && intelligence.DomainIntelligence.DisposableLevel != f.Disposable {
+		deviations = append(deviations, fmt.Sprintf("expected disposable level %s, got %s", f.Disposable, intelligence.DomainIntelligence.DisposableLevel))
+	}
+	if intelligence.TimedOut && len(deviations) > 0 {
+		transient = true
+	}
+	return deviations, transient
+}
+
+// nxdomainDeviation looks domain up and describes how the answer differs from
+// NXDOMAIN, judged by the resolver's error rather than its message. failed is
+// set when the lookup got no answer at all, e.g. a timeout, SERVFAIL or an
+// unreachable server.
+func (e *Engine) nxdomainDeviation(ctx context.Context, domain string) (deviation string, failed bool) {
+	_, err := e.resolver.LookupHost(ctx, domain)
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil:
+		return "expected NXDOMAIN, but the domain resolved; the resolver may be rewriting missing domains", false
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "", false
+	}
+	return fmt.Sprintf("expected NXDOMAIN, but the lookup failed: %v", err), true
+}
+
+// Readiness reports whether the engine should receive traffic. Without a
+// self-test it is always ready; with one it is ready once a run has passed.
+// The report is nil while the self-test has not finished.
+func (e *Engine) Readiness() (bool, *SelfTestReport) {
+	if !e.config.SelfTest {
+		return true, nil
+	}
+	e.selfTest.mu.RLock()
+	defer e.selfTest.mu.RUnlock()
+	if e.selfTest.report == nil {
+		return false, nil
+	}
+	return e.selfTest.report.Passed, e.selfTest.report
+}
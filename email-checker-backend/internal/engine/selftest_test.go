@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/validators"
+)
+
+// hostResolver answers LookupHost with err; other lookups are not used
+type hostResolver struct {
+	validators.Resolver
+	err error
+}
+
+func (r hostResolver) LookupHost(context.Context, string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func TestNXDomainDeviation(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantDeviation bool
+		wantFailed    bool
+	}{
+		{"NXDOMAIN", &net.DNSError{Err: "no such host", IsNotFound: true}, false, false},
+		{"wrapped NXDOMAIN", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "not found", IsNotFound: true}}, false, false},
+		{"resolver rewrites missing domains", nil, true, false},
+		{"timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, true, true},
+		{"SERVFAIL", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, true, true},
+		// The message alone must not pass for NXDOMAIN
+		{"no such host text without the flag", &net.DNSError{Err: "no such host"}, true, true},
+		{"deadline", context.DeadlineExceeded, true, true},
+	}
+	for _, tt := range tests {
+		e := &Engine{resolver: hostResolver{err: tt.err}}
+		deviation, failed := e.nxdomainDeviation(context.Background(), "selftest.example")
+		if (deviation != "") != tt.wantDeviation || failed != tt.wantFailed {
+			t.Errorf("%s: deviation %q, failed %t; want deviation %t, failed %t", tt.name, deviation, failed, tt.wantDeviation, tt.wantFailed)
+		}
+	}
+}
+
+// reportSequence returns the reports in turn, repeating the last
+func reportSequence(reports ...SelfTestReport) func(context.Context) SelfTestReport {
+	return func(context.Context) SelfTestReport {
+		report := reports[0]
+		if len(reports) > 1 {
+			reports = reports[1:]
+		}
+		return report
+	}
+}
+
+var (
+	passed        = SelfTestReport{Passed: true}
+	transientFail = SelfTestReport{Results: []SelfTestResult{{Name: "valid_provider", Transient: true}, {Name: "disposable", Passed: true}}}
+	definiteFail  = SelfTestReport{Results: []SelfTestResult{{Name: "nxdomain"}, {Name: "valid_provider", Transient: true}}}
+)
+
+func TestRetrySelfTest(t *testing.T) {
+	tests := []struct {
+		name         string
+		run          func(context.Context) SelfTestReport
+		wantPassed   bool
+		wantAttempts int
+	}{
+		{"passes first time", reportSequence(passed), true, 1},
+		{"retries lookup failures until it passes", reportSequence(transientFail, transientFail, passed), true, 3},
+		{"stops at a failure a retry will not fix", reportSequence(transientFail, definiteFail, passed), false, 2},
+	}
+	for _, tt := range tests {
+		var kept []SelfTestReport
+		report := retrySelfTest(context.Background(), time.Millisecond, 4*time.Millisecond, tt.run, func(r SelfTestReport) { kept = append(kept, r) })
+		if report.Passed != tt.wantPassed || report.Attempts != tt.wantAttempts {
+			t.Errorf("%s: passed %t after %d attempts, want %t after %d", tt.name, report.Passed, report.Attempts, tt.wantPassed, tt.wantAttempts)
+		}
+		// Readiness sees every run as it finishes
+		if len(kept) != tt.wantAttempts {
+			t.Errorf("%s: %d reports kept, want %d", tt.name, len(kept), tt.wantAttempts)
+		}
+	}
+}
+
+func TestRetrySelfTestStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan SelfTestReport, 1)
+	go func() {
+		done <- retrySelfTest(ctx, time.Hour, time.Hour, reportSequence(transientFail), func(SelfTestReport) {})
+	}()
+	select {
+	case report := <-done:
+		if report.Passed || report.Attempts != 1 {
+			t.Errorf("report = %+v", report)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retries outlived the context")
+	}
+}
+
+func TestSelfTestReportRetryable(t *testing.T) {
+	if passed.retryable() || !transientFail.retryable() || definiteFail.retryable() {
+		t.Errorf("retryable: passed %t, transient %t, definite %t", passed.retryable(), transientFail.retryable(), definiteFail.retryable())
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin admits only requests carrying key, as "Authorization: Bearer
+// <key>" or in the X-API-Key header. With an empty key no request is admitted,
+// so admin endpoints stay closed until ADMIN_API_KEY is set.
+func RequireAdmin(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key == "" {
+			respondError(c, CodeUnauthorized, "Admin endpoints are disabled; set ADMIN_API_KEY to enable them", nil)
+			return
+		}
+		if !validKey(presentedKey(c), key) {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			respondError(c, CodeUnauthorized, "A valid admin API key is required", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// presentedKey is the API key a request carries, from either header
+func presentedKey(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return c.GetHeader("X-API-Key")
+}
+
+// validKey compares in constant time, so response timing does not reveal how
+// much of the key was right
+func validKey(presented, key string) bool {
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(key)) == 1
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"email-intelligence/internal/config"
+	"email-intelligence/internal/engine"
+	"email-intelligence/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func adminRouter(key string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", RequireAdmin(key), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		headers map[string]string
+		want    int
+	}{
+		{"bearer token", "s3cret", map[string]string{"Authorization": "Bearer s3cret"}, http.StatusNoContent},
+		{"lowercase scheme", "s3cret", map[string]string{"Authorization": "bearer s3cret"}, http.StatusNoContent},
+		{"api key header", "s3cret", map[string]string{"X-API-Key": "s3cret"}, http.StatusNoContent},
+		{"no key", "s3cret", nil, http.StatusUnauthorized},
+		{"wrong key", "s3cret", map[string]string{"Authorization": "Bearer s3cre"}, http.StatusUnauthorized},
+		{"other scheme", "s3cret", map[string]string{"Authorization": "Basic s3cret"}, http.StatusUnauthorized},
+		{"disabled without a key", "", map[string]string{"Authorization": "Bearer "}, http.StatusUnauthorized},
+		{"empty key header when disabled", "", map[string]string{"X-API-Key": ""}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			adminRouter(tt.key).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+// fakeStore returns records for any domain
+type fakeStore struct {
+	store.NopStore
+	records []store.Record
+}
+
+func (s fakeStore) RecentByDomain(context.Context, string, int) ([]store.Record, error) {
+	return s.records, nil
+}
+
+func TestRecentResultsRequiresAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{store: fakeStore{records: []store.Record{{Email: "jane@example.com", Domain: "example.com"}}}}
+	router := gin.New()
+	router.GET("/results", RequireAdmin("s3cret"), h.RecentResults)
+
+	for _, tt := range []struct {
+		key  string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"guess", http.StatusUnauthorized},
+		{"s3cret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/results?domain=example.com", nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("key %q: status = %d, want %d", tt.key, rec.Code, tt.want)
+		}
+		if tt.want != http.StatusOK && strings.Contains(rec.Body.String(), "jane") {
+			t.Errorf("key %q: stored addresses leaked: %s", tt.key, rec.Body)
+		}
+	}
+}
+
+func TestStatsRequiresAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{engine: engine.New(config.Load())}
+	router := gin.New()
+	router.GET("/stats", RequireAdmin("s3cret"), h.Stats)
+
+	for key, want := range map[string]int{"": http.StatusUnauthorized, "guess": http.StatusUnauthorized, "s3cret": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("key %q: status = %d, want %d", key, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/metrics"
+)
+
+// bulkSchedule bounds how a bulk request is spread over workers and domains
+type bulkSchedule struct {
+	Workers     int           // analyses in flight across the whole request
+	DomainLimit int           // analyses in flight per domain once its cache is warm
+	SMTPSpacing time.Duration // minimum gap between starts within a domain; zero disables
+}
+
+// domainBatch holds the input positions of one domain's addresses
+type domainBatch struct {
+	domain  string
+	indexes []int
+}
+
+// dedupeEmails returns each distinct address once, compared trimmed and
+// lowercased as the engine analyzes them, and for every input position the
+// index of its address in unique
+func dedupeEmails(emails []string) (unique []string, positions []int) {
+	seen := make(map[string]int, len(emails))
+	positions = make([]int, len(emails))
+	for i, email := range emails {
+		key := strings.ToLower(strings.TrimSpace(email))
+		position, ok := seen[key]
+		if !ok {
+			position = len(unique)
+			seen[key] = position
+			unique = append(unique, email)
+		}
+		positions[i] = position
+	}
+	return unique, positions
+}
+
+// groupByDomain groups addresses by lowercased domain, keeping domains and
+// addresses in input order. Addresses without a domain share the "" batch.
+func groupByDomain(emails []string) []domainBatch {
+	batches := []domainBatch{}
+	positions := map[string]int{}
+	for i, email := range emails {
+		domain := ""
+		if at := strings.LastIndex(email, "@"); at != -1 {
+			domain = strings.ToLower(strings.TrimSpace(email[at+1:]))
+		}
+		position, ok := positions[domain]
+		if !ok {
+			position = len(batches)
+			positions[domain] = position
+			batches = append(batches, domainBatch{domain: domain})
+		}
+		batches[position].indexes = append(batches[position].indexes, i)
+	}
+	return batches
+}
+
+// scheduleByDomain calls analyze for every input position. Domains run side
+// by side, but within a domain the first address is analyzed alone so the DNS,
+// security and SMTP caches are warm before the remaining addresses fan out,
+// at most DomainLimit at a time and SMTPSpacing apart. Addresses without a
+// domain fail syntax checks cheaply and are not throttled. Once ctx is done no
+// further addresses are started; their analyze is never called.
+func scheduleByDomain(ctx context.Context, emails []string, schedule bulkSchedule, analyze func(index int)) {
+	if schedule.Workers < 1 {
+		schedule.Workers = 1
+	}
+	workers := make(chan struct{}, schedule.Workers)
+	run := func(index int) {
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		defer func() { <-workers }()
+		if ctx.Err() != nil {
+			return
+		}
+		metrics.BulkWorkers.Inc()
+		defer metrics.BulkWorkers.Dec()
+		analyze(index)
+	}
+
+	var wg sync.WaitGroup
+	for _, batch := range groupByDomain(emails) {
+		wg.Add(1)
+		go func(batch domainBatch) {
+			defer wg.Done()
+			if batch.domain == "" {
+				fanOut(ctx, batch.indexes, len(batch.indexes), 0, run)
+				return
+			}
+			run(batch.indexes[0])
+			fanOut(ctx, batch.indexes[1:], schedule.DomainLimit, schedule.SMTPSpacing, run)
+		}(batch)
+	}
+	wg.Wait()
+}
+
+// fanOut runs indexes with at most limit in flight, starting each at least
+// spacing after the previous one. It stops starting new ones when ctx is done
+// and returns once those already started have finished.
+func fanOut(ctx context.Context, indexes []int, limit int, spacing time.Duration, run func(index int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	var lastStart time.Time
+	for _, index := range indexes {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		if wait := time.Until(lastStart.Add(spacing)); spacing > 0 && wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				wg.Wait()
+				return
+			}
+		}
+		lastStart = time.Now()
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			run(index)
+		}(index)
+	}
+	wg.Wait()
+}
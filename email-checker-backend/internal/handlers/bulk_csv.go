@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"email-intelligence/internal/engine"
+	"email-intelligence/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkCSVHeader is the first row of a /bulk-analyze-csv response
+var bulkCSVHeader = []string{"row", "email", "is_valid", "validation_score", "risk_category", "error"}
+
+// csvRow is one address read from an uploaded CSV, or a row that could not be
+// used, in which case problem says why
+type csvRow struct {
+	row     int
+	email   string
+	problem string
+}
+
+// BulkAnalyzeCSV analyzes an uploaded CSV and streams one result row per
+// address back as CSV while the upload is still being read, so neither the
+// input nor the results are held in memory. Parameters are taken from the
+// query string because the multipart body is consumed as a stream. Rows are
+// written as they complete, so the row column gives each one's input position.
+func (h *Handlers) BulkAnalyzeCSV(c *gin.Context) {
+	column, err := strconv.Atoi(c.DefaultQuery("column", "0"))
+	if err != nil || column < 0 {
+		respondError(c, CodeInvalidRequest, "column must be a non-negative integer", nil)
+		return
+	}
+	opts := engine.Options{
+		DeepAnalysis: c.Query("deep_analysis") == "true",
+		Profile:      c.Query("profile"),
+	}
+	if _, ok := h.engine.Profile(opts.Profile); !ok {
+		respondError(c, CodeInvalidRequest, "Unknown scoring profile", opts.Profile)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.JobMaxUpload)
+	file, err := csvFilePart(c.Request)
+	if err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid upload", err.Error())
+		return
+	}
+
+	// HTTP/1.x servers stop reading the request once the response starts
+	// unless full duplex is enabled; HTTP/2 is always full duplex
+	http.NewResponseController(c.Writer).EnableFullDuplex()
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="results.csv"`)
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Trailer", "X-Processed-Count, X-Skipped-Count")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(bulkCSVHeader)
+	writer.Flush()
+	c.Writer.Flush()
+
+	var writerMu sync.Mutex
+	processed, skipped := 0, 0
+	write := func(record []string, ok bool) {
+		writerMu.Lock()
+		defer writerMu.Unlock()
+		if ok {
+			processed++
+		} else {
+			skipped++
+		}
+		writer.Write(record)
+		writer.Flush()
+		c.Writer.Flush()
+	}
+
+	ctx := c.Request.Context()
+	caller := callerID(c)
+	rows := make(chan csvRow, h.config.JobWorkers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < h.config.JobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rows {
+				if row.problem != "" {
+					write([]string{strconv.Itoa(row.row), row.email, "", "", "", row.problem}, false)
+					continue
+				}
+				metrics.BulkWorkers.Inc()
+				intelligence, err := h.engine.AnalyzeEmail(ctx, row.email, opts)
+				metrics.BulkWorkers.Dec()
+				if err != nil {
+					write([]string{strconv.Itoa(row.row), row.email, "false", "0", "Error", analyzeError(err).Message}, true)
+					continue
+				}
+				h.persist(caller, intelligence)
+				write([]string{
+					strconv.Itoa(row.row),
+					intelligence.Email,
+					strconv.FormatBool(intelligence.IsValid),
+					strconv.Itoa(intelligence.ValidationScore),
+					intelligence.RiskCategory,
+					"",
+				}, true)
+			}
+		}()
+	}
+
+	scanErr := scanCSVRows(file, column, func(row csvRow) bool {
+		select {
+		case rows <- row:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	close(rows)
+	wg.Wait()
+
+	// A read error such as an oversized upload ends the stream early; report
+	// it as a last row since the status line has already been sent
+	if scanErr != nil && ctx.Err() == nil {
+		message := scanErr.Error()
+		var tooLarge *http.MaxBytesError
+		if errors.As(scanErr, &tooLarge) {
+			message = fmt.Sprintf("upload exceeds the size limit of %d bytes", tooLarge.Limit)
+		}
+		write([]string{"", "", "", "", "", "aborted: " + message}, false)
+	}
+
+	c.Writer.Header().Set("X-Processed-Count", strconv.Itoa(processed))
+	c.Writer.Header().Set("X-Skipped-Count", strconv.Itoa(skipped))
+}
+
+// csvFilePart returns the "file" part of a multipart upload without reading
+// it into memory or onto disk. Parts before it are skipped.
+func csvFilePart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing file part")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// scanCSVRows streams rows from a CSV/TXT upload until fn returns false. A
+// header row and blank fields are skipped; rows the CSV parser cannot read or
+// that lack the email column are passed on with a problem instead of ending
+// the scan.
+func scanCSVRows(src io.Reader, column int, fn func(csvRow) bool) error {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+			if !fn(csvRow{row: row, problem: "malformed row: " + parseErr.Err.Error()}) {
+				return nil
+			}
+			continue
+		}
+
+		if column >= len(record) {
+			if !fn(csvRow{row: row, problem: fmt.Sprintf("row has no column %d", column)}) {
+				return nil
+			}
+			continue
+		}
+		email := strings.TrimSpace(record[column])
+		if email == "" || (row == 1 && !strings.Contains(email, "@")) {
+			continue
+		}
+
+		if !fn(csvRow{row: row, email: email}) {
+			return nil
+		}
+	}
+}
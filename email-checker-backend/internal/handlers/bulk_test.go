@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduleByDomainStopsWhenCancelled(t *testing.T) {
+	emails := make([]string, 40)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("user%d@example%d.com", i, i%2)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first two analyses hold both workers until the client goes away
+	var started int32
+	done := make(chan struct{})
+	go func() {
+		scheduleByDomain(ctx, emails, bulkSchedule{Workers: 2, DomainLimit: 4}, func(int) {
+			if atomic.AddInt32(&started, 1) == 2 {
+				cancel()
+			}
+			<-ctx.Done()
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduling kept going after the context was cancelled")
+	}
+	if n := atomic.LoadInt32(&started); n != 2 {
+		t.Errorf("%d analyses started, want only the 2 running when the context was cancelled", n)
+	}
+}
+
+func TestScheduleByDomainRunsEveryAddress(t *testing.T) {
+	emails := []string{"a@example.com", "b@example.com", "c@example.org", "not-an-address", "d@example.com"}
+	seen := make([]int32, len(emails))
+	scheduleByDomain(context.Background(), emails, bulkSchedule{Workers: 2, DomainLimit: 2, SMTPSpacing: time.Millisecond}, func(index int) {
+		atomic.AddInt32(&seen[index], 1)
+	})
+	for i, n := range seen {
+		if n != 1 {
+			t.Errorf("%s analyzed %d times", emails[i], n)
+		}
+	}
+}
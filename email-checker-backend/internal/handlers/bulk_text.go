@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkAnalyzeText analyzes a pasted list sent as text/plain: addresses
+// separated by newlines, commas or semicolons, optionally in display-name
+// form ("Jane Doe" <jane@example.com>). Repeated addresses are analyzed and
+// reported once. Options are taken from the query string and the response
+// is the same as /bulk-analyze's.
+func (h *Handlers) BulkAnalyzeText(c *gin.Context) {
+	startTime := time.Now()
+
+	if c.ContentType() != "text/plain" {
+		respondError(c, CodeInvalidRequest, "Content-Type must be text/plain", c.ContentType())
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, h.config.BulkTextMaxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, CodePayloadTooLarge, "Request exceeds the size limit", gin.H{"limit_bytes": tooLarge.Limit})
+			return
+		}
+		respondError(c, CodeInvalidRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	emails := parseAddressList(string(body))
+	if len(emails) == 0 {
+		respondError(c, CodeInvalidRequest, "No email addresses found in the request body", nil)
+		return
+	}
+
+	h.bulkAnalyze(c, BulkAnalyzeRequest{
+		Emails:            emails,
+		DeepAnalysis:      c.Query("deep_analysis") == "true",
+		SkipInvalidSyntax: c.Query("skip_invalid_syntax") == "true",
+		SortBy:            c.Query("sort_by"),
+		Profile:           c.Query("profile"),
+	}, startTime)
+}
+
+// parseAddressList returns the addresses of a pasted list in input order,
+// without blanks and without repeats (compared case-insensitively)
+func parseAddressList(text string) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, entry := range splitAddressList(text) {
+		email := extractAddress(entry)
+		key := strings.ToLower(email)
+		if email == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+// splitAddressList splits text on newlines, commas and semicolons. Commas
+// and semicolons inside a quoted display name, angle brackets or a comment
+// belong to the entry, as in "Doe, Jane" <jane@example.com>; a newline
+// always ends one, so an unbalanced quote cannot swallow the rest of the list.
+func splitAddressList(text string) []string {
+	var entries []string
+	var current strings.Builder
+	quoted, escaped := false, false
+	angle, comment := 0, 0
+	flush := func() {
+		entries = append(entries, current.String())
+		current.Reset()
+		quoted, escaped = false, false
+		angle, comment = 0, 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r':
+			flush()
+			continue
+		case escaped:
+			escaped = false
+		case r == '\\' && (quoted || comment > 0):
+			escaped = true
+		case r == '"' && comment == 0:
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			comment++
+		case r == ')' && comment > 0:
+			comment--
+		case comment > 0:
+		case r == '<':
+			angle++
+		case r == '>' && angle > 0:
+			angle--
+		case (r == ',' || r == ';') && angle == 0:
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return entries
+}
+
+// extractAddress returns the addr-spec of one RFC 5322 mailbox: the part in
+// angle brackets when there is a display name, otherwise the entry without
+// comments. Malformed entries are returned trimmed, for the syntax check to
+// report.
+func extractAddress(entry string) string {
+	entry = strings.TrimSpace(entry)
+	var bare strings.Builder
+	quoted, escaped := false, false
+	comment := 0
+	for i, r := range entry {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && (quoted || comment > 0):
+			escaped = true
+		case r == '"' && comment == 0:
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			comment++
+			continue
+		case r == ')' && comment > 0:
+			comment--
+			continue
+		case comment > 0:
+			continue
+		case r == '<':
+			inner := entry[i+1:]
+			if end := strings.IndexByte(inner, '>'); end != -1 {
+				inner = inner[:end]
+			}
+			return strings.TrimSpace(inner)
+		}
+		if comment == 0 {
+			bare.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(bare.String())
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"email-intelligence/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseAddressList(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"quoted display name with a comma", `"Doe, Jane" <jane@x.com>, bob@x.com`, []string{"jane@x.com", "bob@x.com"}},
+		{"comment with a semicolon", "jane@x.com (work; main); bob@x.com", []string{"jane@x.com", "bob@x.com"}},
+		{"unbalanced quote stops at the newline", "\"Jane <jane@x.com>, bob@x.com\ncarol@x.com", []string{`"Jane <jane@x.com>, bob@x.com`, "carol@x.com"}},
+		{"mixed separators", "a@x.com;b@x.com\r\nc@x.com", []string{"a@x.com", "b@x.com", "c@x.com"}},
+		{"unclosed angle bracket", "Jane <jane@x.com, bob@x.com\ncarol@x.com", []string{"jane@x.com, bob@x.com", "carol@x.com"}},
+		{"case-insensitive duplicates", "Jane@X.com, jane@x.com\n<JANE@x.COM>", []string{"Jane@X.com"}},
+		{"blank entries", " , ;\n\n", nil},
+	}
+	for _, tt := range tests {
+		if got := parseAddressList(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBulkAnalyzeTextRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.BulkTextMaxBytes = 64
+	h := &Handlers{config: cfg}
+	router := gin.New()
+	router.POST("/bulk-analyze-text", h.BulkAnalyzeText)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantDetails bool
+	}{
+		{"JSON body", "application/json", `{"emails": ["jane@x.com"]}`, http.StatusBadRequest, CodeInvalidRequest, true},
+		{"over the size limit", "text/plain; charset=utf-8", strings.Repeat("jane@x.com\n", 10), http.StatusRequestEntityTooLarge, CodePayloadTooLarge, true},
+		{"no addresses", "text/plain", "\n , \n", http.StatusBadRequest, CodeInvalidRequest, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/bulk-analyze-text", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		assertEnvelope(t, tt.name, rec, tt.wantCode, tt.wantDetails)
+	}
+}
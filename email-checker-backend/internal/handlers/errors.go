@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"email-intelligence/internal/engine"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes returned in the error envelope
+const (
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodeBulkTooLarge     = "BULK_TOO_LARGE"
+	CodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeNotReady         = "NOT_READY"
+	CodeNotImplemented   = "NOT_IMPLEMENTED"
+	CodeInternal         = "INTERNAL_ERROR"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeUnavailable      = "SERVICE_UNAVAILABLE"
+)
+
+// APIError is the body of every error response: {"error": {code, message, details}}
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// errorStatus maps each code to its HTTP status
+var errorStatus = map[string]int{
+	CodeInvalidRequest:   http.StatusBadRequest,
+	CodeBulkTooLarge:     http.StatusRequestEntityTooLarge,
+	CodePayloadTooLarge:  http.StatusRequestEntityTooLarge,
+	CodeRateLimited:      http.StatusTooManyRequests,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeNotFound:         http.StatusNotFound,
+	CodeNotReady:         http.StatusConflict,
+	CodeNotImplemented:   http.StatusNotImplemented,
+	CodeInternal:         http.StatusInternalServerError,
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+}
+
+// respondError writes the error envelope with the status that belongs to code.
+// details is omitted when nil.
+func respondError(c *gin.Context, code, message string, details interface{}) {
+	status, ok := errorStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": APIError{Code: code, Message: message, Details: details},
+	})
+}
+
+// analyzeError converts an engine error into the envelope body
+func analyzeError(err error) APIError {
+	if errors.Is(err, engine.ErrRateLimited) {
+		return APIError{Code: CodeRateLimited, Message: "Rate limit exceeded, retry shortly"}
+	}
+	if errors.Is(err, engine.ErrUnknownProfile) || errors.Is(err, engine.ErrInvalidWeights) || errors.Is(err, engine.ErrInvalidSelector) ||
+		errors.Is(err, engine.ErrInvalidValidScore) {
+		return APIError{Code: CodeInvalidRequest, Message: err.Error()}
+	}
+	return APIError{Code: CodeInternal, Message: "Analysis failed", Details: err.Error()}
+}
+
+// respondAnalyzeError writes the envelope for an engine error
+func respondAnalyzeError(c *gin.Context, err error) {
+	var limited *engine.RateLimitError
+	if errors.As(err, &limited) {
+		setRateLimitHeaders(c, limited.Quota)
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(limited.Quota.RetryAfter)))
+	}
+	apiErr := analyzeError(err)
+	respondError(c, apiErr.Code, apiErr.Message, apiErr.Details)
+}
+
+// NotFound answers requests for unknown routes with the error envelope
+func NotFound(c *gin.Context) {
+	respondError(c, CodeNotFound, "Route not found", c.Request.Method+" "+c.Request.URL.Path)
+}
+
+// MethodNotAllowed answers requests using an unsupported method with the error envelope
+func MethodNotAllowed(c *gin.Context) {
+	respondError(c, CodeMethodNotAllowed, "Method not allowed", c.Request.Method+" "+c.Request.URL.Path)
+}
+
+// setRateLimitHeaders reports an address's rate limit quota; the reset time is
+// in whole seconds, rounded up
+func setRateLimitHeaders(c *gin.Context, quota engine.RateQuota) {
+	c.Header("X-Rate-Limit-Limit", strconv.Itoa(quota.Limit))
+	c.Header("X-Rate-Limit-Remaining", strconv.Itoa(quota.Remaining))
+	c.Header("X-Rate-Limit-Reset", strconv.Itoa(ceilSeconds(quota.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
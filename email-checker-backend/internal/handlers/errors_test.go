@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/config"
+	"email-intelligence/internal/engine"
+	"email-intelligence/internal/jobs"
+	"email-intelligence/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// envelopeRouter wires a subset of the API the way cmd/server does
+func envelopeRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.DisposableSource = ""
+	cfg.RateLimitBurst = 1
+	cfg.RateLimitWindow = time.Hour
+	eng := engine.New(cfg)
+	h := New(eng, jobs.NewManager(nil, jobs.Options{TTL: time.Hour}), store.NopStore{}, cfg)
+
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NotFound)
+	router.NoMethod(MethodNotAllowed)
+	router.POST("/analyze", h.AnalyzeEmail)
+	router.POST("/bulk-analyze", h.BulkAnalyze)
+	router.GET("/dkim", h.DKIMSelector)
+	router.GET("/jobs/:id", h.JobStatus)
+	router.GET("/results", RequireAdmin(""), h.RecentResults)
+	router.PUT("/scoring-weights", RequireAdmin("secret"), h.UpdateScoringWeights)
+	return router
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	router := envelopeRouter(t)
+	tooMany, _ := json.Marshal(map[string][]string{"emails": make([]string, 1001)})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantDetails bool
+	}{
+		{"malformed JSON", http.MethodPost, "/analyze", `{"email":`, http.StatusBadRequest, CodeInvalidRequest, true},
+		{"missing email", http.MethodPost, "/analyze", `{}`, http.StatusBadRequest, CodeInvalidRequest, true},
+		{"bulk too large", http.MethodPost, "/bulk-analyze", string(tooMany), http.StatusRequestEntityTooLarge, CodeBulkTooLarge, true},
+		{"bad sort order", http.MethodPost, "/bulk-analyze", `{"emails": ["jane@example.com"], "sort_by": "name"}`, http.StatusBadRequest, CodeInvalidRequest, true},
+		{"missing selector", http.MethodGet, "/dkim?domain=example.com", "", http.StatusBadRequest, CodeInvalidRequest, false},
+		{"unknown job", http.MethodGet, "/jobs/missing", "", http.StatusNotFound, CodeNotFound, false},
+		{"admin disabled", http.MethodGet, "/results?domain=example.com", "", http.StatusUnauthorized, CodeUnauthorized, false},
+		{"wrong admin key", http.MethodPut, "/scoring-weights", `{}`, http.StatusUnauthorized, CodeUnauthorized, false},
+		{"unknown route", http.MethodGet, "/nowhere", "", http.StatusNotFound, CodeNotFound, true},
+		{"wrong method", http.MethodDelete, "/analyze", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed, true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		assertEnvelope(t, tt.name, rec, tt.wantCode, tt.wantDetails)
+	}
+}
+
+func TestErrorEnvelopeRateLimited(t *testing.T) {
+	router := envelopeRouter(t)
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(`{"email": "jane@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(); rec.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d (body %s)", rec.Code, rec.Body)
+	}
+	rec := post()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want 429", rec.Code)
+	}
+	assertEnvelope(t, "rate limited", rec, CodeRateLimited, false)
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After not set")
+	}
+}
+
+// assertEnvelope checks the body is exactly {"error": {code, message[, details]}}
+func assertEnvelope(t *testing.T, name string, rec *httptest.ResponseRecorder, wantCode string, wantDetails bool) {
+	t.Helper()
+	var body map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body) != 1 || body["error"] == nil {
+		t.Errorf("%s: body %s is not an error envelope", name, rec.Body)
+		return
+	}
+	apiErr := body["error"]
+	var code, message string
+	json.Unmarshal(apiErr["code"], &code)
+	json.Unmarshal(apiErr["message"], &message)
+	if code != wantCode || message == "" {
+		t.Errorf("%s: code %q, message %q; want code %s and a message", name, code, message, wantCode)
+	}
+	if _, ok := apiErr["details"]; ok != wantDetails {
+		t.Errorf("%s: details present %t, want %t (body %s)", name, ok, wantDetails, rec.Body)
+	}
+	for field := range apiErr {
+		if field != "code" && field != "message" && field != "details" {
+			t.Errorf("%s: unexpected field %q", name, field)
+		}
+	}
+}
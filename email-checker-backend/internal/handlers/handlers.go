@@ -1,13 +1,23 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"email-intelligence/internal/analyzers"
+	"email-intelligence/internal/config"
 	"email-intelligence/internal/engine"
+	"email-intelligence/internal/jobs"
+	"email-intelligence/internal/metrics"
 	"email-intelligence/internal/models"
+	"email-intelligence/internal/store"
+	"email-intelligence/internal/validators"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +25,9 @@ import (
 // Handlers contains all HTTP handlers
 type Handlers struct {
 	engine       *engine.Engine
+	jobs         *jobs.Manager
+	store        store.ResultStore
+	config       *config.Config
 	requestCount int64
 	totalLatency int64
 	errorCount   int64
@@ -22,119 +35,445 @@ type Handlers struct {
 }
 
 // New creates new handlers
-func New(eng *engine.Engine) *Handlers {
+func New(eng *engine.Engine, jobManager *jobs.Manager, resultStore store.ResultStore, cfg *config.Config) *Handlers {
 	return &Handlers{
 		engine: eng,
+		jobs:   jobManager,
+		store:  resultStore,
+		config: cfg,
 	}
 }
 
+// AnalyzeRequest is the body of POST /analyze
+type AnalyzeRequest struct {
+	Email           string                  `json:"email" binding:"required"`
+	DeepAnalysis    bool                    `json:"deep_analysis"`
+	CheckSubmission bool                    `json:"check_submission"`
+	Profile         string                  `json:"profile"`         // named scoring profile, e.g. "strict" or "fraud"
+	IfNoneMatch     string                  `json:"if_none_match"`   // etag of a previous result; an unchanged result is not sent again
+	ScoringProfile  *models.WeightOverrides `json:"scoring_profile"` // weights replaced for this request only
+	DKIMSelector    string                  `json:"dkim_selector"`   // known selector, looked up instead of searching
+	DebugMode       bool                    `json:"debug_mode"`      // same as ?debug=true
+	MinValidScore   *int                    `json:"min_valid_score"` // validity cutoff replaced for this request only
+}
+
 // AnalyzeEmail handles single email analysis
 func (h *Handlers) AnalyzeEmail(c *gin.Context) {
 	startTime := time.Now()
 	
-	var request struct {
-		Email        string `json:"email" binding:"required"`
-		DeepAnalysis bool   `json:"deep_analysis"`
-	}
+	var request AnalyzeRequest
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
 		return
 	}
 	
-	intelligence, err := h.engine.AnalyzeEmail(c.Request.Context(), request.Email, request.DeepAnalysis)
+	// Opt-in, so nothing in the debug object is redacted
+	debug, _ := strconv.ParseBool(c.Query("debug"))
+	intelligence, err := h.engine.AnalyzeEmail(c.Request.Context(), request.Email, engine.Options{
+		DeepAnalysis:    request.DeepAnalysis,
+		CheckSubmission: request.CheckSubmission,
+		Profile:         request.Profile,
+		Weights:         request.ScoringProfile,
+		DKIMSelector:    request.DKIMSelector,
+		Debug:           debug || request.DebugMode,
+		MinValidScore:   request.MinValidScore,
+	})
 	if err != nil {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": err.Error(),
-		})
+		respondAnalyzeError(c, err)
 		return
 	}
 	
+	setRateLimitHeaders(c, h.engine.RateQuota(request.Email))
 	c.Header("X-Processing-Time", fmt.Sprintf("%dms", time.Since(startTime).Milliseconds()))
 	c.Header("X-Confidence-Level", intelligence.ConfidenceLevel)
 	c.Header("X-Risk-Category", intelligence.RiskCategory)
 	
-	h.updateMetrics(intelligence.ProcessingTime, intelligence.IsValid)
+	// Internal test addresses are not counted as failures
+	h.updateMetrics(intelligence.ProcessingTime, intelligence.IsValid || intelligence.RiskCategory == "Internal")
+	h.persist(callerID(c), intelligence)
+	
+	// Re-validation: skip the payload when the content matches the caller's copy
+	etag := `"` + analyzers.ContentHash(intelligence) + `"`
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if etagMatches(request.IfNoneMatch, etag) {
+		c.JSON(http.StatusOK, gin.H{
+			"email":     intelligence.Email,
+			"unchanged": true,
+			"etag":      etag,
+		})
+		return
+	}
 	
 	c.JSON(http.StatusOK, intelligence)
 }
 
+// etagMatches reports whether an If-None-Match value (a comma-separated list,
+// quoted or not, weak or strong) names etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == `""` {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || strings.Trim(candidate, `"`) == strings.Trim(etag, `"`) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeEmailStream streams a single analysis as server-sent events: a "fast"
+// event with syntax/DNS/security/domain results as soon as they are ready, then
+// a "complete" event once SMTP checks finish. Parameters are taken from the
+// query string so browsers can consume it with EventSource.
+func (h *Handlers) AnalyzeEmailStream(c *gin.Context) {
+	var request struct {
+		Email           string `form:"email" binding:"required"`
+		DeepAnalysis    bool   `form:"deep_analysis"`
+		CheckSubmission bool   `form:"check_submission"`
+		Profile         string `form:"profile"`
+		DKIMSelector    string `form:"dkim_selector"`
+		Debug           bool   `form:"debug"`
+		MinValidScore   *int   `form:"min_valid_score"`
+	}
+	
+	if err := c.ShouldBindQuery(&request); err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
+		return
+	}
+	
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	
+	intelligence, err := h.engine.AnalyzeEmailProgressive(c.Request.Context(), request.Email, engine.Options{
+		DeepAnalysis:    request.DeepAnalysis,
+		CheckSubmission: request.CheckSubmission,
+		Profile:         request.Profile,
+		DKIMSelector:    request.DKIMSelector,
+		Debug:           request.Debug,
+		MinValidScore:   request.MinValidScore,
+	}, func(preliminary *models.EmailIntelligence) {
+		c.SSEvent("fast", preliminary)
+		c.Writer.Flush()
+	})
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": analyzeError(err)})
+		c.Writer.Flush()
+		return
+	}
+	
+	h.updateMetrics(intelligence.ProcessingTime, intelligence.IsValid || intelligence.RiskCategory == "Internal")
+	h.persist(callerID(c), intelligence)
+	
+	c.SSEvent("complete", intelligence)
+	c.Writer.Flush()
+}
+
+// BulkAnalyzeRequest is the body of POST /bulk-analyze
+type BulkAnalyzeRequest struct {
+	Emails            []string `json:"emails" binding:"required"`
+	DeepAnalysis      bool     `json:"deep_analysis"`
+	SkipInvalidSyntax bool     `json:"skip_invalid_syntax"`
+	SortBy            string   `json:"sort_by"` // input (default), score_desc, score_asc, risk_desc, risk_asc
+	Profile           string   `json:"profile"`
+}
+
 // BulkAnalyze handles bulk email analysis
 func (h *Handlers) BulkAnalyze(c *gin.Context) {
 	startTime := time.Now()
 	
-	var request struct {
-		Emails       []string `json:"emails" binding:"required"`
-		DeepAnalysis bool     `json:"deep_analysis"`
-	}
+	var request BulkAnalyzeRequest
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
 		return
 	}
 	
+	h.bulkAnalyze(c, request, startTime)
+}
+
+// bulkAnalyze runs a bulk request however its addresses were submitted and
+// writes the bulk response
+func (h *Handlers) bulkAnalyze(c *gin.Context, request BulkAnalyzeRequest, startTime time.Time) {
 	if len(request.Emails) > 1000 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":    "Too many emails. Maximum 1000 emails per request",
+		respondError(c, CodeBulkTooLarge, "Too many emails. Maximum 1000 emails per request", gin.H{
 			"limit":    1000,
 			"received": len(request.Emails),
 		})
 		return
 	}
 	
-	// Process emails concurrently
+	if _, ok := bulkSortOrders[request.SortBy]; !ok {
+		respondError(c, CodeInvalidRequest, "Invalid sort_by", "sort_by must be one of input, score_desc, score_asc, risk_desc, risk_asc")
+		return
+	}
+	
+	if _, ok := h.engine.Profile(request.Profile); !ok {
+		respondError(c, CodeInvalidRequest, "Unknown scoring profile", request.Profile)
+		return
+	}
+	
+	// Repeated addresses are analyzed once and the result is copied to every
+	// position they appear at
+	unique, positions := dedupeEmails(request.Emails)
+	
+	// Process emails grouped by domain so each domain's cache is warmed once
+	// and its mail servers are not probed by many workers at the same time
+	caller := callerID(c)
+	analyzed := make([]*models.EmailIntelligence, len(unique))
+	schedule := bulkSchedule{Workers: 50, DomainLimit: h.config.BulkDomainLimit}
+	if request.DeepAnalysis {
+		schedule.SMTPSpacing = h.config.BulkSMTPSpacing
+	}
+	
+	scheduleByDomain(c.Request.Context(), unique, schedule, func(index int) {
+		emailAddr := unique[index]
+		intelligence, err := h.engine.AnalyzeEmail(c.Request.Context(), emailAddr, engine.Options{
+			DeepAnalysis: request.DeepAnalysis,
+			Profile:      request.Profile,
+		})
+		if err == nil {
+			h.persist(caller, intelligence)
+		} else {
+			intelligence = &models.EmailIntelligence{
+				Email:                 emailAddr,
+				IsValid:               false,
+				ValidationScore:       0,
+				RiskCategory:          "Error",
+				ConfidenceLevel:       "Low",
+				DeliverabilityVerdict: analyzers.VerdictUnknown,
+				Warnings:              []string{err.Error()},
+			}
+		}
+		analyzed[index] = intelligence
+	})
+	
+	// A cancelled request leaves addresses unanalyzed, and nobody is left to read the response
+	if c.Request.Context().Err() != nil {
+		return
+	}
+	
+	// Results may be shared with the cache, so tag a copy with each position
 	results := make([]*models.EmailIntelligence, len(request.Emails))
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 50)
-	
-	for i, email := range request.Emails {
-		wg.Add(1)
-		go func(index int, emailAddr string) {
-			defer wg.Done()
-			
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			
-			intelligence, err := h.engine.AnalyzeEmail(c.Request.Context(), emailAddr, request.DeepAnalysis)
-			if err != nil {
-				intelligence = &models.EmailIntelligence{
-					Email:           emailAddr,
-					IsValid:         false,
-					ValidationScore: 0,
-					RiskCategory:    "Error",
-					ConfidenceLevel: "Low",
-					Warnings:        []string{err.Error()},
-				}
+	for index, position := range positions {
+		tagged := *analyzed[position]
+		tagged.OriginalIndex = &index
+		results[index] = &tagged
+	}
+	
+	// Drop obviously malformed addresses when the caller only wants reviewable results
+	malformed := 0
+	if request.SkipInvalidSyntax {
+		kept := make([]*models.EmailIntelligence, 0, len(results))
+		for _, result := range results {
+			if result.SyntaxValidation.Status == "fail" {
+				malformed++
+				continue
 			}
-			results[index] = intelligence
-		}(i, email)
+			kept = append(kept, result)
+		}
+		results = kept
 	}
 	
-	wg.Wait()
+	if less := bulkSortOrders[request.SortBy]; less != nil {
+		sort.SliceStable(results, func(i, j int) bool { return less(results[i], results[j]) })
+	}
 	
 	summary := h.generateBulkSummary(results)
+	summary["malformed"] = malformed
+	summary["unique_count"] = countUnique(results, positions)
 	processingTime := time.Since(startTime).Milliseconds()
 	
+	// A batch served from cache can finish within a millisecond
+	emailsPerSecond := 0.0
+	if processingTime > 0 {
+		emailsPerSecond = float64(len(request.Emails)) / (float64(processingTime) / 1000)
+	}
+	
 	c.Header("X-Processing-Time", fmt.Sprintf("%dms", processingTime))
-	c.Header("X-Processed-Count", fmt.Sprintf("%d", len(results)))
+	c.Header("X-Processed-Count", fmt.Sprintf("%d", len(request.Emails)))
 	
 	c.JSON(http.StatusOK, gin.H{
 		"results": results,
 		"summary": summary,
 		"performance": gin.H{
 			"processing_time_ms": processingTime,
-			"emails_per_second":  float64(len(results)) / (float64(processingTime) / 1000),
-			"total_emails":       len(results),
+			"emails_per_second":  emailsPerSecond,
+			"total_emails":       len(request.Emails),
 		},
 	})
 }
 
+// countUnique counts the distinct addresses among results, which may have
+// been filtered, using the dedupe positions of their original indexes
+func countUnique(results []*models.EmailIntelligence, positions []int) int {
+	seen := make(map[int]bool, len(results))
+	for _, result := range results {
+		seen[positions[*result.OriginalIndex]] = true
+	}
+	return len(seen)
+}
+
+// bulkSortOrders are the accepted sort_by values; nil keeps input order
+var bulkSortOrders = map[string]func(a, b *models.EmailIntelligence) bool{
+	"":      nil,
+	"input": nil,
+	"score_desc": func(a, b *models.EmailIntelligence) bool {
+		return a.ValidationScore > b.ValidationScore
+	},
+	"score_asc": func(a, b *models.EmailIntelligence) bool {
+		return a.ValidationScore < b.ValidationScore
+	},
+	"risk_desc": func(a, b *models.EmailIntelligence) bool {
+		return a.RiskAnalysis.RiskScore > b.RiskAnalysis.RiskScore
+	},
+	"risk_asc": func(a, b *models.EmailIntelligence) bool {
+		return a.RiskAnalysis.RiskScore < b.RiskAnalysis.RiskScore
+	},
+}
+
+// Feedback records a delivery outcome (delivered, bounced, complaint) for an address
+func (h *Handlers) Feedback(c *gin.Context) {
+	var request struct {
+		Email   string `json:"email" binding:"required"`
+		Outcome string `json:"outcome" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
+		return
+	}
+	
+	// Rate-limited per client IP, not callerID, which clients choose themselves
+	if err := h.engine.RecordFeedback(c.ClientIP(), request.Email, request.Outcome); err != nil {
+		if errors.Is(err, engine.ErrRateLimited) {
+			respondAnalyzeError(c, err)
+			return
+		}
+		respondError(c, CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"email":    request.Email,
+		"outcome":  request.Outcome,
+		"recorded": true,
+	})
+}
+
+// DiffResults compares two bulk result sets of the same list and reports what changed
+func (h *Handlers) DiffResults(c *gin.Context) {
+	var request struct {
+		Previous []models.EmailIntelligence `json:"previous" binding:"required"`
+		Current  []models.EmailIntelligence `json:"current" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
+		return
+	}
+	
+	c.JSON(http.StatusOK, analyzers.NewDiffAnalyzer().Compare(request.Previous, request.Current))
+}
+
+// DKIMSelector looks up and validates a caller-supplied DKIM selector
+func (h *Handlers) DKIMSelector(c *gin.Context) {
+	domain := strings.TrimSpace(c.Query("domain"))
+	selector := strings.TrimSpace(c.Query("selector"))
+	
+	if domain == "" || selector == "" {
+		respondError(c, CodeInvalidRequest, "domain and selector query parameters are required", nil)
+		return
+	}
+	if !validators.ValidSelector(selector) {
+		respondError(c, CodeInvalidRequest, "Invalid selector", "selector must be one or more DNS labels")
+		return
+	}
+	
+	c.JSON(http.StatusOK, h.engine.CheckDKIMSelector(c.Request.Context(), domain, selector))
+}
+
+// ValidateSyntax checks only the format of an address, without DNS or SMTP,
+// for instant feedback in forms
+func (h *Handlers) ValidateSyntax(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
+		return
+	}
+	
+	c.JSON(http.StatusOK, h.engine.ValidateSyntax(request.Email))
+}
+
+// DomainAnalyze returns the DNS, security and reputation checks for a bare
+// domain, without syntax, SMTP or mailbox results
+func (h *Handlers) DomainAnalyze(c *gin.Context) {
+	domain := strings.TrimSuffix(strings.TrimSpace(c.Param("domain")), ".")
+	ascii, err := validators.ASCIIDomain(domain)
+	if err != nil || !validators.ValidDomain(ascii) {
+		respondError(c, CodeInvalidRequest, "Invalid domain", "domain must be a host name such as example.com")
+		return
+	}
+	
+	c.JSON(http.StatusOK, h.engine.AnalyzeDomain(c.Request.Context(), ascii))
+}
+
+// RecentResults returns stored analyses for a domain, newest first. They
+// name the addresses and who asked about them, so the route is admin only.
+func (h *Handlers) RecentResults(c *gin.Context) {
+	domain := strings.ToLower(strings.TrimSpace(c.Query("domain")))
+	if domain == "" {
+		respondError(c, CodeInvalidRequest, "domain query parameter is required", nil)
+		return
+	}
+	
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		respondError(c, CodeInvalidRequest, "limit must be between 1 and 500", nil)
+		return
+	}
+	
+	records, err := h.store.RecentByDomain(c.Request.Context(), domain, limit)
+	if errors.Is(err, store.ErrNotConfigured) {
+		respondError(c, CodeNotImplemented, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		respondError(c, CodeInternal, "Failed to query stored results", err.Error())
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"domain":  domain,
+		"count":   len(records),
+		"results": records,
+	})
+}
+
+// persist hands a finished analysis to the result store without blocking
+func (h *Handlers) persist(caller string, intelligence *models.EmailIntelligence) {
+	h.store.Save(store.NewRecord(intelligence, caller, h.config.StoreFullResults))
+}
+
+// callerID identifies who requested an analysis: the X-Caller-ID header when
+// the client sets one, otherwise its IP address
+func callerID(c *gin.Context) string {
+	if id := strings.TrimSpace(c.GetHeader("X-Caller-ID")); id != "" {
+		return id
+	}
+	return c.ClientIP()
+}
+
 // Health returns health status
 func (h *Handlers) Health(c *gin.Context) {
 	h.metricsLock.RLock()
@@ -167,6 +506,68 @@ func (h *Handlers) Health(c *gin.Context) {
 	})
 }
 
+// Ready reports whether the instance should receive traffic, including the
+// startup self-test report when one is configured
+func (h *Handlers) Ready(c *gin.Context) {
+	ready, report := h.engine.Readiness()
+	if !ready {
+		message := "Startup self-test has not finished"
+		if report != nil {
+			message = "Startup self-test failed"
+		}
+		respondError(c, CodeUnavailable, message, report)
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ready",
+		"self_test": report,
+	})
+}
+
+// ScoringWeights returns the default profile's weights and the named profiles
+func (h *Handlers) ScoringWeights(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm": "Enterprise Email Intelligence Scoring",
+		"version":   "2.0.0",
+		"weights":   h.engine.ScoringWeights(),
+		"total":     100,
+		"profiles":  h.config.ScoringProfiles,
+	})
+}
+
+// UpdateScoringWeights replaces the default profile's weights. Named profiles
+// keep their own weights.
+func (h *Handlers) UpdateScoringWeights(c *gin.Context) {
+	var weights models.ScoringWeights
+	if err := c.ShouldBindJSON(&weights); err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
+		return
+	}
+	if err := h.engine.SetScoringWeights(weights); err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid scoring weights", err.Error())
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"weights": weights,
+		"total":   100,
+	})
+}
+
+// Stats returns the analysis volume over the last minute and hour and the
+// domains analyzed most often, with their average score and valid ratio. The
+// domains reveal who the instance's callers correspond with, so it is admin only.
+func (h *Handlers) Stats(c *gin.Context) {
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil || top < 1 || top > 100 {
+		respondError(c, CodeInvalidRequest, "top must be between 1 and 100", nil)
+		return
+	}
+	
+	c.JSON(http.StatusOK, h.engine.Stats(top))
+}
+
 // Metrics returns performance metrics
 func (h *Handlers) Metrics(c *gin.Context) {
 	h.metricsLock.RLock()
@@ -186,6 +587,14 @@ func (h *Handlers) Metrics(c *gin.Context) {
 	})
 }
 
+// PrometheusMetrics serves the process metrics in the Prometheus text
+// exposition format for scraping
+func (h *Handlers) PrometheusMetrics(c *gin.Context) {
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	metrics.WriteText(c.Writer)
+}
+
 func (h *Handlers) updateMetrics(latency int64, isValid bool) {
 	h.metricsLock.Lock()
 	defer h.metricsLock.Unlock()
@@ -201,6 +610,7 @@ func (h *Handlers) updateMetrics(latency int64, isValid bool) {
 func (h *Handlers) generateBulkSummary(results []*models.EmailIntelligence) gin.H {
 	total := len(results)
 	valid := 0
+	internal := 0
 	premium := 0
 	highRisk := 0
 	disposable := 0
@@ -209,6 +619,9 @@ func (h *Handlers) generateBulkSummary(results []*models.EmailIntelligence) gin.
 		if result.IsValid {
 			valid++
 		}
+		if result.RiskCategory == "Internal" {
+			internal++
+		}
 		if result.QualityTier == "Premium" {
 			premium++
 		}
@@ -220,14 +633,21 @@ func (h *Handlers) generateBulkSummary(results []*models.EmailIntelligence) gin.
 		}
 	}
 	
+	// Every result may have been filtered out by skip_invalid_syntax
+	validPercentage := 0.0
+	if total > 0 {
+		validPercentage = float64(valid) / float64(total) * 100
+	}
+	
 	return gin.H{
 		"total":            total,
 		"valid":            valid,
-		"invalid":          total - valid,
+		"invalid":          total - valid - internal,
+		"internal":         internal,
 		"premium":          premium,
 		"high_risk":        highRisk,
 		"disposable":       disposable,
-		"valid_percentage": float64(valid) / float64(total) * 100,
+		"valid_percentage": validPercentage,
 	}
 }
 
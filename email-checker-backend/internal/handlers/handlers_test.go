@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/config"
+	"email-intelligence/internal/engine"
+	"email-intelligence/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeedbackRateLimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.DisposableSource = ""
+	cfg.FeedbackRateBurst = 1
+	cfg.FeedbackRateWindow = time.Hour
+	h := &Handlers{engine: engine.New(cfg)}
+	router := gin.New()
+	router.POST("/feedback", h.Feedback)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"email": "jane@example.com", "outcome": "sent"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown outcome: status = %d, want 400", rec.Code)
+	}
+	if rec := post(`{"email": "jane@example.com", "outcome": "bounced"}`); rec.Code != http.StatusOK {
+		t.Fatalf("first outcome: status = %d (body %s)", rec.Code, rec.Body)
+	}
+	rec := post(`{"email": "jane@example.com", "outcome": "bounced"}`)
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), CodeRateLimited) {
+		t.Errorf("second outcome: status = %d (body %s), want 429", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After not set")
+	}
+}
+
+func TestBulkAnalyzeEmptyAndInstantBatches(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.DisposableSource = ""
+	h := &Handlers{engine: engine.New(cfg), store: store.NopStore{}, config: cfg}
+	router := gin.New()
+	router.POST("/bulk-analyze", h.BulkAnalyze)
+
+	// A reserved domain is answered without any lookups, so the batch
+	// finishes well within a millisecond
+	for _, body := range []string{`{"emails": []}`, `{"emails": ["jane@example.com"]}`} {
+		req := httptest.NewRequest(http.MethodPost, "/bulk-analyze", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d (body %s)", body, rec.Code, rec.Body)
+		}
+
+		var response struct {
+			Summary struct {
+				ValidPercentage float64 `json:"valid_percentage"`
+			} `json:"summary"`
+			Performance struct {
+				EmailsPerSecond float64 `json:"emails_per_second"`
+			} `json:"performance"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: response is not valid JSON: %v", body, err)
+		}
+		if body == `{"emails": []}` && response.Summary.ValidPercentage != 0 {
+			t.Errorf("%s: valid_percentage = %v, want 0", body, response.Summary.ValidPercentage)
+		}
+		if rate := response.Performance.EmailsPerSecond; math.IsNaN(rate) || math.IsInf(rate, 0) || rate < 0 {
+			t.Errorf("%s: emails_per_second = %v", body, rate)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"email-intelligence/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateJob accepts a JSON list of addresses and analyzes it as a background
+// job, returning the job ID to poll straight away
+func (h *Handlers) CreateJob(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.JobMaxUpload)
+	
+	var request struct {
+		Emails       []string `json:"emails" binding:"required"`
+		DeepAnalysis bool     `json:"deep_analysis"`
+		CallbackURL  string   `json:"callback_url"`
+	}
+	
+	if err := c.ShouldBindJSON(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, CodePayloadTooLarge, "Request exceeds the size limit", gin.H{"limit_bytes": tooLarge.Limit})
+			return
+		}
+		respondError(c, CodeInvalidRequest, "Invalid request format", err.Error())
+		return
+	}
+	if len(request.Emails) == 0 {
+		respondError(c, CodeInvalidRequest, "emails must not be empty", nil)
+		return
+	}
+	
+	if request.CallbackURL != "" {
+		if !h.jobs.CallbacksEnabled() {
+			respondError(c, CodeInvalidRequest, "callback_url is not available: no callback secret is configured", nil)
+			return
+		}
+		if err := jobs.ValidateCallbackURL(request.CallbackURL); err != nil {
+			respondError(c, CodeInvalidRequest, err.Error(), nil)
+			return
+		}
+	}
+	
+	job := h.jobs.SubmitEmails(request.Emails, request.DeepAnalysis, request.CallbackURL, callerID(c))
+	c.JSON(http.StatusAccepted, job.Progress())
+}
+
+// UploadJob accepts a CSV/TXT list and analyzes it as a background job
+func (h *Handlers) UploadJob(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.JobMaxUpload)
+	
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, CodePayloadTooLarge, "Upload exceeds the size limit", gin.H{"limit_bytes": tooLarge.Limit})
+			return
+		}
+		respondError(c, CodeInvalidRequest, "Invalid upload", err.Error())
+		return
+	}
+	
+	column, err := strconv.Atoi(c.DefaultPostForm("column", "0"))
+	if err != nil || column < 0 {
+		respondError(c, CodeInvalidRequest, "column must be a non-negative integer", nil)
+		return
+	}
+	deepAnalysis := c.PostForm("deep_analysis") == "true"
+	
+	src, err := file.Open()
+	if err != nil {
+		respondError(c, CodeInvalidRequest, "Invalid upload", err.Error())
+		return
+	}
+	defer src.Close()
+	
+	job, err := h.jobs.SubmitUpload(src, column, deepAnalysis, callerID(c))
+	if err != nil {
+		respondError(c, CodeInternal, "Failed to store upload", err.Error())
+		return
+	}
+	
+	c.JSON(http.StatusAccepted, job.Progress())
+}
+
+// JobStatus returns the progress of a background job
+func (h *Handlers) JobStatus(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		respondError(c, CodeNotFound, "Job not found", nil)
+		return
+	}
+	
+	c.JSON(http.StatusOK, job.Progress())
+}
+
+// JobReport downloads the CSV report of a completed job
+func (h *Handlers) JobReport(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		respondError(c, CodeNotFound, "Job not found", nil)
+		return
+	}
+	
+	path, ready := job.ReportPath()
+	if !ready {
+		respondError(c, CodeNotReady, "Report not ready", gin.H{"status": job.Progress().Status})
+		return
+	}
+	
+	c.FileAttachment(path, "email-report-"+job.ID+".csv")
+}
+
+// JobResults returns a page of a job's JSON results; pass the returned "next"
+// as offset to fetch the following page
+func (h *Handlers) JobResults(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		respondError(c, CodeNotFound, "Job not found", nil)
+		return
+	}
+	
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, CodeInvalidRequest, "offset must be a non-negative integer", nil)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > jobs.MaxPageSize {
+		respondError(c, CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", jobs.MaxPageSize), nil)
+		return
+	}
+	
+	page, err := job.Results(offset, limit)
+	if err != nil {
+		respondError(c, CodeInternal, "Failed to read job results", err.Error())
+		return
+	}
+	
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The spec reflects over types that cannot change at runtime, so it is
+// generated once, on first request
+var (
+	specOnce sync.Once
+	spec     gin.H
+)
+
+// OpenAPI serves the OpenAPI 3 description of the analysis endpoints, with
+// schemas generated from the request and result types
+func (h *Handlers) OpenAPI(c *gin.Context) {
+	specOnce.Do(func() { spec = buildSpec() })
+	c.JSON(http.StatusOK, spec)
+}
+
+func buildSpec() gin.H {
+	components := openapi.NewComponents()
+
+	apiError := components.For(APIError{})
+	codes := make([]string, 0, len(errorStatus))
+	for code := range errorStatus {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	setEnum(components, "APIError", "code", codes)
+	components.Add("ErrorResponse", openapi.Schema{
+		"type":       "object",
+		"required":   []string{"error"},
+		"properties": map[string]openapi.Schema{"error": apiError},
+	})
+
+	analyzeRequest := components.For(AnalyzeRequest{})
+	unchanged := components.Add("UnchangedResult", openapi.Schema{
+		"type":     "object",
+		"required": []string{"email", "unchanged", "etag"},
+		"properties": map[string]openapi.Schema{
+			"email":     {"type": "string"},
+			"unchanged": {"type": "boolean"},
+			"etag":      {"type": "string"},
+		},
+	})
+	intelligence := components.For(models.EmailIntelligence{})
+
+	bulkRequest := components.For(BulkAnalyzeRequest{})
+	sortOrders := make([]string, 0, len(bulkSortOrders))
+	for order := range bulkSortOrders {
+		sortOrders = append(sortOrders, order)
+	}
+	sort.Strings(sortOrders)
+	setEnum(components, "BulkAnalyzeRequest", "sort_by", sortOrders)
+	count := openapi.Schema{"type": "integer"}
+	bulkResponse := components.Add("BulkAnalyzeResponse", openapi.Schema{
+		"type":     "object",
+		"required": []string{"results", "summary", "performance"},
+		"properties": map[string]openapi.Schema{
+			"results": {"type": "array", "items": intelligence},
+			"summary": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"total":            count,
+					"valid":            count,
+					"invalid":          count,
+					"internal":         count,
+					"premium":          count,
+					"high_risk":        count,
+					"disposable":       count,
+					"malformed":        count,
+					"unique_count":     count,
+					"valid_percentage": {"type": "number"},
+				},
+			},
+			"performance": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"processing_time_ms": count,
+					"emails_per_second":  {"type": "number"},
+					"total_emails":       count,
+				},
+			},
+		},
+	})
+
+	weights := components.For(models.ScoringWeights{})
+	weightsResponse := components.Add("ScoringWeightsResponse", openapi.Schema{
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"algorithm": {"type": "string"},
+			"version":   {"type": "string"},
+			"weights":   weights,
+			"total":     count,
+			"profiles":  components.For(map[string]models.ScoringProfile{}),
+		},
+	})
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "Email Intelligence API",
+			"version": "2.0.0",
+		},
+		"paths": gin.H{
+			"/api/v1/analyze": gin.H{
+				"post": gin.H{
+					"summary": "Analyze one address",
+					"parameters": []gin.H{{
+						"name":        "debug",
+						"in":          "query",
+						"description": "Include raw lookup and SMTP evidence",
+						"schema":      openapi.Schema{"type": "boolean"},
+					}},
+					"requestBody": jsonBody(analyzeRequest),
+					"responses": gin.H{
+						"200": jsonResponse("The analysis, or a short reply when if_none_match names the current result",
+							openapi.Schema{"oneOf": []openapi.Schema{intelligence, unchanged}}),
+						"304": gin.H{"description": "The If-None-Match header names the current result"},
+						"400": errorResponse("Invalid request, scoring profile, weights or validity cutoff"),
+						"429": errorResponse("Rate limit exceeded"),
+						"500": errorResponse("Analysis failed"),
+					},
+				},
+			},
+			"/api/v1/bulk-analyze": gin.H{
+				"post": gin.H{
+					"summary":     "Analyze up to 1000 addresses",
+					"requestBody": jsonBody(bulkRequest),
+					"responses": gin.H{
+						"200": jsonResponse("One result per address, in input order unless sort_by is set", bulkResponse),
+						"400": errorResponse("Invalid request, sort order or scoring profile"),
+						"413": errorResponse("More than 1000 addresses"),
+					},
+				},
+			},
+			"/api/v1/bulk-analyze-text": gin.H{
+				"post": gin.H{
+					"summary": "Analyze a pasted list of up to 1000 addresses",
+					"parameters": []gin.H{
+						queryParameter("deep_analysis", openapi.Schema{"type": "boolean"}),
+						queryParameter("skip_invalid_syntax", openapi.Schema{"type": "boolean"}),
+						queryParameter("sort_by", openapi.Schema{"type": "string", "enum": sortOrders}),
+						queryParameter("profile", openapi.Schema{"type": "string"}),
+					},
+					"requestBody": gin.H{
+						"required":    true,
+						"description": "Addresses separated by newlines, commas or semicolons, optionally as \"Name\" <address>",
+						"content":     gin.H{"text/plain": gin.H{"schema": openapi.Schema{"type": "string"}}},
+					},
+					"responses": gin.H{
+						"200": jsonResponse("One result per distinct address, in input order unless sort_by is set", bulkResponse),
+						"400": errorResponse("Not text/plain, no addresses, or an invalid sort order or scoring profile"),
+						"413": errorResponse("Body over BULK_TEXT_MAX_BYTES, or more than 1000 addresses"),
+					},
+				},
+			},
+			"/api/v1/scoring-weights": gin.H{
+				"get": gin.H{
+					"summary": "The default profile's weights and the named profiles",
+					"responses": gin.H{
+						"200": jsonResponse("Scoring weights", weightsResponse),
+					},
+				},
+				"put": gin.H{
+					"summary":     "Replace the default profile's weights",
+					"security":    adminSecurity,
+					"requestBody": jsonBody(weights),
+					"responses": gin.H{
+						"200": jsonResponse("The weights now in use", openapi.Schema{
+							"type": "object",
+							"properties": map[string]openapi.Schema{
+								"weights": weights,
+								"total":   count,
+							},
+						}),
+						"400": errorResponse("Invalid request or weights"),
+						"401": errorResponse("Missing or wrong admin API key"),
+					},
+				},
+			},
+			"/api/v1/openapi.json": gin.H{
+				"get": gin.H{
+					"summary": "This document",
+					"responses": gin.H{
+						"200": jsonResponse("OpenAPI 3 description of the API", openapi.Schema{"type": "object"}),
+					},
+				},
+			},
+		},
+		"components": gin.H{
+			"schemas": components.Schemas(),
+			"securitySchemes": gin.H{
+				"adminKey": gin.H{"type": "http", "scheme": "bearer", "description": "ADMIN_API_KEY; also accepted in the X-API-Key header"},
+			},
+		},
+	}
+}
+
+// adminSecurity marks an operation as requiring the admin API key
+var adminSecurity = []gin.H{{"adminKey": []string{}}}
+
+// setEnum restricts a string property of a registered component to values
+func setEnum(components *openapi.Components, name, property string, values []string) {
+	properties := components.Schemas()[name]["properties"].(map[string]openapi.Schema)
+	properties[property]["enum"] = values
+}
+
+func queryParameter(name string, schema openapi.Schema) gin.H {
+	return gin.H{"name": name, "in": "query", "schema": schema}
+}
+
+func jsonBody(schema openapi.Schema) gin.H {
+	return gin.H{
+		"required": true,
+		"content":  gin.H{"application/json": gin.H{"schema": schema}},
+	}
+}
+
+func jsonResponse(description string, schema openapi.Schema) gin.H {
+	return gin.H{
+		"description": description,
+		"content":     gin.H{"application/json": gin.H{"schema": schema}},
+	}
+}
+
+func errorResponse(description string) gin.H {
+	return jsonResponse(description, openapi.Ref("ErrorResponse"))
+}
@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Options configures the shared outbound HTTP client
+type Options struct {
+	Timeout     time.Duration // per-attempt timeout
+	MaxRetries  int           // retries after the first attempt
+	BaseBackoff time.Duration // first backoff, doubled on each retry
+	MaxBackoff  time.Duration // upper bound for any single wait; a longer Retry-After is not waited out
+	UserAgent   string        // identifies the service to remote operators
+	Contact     string        // abuse contact email or URL, appended to the User-Agent
+
+	// Control vets every address the client dials, e.g. to refuse private
+	// networks for user-supplied URLs. Proxies are not used when it is set,
+	// since the proxy's address would be vetted instead of the target's.
+	Control func(network, address string, conn syscall.RawConn) error
+}
+
+// Client is a polite HTTP client for external integrations. It retries
+// 429/5xx responses and network errors with jittered exponential backoff and
+// honors the server's Retry-After header: when the server asks for a longer
+// wait than MaxBackoff or the context allows, its response is returned
+// rather than retried early.
+type Client struct {
+	http *http.Client
+	opts Options
+}
+
+// New creates a client with bounded connection pooling
+func New(opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 250 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "EmailIntelligence/2.0"
+	}
+	if opts.Contact != "" {
+		opts.UserAgent += " (+" + opts.Contact + ")"
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if opts.Control != nil {
+		proxy = nil
+	}
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   opts.Control,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		MaxConnsPerHost:       20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: opts.Timeout,
+	}
+
+	return &Client{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+		},
+		opts: opts,
+	}
+}
+
+// Get issues a GET request with retries
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends the request, retrying transient failures. Requests with a body must
+// be replayable (http.NewRequest sets GetBody for common body types).
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	c.identify(req)
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.http.Do(req)
+		if attempt >= c.opts.MaxRetries || (err == nil && !retryable(resp.StatusCode)) {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err // body cannot be replayed
+		}
+
+		wait := c.backoff(attempt)
+		if err == nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if !c.canWait(ctx, retryAfter) {
+					return resp, nil
+				}
+				wait = retryAfter
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// canWait reports whether a server-requested wait fits within MaxBackoff and
+// the context's deadline
+func (c *Client) canWait(ctx context.Context, wait time.Duration) bool {
+	if wait > c.opts.MaxBackoff {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return false
+	}
+	return true
+}
+
+// identify sets the User-Agent and, for email contacts, the From header
+// (RFC 9110) unless the caller already set them
+func (c *Client) identify(req *http.Request) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+	if strings.Contains(c.opts.Contact, "@") && !strings.Contains(c.opts.Contact, "://") && req.Header.Get("From") == "" {
+		req.Header.Set("From", c.opts.Contact)
+	}
+}
+
+// backoff returns the jittered exponential delay for an attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.opts.BaseBackoff << attempt
+	if delay <= 0 || delay > c.opts.MaxBackoff {
+		delay = c.opts.MaxBackoff
+	}
+	// Full jitter between half and the whole delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryable reports whether a status code signals a transient condition
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter accepts both delta-seconds and HTTP-date forms
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
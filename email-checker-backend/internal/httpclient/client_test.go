@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers the nth request with replies[n], repeating the last
+// reply once the script runs out, and counts the requests it gets
+func scriptedServer(t *testing.T, replies ...func(w http.ResponseWriter)) (string, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1)) - 1
+		replies[min(n, len(replies)-1)](w)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &requests
+}
+
+func status(code int, retryAfter string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	ok := status(http.StatusOK, "")
+	past := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	future := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		replies  []func(w http.ResponseWriter)
+		want     int   // final status
+		requests int32 // requests sent
+	}{
+		{"5xx is retried", []func(http.ResponseWriter){status(http.StatusInternalServerError, ""), ok}, http.StatusOK, 2},
+		{"4xx is not retried", []func(http.ResponseWriter){status(http.StatusNotFound, ""), ok}, http.StatusNotFound, 1},
+		{"Retry-After seconds is honored", []func(http.ResponseWriter){status(http.StatusTooManyRequests, "0"), ok}, http.StatusOK, 2},
+		{"Retry-After date is honored", []func(http.ResponseWriter){status(http.StatusServiceUnavailable, past), ok}, http.StatusOK, 2},
+		{"Retry-After seconds beyond MaxBackoff", []func(http.ResponseWriter){status(http.StatusTooManyRequests, "120"), ok}, http.StatusTooManyRequests, 1},
+		{"Retry-After date beyond MaxBackoff", []func(http.ResponseWriter){status(http.StatusServiceUnavailable, future), ok}, http.StatusServiceUnavailable, 1},
+		{"gives up after MaxRetries", []func(http.ResponseWriter){status(http.StatusBadGateway, "")}, http.StatusBadGateway, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, requests := scriptedServer(t, tt.replies...)
+			client := New(Options{MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Second})
+			resp, err := client.Get(context.Background(), url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if n := atomic.LoadInt32(requests); n != tt.requests {
+				t.Errorf("requests = %d, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestClientDoesNotWaitPastDeadline(t *testing.T) {
+	url, requests := scriptedServer(t, status(http.StatusServiceUnavailable, "5"), status(http.StatusOK, ""))
+	client := New(Options{MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(requests) != 1 {
+		t.Errorf("status %d after %d requests; want the 503 without a retry", resp.StatusCode, atomic.LoadInt32(requests))
+	}
+}
+
+func TestClientReplaysBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"job":"1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(Options{MaxRetries: 1, BaseBackoff: time.Millisecond}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0] != `{"job":"1"}` || bodies[1] != bodies[0] {
+		t.Errorf("bodies = %q, want the same body twice", bodies)
+	}
+}
+
+func TestClientStopsWaitingWhenCancelled(t *testing.T) {
+	url, _ := scriptedServer(t, status(http.StatusServiceUnavailable, ""))
+	client := New(Options{MaxRetries: 3, BaseBackoff: 10 * time.Second, MaxBackoff: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.Get(ctx, url)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Get returned after %v, long after the context was cancelled", elapsed)
+	}
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the callback body, keyed
+// with the shared callback secret, as "sha256=<hex>"
+const SignatureHeader = "X-Signature-256"
+
+// callbackAttempts is how often a completion callback is tried in total
+const callbackAttempts = 3
+
+// ErrCallbackURL reports a callback URL that is not a public http(s) URL
+var ErrCallbackURL = errors.New("callback_url must be a public http or https URL")
+
+// Callback is the JSON body posted to a job's callback URL once it finishes
+type Callback struct {
+	JobID       string    `json:"job_id"`
+	Status      string    `json:"status"` // completed or failed
+	Total       int       `json:"total"`
+	Processed   int       `json:"processed"`
+	Valid       int       `json:"valid"`
+	ResultsURL  string    `json:"results_url"`
+	ReportURL   string    `json:"report_url"`
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// ValidateCallbackURL accepts absolute http(s) URLs whose host is not a
+// loopback, private or otherwise internal address. Host names are checked
+// again when dialing, after they resolve.
+func ValidateCallbackURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrCallbackURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".local") {
+		return ErrCallbackURL
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !publicAddr(addr) {
+		return ErrCallbackURL
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598)
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether addr is routable on the public internet
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// refusePrivate is the dial control of the callback client. It catches host
+// names that resolve, or are rebound, to internal addresses, and redirects to
+// them.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(addr) {
+		return fmt.Errorf("callback to non-public address %s refused", host)
+	}
+	return nil
+}
+
+// newCallbackClient retries transient failures with exponential backoff,
+// three attempts in total
+func newCallbackClient() *httpclient.Client {
+	return httpclient.New(httpclient.Options{
+		Timeout:     10 * time.Second,
+		MaxRetries:  callbackAttempts - 1,
+		BaseBackoff: 2 * time.Second,
+		MaxBackoff:  10 * time.Second,
+		Control:     refusePrivate,
+	})
+}
+
+// notify posts the job summary to its callback URL. Failures are logged only;
+// the job's own status is unaffected.
+func (m *Manager) notify(job *Job) {
+	job.mu.RLock()
+	callbackURL := job.callbackURL
+	job.mu.RUnlock()
+	if callbackURL == "" {
+		return
+	}
+
+	progress := job.Progress()
+	resultsPath := "/api/v1/jobs/" + job.ID
+	summary := Callback{
+		JobID:      job.ID,
+		Status:     progress.Status,
+		Total:      progress.Total,
+		Processed:  progress.Processed,
+		Valid:      progress.ValidSoFar,
+		ResultsURL: m.opts.PublicURL + resultsPath + "/results",
+		ReportURL:  m.opts.PublicURL + resultsPath + "/report",
+		Error:      progress.Error,
+	}
+	if progress.CompletedAt != nil {
+		summary.CompletedAt = *progress.CompletedAt
+	}
+	body, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Job %s callback: %v", job.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Job %s callback: %v", job.ID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(m.opts.CallbackSecret, body))
+
+	resp, err := m.callbacks.Do(req)
+	if err != nil {
+		log.Printf("Job %s callback to %s failed after %d attempts: %v", job.ID, callbackURL, callbackAttempts, err)
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Printf("Job %s callback to %s failed: HTTP %d", job.ID, callbackURL, resp.StatusCode)
+	}
+}
+
+// Sign returns the SignatureHeader value for body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+	"email-intelligence/internal/models"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// AnalyzeFunc analyzes a single email address
+type AnalyzeFunc func(ctx context.Context, email string, deepAnalysis bool) (*models.EmailIntelligence, error)
+
+// Options configures the job manager
+type Options struct {
+	Workers           int           // concurrent analyses per job
+	DomainConcurrency int           // concurrent analyses per domain within a job
+	TTL               time.Duration // how long finished jobs are kept
+	CallbackSecret    string        // HMAC key for completion callbacks; callbacks are refused without one
+	PublicURL         string        // scheme and host the API is reached at, prefixed to callback links
+
+	// OnResult, when set, is called with every completed analysis, e.g. to persist it
+	OnResult func(job *Job, intelligence *models.EmailIntelligence)
+}
+
+// Job is a background bulk analysis
+type Job struct {
+	ID           string
+	DeepAnalysis bool
+	CallerID     string // who submitted the job, recorded with its results
+
+	status      string
+	errMsg      string
+	createdAt   time.Time
+	startedAt   time.Time
+	completedAt time.Time
+	total       int
+	processed   int
+	valid       int
+	inputPath   string
+	reportPath  string
+	resultsPath string
+	callbackURL string
+	mu          sync.RWMutex
+
+	// Byte position and length of each JSON line in resultsPath
+	resultOffsets []int64
+	resultSizes   []int64
+}
+
+// Progress is a point-in-time snapshot of a job
+type Progress struct {
+	ID          string     `json:"job_id"`
+	Status      string     `json:"status"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	ValidSoFar  int        `json:"valid_so_far"`
+	Percent     float64    `json:"percent"`
+	ETASeconds  int64      `json:"eta_seconds"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Manager owns the job registry and runs jobs in the background
+type Manager struct {
+	analyze   AnalyzeFunc
+	opts      Options
+	jobs      map[string]*Job
+	callbacks *httpclient.Client
+	mu        sync.RWMutex
+}
+
+// NewManager creates a job manager and starts expiring old jobs
+func NewManager(analyze AnalyzeFunc, opts Options) *Manager {
+	opts.PublicURL = strings.TrimSuffix(opts.PublicURL, "/")
+	m := &Manager{
+		analyze:   analyze,
+		opts:      opts,
+		jobs:      make(map[string]*Job),
+		callbacks: newCallbackClient(),
+	}
+	go m.expireLoop()
+	return m
+}
+
+// CallbacksEnabled reports whether jobs may be given a callback URL
+func (m *Manager) CallbacksEnabled() bool {
+	return m.opts.CallbackSecret != ""
+}
+
+// Get returns a job by ID; jobs past their TTL are not found even before
+// expireLoop has removed them
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	job, ok := m.jobs[id]
+	m.mu.RUnlock()
+
+	if !ok || m.expired(job) {
+		return nil, false
+	}
+	return job, true
+}
+
+// Progress returns a consistent snapshot of the job's progress
+func (j *Job) Progress() Progress {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	progress := Progress{
+		ID:         j.ID,
+		Status:     j.status,
+		Total:      j.total,
+		Processed:  j.processed,
+		ValidSoFar: j.valid,
+		Error:      j.errMsg,
+		CreatedAt:  j.createdAt,
+	}
+
+	if j.total > 0 {
+		progress.Percent = float64(j.processed) / float64(j.total) * 100
+	}
+
+	// Estimate the remaining time from the average pace so far
+	if j.status == StatusRunning && j.processed > 0 {
+		perEmail := time.Since(j.startedAt) / time.Duration(j.processed)
+		progress.ETASeconds = int64((perEmail * time.Duration(j.total-j.processed)).Seconds())
+	}
+
+	if !j.completedAt.IsZero() {
+		completedAt := j.completedAt
+		progress.CompletedAt = &completedAt
+	}
+
+	return progress
+}
+
+// ReportPath returns the CSV report location once the job has completed
+func (j *Job) ReportPath() (string, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return j.reportPath, j.status == StatusCompleted
+}
+
+func (m *Manager) register(deepAnalysis bool, callbackURL, callerID string) *Job {
+	job := &Job{
+		ID:           newJobID(),
+		DeepAnalysis: deepAnalysis,
+		CallerID:     callerID,
+		status:       StatusQueued,
+		createdAt:    time.Now(),
+		callbackURL:  callbackURL,
+	}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	return job
+}
+
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.completedAt = time.Now()
+	if err != nil {
+		j.status = StatusFailed
+		j.errMsg = err.Error()
+		return
+	}
+	j.status = StatusCompleted
+}
+
+// expireLoop removes finished jobs and their files once the TTL has passed
+func (m *Manager) expireLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.mu.Lock()
+		for id, job := range m.jobs {
+			if m.expired(job) {
+				os.Remove(job.inputPath)
+				os.Remove(job.reportPath)
+				os.Remove(job.resultsPath)
+				delete(m.jobs, id)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// expired reports whether a finished job has outlived the TTL
+func (m *Manager) expired(job *Job) bool {
+	job.mu.RLock()
+	defer job.mu.RUnlock()
+
+	return !job.completedAt.IsZero() && time.Since(job.completedAt) > m.opts.TTL
+}
+
+// newJobID returns a random (version 4) UUID
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	id := hex.EncodeToString(b)
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]
+}
@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// gatedAnalyze lets a test release analyses one at a time. Addresses at
+// invalid.example come back invalid; those at error.example fail.
+type gatedAnalyze struct {
+	release chan struct{}
+}
+
+func (g gatedAnalyze) analyze(ctx context.Context, email string, deepAnalysis bool) (*models.EmailIntelligence, error) {
+	<-g.release
+	if strings.HasSuffix(email, "@error.example") {
+		return nil, errors.New("lookup failed")
+	}
+	return &models.EmailIntelligence{Email: email, IsValid: !strings.HasSuffix(email, "@invalid.example")}, nil
+}
+
+func waitFor(t *testing.T, job *Job, done func(Progress) bool) Progress {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		progress := job.Progress()
+		if done(progress) {
+			return progress
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job stuck at %+v", progress)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func cleanup(t *testing.T, job *Job) {
+	t.Cleanup(func() {
+		os.Remove(job.reportPath)
+		os.Remove(job.resultsPath)
+	})
+}
+
+func TestJobProgress(t *testing.T) {
+	gate := gatedAnalyze{release: make(chan struct{})}
+	m := NewManager(gate.analyze, Options{Workers: 2, DomainConcurrency: 2, TTL: time.Hour})
+	emails := []string{"a@example.com", "b@invalid.example", "c@error.example", "d@example.com"}
+	job := m.SubmitEmails(emails, false, "", "")
+	cleanup(t, job)
+
+	if progress := job.Progress(); progress.Total != len(emails) || progress.Processed != 0 {
+		t.Fatalf("initial progress = %+v", progress)
+	}
+
+	for i := range emails {
+		gate.release <- struct{}{}
+		progress := waitFor(t, job, func(p Progress) bool { return p.Processed == i+1 })
+
+		// Every counted address can already be read back
+		page, err := job.Results(0, MaxPageSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != progress.Processed {
+			t.Errorf("after %d: %d results readable, %d processed", i+1, page.Total, progress.Processed)
+		}
+		if want := float64(i+1) / float64(len(emails)) * 100; progress.Percent != want {
+			t.Errorf("after %d: percent %.1f, want %.1f", i+1, progress.Percent, want)
+		}
+	}
+
+	progress := waitFor(t, job, func(p Progress) bool { return p.Status == StatusCompleted })
+	// The invalid address and the failed analysis are not counted as valid
+	if progress.Processed != 4 || progress.ValidSoFar != 2 || progress.ETASeconds != 0 || progress.CompletedAt == nil {
+		t.Errorf("final progress = %+v", progress)
+	}
+}
+
+func TestJobProgressETA(t *testing.T) {
+	job := &Job{status: StatusRunning, startedAt: time.Now().Add(-10 * time.Second), total: 4, processed: 1}
+	if eta := job.Progress().ETASeconds; eta < 29 || eta > 31 {
+		t.Errorf("ETA = %ds, want about 30s at 10s per address", eta)
+	}
+
+	job = &Job{status: StatusRunning, startedAt: time.Now(), total: 4}
+	if eta := job.Progress().ETASeconds; eta != 0 {
+		t.Errorf("ETA = %ds before any address finished, want 0", eta)
+	}
+}
+
+func TestJobProgressConcurrentWorkers(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	m := NewManager(gatedAnalyze{release: release}.analyze, Options{Workers: 8, DomainConcurrency: 8, TTL: time.Hour})
+	emails := make([]string, 200)
+	for i := range emails {
+		emails[i] = "user@example.com"
+	}
+	job := m.SubmitEmails(emails, false, "", "")
+	cleanup(t, job)
+
+	// Pollers read while workers record
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			waitFor(t, job, func(p Progress) bool { return p.Status == StatusCompleted })
+		}()
+	}
+	wg.Wait()
+
+	if progress := job.Progress(); progress.Processed != len(emails) || progress.ValidSoFar != len(emails) {
+		t.Errorf("progress = %+v, want all %d processed and valid", progress, len(emails))
+	}
+}
+
+func TestJobFailsWhenAResultCannotBeStored(t *testing.T) {
+	// NaN has no JSON encoding, so the result cannot be written
+	analyze := func(ctx context.Context, email string, deepAnalysis bool) (*models.EmailIntelligence, error) {
+		intelligence := &models.EmailIntelligence{Email: email, IsValid: true}
+		if strings.HasPrefix(email, "nan@") {
+			intelligence.LocalPartQuality.Entropy = math.NaN()
+		}
+		return intelligence, nil
+	}
+	m := NewManager(analyze, Options{Workers: 2, DomainConcurrency: 2, TTL: time.Hour})
+	job := m.SubmitEmails([]string{"a@example.com", "nan@example.com", "b@example.com"}, false, "", "")
+	cleanup(t, job)
+
+	progress := waitFor(t, job, func(p Progress) bool { return p.Status == StatusCompleted || p.Status == StatusFailed })
+	if progress.Status != StatusFailed || !strings.Contains(progress.Error, "row 2") {
+		t.Errorf("progress = %+v, want the job failed on row 2", progress)
+	}
+	page, err := job.Results(0, MaxPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || progress.Processed != page.Total {
+		t.Errorf("%d results readable, %d processed; want the 2 that could be stored", page.Total, progress.Processed)
+	}
+}
+
+func TestJobReportsEachResult(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var mu sync.Mutex
+	reported := map[string]string{} // address -> caller
+	m := NewManager(gatedAnalyze{release: release}.analyze, Options{
+		Workers:           2,
+		DomainConcurrency: 2,
+		TTL:               time.Hour,
+		OnResult: func(job *Job, intelligence *models.EmailIntelligence) {
+			mu.Lock()
+			reported[intelligence.Email] = job.CallerID
+			mu.Unlock()
+		},
+	})
+	job := m.SubmitEmails([]string{"a@example.com", "b@error.example", "c@invalid.example"}, false, "", "acme")
+	cleanup(t, job)
+	waitFor(t, job, func(p Progress) bool { return p.Status == StatusCompleted })
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The failed analysis has no result to report
+	want := map[string]string{"a@example.com": "acme", "c@invalid.example": "acme"}
+	if !maps.Equal(reported, want) {
+		t.Errorf("reported %v, want %v", reported, want)
+	}
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"encoding/json"
+	"os"
+
+	"email-intelligence/internal/models"
+)
+
+// MaxPageSize caps how many results one page request may return
+const MaxPageSize = 1000
+
+// Result is one analyzed address of a job, in completion order
+type Result struct {
+	Row    int                       `json:"row"`
+	Email  string                    `json:"email"`
+	Result *models.EmailIntelligence `json:"result,omitempty"`
+	Error  string                    `json:"error,omitempty"`
+}
+
+// ResultPage is a slice of a job's results plus the cursor for the next page
+type ResultPage struct {
+	JobID   string            `json:"job_id"`
+	Status  string            `json:"status"`
+	Offset  int               `json:"offset"`
+	Limit   int               `json:"limit"`
+	Total   int               `json:"total"` // results available so far
+	Results []json.RawMessage `json:"results"`
+	Next    *int              `json:"next"` // nil once a finished job has no more results
+}
+
+// appendResult writes one result as a JSON line and indexes its position so
+// pages can be read back without scanning the file. Progress is counted under
+// the same lock once the line is written, so processed never runs ahead of
+// the results a poller can read.
+func (j *Job) appendResult(file *os.File, result Result) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	offset := int64(0)
+	if n := len(j.resultOffsets); n > 0 {
+		offset = j.resultOffsets[n-1] + j.resultSizes[n-1]
+	}
+	if _, err := file.WriteAt(line, offset); err != nil {
+		return err
+	}
+	j.resultOffsets = append(j.resultOffsets, offset)
+	j.resultSizes = append(j.resultSizes, int64(len(line)))
+	j.processed++
+	if result.Result != nil && result.Result.IsValid {
+		j.valid++
+	}
+	return nil
+}
+
+// Results returns up to limit results starting at offset. Results are
+// available while the job is still running.
+func (j *Job) Results(offset, limit int) (ResultPage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	j.mu.RLock()
+	page := ResultPage{
+		JobID:   j.ID,
+		Status:  j.status,
+		Offset:  offset,
+		Limit:   limit,
+		Total:   len(j.resultOffsets),
+		Results: []json.RawMessage{},
+	}
+	end := offset + limit
+	if end > page.Total {
+		end = page.Total
+	}
+	var offsets, sizes []int64
+	if offset < end {
+		offsets = j.resultOffsets[offset:end]
+		sizes = j.resultSizes[offset:end]
+	}
+	path := j.resultsPath
+	finished := j.status == StatusCompleted || j.status == StatusFailed
+	j.mu.RUnlock()
+
+	if len(offsets) > 0 {
+		file, err := os.Open(path)
+		if err != nil {
+			return page, err
+		}
+		defer file.Close()
+
+		for i, start := range offsets {
+			line := make([]byte, sizes[i])
+			if _, err := file.ReadAt(line, start); err != nil {
+				return page, err
+			}
+			page.Results = append(page.Results, json.RawMessage(line[:len(line)-1]))
+		}
+	}
+
+	next := offset + len(page.Results)
+	if !finished || next < page.Total {
+		page.Next = &next
+	}
+	return page, nil
+}
@@ -0,0 +1,264 @@
+package jobs
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/metrics"
+)
+
+// reportHeader is the first row of every downloadable job report
+var reportHeader = []string{
+	"row", "email", "is_valid", "validation_score", "risk_category",
+	"quality_tier", "confidence_level", "error",
+}
+
+// uploadRow is a single address read from an uploaded list
+type uploadRow struct {
+	row   int
+	email string
+}
+
+// SubmitUpload stores an uploaded CSV/TXT list on disk and processes it in the
+// background. column selects the CSV field holding the address.
+func (m *Manager) SubmitUpload(src io.Reader, column int, deepAnalysis bool, callerID string) (*Job, error) {
+	input, err := os.CreateTemp("", "email-job-*.csv")
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	if _, err := io.Copy(input, src); err != nil {
+		os.Remove(input.Name())
+		return nil, err
+	}
+
+	// Count rows up front so progress has a real denominator
+	total := 0
+	if err := scanEmails(input.Name(), column, func(uploadRow) { total++ }); err != nil {
+		os.Remove(input.Name())
+		return nil, err
+	}
+
+	job := m.register(deepAnalysis, "", callerID)
+	job.mu.Lock()
+	job.inputPath = input.Name()
+	job.total = total
+	job.mu.Unlock()
+
+	go m.run(job, func(fn func(uploadRow)) error {
+		return scanEmails(job.inputPath, column, fn)
+	})
+
+	return job, nil
+}
+
+// SubmitEmails processes a list of addresses in the background. A non-empty
+// callbackURL, checked with ValidateCallbackURL, is notified when it finishes.
+func (m *Manager) SubmitEmails(emails []string, deepAnalysis bool, callbackURL, callerID string) *Job {
+	job := m.register(deepAnalysis, callbackURL, callerID)
+	job.mu.Lock()
+	job.total = len(emails)
+	job.mu.Unlock()
+
+	go m.run(job, func(fn func(uploadRow)) error {
+		for i, email := range emails {
+			fn(uploadRow{row: i + 1, email: email})
+		}
+		return nil
+	})
+
+	return job
+}
+
+// run feeds the rows produced by scan through a bounded worker pool and
+// writes one report row per address as it completes
+func (m *Manager) run(job *Job, scan func(fn func(uploadRow)) error) {
+	defer m.notify(job)
+
+	report, err := os.CreateTemp("", "email-report-*.csv")
+	if err != nil {
+		job.finish(err)
+		return
+	}
+	defer report.Close()
+
+	results, err := os.CreateTemp("", "email-results-*.jsonl")
+	if err != nil {
+		job.finish(err)
+		return
+	}
+	defer results.Close()
+
+	job.mu.Lock()
+	job.status = StatusRunning
+	job.startedAt = time.Now()
+	job.reportPath = report.Name()
+	job.resultsPath = results.Name()
+	job.mu.Unlock()
+
+	writer := csv.NewWriter(report)
+	writer.Write(reportHeader)
+	var writerMu sync.Mutex
+
+	rows := make(chan uploadRow, m.opts.Workers)
+	throttle := newDomainThrottle(m.opts.DomainConcurrency)
+	var wg sync.WaitGroup
+	// A result that cannot be stored fails the job: it would otherwise be
+	// counted as processed but missing from /results
+	var resultErr error
+	var resultErrOnce sync.Once
+
+	for i := 0; i < m.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rows {
+				record, result := m.analyzeRow(job, row, throttle)
+
+				writerMu.Lock()
+				writer.Write(record)
+				writerMu.Unlock()
+				if err := job.appendResult(results, result); err != nil {
+					resultErrOnce.Do(func() {
+						resultErr = fmt.Errorf("storing result for row %d: %w", result.Row, err)
+						log.Printf("Job %s: %v", job.ID, resultErr)
+					})
+				}
+			}
+		}()
+	}
+
+	scanErr := scan(func(row uploadRow) {
+		rows <- row
+	})
+	close(rows)
+	wg.Wait()
+
+	writer.Flush()
+	if scanErr == nil {
+		scanErr = writer.Error()
+	}
+	if scanErr == nil {
+		scanErr = resultErr
+	}
+	job.finish(scanErr)
+}
+
+// analyzeRow analyzes one address and returns its report row and full result
+func (m *Manager) analyzeRow(job *Job, row uploadRow, throttle *domainThrottle) ([]string, Result) {
+	domain := ""
+	if at := strings.LastIndex(row.email, "@"); at != -1 {
+		domain = strings.ToLower(row.email[at+1:])
+	}
+
+	throttle.acquire(domain)
+	metrics.BulkWorkers.Inc()
+	intelligence, err := m.analyze(context.Background(), row.email, job.DeepAnalysis)
+	metrics.BulkWorkers.Dec()
+	throttle.release(domain)
+
+	if err != nil {
+		return []string{strconv.Itoa(row.row), row.email, "false", "0", "Error", "", "", err.Error()},
+			Result{Row: row.row, Email: row.email, Error: err.Error()}
+	}
+	if m.opts.OnResult != nil {
+		m.opts.OnResult(job, intelligence)
+	}
+
+	return []string{
+		strconv.Itoa(row.row),
+		intelligence.Email,
+		strconv.FormatBool(intelligence.IsValid),
+		strconv.Itoa(intelligence.ValidationScore),
+		intelligence.RiskCategory,
+		intelligence.QualityTier,
+		intelligence.ConfidenceLevel,
+		"",
+	}, Result{Row: row.row, Email: row.email, Result: intelligence}
+}
+
+// scanEmails streams addresses from a CSV/TXT file, skipping blank rows, a
+// header row, and rows the CSV parser cannot read
+func scanEmails(path string, column int, fn func(uploadRow)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				continue
+			}
+			return err
+		}
+
+		if column >= len(record) {
+			continue
+		}
+		email := strings.TrimSpace(record[column])
+		if email == "" || (row == 1 && !strings.Contains(email, "@")) {
+			continue
+		}
+
+		fn(uploadRow{row: row, email: email})
+	}
+}
+
+// domainThrottle caps how many addresses of one domain are analyzed at once
+type domainThrottle struct {
+	limit int
+	slots map[string]chan struct{}
+	mu    sync.Mutex
+}
+
+func newDomainThrottle(limit int) *domainThrottle {
+	if limit < 1 {
+		limit = 1
+	}
+	return &domainThrottle{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+func (t *domainThrottle) acquire(domain string) {
+	t.mu.Lock()
+	slot, ok := t.slots[domain]
+	if !ok {
+		slot = make(chan struct{}, t.limit)
+		t.slots[domain] = slot
+	}
+	t.mu.Unlock()
+
+	slot <- struct{}{}
+}
+
+func (t *domainThrottle) release(domain string) {
+	t.mu.Lock()
+	slot := t.slots[domain]
+	t.mu.Unlock()
+
+	<-slot
+}
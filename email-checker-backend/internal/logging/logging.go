@@ -0,0 +1,180 @@
+// Package logging sets up structured JSON logs and ties log lines to the HTTP
+// request they belong to through a request ID carried in the context.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID carries the request ID in both directions: a well-formed
+// incoming value is kept so IDs can follow a request across services
+const HeaderRequestID = "X-Request-ID"
+
+// Setup makes slog.Default (and with it the standard log package) write one
+// JSON object per line at level and above. Unknown levels mean info.
+func Setup(level string) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		lvl = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: lvl})))
+}
+
+// ParseLevel accepts debug, info, warn or error in any case
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	err := lvl.UnmarshalText([]byte(strings.TrimSpace(level)))
+	return lvl, err
+}
+
+type contextKey struct{}
+
+// requestState is what the context carries for one request: its ID and what
+// the analyses made on its behalf reported for the request log line
+type requestState struct {
+	id string
+
+	mu          sync.Mutex
+	analyses    int
+	domain      string
+	score       int
+	cacheHits   int
+	cacheMisses int
+}
+
+// RequestID returns the ID of the request ctx belongs to, or ""
+func RequestID(ctx context.Context) string {
+	if state, ok := ctx.Value(contextKey{}).(*requestState); ok {
+		return state.id
+	}
+	return ""
+}
+
+// FromContext returns the default logger, tagged with the request ID when
+// ctx belongs to a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if id := RequestID(ctx); id != "" {
+		return slog.Default().With("request_id", id)
+	}
+	return slog.Default()
+}
+
+// RecordCache notes whether an analysis was answered from the result cache
+func RecordCache(ctx context.Context, hit bool) {
+	state, ok := ctx.Value(contextKey{}).(*requestState)
+	if !ok {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if hit {
+		state.cacheHits++
+	} else {
+		state.cacheMisses++
+	}
+}
+
+// RecordAnalysis notes a finished analysis. Only the domain is kept; full
+// addresses never reach the logs.
+func RecordAnalysis(ctx context.Context, domain string, score int) {
+	state, ok := ctx.Value(contextKey{}).(*requestState)
+	if !ok {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.analyses++
+	state.domain = domain
+	state.score = score
+}
+
+// Middleware assigns each request an ID, returns it in X-Request-ID and logs
+// one line when the request completes. A single-address request logs its
+// domain, cache outcome and score; a bulk request logs counts instead.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		state := &requestState{id: id}
+		c.Header(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, state))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("request_id", id),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+
+		state.mu.Lock()
+		switch {
+		case state.analyses == 1 && state.cacheHits+state.cacheMisses == 1:
+			cache := "miss"
+			if state.cacheHits == 1 {
+				cache = "hit"
+			}
+			attrs = append(attrs,
+				slog.String("domain", state.domain),
+				slog.String("cache", cache),
+				slog.Int("score", state.score),
+			)
+		case state.analyses > 0 || state.cacheHits+state.cacheMisses > 0:
+			attrs = append(attrs,
+				slog.Int("analyses", state.analyses),
+				slog.Int("cache_hits", state.cacheHits),
+				slog.Int("cache_misses", state.cacheMisses),
+			)
+		}
+		state.mu.Unlock()
+
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII without spaces, so a
+// caller cannot inject arbitrary text into the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
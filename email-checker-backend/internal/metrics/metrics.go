@@ -0,0 +1,184 @@
+// Package metrics keeps process-wide counters, gauges and histograms and
+// writes them in the Prometheus text exposition format (version 0.0.4).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the Content-Type of WriteText output
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metrics reported by the service
+var (
+	Analyses         = NewCounter("email_intelligence_analyses_total", "Email analyses requested, including cache hits.")
+	AnalysisErrors   = NewCounter("email_intelligence_analysis_errors_total", "Email analyses that returned an error, such as a rate limit.")
+	CacheHits        = NewCounter("email_intelligence_cache_hits_total", "Analyses answered from the result cache.")
+	CacheMisses      = NewCounter("email_intelligence_cache_misses_total", "Analyses not found in the result cache.")
+	AnalysisDuration = NewHistogram("email_intelligence_analysis_duration_seconds", "Time taken by one analysis.", DefaultBuckets)
+	BulkWorkers      = NewGauge("email_intelligence_bulk_workers_active", "Bulk, job and CSV analyses in progress.")
+)
+
+// DefaultBuckets suit analyses ranging from cache hits to SMTP probes
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// metric is anything WriteText can print
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	registry   = map[string]metric{}
+	registryMu sync.Mutex
+)
+
+// register adds m under name, replacing an earlier metric of the same name
+func register(name string, m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = m
+}
+
+// WriteText writes every metric, sorted by name
+func WriteText(w io.Writer) {
+	registryMu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	metrics := make([]metric, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		metrics[i] = registry[name]
+	}
+	registryMu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	name, help string
+	value      atomic.Uint64
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	register(name, c)
+	return c
+}
+
+// Inc adds one
+func (c *Counter) Inc() { c.value.Add(1) }
+
+func (c *Counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value.Load())
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	name, help string
+	value      atomic.Int64
+}
+
+// NewGauge creates and registers a gauge
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	register(name, g)
+	return g
+}
+
+// Inc adds one
+func (g *Gauge) Inc() { g.value.Add(1) }
+
+// Dec subtracts one
+func (g *Gauge) Dec() { g.value.Add(-1) }
+
+func (g *Gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.name, g.help, g.name, g.name, g.value.Load())
+}
+
+// gaugeFunc is a gauge read from fn at scrape time; negative values are
+// treated as unknown and not reported
+type gaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is fn's result at scrape time.
+// Registering the same name again replaces the earlier function.
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(name, &gaugeFunc{name: name, help: help, fn: fn})
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	value := g.fn()
+	if value < 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(value))
+}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	name, help string
+	buckets    []float64 // upper bounds, ascending
+	counts     []uint64  // per bucket, not cumulative; the last is +Inf
+	sum        float64
+	count      uint64
+	mu         sync.Mutex
+}
+
+// NewHistogram creates and registers a histogram with the given upper bounds
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+	register(name, h)
+	return h
+}
+
+// Observe records one value
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += value
+	h.count++
+	h.mu.Unlock()
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	counts := append([]uint64{}, h.counts...)
+	sum, count := h.sum, h.count
+	h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	cumulative := uint64(0)
+	for i, bound := range h.buckets {
+		cumulative += counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", h.name, formatFloat(sum), h.name, count)
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -5,11 +5,21 @@ import "time"
 // EmailIntelligence represents the complete analysis result
 type EmailIntelligence struct {
 	Email                    string                   `json:"email"`
+	NormalizedEmail          string                   `json:"normalized_email"`        // Unicode form of the domain; lookups use its ASCII (Punycode) form
+	StorageCanonicalEmail    string                   `json:"storage_canonical_email"` // safe to store and send to: trimmed, IDNA domain, local part case kept unless the provider ignores it
+	CanonicalEmail           string                   `json:"canonical_email"`         // for duplicate matching only: provider dots/tags stripped, may not be deliverable
 	IsValid                  bool                     `json:"is_valid"`
 	ValidationScore          int                      `json:"validation_score"`
 	ConfidenceLevel          string                   `json:"confidence_level"`
 	RiskCategory             string                   `json:"risk_category"`
 	QualityTier              string                   `json:"quality_tier"`
+	DeliverabilityVerdict    string                   `json:"deliverability_verdict"` // deliverable, risky, undeliverable or unknown; reconciles the fields above
+	ScoringProfile           string                   `json:"scoring_profile"`
+	PrimaryFailureReason     *FailureReason           `json:"primary_failure_reason,omitempty"`
+	OriginalIndex            *int                     `json:"original_index,omitempty"`    // position in a bulk request
+	DegradedChecks           []string                 `json:"degraded_checks"`             // checks whose DNS lookups failed; their results are "unknown" and unscored
+	TimedOut                 bool                     `json:"timed_out"`                   // the analysis time budget ran out before every stage finished
+	UnfinishedStages         []string                 `json:"unfinished_stages,omitempty"` // stages cut short by the time budget, e.g. smtp
 	
 	// Core Components
 	SyntaxValidation         ValidationResult         `json:"syntax_validation"`
@@ -17,6 +27,11 @@ type EmailIntelligence struct {
 	SMTPValidation           SMTPValidationResult     `json:"smtp_validation"`
 	SecurityAnalysis         SecurityAnalysisResult   `json:"security_analysis"`
 	DomainIntelligence       DomainIntelligenceResult `json:"domain_intelligence"`
+	LocalPartQuality         LocalPartQuality         `json:"local_part_quality"`
+	SpamTrapRisk             SpamTrapRisk             `json:"spam_trap_risk"`
+	
+	SubmissionCapabilities   *SubmissionCapabilities  `json:"submission_capabilities,omitempty"`
+	Debug                    *DebugInfo               `json:"debug,omitempty"` // raw lookup and SMTP evidence, only when requested
 	
 	// Advanced Analytics
 	ScoreBreakdown           ScoreBreakdown           `json:"score_breakdown"`
@@ -24,6 +39,8 @@ type EmailIntelligence struct {
 	MLPredictions            MLPredictions            `json:"ml_predictions"`
 	
 	// Metadata
+	AnalysisDepth            string                   `json:"analysis_depth"`  // standard, deep
+	AnalysisPolicy           string                   `json:"analysis_policy"` // requested, high_value_domain, cheap_domain
 	ProcessingTime           int64                    `json:"processing_time_ms"`
 	Timestamp                time.Time                `json:"timestamp"`
 	APIVersion               string                   `json:"api_version"`
@@ -35,6 +52,38 @@ type EmailIntelligence struct {
 	ExplanationText          string                   `json:"explanation_text"`
 }
 
+// FailureReason is the single most important reason an address is invalid
+type FailureReason struct {
+	Code    string `json:"code"` // invalid_syntax, dns_error, no_mx, disposable, low_score, smtp_unreachable, other
+	Message string `json:"message"`
+}
+
+// LocalPartQuality estimates whether the local part was chosen by a person
+type LocalPartQuality struct {
+	Score            int      `json:"score"`   // 0-100, higher is more human-like
+	Verdict          string   `json:"verdict"` // human, suspicious, random
+	Entropy          float64  `json:"entropy"` // bits per character
+	DigitRatio       float64  `json:"digit_ratio"`
+	LongestRandomRun int      `json:"longest_random_run"` // longest segment mixing letters and digits
+	NameLike         bool     `json:"name_like"`
+	Signals          []string `json:"signals"`
+}
+
+// CanonicalRule describes how a mailbox provider treats local parts
+type CanonicalRule struct {
+	CaseInsensitive bool   `json:"case_insensitive"` // local part case is ignored by the provider
+	IgnoreDots      bool   `json:"ignore_dots"`      // dots in the local part are ignored
+	TagSeparators   string `json:"tag_separators"`   // characters that start a sub-address tag, e.g. "+"
+	Domain          string `json:"domain"`           // domain that owns the same mailboxes, e.g. gmail.com for googlemail.com
+}
+
+// SpamTrapRisk estimates how likely an address is a recycled or pristine spam trap
+type SpamTrapRisk struct {
+	Level   string   `json:"level"` // none, low, medium, high
+	Score   int      `json:"score"` // 0-100, higher is riskier
+	Signals []string `json:"signals"`
+}
+
 // ValidationResult represents a single validation check result
 type ValidationResult struct {
 	Status      string `json:"status"`      // pass, fail, unknown
@@ -44,57 +93,263 @@ type ValidationResult struct {
 	Weight      int    `json:"weight"`
 }
 
+// SyntaxCheckResult is the network-free format check used for inline form
+// validation
+type SyntaxCheckResult struct {
+	Valid       bool     `json:"valid"`
+	Reason      string   `json:"reason"`
+	Suggestions []string `json:"suggestions"` // the address with a mistyped domain corrected
+}
+
 // DNSValidationResult contains DNS validation details
 type DNSValidationResult struct {
-	DomainExists    ValidationResult `json:"domain_exists"`
-	MXRecords       ValidationResult `json:"mx_records"`
-	ARecords        []string         `json:"a_records"`
-	MXDetails       []MXRecord       `json:"mx_details"`
-	ResponseTime    int64            `json:"response_time_ms"`
+	DomainExists          ValidationResult `json:"domain_exists"`
+	MXRecords             ValidationResult `json:"mx_records"`
+	ARecords              []string         `json:"a_records"`
+	AAAARecords           []string         `json:"aaaa_records"`
+	MXDetails             []MXRecord       `json:"mx_details"`
+	WildcardDNS           bool             `json:"wildcard_dns"`
+	Nameservers           []string         `json:"nameservers"`
+	SuspiciousNameservers bool             `json:"suspicious_nameservers"`
+	DNSSEC                ValidationResult `json:"dnssec"` // unsigned domains are "unknown", not "fail"
+	DNSSECValid           bool             `json:"dnssec_valid"`
+	Warnings              []string         `json:"warnings"` // e.g. MX hosts that do not resolve
+	ResponseTime          int64            `json:"response_time_ms"`
 }
 
 // SMTPValidationResult contains SMTP validation details
 type SMTPValidationResult struct {
-	Reachable       ValidationResult `json:"reachable"`
-	ResponseTime    int64            `json:"response_time_ms"`
-	ServerResponse  string           `json:"server_response"`
-	Port            int              `json:"port"`
-	TLSSupported    bool             `json:"tls_supported"`
+	Reachable         ValidationResult `json:"reachable"`
+	ResponseTime      int64            `json:"response_time_ms"`
+	ServerResponse    string           `json:"server_response"`
+	MXHost            string           `json:"mx_host,omitempty"`
+	Port              int              `json:"port"`
+	SourceIP          string           `json:"source_ip,omitempty"` // local address the probe was sent from
+	TLSSupported      bool             `json:"tls_supported"`
+	TLSUsed           bool             `json:"tls_used"`
+	Capabilities      []string         `json:"capabilities,omitempty"`
+	SMTPUTF8Supported bool             `json:"smtputf8_supported"`
+	MailboxStatus     string           `json:"mailbox_status"` // active, disabled, nonexistent, full, unknown
+	Deferred          bool             `json:"deferred"`       // a 4xx reply postponed the check, e.g. greylisting; retry later
+	Attempts          int              `json:"attempts"`       // SMTP validation rounds; more than 1 when a deferral was retried
+	MXResults         []MXTestResult   `json:"mx_results,omitempty"`
+
+	// Provenance of the verdict: whether a mail server was actually contacted
+	SMTPVerificationPerformed bool   `json:"smtp_verification_performed"`
+	VerificationMethod        string `json:"verification_method"` // rcpt_verified, tcp_only, assumed_mx, trusted_provider, skipped
+
+	Transcript []SMTPExchange `json:"-"` // the session behind the verdict, for debug output
+}
+
+// MXTestResult records how one MX host responded during SMTP validation
+type MXTestResult struct {
+	Host         string `json:"host"`
+	Priority     int    `json:"priority"`
+	Port         int    `json:"port"`    // best responding port, or the last port tried
+	Outcome      string `json:"outcome"` // connected, failed, timeout
+	RawSignal    string `json:"raw_signal,omitempty"`
+	ResponseTime int64  `json:"response_time_ms"`
+}
+
+// SubmissionCapabilities describes authenticated submission support on port 587
+type SubmissionCapabilities struct {
+	Host           string   `json:"host"`
+	Port           int      `json:"port"`
+	Reachable      bool     `json:"reachable"`
+	StartTLS       bool     `json:"starttls"`
+	AuthMechanisms []string `json:"auth_mechanisms"`
+	Capabilities   []string `json:"capabilities"`
+	Error          string   `json:"error,omitempty"`
 }
 
 // SecurityAnalysisResult contains security record analysis
 type SecurityAnalysisResult struct {
-	SPFRecord       ValidationResult `json:"spf_record"`
-	DKIMRecord      ValidationResult `json:"dkim_record"`
-	DMARCRecord     ValidationResult `json:"dmarc_record"`
-	SecurityScore   int              `json:"security_score"`
-	ThreatLevel     string           `json:"threat_level"`
+	SPFRecord        ValidationResult `json:"spf_record"`      // RawSignal is the effective all qualifier: -all, ~all, ?all, +all or no_all
+	SPFRecordText    string           `json:"spf_record_text"` // the SPF record as published
+	SPFLookups       int              `json:"spf_lookups"`     // DNS-querying terms across the record and its includes; RFC 7208 allows 10
+	SPFWarnings      []string         `json:"spf_warnings"`
+	DKIMRecord       ValidationResult `json:"dkim_record"`
+	DKIMSelector     string           `json:"dkim_selector"`          // selector whose key record was found
+	BIMIRecord       ValidationResult `json:"bimi_record"`            // reported only, never part of SecurityScore
+	BIMILogoURL      string           `json:"bimi_logo_url"`          // l= tag of the BIMI record
+	BIMIAuthority    string           `json:"bimi_authority"`         // a= tag, the verified mark certificate URL
+	DMARCRecord      ValidationResult `json:"dmarc_record"`           // RawSignal is the parsed policy, e.g. "p=reject sp=none pct=100"
+	DMARCRecordText  string           `json:"dmarc_record_text"`      // the DMARC record as published
+	DMARCPolicy      string           `json:"dmarc_policy"`           // none, quarantine, reject; empty without DMARC
+	DMARCSubPolicy   string           `json:"dmarc_subdomain_policy"` // sp=, or the p= policy when absent
+	DMARCPercent     int              `json:"dmarc_pct"`              // share of failing mail the policy applies to
+	SecurityScore    int              `json:"security_score"`
+	ThreatLevel      string           `json:"threat_level"`
+	SPFIncludes      []string         `json:"spf_includes"`      // include:/redirect= targets of the SPF record
+	SendingProviders []string         `json:"sending_providers"` // recognized reputable ESPs among the includes
+	ReputableSender  bool             `json:"reputable_sender"`  // sends via a reputable ESP with DMARC in place
+	AbnormalTXT      bool             `json:"abnormal_txt"`      // oversized or excessive TXT answers were truncated
+	TXTAnomalies     []string         `json:"txt_anomalies"`
+
+	// Raw evidence for debug output
+	TXTLookups    []TXTLookup          `json:"-"` // every TXT query made, including SPF includes
+	DKIMSelectors []DKIMSelectorResult `json:"-"` // every DKIM selector tried
+}
+
+// DKIMSelectorResult is the outcome of checking one explicit DKIM selector
+type DKIMSelectorResult struct {
+	Domain   string            `json:"domain"`
+	Selector string            `json:"selector"`
+	Name     string            `json:"name"`   // <selector>._domainkey.<domain>
+	Status   string            `json:"status"` // valid, invalid, revoked, not_found, unknown
+	Reason   string            `json:"reason"`
+	Found    bool              `json:"found"`
+	Record   string            `json:"record,omitempty"`
+	Tags     map[string]string `json:"tags"`
+	KeyType  string            `json:"key_type,omitempty"`
+	KeyBits  int               `json:"key_bits,omitempty"`
+	Weak     bool              `json:"weak"`
+	TestMode bool              `json:"test_mode"`
 }
 
 // DomainIntelligenceResult contains domain intelligence data
 type DomainIntelligenceResult struct {
-	IsDisposable     ValidationResult `json:"is_disposable"`
-	IsFreeProvider   ValidationResult `json:"is_free_provider"`
-	IsCorporate      ValidationResult `json:"is_corporate"`
-	IsCatchAll       ValidationResult `json:"is_catch_all"`
-	IsBlacklisted    ValidationResult `json:"is_blacklisted"`
-	DomainAge        int              `json:"domain_age_days"`
-	ReputationScore  int              `json:"reputation_score"`
-	RiskIndicators   []string         `json:"risk_indicators"`
+	RegistrableDomain   string           `json:"registrable_domain"` // eTLD+1, e.g. acme.co.uk
+	Subdomain           string           `json:"subdomain"`          // labels left of the registrable domain
+	IsDisposable        ValidationResult `json:"is_disposable"`
+	DisposableLevel     string           `json:"disposable_level"` // none, suspected (name keyword only), confirmed (listed domain or MX)
+	IsFreeProvider      ValidationResult `json:"is_free_provider"`
+	IsCorporate         ValidationResult `json:"is_corporate"`
+	IsCatchAll          ValidationResult `json:"is_catch_all"`
+	IsBlacklisted       ValidationResult `json:"is_blacklisted"`
+	HomoglyphRisk       ValidationResult `json:"homoglyph_risk"`           // fail for domains imitating a brand with mixed scripts or lookalike characters
+	ScannerReputation   ValidationResult `json:"scanner_reputation"`       // external security scanner verdict; fail when flagged malicious
+	MailboxPlausibility ValidationResult `json:"mailbox_plausibility"`     // the address's local part against the provider's naming rules
+	IsRoleAccount       ValidationResult `json:"is_role_account"`          // fail for function mailboxes such as support@ or noreply@
+	BlocklistHits       []string         `json:"blocklist_hits,omitempty"` // DNS blocklist zones listing the domain's mail servers
+	DomainAge           int              `json:"domain_age_days"`          // days since registration (RDAP); -1 when unknown
+	ReputationScore     int              `json:"reputation_score"`
+	RiskIndicators      []string         `json:"risk_indicators"`
+	Feedback            DomainFeedback   `json:"feedback"`
+}
+
+// DomainStats aggregates the analyses of one domain seen by this process
+type DomainStats struct {
+	Domain       string    `json:"domain"`
+	Analyses     int64     `json:"analyses"`
+	AverageScore float64   `json:"average_score"`
+	Valid        int64     `json:"valid"`
+	Invalid      int64     `json:"invalid"`
+	ValidRatio   float64   `json:"valid_ratio"` // valid / analyses
+	Disposable   int64     `json:"disposable"`  // analyses that confirmed the domain disposable
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// AnalysisStats summarizes the analyses served by this process: recent
+// volume and the domains seen most often
+type AnalysisStats struct {
+	Since                time.Time     `json:"since"`
+	TotalAnalyses        int64         `json:"total_analyses"` // including failed analyses
+	RequestsLastMinute   int64         `json:"requests_last_minute"`
+	RequestsLastHour     int64         `json:"requests_last_hour"`
+	TrackedDomains       int           `json:"tracked_domains"` // bounded; the least recently seen domains are dropped first
+	TopDomains           []DomainStats `json:"top_domains"`
+	TopDisposableDomains []DomainStats `json:"top_disposable_domains"`
+}
+
+// DomainFeedback aggregates reported delivery outcomes for a domain
+type DomainFeedback struct {
+	Delivered     int     `json:"delivered"`
+	Bounced       int     `json:"bounced"`
+	Complaints    int     `json:"complaints"`
+	Total         int     `json:"total"`
+	BounceRate    float64 `json:"bounce_rate"`
+	ComplaintRate float64 `json:"complaint_rate"`
+}
+
+// DebugInfo is the raw evidence behind a verdict, returned only when a caller
+// asks for it to investigate a disputed result
+type DebugInfo struct {
+	TXTRecords     []TXTLookup          `json:"txt_records"`     // SPF, DMARC and DKIM queries with their answers
+	MXRecords      []DebugMXRecord      `json:"mx_records"`      // the full MX list with every resolved address
+	DKIMSelectors  []DKIMSelectorResult `json:"dkim_selectors"`  // every selector tried and what it held
+	SMTPTranscript []SMTPExchange       `json:"smtp_transcript"` // the session the SMTP verdict came from
+}
+
+// TXTLookup is one TXT query and its answer
+type TXTLookup struct {
+	Name    string   `json:"name"`
+	Records []string `json:"records"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// DebugMXRecord is an MX host with all of its addresses
+type DebugMXRecord struct {
+	Host      string   `json:"host"`
+	Priority  int      `json:"priority"`
+	Addresses []string `json:"addresses"`
+}
+
+// SMTPExchange is one command of an SMTP session and the server's reply. The
+// greeting has no command; a command the session ended on may have no reply.
+type SMTPExchange struct {
+	Command string `json:"command,omitempty"`
+	Reply   string `json:"reply"`
+}
+
+// DomainAnalysis is the domain-only intelligence returned without a mailbox:
+// the DNS, security and reputation checks every address at the domain shares
+type DomainAnalysis struct {
+	Domain             string                 `json:"domain"`
+	DNSValidation      DNSValidationResult    `json:"dns_validation"`
+	SecurityAnalysis   SecurityAnalysisResult `json:"security_analysis"`
+	DomainIntelligence DomainReputation       `json:"domain_intelligence"`
+	DegradedChecks     []string               `json:"degraded_checks"` // checks whose DNS lookups failed
+	Cached             bool                   `json:"cached"`          // shared with a recent analysis of the domain
+	ProcessingTime     int64                  `json:"processing_time_ms"`
+	Timestamp          time.Time              `json:"timestamp"`
+}
+
+// DomainReputation is DomainIntelligenceResult without the fields that judge
+// a particular mailbox
+type DomainReputation struct {
+	RegistrableDomain string           `json:"registrable_domain"`
+	Subdomain         string           `json:"subdomain"`
+	IsDisposable      ValidationResult `json:"is_disposable"`
+	DisposableLevel   string           `json:"disposable_level"`
+	IsFreeProvider    ValidationResult `json:"is_free_provider"`
+	IsCorporate       ValidationResult `json:"is_corporate"`
+	IsCatchAll        ValidationResult `json:"is_catch_all"` // from the domain alone; no probe is sent
+	IsBlacklisted     ValidationResult `json:"is_blacklisted"`
+	HomoglyphRisk     ValidationResult `json:"homoglyph_risk"`
+	ScannerReputation ValidationResult `json:"scanner_reputation"`
+	BlocklistHits     []string         `json:"blocklist_hits,omitempty"`
+	DomainAge         int              `json:"domain_age_days"`
+	ReputationScore   int              `json:"reputation_score"`
+	RiskIndicators    []string         `json:"risk_indicators"`
+	Feedback          DomainFeedback   `json:"feedback"`
 }
 
 // ScoreBreakdown shows detailed scoring
 type ScoreBreakdown struct {
-	SyntaxScore      int    `json:"syntax_score"`
-	MXScore          int    `json:"mx_score"`
-	SecurityScore    int    `json:"security_score"`
-	SMTPScore        int    `json:"smtp_score"`
-	DisposableScore  int    `json:"disposable_score"`
-	ReputationScore  int    `json:"reputation_score"`
-	CatchAllScore    int    `json:"catch_all_score"`
-	TotalScore       int    `json:"total_score"`
-	MaxPossible      int    `json:"max_possible"`
-	Explanation      string `json:"explanation"`
+	SyntaxScore      int              `json:"syntax_score"`
+	MXScore          int              `json:"mx_score"`
+	SecurityScore    int              `json:"security_score"`
+	SMTPScore        int              `json:"smtp_score"`
+	DisposableScore  int              `json:"disposable_score"`
+	ReputationScore  int              `json:"reputation_score"`
+	CatchAllScore    int              `json:"catch_all_score"`
+	TotalScore       int              `json:"total_score"`
+	MaxPossible      int              `json:"max_possible"`
+	Explanation      string           `json:"explanation"`
+	CategoryMax      CategoryMaximums `json:"category_max"`
+	OverridesApplied []string         `json:"overrides_applied"`
+}
+
+// CategoryMaximums holds the points available in each scoring category
+type CategoryMaximums struct {
+	Syntax     int `json:"syntax"`
+	MX         int `json:"mx"`
+	Security   int `json:"security"`
+	SMTP       int `json:"smtp"`
+	Disposable int `json:"disposable"`
+	Reputation int `json:"reputation"`
+	CatchAll   int `json:"catch_all"`
 }
 
 // RiskAnalysis contains risk assessment
@@ -126,9 +381,11 @@ type MLPredictions struct {
 
 // MXRecord represents a mail exchange record
 type MXRecord struct {
-	Host     string `json:"host"`
-	Priority int    `json:"priority"`
-	IP       string `json:"ip,omitempty"`
+	Host     string   `json:"host"`
+	Priority int      `json:"priority"`
+	IP       string   `json:"ip,omitempty"`   // first IPv4 address
+	IPv4     []string `json:"ipv4,omitempty"` // every A address, for hosts with several
+	IPv6     []string `json:"ipv6,omitempty"` // AAAA addresses
 }
 
 // ScoringWeights defines the scoring system
@@ -141,3 +398,89 @@ type ScoringWeights struct {
 	DomainReputation int `json:"domain_reputation"`  // 10 points
 	CatchAllRisk     int `json:"catch_all_risk"`     // 10 points
 }
+
+// ScoringProfile is a named bundle of weights and verdict thresholds
+type ScoringProfile struct {
+	Name          string         `json:"name"`
+	Weights       ScoringWeights `json:"weights"`
+	ValidScore    int            `json:"valid_score"`     // minimum score for an address to be valid
+	HighRiskScore int            `json:"high_risk_score"` // risk score at which an address is High Risk; half of it is Medium Risk
+}
+
+// QualityThresholds are the validation scores at which an address reaches
+// each confidence level and quality tier
+type QualityThresholds struct {
+	HighConfidence           int `json:"high_confidence"`
+	MediumConfidence         int `json:"medium_confidence"`
+	VerifiedHighConfidence   int `json:"verified_high_confidence"`   // used instead for RCPT-verified mailboxes
+	VerifiedMediumConfidence int `json:"verified_medium_confidence"` // used instead for RCPT-verified mailboxes
+	SafeFreeProvider         int `json:"safe_free_provider"`         // trusted free provider addresses at or above this are Safe
+	Premium                  int `json:"premium"`
+	Excellent                int `json:"excellent"`
+	Good                     int `json:"good"`
+	Fair                     int `json:"fair"`
+}
+
+// WeightOverrides replaces some weights of a scoring profile for a single
+// request; omitted components keep the profile's value
+type WeightOverrides struct {
+	SyntaxFormat     *int `json:"syntax_format"`
+	MXRecords        *int `json:"mx_records"`
+	SecurityRecords  *int `json:"security_records"`
+	SMTPReachability *int `json:"smtp_reachability"`
+	DisposableCheck  *int `json:"disposable_check"`
+	DomainReputation *int `json:"domain_reputation"`
+	CatchAllRisk     *int `json:"catch_all_risk"`
+}
+
+// Apply returns base with the overridden components replaced
+func (o WeightOverrides) Apply(base ScoringWeights) ScoringWeights {
+	override := func(value *int, current int) int {
+		if value == nil {
+			return current
+		}
+		return *value
+	}
+	return ScoringWeights{
+		SyntaxFormat:     override(o.SyntaxFormat, base.SyntaxFormat),
+		MXRecords:        override(o.MXRecords, base.MXRecords),
+		SecurityRecords:  override(o.SecurityRecords, base.SecurityRecords),
+		SMTPReachability: override(o.SMTPReachability, base.SMTPReachability),
+		DisposableCheck:  override(o.DisposableCheck, base.DisposableCheck),
+		DomainReputation: override(o.DomainReputation, base.DomainReputation),
+		CatchAllRisk:     override(o.CatchAllRisk, base.CatchAllRisk),
+	}
+}
+
+// ResultDiff summarizes what changed between two analysis runs of the same list
+type ResultDiff struct {
+	Summary DiffSummary `json:"summary"`
+	Changes []EmailDiff `json:"changes"`
+	Added   []string    `json:"added"`
+	Removed []string    `json:"removed"`
+}
+
+// DiffSummary counts changes across the compared runs
+type DiffSummary struct {
+	Compared      int `json:"compared"`
+	Changed       int `json:"changed"`
+	Unchanged     int `json:"unchanged"`
+	Added         int `json:"added"`
+	Removed       int `json:"removed"`
+	BecameValid   int `json:"became_valid"`
+	BecameInvalid int `json:"became_invalid"`
+}
+
+// EmailDiff lists the meaningful changes for one address
+type EmailDiff struct {
+	Email   string        `json:"email"`
+	Domain  string        `json:"domain"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a single field that moved between runs
+type FieldChange struct {
+	Field    string      `json:"field"`
+	Previous interface{} `json:"previous"`
+	Current  interface{} `json:"current"`
+}
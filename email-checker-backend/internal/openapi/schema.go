@@ -0,0 +1,191 @@
+// Package openapi builds OpenAPI 3 schemas by reflecting over the Go types
+// the API encodes, so the published contract follows the structs instead of
+// a hand-maintained copy.
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is one OpenAPI schema object, encoded as JSON as-is
+type Schema map[string]interface{}
+
+// Components collects the schemas of named struct types. Each struct is
+// described once under #/components/schemas and referenced everywhere else.
+type Components struct {
+	schemas map[string]Schema
+	names   map[reflect.Type]string
+}
+
+// NewComponents creates an empty schema registry
+func NewComponents() *Components {
+	return &Components{
+		schemas: make(map[string]Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns the registered schemas keyed by component name
+func (c *Components) Schemas() map[string]Schema {
+	return c.schemas
+}
+
+// Add registers schema under name, for shapes that have no Go type, and
+// returns a reference to it
+func (c *Components) Add(name string, schema Schema) Schema {
+	c.schemas[name] = schema
+	return Ref(name)
+}
+
+// Ref is a reference to the component schema called name
+func Ref(name string) Schema {
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+// For returns the schema of value's type; named structs are registered as
+// components and returned as references
+func (c *Components) For(value interface{}) Schema {
+	return c.schema(reflect.TypeOf(value))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (c *Components) schema(t reflect.Type) Schema {
+	if t == nil {
+		return Schema{}
+	}
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return Schema{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return Schema{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return Schema{"type": "number", "format": "double"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Ptr:
+		return nullable(c.schema(t.Elem()))
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		// encoding/json writes a nil slice as null
+		return Schema{"type": "array", "items": c.schema(t.Elem()), "nullable": true}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": c.schema(t.Elem()), "nullable": true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return c.object(t)
+		}
+		if name, ok := c.names[t]; ok {
+			return Ref(name)
+		}
+		name := c.componentName(t)
+		c.names[t] = name
+		// Registered before the fields are walked so recursive types terminate
+		c.schemas[name] = Schema{}
+		c.schemas[name] = c.object(t)
+		return Ref(name)
+	}
+	// interface{} and anything else JSON can hold: any value
+	return Schema{}
+}
+
+// componentName is the type's name, qualified by its package when another
+// package already registered the same name
+func (c *Components) componentName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := c.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if slash := strings.LastIndex(pkg, "/"); slash != -1 {
+		pkg = pkg[slash+1:]
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+// object describes a struct the way encoding/json encodes it: exported
+// fields by their json name, embedded structs flattened, "-" skipped. Fields
+// tagged binding:"required" are listed as required.
+func (c *Components) object(t reflect.Type) Schema {
+	properties := make(map[string]Schema)
+	required := make([]string, 0)
+	c.fields(t, properties, &required)
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (c *Components) fields(t reflect.Type, properties map[string]Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				c.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = c.schema(field.Type)
+		if hasOption(field.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable marks a schema as also accepting null; references cannot carry
+// siblings in OpenAPI 3.0, so they are wrapped in allOf
+func nullable(schema Schema) Schema {
+	if _, isRef := schema["$ref"]; isRef {
+		return Schema{"allOf": []Schema{schema}, "nullable": true}
+	}
+	if len(schema) == 0 {
+		return schema
+	}
+	copied := make(Schema, len(schema)+1)
+	for key, value := range schema {
+		copied[key] = value
+	}
+	copied["nullable"] = true
+	return copied
+}
+
+func hasOption(options, option string) bool {
+	for _, candidate := range strings.Split(options, ",") {
+		if candidate == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package resultcache
+
+import (
+	"sync"
+	"time"
+
+	"email-intelligence/internal/models"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Cache stores analysis results by cache key. Each result is also indexed
+// under the address it is for, so the results of one address can be dropped
+// without walking the whole cache. A failing backend behaves like an empty
+// cache: lookups miss and writes are dropped.
+type Cache interface {
+	Get(key string) (*models.EmailIntelligence, bool)
+	Set(address, key string, value *models.EmailIntelligence, ttl time.Duration)
+	DeleteAddress(address string) // drop every result stored for address
+	Flush()                       // drop every result
+	Len() int                     // results held, or -1 when the backend cannot count them cheaply
+}
+
+// Memory is the in-process cache; results are not shared between replicas
+type Memory struct {
+	items *cache.Cache
+
+	mu        sync.Mutex
+	byAddress map[string]map[string]struct{} // address -> keys of its results
+}
+
+// memoryEntry is what Memory keeps under a key; the address lets an evicted
+// entry be removed from the index
+type memoryEntry struct {
+	address string
+	value   *models.EmailIntelligence
+}
+
+// NewMemory creates an in-process cache expiring entries after ttl by default
+func NewMemory(ttl time.Duration) *Memory {
+	m := &Memory{items: cache.New(ttl, ttl*2), byAddress: make(map[string]map[string]struct{})}
+	m.items.OnEvicted(m.unindex)
+	return m
+}
+
+// Get returns the cached result for key
+func (m *Memory) Get(key string) (*models.EmailIntelligence, bool) {
+	value, found := m.items.Get(key)
+	if !found {
+		return nil, false
+	}
+	entry, ok := value.(memoryEntry)
+	return entry.value, ok
+}
+
+// Set stores value under key for ttl
+func (m *Memory) Set(address, key string, value *models.EmailIntelligence, ttl time.Duration) {
+	m.items.Set(key, memoryEntry{address: address, value: value}, ttl)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys, ok := m.byAddress[address]
+	if !ok {
+		keys = make(map[string]struct{})
+		m.byAddress[address] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// DeleteAddress drops every result stored for address
+func (m *Memory) DeleteAddress(address string) {
+	m.mu.Lock()
+	keys := m.byAddress[address]
+	delete(m.byAddress, address)
+	m.mu.Unlock()
+	for key := range keys {
+		m.items.Delete(key)
+	}
+}
+
+// unindex removes an expired or deleted entry from the address index
+func (m *Memory) unindex(key string, value interface{}) {
+	entry, ok := value.(memoryEntry)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if keys, ok := m.byAddress[entry.address]; ok {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(m.byAddress, entry.address)
+		}
+	}
+}
+
+// Flush drops every result
+func (m *Memory) Flush() {
+	m.items.Flush()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byAddress = make(map[string]map[string]struct{})
+}
+
+// Len returns the number of results held, including expired ones not yet
+// cleaned up
+func (m *Memory) Len() int {
+	return m.items.ItemCount()
+}
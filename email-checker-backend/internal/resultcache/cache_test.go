@@ -0,0 +1,37 @@
+package resultcache
+
+import (
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+func TestMemoryDeleteAddress(t *testing.T) {
+	m := NewMemory(time.Minute)
+	result := &models.EmailIntelligence{Email: "jane@example.com"}
+	m.Set("jane@example.com", "jane@example.com|deep=false", result, time.Minute)
+	m.Set("jane@example.com", "jane@example.com|deep=true", result, time.Minute)
+	m.Set("jane@example.co", "jane@example.co|deep=false", result, time.Minute)
+
+	m.DeleteAddress("jane@example.com")
+	if m.Len() != 1 {
+		t.Errorf("%d results left, want 1", m.Len())
+	}
+	if got, ok := m.Get("jane@example.co|deep=false"); !ok || got != result {
+		t.Error("another address's result was deleted")
+	}
+	if len(m.byAddress) != 1 {
+		t.Errorf("index holds %d addresses, want 1", len(m.byAddress))
+	}
+
+	// Entries leaving the cache on their own leave the index too
+	m.Set("bob@example.com", "bob@example.com|deep=false", result, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	m.items.DeleteExpired()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.byAddress["bob@example.com"]; ok {
+		t.Error("an expired result is still indexed")
+	}
+}
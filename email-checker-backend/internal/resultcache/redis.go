@@ -0,0 +1,364 @@
+package resultcache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// keyPrefix namespaces results so the Redis database can be shared. The
+// version changes whenever the stored form does, so old entries are ignored.
+const keyPrefix = "email-intelligence:result:v2:"
+
+// addressPrefix namespaces the per-address sets holding the keys of an
+// address's results
+const addressPrefix = "email-intelligence:address:v2:"
+
+// After breakerThreshold consecutive connection failures, Redis is not
+// contacted for breakerCooldown: every call fails at once instead of waiting
+// out its timeout. The first call after the cooldown tries again.
+const (
+	breakerThreshold = 3
+	breakerCooldown  = 30 * time.Second
+)
+
+// errCircuitOpen is returned while the circuit breaker keeps calls away from Redis
+var errCircuitOpen = errors.New("redis unavailable, retrying later")
+
+// storedResult is the form a result is kept in: the JSON of the result leaves
+// out the evidence behind it, which a hit must carry as a memory hit does
+type storedResult struct {
+	Result        *models.EmailIntelligence   `json:"result"`
+	Transcript    []models.SMTPExchange       `json:"smtp_transcript,omitempty"`
+	TXTLookups    []models.TXTLookup          `json:"txt_lookups,omitempty"`
+	DKIMSelectors []models.DKIMSelectorResult `json:"dkim_selectors,omitempty"`
+}
+
+// Redis keeps results in Redis as JSON so every replica shares them. It
+// speaks RESP directly over a small pool of connections.
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+	timeout  time.Duration
+	pool     chan *redisConn
+
+	mu        sync.Mutex
+	failures  int       // consecutive connection failures
+	openUntil time.Time // calls fail fast until then
+	now       func() time.Time
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// NewRedis parses a redis:// or rediss:// URL, e.g.
+// redis://:password@host:6379/0. Connections are made on first use, so
+// Redis being down at startup only means cache misses.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported scheme %q, want redis or rediss", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	r := &Redis{
+		addr:    u.Host,
+		tls:     u.Scheme == "rediss",
+		timeout: 2 * time.Second,
+		pool:    make(chan *redisConn, 16),
+		now:     time.Now,
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid database %q", db)
+		}
+	}
+	return r, nil
+}
+
+// Get returns the cached result for key; errors count as a miss
+func (r *Redis) Get(key string) (*models.EmailIntelligence, bool) {
+	reply, err := r.do("GET", keyPrefix+key)
+	data, ok := reply.([]byte)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var stored storedResult
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Result == nil {
+		return nil, false
+	}
+	intelligence := stored.Result
+	intelligence.SMTPValidation.Transcript = stored.Transcript
+	intelligence.SecurityAnalysis.TXTLookups = stored.TXTLookups
+	intelligence.SecurityAnalysis.DKIMSelectors = stored.DKIMSelectors
+	return intelligence, true
+}
+
+// Set stores value under key for ttl and adds key to the set of address's
+// results; errors are dropped. The set's expiry is pushed out to ttl on
+// every write, so with one TTL for all results it outlives its members.
+func (r *Redis) Set(address, key string, value *models.EmailIntelligence, ttl time.Duration) {
+	data, err := json.Marshal(storedResult{
+		Result:        value,
+		Transcript:    value.SMTPValidation.Transcript,
+		TXTLookups:    value.SecurityAnalysis.TXTLookups,
+		DKIMSelectors: value.SecurityAnalysis.DKIMSelectors,
+	})
+	if err != nil {
+		return
+	}
+	millis := strconv.FormatInt(ttl.Milliseconds(), 10)
+	if _, err := r.do("SET", keyPrefix+key, string(data), "PX", millis); err != nil {
+		return
+	}
+	if _, err := r.do("SADD", addressPrefix+address, keyPrefix+key); err != nil {
+		return
+	}
+	r.do("PEXPIRE", addressPrefix+address, millis)
+}
+
+// DeleteAddress drops every result stored for address, with the set that
+// lists them
+func (r *Redis) DeleteAddress(address string) {
+	reply, err := r.do("SMEMBERS", addressPrefix+address)
+	members, ok := reply.([]interface{})
+	if err != nil || !ok {
+		return
+	}
+	args := []string{"DEL", addressPrefix + address}
+	for _, member := range members {
+		args = append(args, string(asBytes(member)))
+	}
+	r.do(args...)
+}
+
+// Flush drops every result written by this service
+func (r *Redis) Flush() {
+	r.deleteMatching(keyPrefix + "*")
+	r.deleteMatching(addressPrefix + "*")
+}
+
+// Len returns -1: counting means scanning the whole keyspace, and the
+// database may hold keys of other services
+func (r *Redis) Len() int {
+	return -1
+}
+
+// deleteMatching walks the keyspace with SCAN, which unlike KEYS does not
+// block the server, and deletes matches batch by batch
+func (r *Redis) deleteMatching(pattern string) {
+	cursor := "0"
+	for {
+		reply, err := r.do("SCAN", cursor, "MATCH", pattern, "COUNT", "500")
+		page, ok := reply.([]interface{})
+		if err != nil || !ok || len(page) != 2 {
+			return
+		}
+		cursor = string(asBytes(page[0]))
+		keys, _ := page[1].([]interface{})
+		if len(keys) > 0 {
+			args := []string{"DEL"}
+			for _, key := range keys {
+				args = append(args, string(asBytes(key)))
+			}
+			r.do(args...)
+		}
+		if cursor == "0" {
+			return
+		}
+	}
+}
+
+// do sends one command and returns its reply. Connections with I/O errors
+// are discarded; error replies leave the connection usable.
+func (r *Redis) do(args ...string) (interface{}, error) {
+	if !r.allow() {
+		return nil, errCircuitOpen
+	}
+	c, err := r.conn()
+	if err != nil {
+		r.record(err)
+		return nil, err
+	}
+	c.conn.SetDeadline(time.Now().Add(r.timeout))
+	reply, err := c.roundTrip(args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		c.conn.Close()
+		r.record(err)
+		return nil, err
+	}
+	r.record(nil)
+	select {
+	case r.pool <- c:
+	default:
+		c.conn.Close()
+	}
+	return reply, err
+}
+
+// allow reports whether a call may go to Redis: always, unless the circuit
+// breaker is open
+func (r *Redis) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.now().Before(r.openUntil)
+}
+
+// record counts a call's outcome towards the circuit breaker. Error replies
+// count as success: the server answered.
+func (r *Redis) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.failures >= breakerThreshold {
+		if !r.now().Before(r.openUntil) {
+			log.Printf("redis cache: %v; skipping Redis for %s", err, breakerCooldown)
+		}
+		r.openUntil = r.now().Add(breakerCooldown)
+	}
+}
+
+func (r *Redis) conn() (*redisConn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: r.timeout}
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", r.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(r.timeout))
+
+	if r.password != "" {
+		auth := []string{"AUTH", r.password}
+		if r.username != "" {
+			auth = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := c.roundTrip(auth); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.roundTrip([]string{"SELECT", strconv.Itoa(r.db)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// roundTrip writes a command as a RESP array of bulk strings and reads the reply
+func (c *redisConn) roundTrip(args []string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply parses one RESP2 reply: simple strings and integers as strings,
+// bulk strings as []byte (nil when absent), arrays as []interface{}
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
+
+func asBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package resultcache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// fakeRedis answers the string and set commands the cache sends from maps.
+// Expiry is accepted and ignored.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	data     map[string]string
+	sets     map[string]map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{listener: listener, data: map[string]string{}, sets: map[string]map[string]bool{}}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) url() string {
+	return "redis://" + f.listener.Addr().String() + "/0"
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			if value, ok := f.data[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+			} else {
+				io.WriteString(conn, "$-1\r\n")
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			io.WriteString(conn, "+OK\r\n")
+		case "SADD":
+			if f.sets[args[1]] == nil {
+				f.sets[args[1]] = map[string]bool{}
+			}
+			for _, member := range args[2:] {
+				f.sets[args[1]][member] = true
+			}
+			io.WriteString(conn, ":1\r\n")
+		case "PEXPIRE":
+			io.WriteString(conn, ":1\r\n")
+		case "SMEMBERS":
+			fmt.Fprintf(conn, "*%d\r\n", len(f.sets[args[1]]))
+			for member := range f.sets[args[1]] {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(member), member)
+			}
+		case "DEL":
+			for _, key := range args[1:] {
+				delete(f.data, key)
+				delete(f.sets, key)
+			}
+			io.WriteString(conn, ":1\r\n")
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command %s\r\n", args[0])
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisKeepsDebugEvidence(t *testing.T) {
+	server := newFakeRedis(t)
+	r, err := NewRedis(server.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &models.EmailIntelligence{Email: "jane@example.com", ValidationScore: 87}
+	want.SMTPValidation.Transcript = []models.SMTPExchange{{Reply: "220 mx.example.com ESMTP"}, {Command: "RCPT TO:<jane@example.com>", Reply: "250 OK"}}
+	want.SecurityAnalysis.TXTLookups = []models.TXTLookup{{Name: "example.com", Records: []string{"v=spf1 -all"}}}
+	want.SecurityAnalysis.DKIMSelectors = []models.DKIMSelectorResult{{Domain: "example.com", Selector: "s1", Status: "not_found"}}
+
+	r.Set("jane@example.com", "jane@example.com|deep=true", want, time.Minute)
+	got, ok := r.Get("jane@example.com|deep=true")
+	if !ok {
+		t.Fatal("miss after Set")
+	}
+	if got.Email != want.Email || got.ValidationScore != want.ValidationScore {
+		t.Errorf("result = %s %d, want %s %d", got.Email, got.ValidationScore, want.Email, want.ValidationScore)
+	}
+	if !reflect.DeepEqual(got.SMTPValidation.Transcript, want.SMTPValidation.Transcript) {
+		t.Errorf("transcript = %+v", got.SMTPValidation.Transcript)
+	}
+	if !reflect.DeepEqual(got.SecurityAnalysis.TXTLookups, want.SecurityAnalysis.TXTLookups) {
+		t.Errorf("TXT lookups = %+v", got.SecurityAnalysis.TXTLookups)
+	}
+	if !reflect.DeepEqual(got.SecurityAnalysis.DKIMSelectors, want.SecurityAnalysis.DKIMSelectors) {
+		t.Errorf("DKIM selectors = %+v", got.SecurityAnalysis.DKIMSelectors)
+	}
+}
+
+func TestRedisIgnoresEntriesInAnOlderForm(t *testing.T) {
+	server := newFakeRedis(t)
+	r, _ := NewRedis(server.url())
+	server.data[keyPrefix+"old"] = `{"email":"jane@example.com"}`
+	if _, ok := r.Get("old"); ok {
+		t.Error("an entry without the stored envelope was used")
+	}
+}
+
+func TestRedisDeleteAddress(t *testing.T) {
+	server := newFakeRedis(t)
+	r, _ := NewRedis(server.url())
+	result := &models.EmailIntelligence{Email: "jane@example.com"}
+	r.Set("jane@example.com", "jane@example.com|deep=false", result, time.Minute)
+	r.Set("jane@example.com", "jane@example.com|deep=true", result, time.Minute)
+	r.Set("jane@example.co", "jane@example.co|deep=false", result, time.Minute)
+
+	r.DeleteAddress("jane@example.com")
+	for _, key := range []string{"jane@example.com|deep=false", "jane@example.com|deep=true"} {
+		if _, ok := r.Get(key); ok {
+			t.Errorf("%s survived DeleteAddress", key)
+		}
+	}
+	if _, ok := r.Get("jane@example.co|deep=false"); !ok {
+		t.Error("another address's result was deleted")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if _, ok := server.sets[addressPrefix+"jane@example.com"]; ok {
+		t.Error("the address's key set was left behind")
+	}
+}
+
+func TestRedisCircuitBreaker(t *testing.T) {
+	// A port nothing listens on
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+
+	r, _ := NewRedis("redis://" + addr)
+	clock := time.Now()
+	r.now = func() time.Time { return clock }
+
+	for i := 0; i < breakerThreshold; i++ {
+		if _, err := r.do("GET", "x"); err == nil || err == errCircuitOpen {
+			t.Fatalf("call %d: err = %v, want a connection error", i, err)
+		}
+	}
+	if _, err := r.do("GET", "x"); err != errCircuitOpen {
+		t.Fatalf("err = %v after %d failures, want the breaker open", err, breakerThreshold)
+	}
+
+	// After the cooldown one call tries again; its failure reopens the breaker
+	clock = clock.Add(breakerCooldown)
+	if _, err := r.do("GET", "x"); err == nil || err == errCircuitOpen {
+		t.Fatalf("err = %v after the cooldown, want a fresh attempt", err)
+	}
+	if _, err := r.do("GET", "x"); err != errCircuitOpen {
+		t.Fatalf("err = %v, want the breaker open again", err)
+	}
+
+	// Once Redis answers, the breaker closes
+	server := newFakeRedis(t)
+	r.addr = server.listener.Addr().String()
+	clock = clock.Add(breakerCooldown)
+	r.Set("jane@example.com", "k", &models.EmailIntelligence{Email: "jane@example.com"}, time.Minute)
+	for i := 0; i < breakerThreshold+1; i++ {
+		if _, ok := r.Get("k"); !ok {
+			t.Fatalf("call %d: miss with Redis back", i)
+		}
+	}
+}
+
+func TestNewRedisRejectsUnusableURLs(t *testing.T) {
+	for _, raw := range []string{"localhost:6379", "http://localhost:6379", "redis://", "redis://localhost/zero", "redis://%zz"} {
+		if _, err := NewRedis(raw); err == nil {
+			t.Errorf("NewRedis(%q) accepted", raw)
+		}
+	}
+	if _, err := NewRedis("rediss://:secret@cache.internal:6380/2"); err != nil {
+		t.Errorf("NewRedis rejected a valid URL: %v", err)
+	}
+}
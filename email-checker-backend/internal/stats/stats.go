@@ -0,0 +1,187 @@
+// Package stats aggregates finished analyses per domain in memory, for the
+// /stats endpoint. Domains are kept in a bounded LRU so high-cardinality
+// traffic cannot grow memory without limit.
+package stats
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/models"
+	"email-intelligence/internal/validators"
+)
+
+// DefaultMaxDomains bounds the tracked domains when NewAggregator is given 0
+const DefaultMaxDomains = 10000
+
+// window is the longest period request counts are kept for, in minute buckets
+const window = 60
+
+// Aggregator counts analyses per domain and per minute. It is safe for
+// concurrent use.
+type Aggregator struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is the most recently seen domain
+	domains map[string]*list.Element
+	total   int64
+	since   time.Time
+	minutes [window]minuteBucket
+}
+
+type domainEntry struct {
+	domain     string
+	analyses   int64
+	scoreSum   int64
+	valid      int64
+	disposable int64
+	lastSeen   time.Time
+}
+
+// minuteBucket counts the analyses of one wall-clock minute
+type minuteBucket struct {
+	minute int64 // Unix minute the count belongs to
+	count  int64
+}
+
+// NewAggregator creates an aggregator tracking at most maxDomains domains
+func NewAggregator(maxDomains int) *Aggregator {
+	if maxDomains < 1 {
+		maxDomains = DefaultMaxDomains
+	}
+	return &Aggregator{
+		size:    maxDomains,
+		order:   list.New(),
+		domains: make(map[string]*list.Element),
+		since:   time.Now(),
+	}
+}
+
+// Record adds one finished analysis. A nil result is a failed analysis: it
+// counts towards the request totals but not towards any domain.
+func (a *Aggregator) Record(intelligence *models.EmailIntelligence) {
+	now := time.Now()
+	minute := now.Unix() / 60
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.total++
+	bucket := &a.minutes[minute%window]
+	if bucket.minute != minute {
+		*bucket = minuteBucket{minute: minute}
+	}
+	bucket.count++
+
+	if intelligence == nil {
+		return
+	}
+	domain := resultDomain(intelligence)
+	if domain == "" {
+		return
+	}
+
+	entry := a.entry(domain)
+	entry.analyses++
+	entry.scoreSum += int64(intelligence.ValidationScore)
+	if intelligence.IsValid {
+		entry.valid++
+	}
+	if intelligence.DomainIntelligence.DisposableLevel == validators.DisposableConfirmed {
+		entry.disposable++
+	}
+	entry.lastSeen = now
+}
+
+// entry returns the domain's counters, creating them and evicting the least
+// recently seen domain when the LRU is full. The caller holds a.mu.
+func (a *Aggregator) entry(domain string) *domainEntry {
+	if element, ok := a.domains[domain]; ok {
+		a.order.MoveToFront(element)
+		return element.Value.(*domainEntry)
+	}
+	entry := &domainEntry{domain: domain}
+	a.domains[domain] = a.order.PushFront(entry)
+	if a.order.Len() > a.size {
+		oldest := a.order.Back()
+		a.order.Remove(oldest)
+		delete(a.domains, oldest.Value.(*domainEntry).domain)
+	}
+	return entry
+}
+
+// resultDomain is the registrable domain of an analyzed address, or its
+// domain as given when the analysis stopped before classifying it
+func resultDomain(intelligence *models.EmailIntelligence) string {
+	if domain := intelligence.DomainIntelligence.RegistrableDomain; domain != "" {
+		return domain
+	}
+	at := strings.LastIndex(intelligence.Email, "@")
+	if at == -1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(intelligence.Email[at+1:]))
+}
+
+// Snapshot returns the request counts and the top domains by volume, and
+// the top disposable domains by disposable verdicts
+func (a *Aggregator) Snapshot(top int) models.AnalysisStats {
+	now := time.Now()
+	minute := now.Unix() / 60
+
+	a.mu.Lock()
+	snapshot := models.AnalysisStats{
+		Since:          a.since,
+		TotalAnalyses:  a.total,
+		TrackedDomains: len(a.domains),
+	}
+	for _, bucket := range a.minutes {
+		if age := minute - bucket.minute; age >= 0 && age < window {
+			snapshot.RequestsLastHour += bucket.count
+			if age == 0 {
+				snapshot.RequestsLastMinute += bucket.count
+			}
+		}
+	}
+	domains := make([]models.DomainStats, 0, len(a.domains))
+	for element := a.order.Front(); element != nil; element = element.Next() {
+		domains = append(domains, element.Value.(*domainEntry).stats())
+	}
+	a.mu.Unlock()
+
+	sort.SliceStable(domains, func(i, j int) bool { return domains[i].Analyses > domains[j].Analyses })
+	snapshot.TopDomains = first(domains, top)
+
+	disposable := make([]models.DomainStats, 0)
+	for _, domain := range domains {
+		if domain.Disposable > 0 {
+			disposable = append(disposable, domain)
+		}
+	}
+	sort.SliceStable(disposable, func(i, j int) bool { return disposable[i].Disposable > disposable[j].Disposable })
+	snapshot.TopDisposableDomains = first(disposable, top)
+	return snapshot
+}
+
+func (e *domainEntry) stats() models.DomainStats {
+	return models.DomainStats{
+		Domain:       e.domain,
+		Analyses:     e.analyses,
+		AverageScore: float64(e.scoreSum) / float64(e.analyses),
+		Valid:        e.valid,
+		Invalid:      e.analyses - e.valid,
+		ValidRatio:   float64(e.valid) / float64(e.analyses),
+		Disposable:   e.disposable,
+		LastSeen:     e.lastSeen,
+	}
+}
+
+func first(domains []models.DomainStats, n int) []models.DomainStats {
+	if len(domains) > n {
+		return domains[:n]
+	}
+	return domains
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"email-intelligence/internal/logging"
+	"email-intelligence/internal/metrics"
+
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS analysis_results (
+	id               BIGSERIAL PRIMARY KEY,
+	email            TEXT        NOT NULL,
+	domain           TEXT        NOT NULL,
+	is_valid         BOOLEAN     NOT NULL,
+	validation_score INTEGER     NOT NULL,
+	risk_category    TEXT        NOT NULL,
+	quality_tier     TEXT        NOT NULL,
+	confidence_level TEXT        NOT NULL,
+	analysis_depth   TEXT        NOT NULL,
+	caller_id        TEXT        NOT NULL,
+	analyzed_at      TIMESTAMPTZ NOT NULL,
+	result           JSONB
+);
+CREATE INDEX IF NOT EXISTS analysis_results_domain_time_idx
+	ON analysis_results (domain, analyzed_at DESC);
+`
+
+// PostgresStore writes records to Postgres from a background goroutine. Save
+// hands records to a bounded buffer; when it is full, records are dropped and
+// counted rather than slowing down the request path. The count is exported as
+// email_intelligence_result_store_dropped_records.
+type PostgresStore struct {
+	db      *sql.DB
+	queue   chan Record
+	dropped atomic.Int64
+	wg      sync.WaitGroup
+	closed  bool         // set by Close; later records are dropped
+	mu      sync.RWMutex // guards closed and sends on queue
+}
+
+// NewPostgresStore connects, ensures the schema exists and starts the writer
+func NewPostgresStore(dsn string, bufferSize int) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	s := &PostgresStore{
+		db:    db,
+		queue: make(chan Record, bufferSize),
+	}
+	metrics.NewGaugeFunc("email_intelligence_result_store_dropped_records", "Results dropped instead of written to the result store.", func() float64 {
+		return float64(s.dropped.Load())
+	})
+	s.wg.Add(1)
+	go s.writeLoop()
+	return s, nil
+}
+
+// Save queues a record without blocking. Records saved after Close are dropped.
+func (s *PostgresStore) Save(record Record) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.queue <- record:
+	default:
+		if dropped := s.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			slog.Warn("result store buffer full", "dropped", dropped)
+		}
+	}
+}
+
+// RecentByDomain returns the newest records for a domain
+func (s *PostgresStore) RecentByDomain(ctx context.Context, domain string, limit int) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT email, domain, is_valid, validation_score, risk_category, quality_tier,
+		       confidence_level, analysis_depth, caller_id, analyzed_at, result
+		FROM analysis_results
+		WHERE domain = $1
+		ORDER BY analyzed_at DESC
+		LIMIT $2`, domain, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []Record{}
+	for rows.Next() {
+		var record Record
+		var result []byte
+		if err := rows.Scan(&record.Email, &record.Domain, &record.IsValid, &record.ValidationScore,
+			&record.RiskCategory, &record.QualityTier, &record.ConfidenceLevel, &record.AnalysisDepth,
+			&record.CallerID, &record.AnalyzedAt, &result); err != nil {
+			return nil, err
+		}
+		// An unreadable result leaves the record's summary columns usable
+		if len(result) > 0 {
+			if err := json.Unmarshal(result, &record.Result); err != nil {
+				logging.FromContext(ctx).Warn("stored result unreadable", "domain", domain, "analyzed_at", record.AnalyzedAt, "error", err.Error())
+				record.Result = nil
+			}
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// Close stops accepting records, writes what is queued and closes the database
+func (s *PostgresStore) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return s.db.Close()
+}
+
+func (s *PostgresStore) writeLoop() {
+	defer s.wg.Done()
+
+	for record := range s.queue {
+		if err := s.insert(record); err != nil {
+			slog.Error("result store write failed", "domain", record.Domain, "error", err.Error())
+		}
+	}
+}
+
+func (s *PostgresStore) insert(record Record) error {
+	var result []byte
+	if record.Result != nil {
+		encoded, err := json.Marshal(record.Result)
+		if err != nil {
+			return err
+		}
+		result = encoded
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO analysis_results (email, domain, is_valid, validation_score, risk_category,
+			quality_tier, confidence_level, analysis_depth, caller_id, analyzed_at, result)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		record.Email, record.Domain, record.IsValid, record.ValidationScore, record.RiskCategory,
+		record.QualityTier, record.ConfidenceLevel, record.AnalysisDepth, record.CallerID,
+		record.AnalyzedAt, result)
+	return err
+}
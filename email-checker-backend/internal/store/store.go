@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// ErrNotConfigured is returned by queries when no storage backend is set up
+var ErrNotConfigured = errors.New("result storage is not configured")
+
+// Record is the persisted, privacy-conscious view of one analysis
+type Record struct {
+	Email           string                    `json:"email"`
+	Domain          string                    `json:"domain"`
+	IsValid         bool                      `json:"is_valid"`
+	ValidationScore int                       `json:"validation_score"`
+	RiskCategory    string                    `json:"risk_category"`
+	QualityTier     string                    `json:"quality_tier"`
+	ConfidenceLevel string                    `json:"confidence_level"`
+	AnalysisDepth   string                    `json:"analysis_depth"`
+	CallerID        string                    `json:"caller_id"`
+	AnalyzedAt      time.Time                 `json:"analyzed_at"`
+	Result          *models.EmailIntelligence `json:"result,omitempty"` // only when full results are stored
+}
+
+// ResultStore persists analysis results for auditing and analytics
+type ResultStore interface {
+	// Save queues a record for writing; it must never block the caller
+	Save(record Record)
+	// RecentByDomain returns the newest records for a domain
+	RecentByDomain(ctx context.Context, domain string, limit int) ([]Record, error)
+	// Close flushes queued records and releases resources
+	Close() error
+}
+
+// NewRecord builds a record from a result. The full result is kept only when
+// includeResult is set, since it carries server responses and DNS details.
+func NewRecord(intelligence *models.EmailIntelligence, callerID string, includeResult bool) Record {
+	record := Record{
+		Email:           intelligence.Email,
+		Domain:          intelligence.DomainIntelligence.RegistrableDomain,
+		IsValid:         intelligence.IsValid,
+		ValidationScore: intelligence.ValidationScore,
+		RiskCategory:    intelligence.RiskCategory,
+		QualityTier:     intelligence.QualityTier,
+		ConfidenceLevel: intelligence.ConfidenceLevel,
+		AnalysisDepth:   intelligence.AnalysisDepth,
+		CallerID:        callerID,
+		AnalyzedAt:      intelligence.Timestamp,
+	}
+	if includeResult {
+		record.Result = intelligence
+	}
+	return record
+}
+
+// NopStore discards records; it is used when no database is configured
+type NopStore struct{}
+
+// Save discards the record
+func (NopStore) Save(Record) {}
+
+// RecentByDomain always reports that storage is not configured
+func (NopStore) RecentByDomain(context.Context, string, int) ([]Record, error) {
+	return nil, ErrNotConfigured
+}
+
+// Close does nothing
+func (NopStore) Close() error { return nil }
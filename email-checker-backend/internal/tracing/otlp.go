@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Batching limits for the exporter. Spans beyond the queue size are dropped
+// rather than slowing down analyses.
+const (
+	queueSize     = 4096
+	batchSize     = 512
+	flushInterval = 5 * time.Second
+)
+
+// otlpExporter sends finished spans in batches to an OTLP/HTTP endpoint
+// using the JSON encoding
+type otlpExporter struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	queue    chan *Span
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+func newOTLPExporter(opts Options) *otlpExporter {
+	if opts.ServiceName == "" {
+		opts.ServiceName = "email-intelligence"
+	}
+	e := &otlpExporter{
+		endpoint: opts.Endpoint,
+		headers:  opts.Headers,
+		service:  opts.ServiceName,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan *Span, queueSize),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *otlpExporter) enqueue(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+	}
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) == batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown exports queued spans and stops the exporter
+func (e *otlpExporter) shutdown(ctx context.Context) error {
+	close(e.done)
+	select {
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// export posts one batch; failures are dropped, tracing must never affect analyses
+func (e *otlpExporter) export(batch []*Span) {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// OTLP JSON payload (ExportTraceServiceRequest). IDs are hex encoded and
+// timestamps are decimal strings of Unix nanoseconds.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0 unset, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+func (e *otlpExporter) request(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, span.otlp())
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: e.service}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "email-intelligence/internal/tracing"},
+			Spans: spans,
+		}},
+	}}}
+}
+
+func (s *Span) otlp() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.ctx.traceID[:]),
+		SpanID:            hex.EncodeToString(s.ctx.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for key, value := range s.attributes {
+		span.Attributes = append(span.Attributes, otlpAttribute{Key: key, Value: otlpValue{StringValue: value}})
+	}
+	if s.errMessage != "" {
+		span.Status = otlpStatus{Code: 2, Message: s.errMessage}
+	}
+	return span
+}
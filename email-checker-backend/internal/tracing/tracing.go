@@ -0,0 +1,214 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Options configures trace export. An empty Endpoint disables tracing and
+// every call in this package becomes a no-op.
+type Options struct {
+	Endpoint    string            // OTLP/HTTP traces URL, e.g. http://collector:4318/v1/traces
+	Headers     map[string]string // extra request headers, e.g. an API key for a hosted backend
+	ServiceName string            // service.name resource attribute
+}
+
+// Span kinds as numbered by OTLP
+const (
+	kindInternal = 1
+	kindServer   = 2
+)
+
+// exporter is the active exporter; nil while tracing is disabled
+var exporter atomic.Pointer[otlpExporter]
+
+// Init starts exporting spans and returns a function that flushes pending
+// spans and stops the exporter. With no endpoint it does nothing.
+func Init(opts Options) func(context.Context) error {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+	e := newOTLPExporter(opts)
+	exporter.Store(e)
+	return func(ctx context.Context) error {
+		exporter.Store(nil)
+		return e.shutdown(ctx)
+	}
+}
+
+// spanContext identifies a span within a trace
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+// Span is one timed operation. All methods are safe on a nil span, which is
+// what Start returns while tracing is disabled.
+type Span struct {
+	name       string
+	kind       int
+	ctx        spanContext
+	parentID   [8]byte
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	errMessage string
+	exporter   *otlpExporter
+	mu         sync.Mutex
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// Start begins a span named name as a child of the span (or incoming trace
+// context) in ctx. The returned context carries the new span, so spans started
+// from it in other goroutines nest under it.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return start(ctx, name, kindInternal)
+}
+
+func start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	e := exporter.Load()
+	if e == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: map[string]string{},
+		exporter:   e,
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.ctx.traceID = parent.ctx.traceID
+		span.ctx.sampled = parent.ctx.sampled
+		span.parentID = parent.ctx.spanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(spanContext); ok {
+		span.ctx.traceID = remote.traceID
+		span.ctx.sampled = remote.sampled
+		span.parentID = remote.spanID
+	} else {
+		rand.Read(span.ctx.traceID[:])
+		span.ctx.sampled = true
+	}
+	rand.Read(span.ctx.spanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute records a string attribute on the span
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// RecordError marks the span as failed with err's message; nil is ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = err.Error()
+}
+
+// End finishes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.ctx.sampled {
+		s.exporter.enqueue(s)
+	}
+}
+
+// Extract returns ctx carrying the caller's trace context from a W3C
+// traceparent header, so spans started from it join the caller's trace
+func Extract(ctx context.Context, header http.Header) context.Context {
+	remote, ok := parseTraceparent(header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, remote)
+}
+
+// parseTraceparent parses "00-<trace-id>-<parent-id>-<flags>"
+func parseTraceparent(value string) (spanContext, bool) {
+	var sc spanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil || sc.traceID == [16]byte{} {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil || sc.spanID == [8]byte{} {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.sampled = flags[0]&1 == 1
+	return sc, true
+}
+
+// traceparent formats the W3C header value for a span
+func (s *Span) traceparent() string {
+	flags := "00"
+	if s.ctx.sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(s.ctx.traceID[:]) + "-" + hex.EncodeToString(s.ctx.spanID[:]) + "-" + flags
+}
+
+// Middleware starts a server span per request, joined to the caller's trace
+// when a traceparent header is present, and stores it in the request context
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exporter.Load() == nil {
+			c.Next()
+			return
+		}
+
+		ctx := Extract(c.Request.Context(), c.Request.Header)
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := start(ctx, c.Request.Method+" "+route, kindServer)
+		span.SetAttribute("http.request.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		c.Header("traceparent", span.traceparent())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.response.status_code", strconv.Itoa(status))
+		if status >= 500 {
+			span.mu.Lock()
+			span.errMessage = http.StatusText(status)
+			span.mu.Unlock()
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,100 @@
+package validators
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// mxBackoff tracks consecutive refusals per MX host and pauses probing a host
+// that appears to be throttling or blocking us. Once the cooldown expires the
+// next validation probes the host again; another failure restarts the cooldown.
+// A host that has neither failed nor been blocked for a full cooldown is
+// forgotten, so bulk runs over many domains do not grow the table without limit.
+type mxBackoff struct {
+	threshold int
+	cooldown  time.Duration
+	hosts     map[string]*mxHostState
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+type mxHostState struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// expired reports whether the state says nothing any more at now
+func (s *mxHostState) expired(now time.Time, cooldown time.Duration) bool {
+	last := s.lastFailure
+	if s.blockedUntil.After(last) {
+		last = s.blockedUntil
+	}
+	return now.Sub(last) > cooldown
+}
+
+func newMXBackoff(threshold int, cooldown time.Duration) *mxBackoff {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &mxBackoff{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*mxHostState),
+	}
+}
+
+// blocked reports whether host is cooling down and until when
+func (b *mxBackoff) blocked(host string) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.hosts[strings.ToLower(host)]
+	if !ok || !time.Now().Before(state.blockedUntil) {
+		return time.Time{}, false
+	}
+	return state.blockedUntil, true
+}
+
+// failure records a refused connection or 421 reply from host
+func (b *mxBackoff) failure(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.prune(now)
+
+	host = strings.ToLower(host)
+	state, ok := b.hosts[host]
+	if !ok || state.expired(now, b.cooldown) {
+		state = &mxHostState{}
+		b.hosts[host] = state
+	}
+	state.failures++
+	state.lastFailure = now
+	if state.failures >= b.threshold {
+		state.blockedUntil = now.Add(b.cooldown)
+	}
+}
+
+// prune drops expired hosts, at most once per cooldown. b.mu must be held.
+func (b *mxBackoff) prune(now time.Time) {
+	if now.Sub(b.lastPrune) < b.cooldown {
+		return
+	}
+	b.lastPrune = now
+	for host, state := range b.hosts {
+		if state.expired(now, b.cooldown) {
+			delete(b.hosts, host)
+		}
+	}
+}
+
+// success clears the failure run for host
+func (b *mxBackoff) success(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.hosts, strings.ToLower(host))
+}
@@ -0,0 +1,108 @@
+package validators
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"email-intelligence/internal/models"
+)
+
+// BIMIRecord is a domain's Brand Indicators for Message Identification record
+// at default._bimi.<domain>. Mailbox providers only show the logo for mail
+// that passes an enforcing DMARC policy, so a record signals a brand that has
+// invested in authentication.
+type BIMIRecord struct {
+	Result    models.ValidationResult
+	LogoURL   string // l=, an SVG served over HTTPS
+	Authority string // a=, the verified mark certificate; empty when self-asserted
+}
+
+// bimiNotChecked is reported when the lookup was skipped, e.g. outside deep analysis
+var bimiNotChecked = models.ValidationResult{
+	Status:    "unknown",
+	Reason:    "BIMI is only checked in deep analysis",
+	RawSignal: "bimi_not_checked",
+	Score:     0,
+	Weight:    0,
+}
+
+// CheckBIMI looks up the default BIMI record of domain. It runs beside
+// Validate and never affects the security score.
+func (v *SecurityValidator) CheckBIMI(ctx context.Context, domain string) BIMIRecord {
+	if v.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.timeout)
+		defer cancel()
+	}
+	return v.lookupBIMI(ctx, domain)
+}
+
+func (v *SecurityValidator) lookupBIMI(ctx context.Context, domain string) BIMIRecord {
+	records, err := v.lookupTXT(ctx, "default._bimi."+domain, &txtTrace{})
+	if err != nil && ctx.Err() != nil {
+		return BIMIRecord{Result: incompleteLookup("BIMI", 0)}
+	}
+	if lookupFailed(err) {
+		return BIMIRecord{Result: dnsErrorResult("BIMI", 0, err)}
+	}
+	if err == nil {
+		for _, record := range records {
+			if strings.HasPrefix(record, "v=BIMI1") {
+				return parseBIMI(record)
+			}
+		}
+	}
+
+	return BIMIRecord{Result: models.ValidationResult{
+		Status:    "fail",
+		Reason:    "No BIMI record found",
+		RawSignal: "no_bimi_record",
+		Score:     0,
+		Weight:    0,
+	}}
+}
+
+// parseBIMI reads the l= and a= tags of a BIMI record. A record with an empty
+// l= declares that the domain publishes no logo.
+func parseBIMI(record string) BIMIRecord {
+	bimi := BIMIRecord{}
+	for _, tag := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "l":
+			bimi.LogoURL = strings.TrimSpace(value)
+		case "a":
+			bimi.Authority = strings.TrimSpace(value)
+		}
+	}
+
+	bimi.Result = models.ValidationResult{
+		Status:    "pass",
+		Reason:    "BIMI record found (self-asserted logo)",
+		RawSignal: record,
+		Score:     0,
+		Weight:    0,
+	}
+	if bimi.Authority != "" {
+		bimi.Result.Reason = "BIMI record found with a verified mark certificate"
+	}
+	if bimi.LogoURL == "" {
+		bimi.Result.Status = "fail"
+		bimi.Result.Reason = "BIMI record declines to publish a logo"
+	} else if logo, err := url.Parse(bimi.LogoURL); err != nil || logo.Scheme != "https" {
+		bimi.Result.Status = "fail"
+		bimi.Result.Reason = "BIMI logo is not an HTTPS URL"
+	}
+	return bimi
+}
+
+// ApplyBIMI records a BIMI lookup made beside Validate
+func (v *SecurityValidator) ApplyBIMI(result *models.SecurityAnalysisResult, bimi BIMIRecord) {
+	result.BIMIRecord = bimi.Result
+	result.BIMILogoURL = bimi.LogoURL
+	result.BIMIAuthority = bimi.Authority
+}
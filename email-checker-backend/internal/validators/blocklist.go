@@ -0,0 +1,116 @@
+package validators
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// maxBlocklistAddrs caps how many of a domain's addresses are looked up, so a
+// domain with a large round-robin A set costs a bounded number of queries
+const maxBlocklistAddrs = 4
+
+// BlocklistChecker looks up a domain's mail server IPv4 addresses in DNS
+// blocklists (DNSBLs). Queries go through the shared caching resolver, so bulk runs
+// against one domain or hosting provider reuse earlier answers.
+type BlocklistChecker struct {
+	resolver Resolver
+	zones    []string
+	timeout  time.Duration
+}
+
+// NewBlocklistChecker creates a checker for the given zones; with no zones
+// Check never reports a listing
+func NewBlocklistChecker(resolver Resolver, zones []string, timeout time.Duration) *BlocklistChecker {
+	return &BlocklistChecker{resolver: resolver, zones: zones, timeout: timeout}
+}
+
+// Enabled reports whether any zones are configured
+func (c *BlocklistChecker) Enabled() bool {
+	return len(c.zones) > 0
+}
+
+// Check returns the zones, sorted, that list any of the domain's mail server
+// addresses. A domain without resolved MX hosts receives mail at its own
+// address (RFC 5321 implicit MX), so its A records are checked instead.
+func (c *BlocklistChecker) Check(ctx context.Context, domain string, mxDetails []models.MXRecord) []string {
+	if len(c.zones) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	addrs := []string{}
+	for _, mx := range mxDetails {
+		addrs = append(addrs, mx.IPv4...)
+	}
+	if len(addrs) == 0 {
+		var err error
+		if addrs, err = c.resolver.LookupHost(ctx, domain); err != nil {
+			return nil
+		}
+	}
+	reversed := []string{}
+	for _, addr := range addrs {
+		if name, ok := reverseIPv4(addr); ok && len(reversed) < maxBlocklistAddrs {
+			reversed = append(reversed, name)
+		}
+	}
+
+	listed := map[string]bool{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, zone := range c.zones {
+		for _, name := range reversed {
+			wg.Add(1)
+			go func(zone, name string) {
+				defer wg.Done()
+				if c.listed(ctx, name+"."+zone) {
+					mu.Lock()
+					listed[zone] = true
+					mu.Unlock()
+				}
+			}(zone, name)
+		}
+	}
+	wg.Wait()
+
+	zones := make([]string, 0, len(listed))
+	for zone := range listed {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// listed reports whether a DNSBL query name has a listing answer. Lists answer
+// 127.0.0.x for listed addresses and NXDOMAIN otherwise; Spamhaus answers
+// 127.255.255.x when it refuses the query (e.g. from a public resolver),
+// which says nothing about the address.
+func (c *BlocklistChecker) listed(ctx context.Context, name string) bool {
+	answers, err := c.resolver.LookupHost(ctx, name)
+	if err != nil {
+		return false
+	}
+	for _, answer := range answers {
+		if strings.HasPrefix(answer, "127.") && !strings.HasPrefix(answer, "127.255.255.") {
+			return true
+		}
+	}
+	return false
+}
+
+// reverseIPv4 returns the octets of an IPv4 address in reverse order, as
+// DNSBL queries expect (1.2.3.4 -> 4.3.2.1). IPv6 addresses are not checked.
+func reverseIPv4(addr string) (string, bool) {
+	ip := net.ParseIP(addr).To4()
+	if ip == nil {
+		return "", false
+	}
+	return net.IPv4(ip[3], ip[2], ip[1], ip[0]).String(), true
+}
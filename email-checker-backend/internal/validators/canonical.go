@@ -0,0 +1,66 @@
+package validators
+
+import (
+	"strings"
+
+	"email-intelligence/internal/models"
+
+	"golang.org/x/net/idna"
+)
+
+// Canonicalizer produces the two normalized forms of an address:
+//
+//   - the storage form keeps the mailbox deliverable. The address is trimmed and
+//     the domain is lowercased and IDNA-encoded. The local part is lowercased only
+//     for providers known to ignore case, because RFC 5321 lets other servers
+//     treat it as case-sensitive.
+//   - the canonical form is for duplicate matching. It also lowercases the local
+//     part and applies provider rules such as Gmail's dot and "+tag" handling.
+//     It identifies a mailbox but may not be a usable address.
+type Canonicalizer struct {
+	rules map[string]models.CanonicalRule
+}
+
+// NewCanonicalizer creates a canonicalizer with rules keyed by lowercase domain
+func NewCanonicalizer(rules map[string]models.CanonicalRule) *Canonicalizer {
+	return &Canonicalizer{rules: rules}
+}
+
+// Canonicalize returns the storage and canonical forms of email. ok is false
+// when the address has no "@" or its domain is not a valid IDNA name.
+func (c *Canonicalizer) Canonicalize(email string) (storage, canonical string, ok bool) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return "", "", false
+	}
+	local := email[:at]
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.ToLower(email[at+1:]), "."))
+	if err != nil || domain == "" {
+		return "", "", false
+	}
+
+	rule, known := c.rules[domain]
+	storageLocal := local
+	if known && rule.CaseInsensitive {
+		storageLocal = strings.ToLower(local)
+	}
+
+	canonicalLocal := strings.ToLower(local)
+	canonicalDomain := domain
+	if known {
+		if rule.TagSeparators != "" {
+			if i := strings.IndexAny(canonicalLocal, rule.TagSeparators); i > 0 {
+				canonicalLocal = canonicalLocal[:i]
+			}
+		}
+		if rule.IgnoreDots {
+			canonicalLocal = strings.ReplaceAll(canonicalLocal, ".", "")
+		}
+		if rule.Domain != "" {
+			canonicalDomain = strings.ToLower(rule.Domain)
+		}
+	}
+
+	return storageLocal + "@" + domain, canonicalLocal + "@" + canonicalDomain, true
+}
@@ -0,0 +1,46 @@
+package validators
+
+import (
+	"testing"
+
+	"email-intelligence/internal/models"
+)
+
+func TestCanonicalize(t *testing.T) {
+	c := NewCanonicalizer(map[string]models.CanonicalRule{
+		"gmail.com":      {CaseInsensitive: true, IgnoreDots: true, TagSeparators: "+"},
+		"googlemail.com": {CaseInsensitive: true, IgnoreDots: true, TagSeparators: "+", Domain: "gmail.com"},
+		"outlook.com":    {CaseInsensitive: true, TagSeparators: "+"},
+	})
+	tests := []struct {
+		email         string
+		wantStorage   string
+		wantCanonical string
+	}{
+		{"john.doe+promo@gmail.com", "john.doe+promo@gmail.com", "johndoe@gmail.com"},
+		{"JohnDoe@Gmail.com", "johndoe@gmail.com", "johndoe@gmail.com"},
+		{"John.Doe+news@googlemail.com", "john.doe+news@googlemail.com", "johndoe@gmail.com"},
+		// Outlook strips tags but dots are significant
+		{"john.doe+promo@outlook.com", "john.doe+promo@outlook.com", "john.doe@outlook.com"},
+		// Unknown providers keep their local part apart from case folding
+		{"John.Doe+promo@example.com", "John.Doe+promo@example.com", "john.doe+promo@example.com"},
+		{" jane@Bücher.example ", "jane@xn--bcher-kva.example", "jane@xn--bcher-kva.example"},
+		// A local part that is only a tag is left alone
+		{"+promo@gmail.com", "+promo@gmail.com", "+promo@gmail.com"},
+	}
+	for _, tt := range tests {
+		storage, canonical, ok := c.Canonicalize(tt.email)
+		if !ok || storage != tt.wantStorage || canonical != tt.wantCanonical {
+			t.Errorf("Canonicalize(%q) = %q, %q, %t; want %q, %q", tt.email, storage, canonical, ok, tt.wantStorage, tt.wantCanonical)
+		}
+	}
+}
+
+func TestCanonicalizeRejectsMalformed(t *testing.T) {
+	c := NewCanonicalizer(nil)
+	for _, email := range []string{"", "jane", "@example.com", "jane@", "jane@exa mple.com"} {
+		if _, _, ok := c.Canonicalize(email); ok {
+			t.Errorf("Canonicalize(%q) accepted", email)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package validators
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"email-intelligence/internal/models"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// CheckCatchAll probes whether the domain's mail server accepts RCPT TO for a
+// random mailbox that cannot exist. Acceptance means the domain is catch-all
+// and a verified mailbox says little. Definitive answers are cached per
+// domain; blocked, throttled or timed out probes return "unknown".
+func (v *SMTPValidator) CheckCatchAll(ctx context.Context, domain string, mxRecords []models.MXRecord) models.ValidationResult {
+	domain = strings.ToLower(domain)
+	if cached, found := v.catchAll.Get(domain); found {
+		return cached.(models.ValidationResult)
+	}
+	if len(mxRecords) == 0 {
+		return v.catchAllUnknown(SignalNoMXRecords)
+	}
+
+	// Probe the preferred MX host that is not backing off
+	host, family := "", ""
+	for _, mx := range mxRecords {
+		if _, blocked := v.backoff.blocked(mx.Host); !blocked {
+			host, family = mx.Host, mxFamily(mx)
+			break
+		}
+	}
+	if host == "" {
+		return v.catchAllUnknown("mx_backoff")
+	}
+
+	probe, err := catchAllProbe(domain)
+	if err != nil {
+		return v.catchAllUnknown("probe_failed")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+	source := v.sources.pick(family)
+	reply := v.trySMTPConnection(ctx, probe, "", host, v.ports[0], source, time.Now())
+	v.sources.record(source, smtpProbe{reply: reply.ServerResponse, answered: reply.MailboxStatus != ""})
+	if strings.HasPrefix(reply.ServerResponse, "421") {
+		v.backoff.failure(host)
+	}
+
+	result, ok := v.catchAllVerdict(reply.MailboxStatus)
+	if !ok {
+		// Connection refused, greylisted or an ambiguous reply
+		signal := reply.Reachable.RawSignal
+		if reply.ServerResponse != "" {
+			signal = reply.ServerResponse
+		}
+		return v.catchAllUnknown(signal)
+	}
+	v.catchAll.Set(domain, result, cache.DefaultExpiration)
+	return result
+}
+
+// catchAllProbe returns a random address at domain that cannot exist
+func catchAllProbe(domain string) (string, error) {
+	label := make([]byte, 16)
+	if _, err := rand.Read(label); err != nil {
+		return "", err
+	}
+	return "nonexistent-" + hex.EncodeToString(label) + "@" + strings.ToLower(domain), nil
+}
+
+// catchAllVerdict turns the mailbox status of a probe address into the
+// catch-all result; ok is false when the reply settles nothing
+func (v *SMTPValidator) catchAllVerdict(status string) (models.ValidationResult, bool) {
+	switch status {
+	case MailboxActive:
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Domain accepts mail for any address (catch-all)",
+			RawSignal: "catch_all_detected",
+			Score:     0,
+			Weight:    v.weights.CatchAllRisk,
+		}, true
+	case MailboxNonexistent, MailboxDisabled:
+		return models.ValidationResult{
+			Status:    "pass",
+			Reason:    "Domain rejects unknown mailboxes",
+			RawSignal: "catch_all_rejected",
+			Score:     v.weights.CatchAllRisk,
+			Weight:    v.weights.CatchAllRisk,
+		}, true
+	}
+	return models.ValidationResult{}, false
+}
+
+// pipelineCatchAll asks about probe in the session that just answered RCPT TO
+// for the real address, and caches a definitive catch-all verdict. A real
+// address refused as nonexistent already proves unknown mailboxes are
+// rejected, so the probe is only sent after an acceptance.
+func (v *SMTPValidator) pipelineCatchAll(probe, host, rcptResp string, write func(string), read func() string) {
+	status := classifyMailbox(rcptResp)
+	if status == MailboxActive {
+		write("RCPT TO:<" + probe + ">")
+		reply := read()
+		if strings.HasPrefix(reply, "421") {
+			v.backoff.failure(host)
+		}
+		status = classifyMailbox(reply)
+	} else if status != MailboxNonexistent {
+		return
+	}
+	if result, ok := v.catchAllVerdict(status); ok {
+		_, domain, _ := SplitAddress(probe)
+		v.catchAll.Set(domain, result, cache.DefaultExpiration)
+	}
+}
+
+func (v *SMTPValidator) catchAllUnknown(signal string) models.ValidationResult {
+	return models.ValidationResult{
+		Status:    "unknown",
+		Reason:    "Catch-all status could not be determined",
+		RawSignal: signal,
+		Score:     v.weights.CatchAllRisk / 2,
+		Weight:    v.weights.CatchAllRisk,
+	}
+}
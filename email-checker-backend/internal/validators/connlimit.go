@@ -0,0 +1,69 @@
+package validators
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxConnsPerHost is the number of simultaneous connections to one
+// mail server when SMTPOptions.MaxConnsPerHost is not set
+const DefaultMaxConnsPerHost = 2
+
+// connLimiter caps simultaneous connections to each mail server host across
+// all validations, so a bulk run cannot open dozens of sockets to one MX and
+// get the prober rate-limited or banned
+type connLimiter struct {
+	limit int
+	hosts map[string]*hostSlots
+	mu    sync.Mutex
+}
+
+// hostSlots is the semaphore of one host; it is dropped once nobody holds or
+// waits for a slot
+type hostSlots struct {
+	slots chan struct{}
+	users int
+}
+
+func newConnLimiter(limit int) *connLimiter {
+	if limit < 1 {
+		limit = DefaultMaxConnsPerHost
+	}
+	return &connLimiter{limit: limit, hosts: make(map[string]*hostSlots)}
+}
+
+// acquire waits for a connection slot to host. The returned release must be
+// called once the connection is closed.
+func (l *connLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	l.mu.Lock()
+	entry, ok := l.hosts[host]
+	if !ok {
+		entry = &hostSlots{slots: make(chan struct{}, l.limit)}
+		l.hosts[host] = entry
+	}
+	entry.users++
+	l.mu.Unlock()
+
+	done := func() {
+		l.mu.Lock()
+		entry.users--
+		if entry.users == 0 {
+			delete(l.hosts, host)
+		}
+		l.mu.Unlock()
+	}
+
+	select {
+	case entry.slots <- struct{}{}:
+		return func() {
+			<-entry.slots
+			done()
+		}, nil
+	case <-ctx.Done():
+		done()
+		return nil, ctx.Err()
+	}
+}
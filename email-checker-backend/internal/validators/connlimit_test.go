@@ -0,0 +1,126 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+func TestConnLimiterCapsEachHost(t *testing.T) {
+	const limit = 3
+	l := newConnLimiter(limit)
+	var held [2]int32
+	var most [2]int32
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Names differing only in case and the root dot share slots
+			name, host := "mx.example.com", 0
+			switch i % 3 {
+			case 1:
+				name = "MX.Example.com."
+			case 2:
+				name, host = "mx.example.org", 1
+			}
+			release, err := l.acquire(context.Background(), name)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := atomic.AddInt32(&held[host], 1)
+			for {
+				seen := atomic.LoadInt32(&most[host])
+				if n <= seen || atomic.CompareAndSwapInt32(&most[host], seen, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&held[host], -1)
+			release()
+		}(i)
+	}
+	wg.Wait()
+
+	for host, n := range most {
+		if n > limit {
+			t.Errorf("host %d: %d connections at once, limit %d", host, n, limit)
+		}
+		if n < limit {
+			t.Errorf("host %d: at most %d connections at once, want the limit %d used", host, n, limit)
+		}
+	}
+	if len(l.hosts) != 0 {
+		t.Errorf("%d idle hosts still tracked", len(l.hosts))
+	}
+}
+
+func TestConnLimiterWaitEndsWithContext(t *testing.T) {
+	l := newConnLimiter(1)
+	release, err := l.acquire(context.Background(), "mx.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "mx.example.com"); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want the deadline while the host is busy", err)
+	}
+
+	release()
+	if len(l.hosts) != 0 {
+		t.Errorf("%d hosts tracked after the last release", len(l.hosts))
+	}
+}
+
+func TestSMTPValidateLimitsSocketsPerHost(t *testing.T) {
+	const limit = 2
+	gate := make(chan struct{})
+	server := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+		s.rcpt = func(string) string {
+			<-gate
+			return "250 2.1.5 OK"
+		}
+	})
+	v := NewSMTPValidator(SMTPOptions{
+		Timeout:         5 * time.Second,
+		CacheTTL:        time.Minute,
+		BlockThreshold:  3,
+		BlockCooldown:   time.Minute,
+		MaxConnsPerHost: limit,
+	}, models.ScoringWeights{SMTPReachability: 20, CatchAllRisk: 10})
+	v.ports = []int{server.port()}
+
+	const validations = 6
+	var wg sync.WaitGroup
+	for i := 0; i < validations; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v.Validate(context.Background(), fmt.Sprintf("user%d@example.test", i), []models.MXRecord{server.mx(10)})
+		}(i)
+	}
+
+	// With every session held at RCPT, the rest must be queued rather than connected
+	deadline := time.Now().Add(2 * time.Second)
+	for server.openConns() < limit && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if open := server.openConns(); open != limit {
+		t.Errorf("%d sockets open to the host, want %d", open, limit)
+	}
+
+	close(gate)
+	wg.Wait()
+	if sessions := len(server.commands()); sessions != validations {
+		t.Errorf("%d sessions, want one per validation", sessions)
+	}
+}
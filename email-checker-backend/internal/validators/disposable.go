@@ -0,0 +1,295 @@
+package validators
+
+import (
+	"container/list"
+	"path"
+	"strings"
+	"sync"
+)
+
+// DefaultDisposableDomains are well-known throwaway mailbox providers. A listed
+// domain also matches all of its subdomains.
+var DefaultDisposableDomains = []string{
+	"10minutemail.com", "10minutemail.net", "guerrillamail.com", "guerrillamail.net",
+	"guerrillamail.org", "guerrillamailblock.com", "sharklasers.com", "grr.la",
+	"mailinator.com", "mailinator.net", "mailinator2.com", "tempmail.com", "temp-mail.org",
+	"tempmail.net", "yopmail.com", "yopmail.net", "yopmail.fr", "throwawaymail.com",
+	"trashmail.com", "trashmail.net", "getnada.com", "dispostable.com", "maildrop.cc",
+	"mintemail.com", "fakeinbox.com", "spamgourmet.com", "mohmal.com", "emailondeck.com",
+}
+
+// DefaultDisposableKeywords are fuzzy patterns; a registrable domain with a
+// word starting with one is suspected, not confirmed, to be disposable when
+// fuzzy matching is enabled
+var DefaultDisposableKeywords = []string{
+	"10minutemail", "guerrillamail", "mailinator", "tempmail", "yopmail",
+	"throwaway", "disposable", "temporary", "fake", "trash", "spam",
+}
+
+// Disposable verdict levels. Confirmed means an exact or parent-domain match
+// against the list, a configured wildcard pattern match, or an MX host on the
+// list; suspected means only a keyword in the domain name matched, which also
+// hits legitimate names such as fakenews.com.
+const (
+	DisposableNone      = "none"
+	DisposableSuspected = "suspected"
+	DisposableConfirmed = "confirmed"
+)
+
+// KnownDisposableList is the match reported for domains found on the list
+// loaded from DISPOSABLE_LIST_SOURCE
+const KnownDisposableList = "known_disposable_list"
+
+// DisposableIndex answers "is this domain disposable?" without scanning the
+// whole list: exact and parent-domain matches are map lookups (one per label),
+// wildcard patterns and optional keyword matching are applied after that, and
+// recent verdicts are kept in a bounded LRU so repeated domains skip them all.
+// An external list, when loaded, is consulted before the built-in one and can
+// be replaced at runtime.
+type DisposableIndex struct {
+	domains  map[string]struct{}
+	external map[string]struct{}
+	patterns []string
+	keywords []string
+	verdicts *verdictLRU
+	version  uint64       // bumped by SetExternalList
+	mu       sync.RWMutex // guards external and version
+}
+
+// NewDisposableIndex builds an index from domains, wildcard patterns and fuzzy
+// keywords. Patterns use path.Match syntax, where * also spans dots, so
+// "*.tempmail.*" matches a.tempmail.io. Pass nil keywords to disable fuzzy
+// matching. cacheSize bounds the verdict LRU.
+func NewDisposableIndex(domains, patterns, keywords []string, cacheSize int) *DisposableIndex {
+	index := &DisposableIndex{
+		domains:  make(map[string]struct{}, len(domains)),
+		verdicts: newVerdictLRU(cacheSize),
+	}
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			index.domains[domain] = struct{}{}
+		}
+	}
+	for _, pattern := range patterns {
+		if pattern = strings.Trim(strings.ToLower(strings.TrimSpace(pattern)), "."); pattern != "" {
+			index.patterns = append(index.patterns, pattern)
+		}
+	}
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			index.keywords = append(index.keywords, keyword)
+		}
+	}
+	return index
+}
+
+// Match returns the entry that matched domain and the verdict level
+// (DisposableNone, DisposableSuspected or DisposableConfirmed)
+func (i *DisposableIndex) Match(domain string) (string, string) {
+	domain = strings.Trim(strings.ToLower(domain), ".")
+
+	if entry, found := i.verdicts.get(domain); found {
+		return entry.match, entry.level
+	}
+
+	version := i.listVersion()
+	match, level := i.lookup(domain)
+	i.remember(domain, match, level, version)
+	return match, level
+}
+
+// listVersion identifies the external list in use
+func (i *DisposableIndex) listVersion() uint64 {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.version
+}
+
+// remember caches a verdict looked up against list version. A verdict from a
+// list that has since been replaced is dropped, or it would outlive the clear.
+func (i *DisposableIndex) remember(domain, match, level string, version uint64) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.version == version {
+		i.verdicts.add(domain, match, level)
+	}
+}
+
+// SetExternalList replaces the externally loaded domain list. Cached verdicts
+// are dropped so the new list takes effect immediately.
+func (i *DisposableIndex) SetExternalList(domains []string) {
+	external := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			external[domain] = struct{}{}
+		}
+	}
+
+	i.mu.Lock()
+	i.external = external
+	i.version++
+	i.verdicts.clear()
+	i.mu.Unlock()
+}
+
+// MatchMX reports the first MX host served by a listed disposable provider,
+// which confirms custom domains that front a throwaway mail service
+func (i *DisposableIndex) MatchMX(hosts []string) (string, bool) {
+	for _, host := range hosts {
+		host = strings.Trim(strings.ToLower(host), ".")
+		if match := i.listedExternal(host); match != "" {
+			return match, true
+		}
+		if match := listedIn(i.domains, host); match != "" {
+			return match, true
+		}
+		if match := i.matchPattern(host); match != "" {
+			return match, true
+		}
+	}
+	return "", false
+}
+
+func (i *DisposableIndex) lookup(domain string) (string, string) {
+	// The loaded list is kept up to date, so its exact matches win
+	if i.listedExternal(domain) != "" {
+		return KnownDisposableList, DisposableConfirmed
+	}
+	if match := listedIn(i.domains, domain); match != "" {
+		return match, DisposableConfirmed
+	}
+	if match := i.matchPattern(domain); match != "" {
+		return match, DisposableConfirmed
+	}
+
+	// Keywords are matched against the organization's domain only, so a
+	// mail host named trash.example.com says nothing about example.com
+	registrable, _ := SplitRegistrable(domain)
+	for _, keyword := range i.keywords {
+		if startsWord(registrable, keyword) {
+			return keyword, DisposableSuspected
+		}
+	}
+	return "", DisposableNone
+}
+
+// matchPattern returns the first wildcard pattern matching domain
+func (i *DisposableIndex) matchPattern(domain string) string {
+	for _, pattern := range i.patterns {
+		if matched, _ := path.Match(pattern, domain); matched {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// startsWord reports whether keyword begins a word of domain: at the start
+// of a label or after a hyphen or digit. tempmail-1.com and my-tempmail.com
+// match "tempmail"; contemporary.com does not match "temporary", nor
+// antispam.org "spam".
+func startsWord(domain, keyword string) bool {
+	for offset := 0; ; {
+		at := strings.Index(domain[offset:], keyword)
+		if at == -1 {
+			return false
+		}
+		at += offset
+		if at == 0 || !isLetter(domain[at-1]) {
+			return true
+		}
+		offset = at + 1
+	}
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// listedExternal returns the external list entry matching domain or one of its parents
+func (i *DisposableIndex) listedExternal(domain string) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return listedIn(i.external, domain)
+}
+
+// listedIn returns the entry of domains matching domain or one of its parents
+func listedIn(domains map[string]struct{}, domain string) string {
+	// Walk from the full host up to its parents: a.b.mailinator.com, b.mailinator.com, mailinator.com
+	for candidate := domain; candidate != ""; {
+		if _, ok := domains[candidate]; ok {
+			return candidate
+		}
+		dot := strings.IndexByte(candidate, '.')
+		if dot == -1 {
+			break
+		}
+		candidate = candidate[dot+1:]
+	}
+	return ""
+}
+
+// verdictLRU is a fixed-size, least-recently-used cache of domain verdicts
+type verdictLRU struct {
+	size  int
+	order *list.List
+	items map[string]*list.Element
+	mu    sync.Mutex
+}
+
+type verdictEntry struct {
+	domain string
+	match  string
+	level  string
+}
+
+func newVerdictLRU(size int) *verdictLRU {
+	if size < 1 {
+		size = 1
+	}
+	return &verdictLRU{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *verdictLRU) get(domain string) (verdictEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[domain]
+	if !ok {
+		return verdictEntry{}, false
+	}
+	c.order.MoveToFront(element)
+	return *element.Value.(*verdictEntry), true
+}
+
+func (c *verdictLRU) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element, c.size)
+}
+
+func (c *verdictLRU) add(domain, match, level string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[domain]; ok {
+		entry := element.Value.(*verdictEntry)
+		entry.match, entry.level = match, level
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[domain] = c.order.PushFront(&verdictEntry{domain: domain, match: match, level: level})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*verdictEntry).domain)
+	}
+}
@@ -0,0 +1,59 @@
+package validators
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"email-intelligence/internal/httpclient"
+)
+
+// maxDisposableListSize bounds how much of a disposable list is read
+const maxDisposableListSize = 32 << 20
+
+// LoadDisposableList reads a newline-delimited domain list, such as the
+// disposable-email-domains project publishes, from a local file or an http(s)
+// URL. Blank lines and lines starting with "#" are skipped.
+func LoadDisposableList(ctx context.Context, client *httpclient.Client, source string) ([]string, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return parseDisposableList(file)
+	}
+
+	resp, err := client.Get(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", source, resp.Status)
+	}
+	return parseDisposableList(resp.Body)
+}
+
+func parseDisposableList(r io.Reader) ([]string, error) {
+	domains := []string{}
+	scanner := bufio.NewScanner(io.LimitReader(r, maxDisposableListSize))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("disposable list is empty")
+	}
+	return domains, nil
+}
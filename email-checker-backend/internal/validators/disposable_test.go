@@ -0,0 +1,212 @@
+package validators
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+func TestDisposableIndexMatch(t *testing.T) {
+	index := NewDisposableIndex(DefaultDisposableDomains, []string{"*.tempmail.*"}, slices.Concat(DefaultDisposableKeywords, []string{"test"}), 16)
+	tests := []struct {
+		domain    string
+		wantMatch string
+		wantLevel string
+	}{
+		// A keyword inside a word is not a match
+		{"protest.com", "", DisposableNone},
+		{"contemporary.com", "", DisposableNone},
+		{"antispam.org", "", DisposableNone},
+		{"notmailinator.com", "", DisposableNone},
+
+		// Listed domains and their subdomains are confirmed
+		{"mailinator.com", "mailinator.com", DisposableConfirmed},
+		{"abc.mailinator.com", "mailinator.com", DisposableConfirmed},
+		{"a.b.MAILINATOR.com.", "mailinator.com", DisposableConfirmed},
+		{"a.tempmail.io", "*.tempmail.*", DisposableConfirmed},
+
+		// A keyword starting a word is only suspected
+		{"my-tempmail.com", "tempmail", DisposableSuspected},
+		{"test-inbox.com", "test", DisposableSuspected},
+		{"trash.example.com", "", DisposableNone},
+	}
+	for _, tt := range tests {
+		// Ask twice so the cached verdict is checked as well
+		for range 2 {
+			if match, level := index.Match(tt.domain); match != tt.wantMatch || level != tt.wantLevel {
+				t.Errorf("Match(%q) = %q, %q; want %q, %q", tt.domain, match, level, tt.wantMatch, tt.wantLevel)
+			}
+		}
+	}
+}
+
+func TestDisposableIndexWithoutKeywords(t *testing.T) {
+	index := NewDisposableIndex(DefaultDisposableDomains, nil, nil, 16)
+	if match, level := index.Match("my-tempmail.com"); level != DisposableNone {
+		t.Errorf("Match(my-tempmail.com) = %q, %q with fuzzy matching disabled", match, level)
+	}
+}
+
+func TestDisposableIndexExternalList(t *testing.T) {
+	index := NewDisposableIndex(DefaultDisposableDomains, nil, nil, 16)
+	if _, level := index.Match("mx.burner.io"); level != DisposableNone {
+		t.Fatalf("burner.io flagged before the list was loaded")
+	}
+
+	index.SetExternalList([]string{"burner.io"})
+	if match, level := index.Match("mx.burner.io"); match != KnownDisposableList || level != DisposableConfirmed {
+		t.Errorf("Match(mx.burner.io) = %q, %q after loading the list", match, level)
+	}
+	if match, ok := index.MatchMX([]string{"mx1.example.net", "in.mailinator.com."}); !ok || match != "mailinator.com" {
+		t.Errorf("MatchMX = %q, %t; want mailinator.com", match, ok)
+	}
+}
+
+func TestDisposableIndexDropsVerdictsFromReplacedList(t *testing.T) {
+	index := NewDisposableIndex(nil, nil, nil, 16)
+	// A lookup that started before the list was loaded finishes after it
+	version := index.listVersion()
+	match, level := index.lookup("burner.io")
+	index.SetExternalList([]string{"burner.io"})
+	index.remember("burner.io", match, level, version)
+
+	if match, level := index.Match("burner.io"); match != KnownDisposableList || level != DisposableConfirmed {
+		t.Errorf("Match(burner.io) = %q, %q; the stale verdict was cached", match, level)
+	}
+}
+
+func TestCheckDisposableEmail(t *testing.T) {
+	weights := models.ScoringWeights{DisposableCheck: 10}
+	v := NewDomainValidator(weights, nil, NewDisposableIndex(DefaultDisposableDomains, nil, []string{"test"}, 16), nil, nil)
+	tests := []struct {
+		domain    string
+		wantScore int
+		wantLevel string
+	}{
+		{"protest.com", 10, DisposableNone},
+		{"test-inbox.com", 5, DisposableSuspected},
+		{"abc.mailinator.com", 0, DisposableConfirmed},
+	}
+	for _, tt := range tests {
+		result, level := v.checkDisposableEmail(tt.domain)
+		if result.Score != tt.wantScore || level != tt.wantLevel {
+			t.Errorf("%s: score %d, level %q; want %d, %q", tt.domain, result.Score, level, tt.wantScore, tt.wantLevel)
+		}
+	}
+}
+
+func TestVerdictLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newVerdictLRU(2)
+	c.add("a.com", "", DisposableNone)
+	c.add("b.com", "", DisposableNone)
+	c.get("a.com")
+	c.add("c.com", "c.com", DisposableConfirmed)
+
+	if _, ok := c.get("b.com"); ok {
+		t.Error("b.com kept though it was used least recently")
+	}
+	for _, domain := range []string{"a.com", "c.com"} {
+		if _, ok := c.get(domain); !ok {
+			t.Errorf("%s evicted", domain)
+		}
+	}
+	if c.order.Len() != 2 || len(c.items) != 2 {
+		t.Errorf("cache holds %d entries (%d indexed), want 2", c.order.Len(), len(c.items))
+	}
+}
+
+// BenchmarkDisposableMatch compares the index with the substring scan it
+// replaced, against a list the size of the public disposable lists
+func BenchmarkDisposableMatch(b *testing.B) {
+	domains := make([]string, 100000)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("throwaway%d.example", i)
+	}
+	queries := make([]string, 1024)
+	for i := range queries {
+		queries[i] = fmt.Sprintf("user%d.example.org", i)
+	}
+
+	b.Run("linear", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			domain := queries[n%len(queries)]
+			for _, pattern := range domains {
+				if strings.Contains(domain, pattern) {
+					break
+				}
+			}
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		// A one-entry cache makes every lookup miss
+		index := NewDisposableIndex(domains, nil, DefaultDisposableKeywords, 1)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			index.Match(queries[n%len(queries)])
+		}
+	})
+	b.Run("index_cached", func(b *testing.B) {
+		index := NewDisposableIndex(domains, nil, DefaultDisposableKeywords, len(queries))
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			index.Match(queries[n%len(queries)])
+		}
+	})
+}
+
+func TestDomainValidateDisposableLevels(t *testing.T) {
+	weights := models.ScoringWeights{DisposableCheck: 10}
+	feedback := NewFeedbackStore(10, time.Hour)
+	fuzzy := NewDomainValidator(weights, nil, NewDisposableIndex(DefaultDisposableDomains, nil, DefaultDisposableKeywords, 16), feedback, nil)
+	strict := NewDomainValidator(weights, nil, NewDisposableIndex(DefaultDisposableDomains, nil, nil, 16), feedback, nil)
+	tests := []struct {
+		domain     string
+		fuzzyLevel string
+		fuzzyScore int
+		strictIsOK bool
+	}{
+		// Former substring false positives
+		{"testa.com", DisposableNone, 10, true},
+		{"contemporary.com", DisposableNone, 10, true},
+		// Keyword hits cost half the points and only when fuzzy matching is on
+		{"fakenews.com", DisposableSuspected, 5, true},
+		{"spamhaus.org", DisposableSuspected, 5, true},
+		{"inbox.mailinator.com", DisposableConfirmed, 0, false},
+	}
+	for _, tt := range tests {
+		result := fuzzy.Validate(tt.domain)
+		if result.DisposableLevel != tt.fuzzyLevel || result.IsDisposable.Score != tt.fuzzyScore {
+			t.Errorf("fuzzy %s: level %q, score %d; want %q, %d", tt.domain, result.DisposableLevel, result.IsDisposable.Score, tt.fuzzyLevel, tt.fuzzyScore)
+		}
+		result = strict.Validate(tt.domain)
+		if ok := result.DisposableLevel == DisposableNone && result.IsDisposable.Status == "pass"; ok != tt.strictIsOK {
+			t.Errorf("strict %s: level %q, status %q", tt.domain, result.DisposableLevel, result.IsDisposable.Status)
+		}
+	}
+}
+
+func TestApplyMXFingerprint(t *testing.T) {
+	v := NewDomainValidator(models.ScoringWeights{DisposableCheck: 10}, nil, NewDisposableIndex(DefaultDisposableDomains, nil, DefaultDisposableKeywords, 16), NewFeedbackStore(10, time.Hour), nil)
+	tests := []struct {
+		domain    string
+		mx        string
+		wantLevel string
+		wantMatch string
+	}{
+		{"inbox-relay.net", "mx.mailinator.com.", DisposableConfirmed, "mx:mailinator.com"},
+		{"fakenews.com", "mx.mailinator.com", DisposableConfirmed, "mx:mailinator.com"},
+		{"fakenews.com", "aspmx.l.google.com", DisposableSuspected, "fake"},
+		{"inbox-relay.net", "aspmx.l.google.com", DisposableNone, "legitimate_domain"},
+	}
+	for _, tt := range tests {
+		result := v.Validate(tt.domain)
+		v.ApplyMXFingerprint(&result, []models.MXRecord{{Host: tt.mx, Priority: 10}})
+		if result.DisposableLevel != tt.wantLevel || result.IsDisposable.RawSignal != tt.wantMatch {
+			t.Errorf("%s via %s: level %q, signal %q; want %q, %q", tt.domain, tt.mx, result.DisposableLevel, result.IsDisposable.RawSignal, tt.wantLevel, tt.wantMatch)
+		}
+	}
+}
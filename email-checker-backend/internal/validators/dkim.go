@@ -0,0 +1,152 @@
+package validators
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"email-intelligence/internal/models"
+)
+
+// DKIM selector check outcomes
+const (
+	DKIMValid    = "valid"
+	DKIMInvalid  = "invalid"
+	DKIMRevoked  = "revoked"
+	DKIMNotFound = "not_found"
+	DKIMUnknown  = "unknown" // the lookup failed, so the record may still exist
+)
+
+// MinDKIMKeyBits is the smallest RSA key considered strong. RFC 8301 still
+// allows 1024-bit keys, so shorter keys are flagged as weak rather than invalid.
+const MinDKIMKeyBits = 2048
+
+// selectorPattern accepts one or more DNS labels, e.g. "s1" or "2024.mail"
+var selectorPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// ValidSelector reports whether a DKIM selector is syntactically usable
+func ValidSelector(selector string) bool {
+	return len(selector) <= 253 && selectorPattern.MatchString(selector)
+}
+
+// CheckDKIMSelector looks up one selector's key record and validates it
+func (v *SecurityValidator) CheckDKIMSelector(ctx context.Context, domain, selector string) models.DKIMSelectorResult {
+	trace := &txtTrace{}
+	records, err := v.lookupTXT(ctx, selector+"._domainkey."+domain, trace)
+	result := dkimSelectorResult(domain, selector, records, err)
+	if notes := trace.list(); result.Found && len(notes) > 0 {
+		result.Reason += " (abnormal TXT answer truncated: " + strings.Join(notes, "; ") + ")"
+	}
+	return result
+}
+
+// dkimSelectorResult judges the answer to one selector's key record lookup
+func dkimSelectorResult(domain, selector string, records []string, err error) models.DKIMSelectorResult {
+	result := models.DKIMSelectorResult{
+		Domain:   domain,
+		Selector: selector,
+		Name:     selector + "._domainkey." + domain,
+		Status:   DKIMNotFound,
+		Tags:     map[string]string{},
+	}
+	if err != nil || len(records) == 0 {
+		result.Reason = "No DKIM record published for this selector"
+		if lookupFailed(err) {
+			result.Status = DKIMUnknown
+			result.Reason = "DKIM lookup failed: " + err.Error()
+		}
+		return result
+	}
+
+	result.Found = true
+	result.Record = strings.Join(records, "")
+	parseDKIMRecord(&result)
+	return result
+}
+
+// parseDKIMRecord fills in the tags, key details and verdict of a found record
+func parseDKIMRecord(result *models.DKIMSelectorResult) {
+	for _, part := range strings.Split(result.Record, ";") {
+		tag, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		// Folding whitespace may appear anywhere inside a tag value (RFC 6376 3.6.1)
+		result.Tags[strings.TrimSpace(tag)] = strings.Join(strings.Fields(value), "")
+	}
+
+	if version, ok := result.Tags["v"]; ok && version != "DKIM1" {
+		result.Status = DKIMInvalid
+		result.Reason = "Unsupported version " + version
+		return
+	}
+
+	result.KeyType = "rsa"
+	if keyType, ok := result.Tags["k"]; ok {
+		result.KeyType = strings.ToLower(keyType)
+	}
+	result.TestMode = strings.Contains(result.Tags["t"], "y")
+
+	publicKey, ok := result.Tags["p"]
+	if !ok {
+		result.Status = DKIMInvalid
+		result.Reason = "Record has no p= (public key) tag"
+		return
+	}
+	if publicKey == "" {
+		result.Status = DKIMRevoked
+		result.Reason = "Key has been revoked (empty p= tag)"
+		return
+	}
+
+	keyData, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		result.Status = DKIMInvalid
+		result.Reason = "Public key is not valid base64"
+		return
+	}
+
+	switch result.KeyType {
+	case "rsa":
+		parsed, err := x509.ParsePKIXPublicKey(keyData)
+		if err != nil {
+			// Some publishers use a bare PKCS#1 key instead of SubjectPublicKeyInfo
+			parsed, err = x509.ParsePKCS1PublicKey(keyData)
+		}
+		rsaKey, isRSA := parsed.(*rsa.PublicKey)
+		if err != nil || !isRSA {
+			result.Status = DKIMInvalid
+			result.Reason = "Public key is not a valid RSA key"
+			return
+		}
+		result.KeyBits = rsaKey.N.BitLen()
+	case "ed25519":
+		if len(keyData) != ed25519.PublicKeySize {
+			result.Status = DKIMInvalid
+			result.Reason = "Public key is not a valid Ed25519 key"
+			return
+		}
+		result.KeyBits = 256
+	default:
+		result.Status = DKIMInvalid
+		result.Reason = "Unsupported key type " + result.KeyType
+		return
+	}
+
+	if result.KeyType == "rsa" && result.KeyBits < 1024 {
+		result.Status = DKIMInvalid
+		result.Reason = "RSA key is shorter than the 1024-bit minimum"
+		return
+	}
+
+	result.Weak = result.KeyType == "rsa" && result.KeyBits < MinDKIMKeyBits
+	result.Status = DKIMValid
+	result.Reason = "Valid DKIM key"
+	if result.Weak {
+		result.Reason = "Valid DKIM key, but shorter than 2048 bits"
+	}
+}
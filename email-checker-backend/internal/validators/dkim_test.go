@@ -0,0 +1,43 @@
+package validators
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCheckDKIMSelectorStatus(t *testing.T) {
+	failing := func(err error) func(context.Context) ([]string, error) {
+		return func(context.Context) ([]string, error) { return nil, err }
+	}
+	tests := []struct {
+		name      string
+		answer    func(context.Context) ([]string, error)
+		want      string
+		wantFound bool
+	}{
+		{"NXDOMAIN", failing(&net.DNSError{Err: "no such host", IsNotFound: true}), DKIMNotFound, false},
+		{"empty answer", answers(), DKIMNotFound, false},
+		{"timeout", failing(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), DKIMUnknown, false},
+		{"SERVFAIL", failing(&net.DNSError{Err: "server misbehaving", IsTemporary: true}), DKIMUnknown, false},
+		{"revoked key", answers("v=DKIM1; k=rsa; p="), DKIMRevoked, true},
+	}
+	for _, tt := range tests {
+		v := NewSecurityValidator(&fakeResolver{answer: tt.answer}, SecurityOptions{Timeout: time.Second})
+		result := v.CheckDKIMSelector(context.Background(), "example.com", "s1")
+		if result.Status != tt.want || result.Found != tt.wantFound {
+			t.Errorf("%s: status %q, found %t; want %q, found %t (%s)", tt.name, result.Status, result.Found, tt.want, tt.wantFound, result.Reason)
+		}
+	}
+}
+
+func TestLookupDKIMSelectorFailureIsDegraded(t *testing.T) {
+	v := NewSecurityValidator(&fakeResolver{answer: func(context.Context) ([]string, error) {
+		return nil, &net.DNSError{Err: "server misbehaving", IsTemporary: true}
+	}}, SecurityOptions{Timeout: time.Second})
+	result := v.lookupDKIMSelector(context.Background(), "example.com", "s1", &txtTrace{})
+	if result.Status == "fail" || !Degraded(result) {
+		t.Errorf("result = %+v, want an unknown result marked as a DNS error", result)
+	}
+}
@@ -2,37 +2,51 @@ package validators
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"email-intelligence/internal/models"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/errgroup"
 )
 
+// MXPoints is what the MX check is scored out of, whatever the scoring weights;
+// the score analyzer rescales it to the MX weight
+const MXPoints = 20
+
 // DNSValidator validates DNS records
 type DNSValidator struct {
-	resolver *net.Resolver
+	resolver Resolver
+	dnssec   *DNSSECChecker
 	timeout  time.Duration
+	wildcard *cache.Cache // wildcard verdicts keyed by domain
 }
 
-// NewDNSValidator creates a new DNS validator
-func NewDNSValidator(timeout time.Duration) *DNSValidator {
+// NewDNSValidator creates a new DNS validator. dnssec may be nil to skip the
+// DNSSEC check. Wildcard verdicts are reused for cacheTTL.
+func NewDNSValidator(resolver Resolver, dnssec *DNSSECChecker, timeout, cacheTTL time.Duration) *DNSValidator {
 	return &DNSValidator{
-		resolver: createOptimizedResolver(),
+		resolver: resolver,
+		dnssec:   dnssec,
 		timeout:  timeout,
+		wildcard: cache.New(cacheTTL, cacheTTL*2),
 	}
 }
 
-func createOptimizedResolver() *net.Resolver {
+func createOptimizedResolver(transport DNSTransport) *net.Resolver {
+	if transport.Timeout == 0 {
+		transport.Timeout = 1 * time.Second
+	}
 	return &net.Resolver{
 		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: 1 * time.Second,
-			}
-			return d.DialContext(ctx, network, address)
-		},
+		Dial:     recordingTTLs(transport.dialer()),
 	}
 }
 
@@ -42,69 +56,261 @@ func (v *DNSValidator) Validate(ctx context.Context, domain string) models.DNSVa
 	
 	result := models.DNSValidationResult{
 		MXDetails: []models.MXRecord{},
+		Warnings:  []string{},
 	}
 	
 	// Create timeout context
 	dnsCtx, cancel := context.WithTimeout(ctx, v.timeout)
 	defer cancel()
 	
-	// Check A records (domain existence) - Informational only, no score
-	aRecords, err := v.resolver.LookupHost(dnsCtx, domain)
-	if err != nil {
-		result.DomainExists = models.ValidationResult{
-			Status:    "fail",
-			Reason:    "Domain does not exist",
-			RawSignal: err.Error(),
-			Score:     0,
-			Weight:    0,
-		}
-	} else {
-		result.DomainExists = models.ValidationResult{
-			Status:    "pass",
-			Reason:    "Domain exists",
-			RawSignal: fmt.Sprintf("%d_a_records", len(aRecords)),
-			Score:     0,
-			Weight:    0,
+	// Nameservers are resolved alongside the A and MX lookups
+	nsDone := make(chan []string, 1)
+	go func() {
+		nsDone <- v.lookupNameservers(dnsCtx, domain)
+	}()
+	
+	// DNSSEC status is queried directly since the stdlib resolver hides the AD bit
+	dnssecDone := make(chan models.ValidationResult, 1)
+	go func() {
+		if v.dnssec == nil {
+			dnssecDone <- models.ValidationResult{Status: "unknown", Reason: "DNSSEC check disabled", RawSignal: "dnssec_disabled"}
+			return
 		}
-		result.ARecords = aRecords
-	}
+		dnssecDone <- v.dnssec.Check(dnsCtx, domain)
+	}()
 	
-	// Check MX records
-	mxRecords, err := v.resolver.LookupMX(dnsCtx, domain)
-	if err != nil || len(mxRecords) == 0 {
-		result.MXRecords = models.ValidationResult{
-			Status:    "fail",
-			Reason:    "No MX records found",
-			RawSignal: "no_mx_records",
-			Score:     0,
-			Weight:    20,
+	// A, AAAA and MX lookups run concurrently under the one timeout, so a slow
+	// authoritative server costs its latency once rather than three times
+	var lookups errgroup.Group
+	lookups.Go(func() error {
+		// Check A records (domain existence) - Informational only, no score
+		aRecords, err := v.resolver.LookupHost(dnsCtx, domain)
+		if lookupFailed(err) {
+			result.DomainExists = dnsErrorResult("Domain", 0, err)
+		} else if err != nil {
+			result.DomainExists = models.ValidationResult{
+				Status:    "fail",
+				Reason:    "Domain does not exist",
+				RawSignal: err.Error(),
+				Score:     0,
+				Weight:    0,
+			}
+		} else if v.isWildcardDomain(dnsCtx, domain) {
+			// Every host resolves, so the A record says little about the domain
+			result.WildcardDNS = true
+			result.DomainExists = models.ValidationResult{
+				Status:    "pass",
+				Reason:    "Domain resolves, but wildcard DNS answers every hostname",
+				RawSignal: "wildcard_dns",
+				Score:     0,
+				Weight:    0,
+			}
+			result.ARecords = ipv4Only(aRecords)
+		} else {
+			result.DomainExists = models.ValidationResult{
+				Status:    "pass",
+				Reason:    "Domain exists",
+				RawSignal: fmt.Sprintf("%d_a_records", len(aRecords)),
+				Score:     0,
+				Weight:    0,
+			}
+			result.ARecords = ipv4Only(aRecords)
 		}
-	} else {
-		result.MXRecords = models.ValidationResult{
-			Status:    "pass",
-			Reason:    fmt.Sprintf("Found %d MX records", len(mxRecords)),
-			RawSignal: fmt.Sprintf("%d_mx_records", len(mxRecords)),
-			Score:     20,
-			Weight:    20,
+		return nil
+	})
+	lookups.Go(func() error {
+		// LookupHost mixes both families, so IPv6 is asked for explicitly
+		aaaaRecords, err := v.resolver.LookupIP(dnsCtx, "ip6", domain)
+		if err == nil {
+			result.AAAARecords = ipStrings(aaaaRecords)
 		}
-		
-		// Convert to our format and sort by priority
-		for _, mx := range mxRecords {
-			result.MXDetails = append(result.MXDetails, models.MXRecord{
-				Host:     trimSuffix(mx.Host, "."),
-				Priority: int(mx.Pref),
+		return nil
+	})
+	lookups.Go(func() error {
+		// Check MX records
+		mxRecords, err := v.resolver.LookupMX(dnsCtx, domain)
+		if lookupFailed(err) {
+			result.MXRecords = dnsErrorResult("MX", MXPoints, err)
+		} else if err != nil || len(mxRecords) == 0 {
+			result.MXRecords = models.ValidationResult{
+				Status:    "fail",
+				Reason:    "No MX records found",
+				RawSignal: SignalNoMXRecords,
+				Score:     0,
+				Weight:    MXPoints,
+			}
+		} else {
+			result.MXRecords = models.ValidationResult{
+				Status:    "pass",
+				Reason:    fmt.Sprintf("Found %d MX records", len(mxRecords)),
+				RawSignal: fmt.Sprintf("%d_mx_records", len(mxRecords)),
+				Score:     MXPoints,
+				Weight:    MXPoints,
+			}
+			
+			// Convert to our format and sort by priority, then host name, so
+			// equal-priority hosts keep one order whatever order DNS returned
+			for _, mx := range mxRecords {
+				result.MXDetails = append(result.MXDetails, models.MXRecord{
+					Host:     trimSuffix(mx.Host, "."),
+					Priority: int(mx.Pref),
+				})
+			}
+			
+			sort.Slice(result.MXDetails, func(i, j int) bool {
+				if result.MXDetails[i].Priority != result.MXDetails[j].Priority {
+					return result.MXDetails[i].Priority < result.MXDetails[j].Priority
+				}
+				return result.MXDetails[i].Host < result.MXDetails[j].Host
 			})
+			
+			v.resolveMXAddrs(dnsCtx, result.MXDetails)
+			if ipv6OnlyMX(result.MXDetails) {
+				result.MXRecords.Reason += ", reachable over IPv6 only"
+			}
+			for _, mx := range result.MXDetails {
+				if mx.IP == "" && len(mx.IPv6) == 0 {
+					result.Warnings = append(result.Warnings, "MX host "+mx.Host+" does not resolve to an address")
+				}
+			}
 		}
-		
-		sort.Slice(result.MXDetails, func(i, j int) bool {
-			return result.MXDetails[i].Priority < result.MXDetails[j].Priority
-		})
+		return nil
+	})
+	lookups.Wait()
+	
+	if result.DomainExists.Status == "pass" && len(result.ARecords) == 0 && len(result.AAAARecords) > 0 {
+		result.DomainExists.Reason += " (IPv6 only)"
 	}
 	
+	result.Nameservers = <-nsDone
+	result.SuspiciousNameservers = hasSuspiciousNameserver(result.Nameservers)
+	result.DNSSEC = <-dnssecDone
+	result.DNSSECValid = result.DNSSEC.Status == "pass"
+	
 	result.ResponseTime = time.Since(startTime).Milliseconds()
 	return result
 }
 
+// resolveMXAddrs fills in the IPv4 and IPv6 addresses of each MX host in
+// parallel, under the DNS timeout. Hosts that do not resolve are left without
+// addresses.
+func (v *DNSValidator) resolveMXAddrs(ctx context.Context, mxDetails []models.MXRecord) {
+	var wg sync.WaitGroup
+	for i := range mxDetails {
+		wg.Add(1)
+		go func(mx *models.MXRecord) {
+			defer wg.Done()
+			ips, err := v.resolver.LookupIP(ctx, "ip", mx.Host)
+			if err != nil {
+				return
+			}
+			for _, ip := range ips {
+				if ip.To4() == nil {
+					mx.IPv6 = append(mx.IPv6, ip.String())
+				} else {
+					mx.IPv4 = append(mx.IPv4, ip.String())
+				}
+			}
+			if len(mx.IPv4) > 0 {
+				mx.IP = mx.IPv4[0]
+			}
+		}(&mxDetails[i])
+	}
+	wg.Wait()
+}
+
+// ipv6OnlyMX reports whether every MX host that resolved has only IPv6
+// addresses, i.e. IPv4-only senders cannot deliver to the domain
+func ipv6OnlyMX(mxDetails []models.MXRecord) bool {
+	resolved := 0
+	for _, mx := range mxDetails {
+		if mx.IP != "" {
+			return false
+		}
+		if len(mx.IPv6) > 0 {
+			resolved++
+		}
+	}
+	return resolved > 0
+}
+
+// ipv4Only keeps the IPv4 addresses of a mixed LookupHost answer
+func ipv4Only(addrs []string) []string {
+	ipv4 := []string{}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+			ipv4 = append(ipv4, addr)
+		}
+	}
+	return ipv4
+}
+
+func ipStrings(ips []net.IP) []string {
+	out := make([]string, len(ips))
+	for i, ip := range ips {
+		out[i] = ip.String()
+	}
+	return out
+}
+
+// lookupNameservers returns the domain's authoritative nameserver hosts, sorted
+func (v *DNSValidator) lookupNameservers(ctx context.Context, domain string) []string {
+	records, err := v.resolver.LookupNS(ctx, domain)
+	if err != nil {
+		return []string{}
+	}
+	
+	nameservers := make([]string, 0, len(records))
+	for _, ns := range records {
+		nameservers = append(nameservers, strings.ToLower(trimSuffix(ns.Host, ".")))
+	}
+	sort.Strings(nameservers)
+	return nameservers
+}
+
+// suspiciousNameserverSuffixes are free DNS and domain-parking providers
+// commonly behind throwaway or parked domains
+var suspiciousNameserverSuffixes = []string{
+	"freenom.com", "afraid.org", "dnsexit.com", "dynu.com",
+	"sedoparking.com", "parkingcrew.net", "bodis.com", "above.com",
+	"parklogic.com", "dan.com", "namebrightdns.com", "uniregistrymarket.link",
+}
+
+func hasSuspiciousNameserver(nameservers []string) bool {
+	for _, ns := range nameservers {
+		for _, suffix := range suspiciousNameserverSuffixes {
+			if ns == suffix || strings.HasSuffix(ns, "."+suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWildcardDomain checks whether a random, certainly nonexistent subdomain
+// resolves. Answered probes are cached per domain, so repeated addresses at a
+// domain cost one probe; failed lookups are not cached so the next request can retry.
+func (v *DNSValidator) isWildcardDomain(ctx context.Context, domain string) bool {
+	domain = strings.ToLower(domain)
+	if cached, found := v.wildcard.Get(domain); found {
+		return cached.(bool)
+	}
+	
+	label := make([]byte, 12)
+	if _, err := rand.Read(label); err != nil {
+		return false
+	}
+	
+	probe := "wildcard-" + hex.EncodeToString(label) + "." + domain
+	addrs, err := v.resolver.LookupHost(ctx, probe)
+	if lookupFailed(err) {
+		return false
+	}
+	wildcard := err == nil && len(addrs) > 0
+	v.wildcard.Set(domain, wildcard, cache.DefaultExpiration)
+	return wildcard
+}
+
 func trimSuffix(s, suffix string) string {
 	if len(s) >= len(suffix) && s[len(s)-len(suffix):] == suffix {
 		return s[:len(s)-len(suffix)]
@@ -0,0 +1,215 @@
+package validators
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// probeResolver answers every hostname lookup with err, or with an address
+// when err is nil, and counts wildcard probes
+type probeResolver struct {
+	Resolver
+	err    error
+	probes int32
+}
+
+func (r *probeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if strings.HasPrefix(host, "wildcard-") {
+		atomic.AddInt32(&r.probes, 1)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func TestWildcardVerdictCached(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       bool
+		wantProbes int32
+	}{
+		{"wildcard", nil, true, 1},
+		{"NXDOMAIN", &net.DNSError{Err: "no such host", IsNotFound: true}, false, 1},
+		// A failed probe proves nothing, so the next check asks again
+		{"SERVFAIL", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, false, 3},
+	}
+	for _, tt := range tests {
+		resolver := &probeResolver{err: tt.err}
+		v := NewDNSValidator(resolver, nil, time.Second, time.Minute)
+		for i := 0; i < 3; i++ {
+			if got := v.isWildcardDomain(context.Background(), "Example.com"); got != tt.want {
+				t.Errorf("%s: check %d = %t, want %t", tt.name, i, got, tt.want)
+			}
+		}
+		if resolver.probes != tt.wantProbes {
+			t.Errorf("%s: %d probes, want %d", tt.name, resolver.probes, tt.wantProbes)
+		}
+	}
+}
+
+// stallingResolver answers nothing until the lookup's context is done, and
+// counts the lookups still running
+type stallingResolver struct {
+	running int32
+}
+
+func (r *stallingResolver) stall(ctx context.Context) error {
+	atomic.AddInt32(&r.running, 1)
+	defer atomic.AddInt32(&r.running, -1)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (r *stallingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return nil, r.stall(ctx)
+}
+
+func (r *stallingResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return nil, r.stall(ctx)
+}
+
+func (r *stallingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return nil, r.stall(ctx)
+}
+
+func (r *stallingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, r.stall(ctx)
+}
+
+func (r *stallingResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	return nil, r.stall(ctx)
+}
+
+func TestDNSValidateStopsWhenCancelled(t *testing.T) {
+	resolver := &stallingResolver{}
+	v := NewDNSValidator(resolver, nil, time.Minute, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		v.Validate(ctx, "example.com")
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&resolver.running) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Validate kept waiting on DNS after its context was cancelled")
+	}
+	// Lookups started alongside the ones Validate waited for end too
+	deadline = time.Now().Add(time.Second)
+	for atomic.LoadInt32(&resolver.running) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if running := atomic.LoadInt32(&resolver.running); running != 0 {
+		t.Errorf("%d lookups still running", running)
+	}
+}
+
+// slowServerResolver answers queries for domain after delay, like a slow
+// authoritative server; other names, such as MX hosts, answer at once
+type slowServerResolver struct {
+	domain string
+	delay  time.Duration
+}
+
+func (r *slowServerResolver) wait(ctx context.Context, name string) error {
+	if name != r.domain {
+		return nil
+	}
+	select {
+	case <-time.After(r.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *slowServerResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if err := r.wait(ctx, host); err != nil {
+		return nil, err
+	}
+	if host != r.domain {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func (r *slowServerResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if err := r.wait(ctx, host); err != nil {
+		return nil, err
+	}
+	return []net.IP{net.ParseIP("192.0.2.25")}, nil
+}
+
+func (r *slowServerResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if err := r.wait(ctx, name); err != nil {
+		return nil, err
+	}
+	return []*net.MX{{Host: "mx." + name + ".", Pref: 10}}, nil
+}
+
+func (r *slowServerResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, r.wait(ctx, name)
+}
+
+func (r *slowServerResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	if err := r.wait(ctx, name); err != nil {
+		return nil, err
+	}
+	return []*net.NS{{Host: "ns1." + name + "."}}, nil
+}
+
+func TestDNSValidateLookupsRunConcurrently(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	v := NewDNSValidator(&slowServerResolver{domain: "slow.example", delay: delay}, nil, 5*time.Second, time.Minute)
+
+	start := time.Now()
+	result := v.Validate(context.Background(), "slow.example")
+	elapsed := time.Since(start)
+
+	if result.DomainExists.Status != "pass" || result.MXRecords.Status != "pass" || len(result.AAAARecords) != 1 || len(result.Nameservers) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	// The A, AAAA, MX and NS queries each wait once; in sequence they would take 4x
+	if elapsed >= 2*delay {
+		t.Errorf("Validate took %s against a %s server, want the delay paid once", elapsed, delay)
+	}
+	// ResponseTime is wall-clock, not the sum of the lookups
+	if rt := time.Duration(result.ResponseTime) * time.Millisecond; rt < delay || rt > elapsed {
+		t.Errorf("ResponseTime = %s, want between %s and %s", rt, delay, elapsed)
+	}
+}
+
+// BenchmarkDNSValidateSlowServer measures Validate against a domain whose
+// authoritative server takes 500ms per answer, next to the A then MX
+// sequence Validate used to run
+func BenchmarkDNSValidateSlowServer(b *testing.B) {
+	resolver := &slowServerResolver{domain: "slow.example", delay: 500 * time.Millisecond}
+	ctx := context.Background()
+
+	b.Run("sequential", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			resolver.LookupHost(ctx, resolver.domain)
+			resolver.LookupMX(ctx, resolver.domain)
+		}
+	})
+	b.Run("validate", func(b *testing.B) {
+		v := NewDNSValidator(resolver, nil, 5*time.Second, time.Minute)
+		for n := 0; n < b.N; n++ {
+			v.Validate(ctx, resolver.domain)
+		}
+	})
+}
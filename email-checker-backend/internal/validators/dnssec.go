@@ -0,0 +1,238 @@
+package validators
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"email-intelligence/internal/models"
+)
+
+// Record types not predefined by dnsmessage
+const (
+	typeRRSIG  dnsmessage.Type = 46
+	typeDNSKEY dnsmessage.Type = 48
+)
+
+// DNSSECChecker asks a validating resolver for a domain's DNSKEY set with the
+// DO bit set. The stdlib resolver hides the AD (authenticated data) bit, so the
+// query is built and sent directly. Verdicts are cached per domain.
+type DNSSECChecker struct {
+	dial    dialFunc
+	server  string
+	timeout time.Duration
+	cache   *cache.Cache
+}
+
+// NewDNSSECChecker creates a checker that queries through transport. A plain
+// transport without a server uses the first nameserver in /etc/resolv.conf;
+// the resolver must validate DNSSEC for signed domains to be reported as valid.
+func NewDNSSECChecker(transport DNSTransport, timeout, cacheTTL time.Duration) *DNSSECChecker {
+	return &DNSSECChecker{
+		dial:    transport.dialer(),
+		server:  systemNameserver(),
+		timeout: timeout,
+		cache:   cache.New(cacheTTL, cacheTTL*2),
+	}
+}
+
+// Check reports whether the domain's DNS is DNSSEC-signed and validated.
+// Unsigned domains are neutral: status "unknown" with no weight.
+func (c *DNSSECChecker) Check(ctx context.Context, domain string) models.ValidationResult {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if cached, found := c.cache.Get(domain); found {
+		return cached.(models.ValidationResult)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	header, signed, err := c.query(queryCtx, domain)
+	if err != nil {
+		// Lookup errors are not cached so the next request can retry
+		return models.ValidationResult{
+			Status:    "unknown",
+			Reason:    "DNSSEC status could not be determined",
+			RawSignal: err.Error(),
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	var result models.ValidationResult
+	switch {
+	case header.AuthenticData:
+		result = models.ValidationResult{
+			Status:    "pass",
+			Reason:    "DNS is DNSSEC-signed and validated",
+			RawSignal: "dnssec_validated",
+		}
+	case header.RCode == dnsmessage.RCodeServerFailure:
+		// Validating resolvers answer SERVFAIL when signatures do not verify
+		result = models.ValidationResult{
+			Status:    "fail",
+			Reason:    "DNSSEC validation failed (bogus signatures)",
+			RawSignal: "dnssec_bogus",
+		}
+	case signed:
+		result = models.ValidationResult{
+			Status:    "unknown",
+			Reason:    "DNS is signed, but the resolver did not validate it",
+			RawSignal: "dnssec_unvalidated",
+		}
+	default:
+		result = models.ValidationResult{
+			Status:    "unknown",
+			Reason:    "Domain is not DNSSEC-signed",
+			RawSignal: "dnssec_unsigned",
+		}
+	}
+
+	c.cache.Set(domain, result, cache.DefaultExpiration)
+	return result
+}
+
+// query sends a DNSKEY query, retrying over TCP when a UDP answer is truncated, and
+// returns the response header and whether the answer carried signatures
+func (c *DNSSECChecker) query(ctx context.Context, domain string) (dnsmessage.Header, bool, error) {
+	name, err := dnsmessage.NewName(domain + ".")
+	if err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true, AuthenticData: true})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: name, Type: typeDNSKEY, Class: dnsmessage.ClassINET}); err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+	if err := builder.StartAdditionals(); err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(1232, dnsmessage.RCodeSuccess, true); err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+	packet, err := builder.Finish()
+	if err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+
+	response, err := c.exchange(ctx, "udp", packet)
+	if err != nil {
+		return dnsmessage.Header{}, false, err
+	}
+	header, signed, err := parseDNSSECResponse(response, id)
+	if err == nil && header.Truncated {
+		if response, err = c.exchange(ctx, "tcp", packet); err == nil {
+			header, signed, err = parseDNSSECResponse(response, id)
+		}
+	}
+	return header, signed, err
+}
+
+// exchange sends one DNS message and reads the reply
+func (c *DNSSECChecker) exchange(ctx context.Context, network string, packet []byte) ([]byte, error) {
+	conn, err := c.dial(ctx, network, c.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, ok := conn.(net.PacketConn); ok {
+		if _, err := conn.Write(packet); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 4096)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	// Stream transports (TCP, DoT, DoH) prefix each message with its 2-byte length
+	framed := make([]byte, 2+len(packet))
+	binary.BigEndian.PutUint16(framed, uint16(len(packet)))
+	copy(framed[2:], packet)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// parseDNSSECResponse returns the header and whether the answer has RRSIGs
+func parseDNSSECResponse(response []byte, id uint16) (dnsmessage.Header, bool, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil {
+		return header, false, err
+	}
+	if header.ID != id {
+		return header, false, errors.New("mismatched DNS response id")
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return header, false, err
+	}
+
+	signed := false
+	for {
+		answer, err := parser.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return header, false, err
+		}
+		if answer.Type == typeRRSIG || answer.Type == typeDNSKEY {
+			signed = true
+		}
+		if err := parser.SkipAnswer(); err != nil {
+			return header, false, err
+		}
+	}
+	return header, signed, nil
+}
+
+// systemNameserver returns the first nameserver from /etc/resolv.conf
+func systemNameserver() string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				return net.JoinHostPort(fields[1], "53")
+			}
+		}
+	}
+	return "127.0.0.1:53"
+}
@@ -0,0 +1,147 @@
+package validators
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ttlRecorder collects the TTLs of the DNS responses seen while answering one
+// cached lookup, so the answer is kept no longer than its records allow
+type ttlRecorder struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	set bool
+}
+
+type ttlRecorderKey struct{}
+
+// withTTLRecorder has the resolvers report the TTLs of ctx's lookups to recorder
+func withTTLRecorder(ctx context.Context, recorder *ttlRecorder) context.Context {
+	return context.WithValue(ctx, ttlRecorderKey{}, recorder)
+}
+
+// recordTTL reports ttl to the recorder of ctx's lookup, if it has one
+func recordTTL(ctx context.Context, ttl time.Duration) {
+	if recorder, ok := ctx.Value(ttlRecorderKey{}).(*ttlRecorder); ok {
+		recorder.record(ttl)
+	}
+}
+
+// record keeps the lowest TTL seen: a lookup such as LookupHost is answered
+// by several responses, and is only as fresh as the first to expire
+func (r *ttlRecorder) record(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.set || ttl < r.ttl {
+		r.ttl, r.set = ttl, true
+	}
+}
+
+// get returns the lowest TTL seen, or false when no response carried one
+func (r *ttlRecorder) get() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ttl, r.set
+}
+
+// messageTTL is how long a DNS response may be cached: the lowest TTL of its
+// answers or, for a negative answer, the lower of its SOA record's TTL and the
+// SOA's minimum field (RFC 2308). ok is false when it has neither.
+func messageTTL(msg []byte) (ttl time.Duration, ok bool) {
+	var m dnsmessage.Message
+	if err := m.Unpack(msg); err != nil {
+		return 0, false
+	}
+	var lowest uint32
+	keep := func(seconds uint32) {
+		if !ok || seconds < lowest {
+			lowest, ok = seconds, true
+		}
+	}
+	for _, answer := range m.Answers {
+		keep(answer.Header.TTL)
+	}
+	if !ok {
+		for _, authority := range m.Authorities {
+			if soa, isSOA := authority.Body.(*dnsmessage.SOAResource); isSOA {
+				keep(authority.Header.TTL)
+				keep(soa.MinTTL)
+			}
+		}
+	}
+	return time.Duration(lowest) * time.Second, ok
+}
+
+// recordingTTLs wraps a resolver dialer so the responses read on connections
+// dialed for a lookup with a ttlRecorder are reported to it. net.Resolver does
+// not expose TTLs, so they are read off the wire as it receives them.
+func recordingTTLs(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil || ctx.Value(ttlRecorderKey{}) == nil {
+			return conn, err
+		}
+		wrapped := &ttlConn{Conn: conn, ctx: ctx}
+		// The Go resolver frames messages by whether the conn is a PacketConn
+		if packet, ok := conn.(net.PacketConn); ok {
+			return ttlPacketConn{ttlConn: wrapped, packet: packet}, nil
+		}
+		wrapped.stream = true
+		return wrapped, nil
+	}
+}
+
+// ttlConn reports the TTL of every DNS response read through it. Stream
+// connections carry length-prefixed messages that may arrive in pieces.
+type ttlConn struct {
+	net.Conn
+	ctx     context.Context
+	stream  bool
+	pending []byte
+}
+
+func (c *ttlConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n == 0 {
+		return n, err
+	}
+	if !c.stream {
+		c.record(p[:n])
+		return n, err
+	}
+	c.pending = append(c.pending, p[:n]...)
+	for len(c.pending) >= 2 {
+		size := int(binary.BigEndian.Uint16(c.pending))
+		if len(c.pending) < 2+size {
+			break
+		}
+		c.record(c.pending[2 : 2+size])
+		c.pending = c.pending[2+size:]
+	}
+	return n, err
+}
+
+func (c *ttlConn) record(msg []byte) {
+	if ttl, ok := messageTTL(msg); ok {
+		recordTTL(c.ctx, ttl)
+	}
+}
+
+// ttlPacketConn is a ttlConn over a datagram connection
+type ttlPacketConn struct {
+	*ttlConn
+	packet net.PacketConn
+}
+
+func (c ttlPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	return c.packet.ReadFrom(p)
+}
+
+func (c ttlPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	return c.packet.WriteTo(p, addr)
+}
@@ -0,0 +1,283 @@
+package validators
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+)
+
+// DNS record types queried over the JSON API
+const (
+	typeA    = 1
+	typeNS   = 2
+	typeSOA  = 6
+	typeMX   = 15
+	typeTXT  = 16
+	typeAAAA = 28
+)
+
+// dohJSONResolver queries a DNS over HTTPS JSON API, as served by Cloudflare
+// (https://cloudflare-dns.com/dns-query) and Google (https://dns.google/resolve).
+// It needs nothing but outbound HTTPS, so it works where port 53 is blocked
+// and the wire-format DoH endpoint of a provider is not available.
+type dohJSONResolver struct {
+	client   *httpclient.Client
+	endpoint string
+	timeout  time.Duration
+}
+
+func newDoHJSONResolver(transport DNSTransport) *dohJSONResolver {
+	endpoint := transport.Server
+	if endpoint == "" {
+		endpoint = defaultDoHServer
+	}
+	timeout := transport.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	client := transport.HTTPClient
+	if client == nil {
+		client = httpclient.New(httpclient.Options{MaxRetries: 1})
+	}
+	return &dohJSONResolver{
+		client:   client,
+		endpoint: endpoint,
+		timeout:  timeout,
+	}
+}
+
+// dohJSONResponse is the part of a JSON API answer used here
+type dohJSONResponse struct {
+	Status int `json:"Status"` // DNS RCODE: 0 NOERROR, 3 NXDOMAIN; others (SERVFAIL, REFUSED, ...) are failures
+	Answer []struct {
+		Type int    `json:"type"`
+		TTL  uint32 `json:"TTL"`
+		Data string `json:"data"`
+	} `json:"Answer"`
+	Authority []struct {
+		Type int    `json:"type"`
+		TTL  uint32 `json:"TTL"`
+		Data string `json:"data"` // for SOA, "mname rname serial refresh retry expire minimum"
+	} `json:"Authority"`
+}
+
+// ttl is how long the answer may be cached, as messageTTL works it out for
+// wire-format responses
+func (a dohJSONResponse) ttl() (time.Duration, bool) {
+	var lowest uint32
+	ok := false
+	keep := func(seconds uint32) {
+		if !ok || seconds < lowest {
+			lowest, ok = seconds, true
+		}
+	}
+	for _, record := range a.Answer {
+		keep(record.TTL)
+	}
+	if !ok {
+		for _, record := range a.Authority {
+			if record.Type != typeSOA {
+				continue
+			}
+			keep(record.TTL)
+			if fields := strings.Fields(record.Data); len(fields) == 7 {
+				if minimum, err := strconv.ParseUint(fields[6], 10, 32); err == nil {
+					keep(uint32(minimum))
+				}
+			}
+		}
+	}
+	return time.Duration(lowest) * time.Second, ok
+}
+
+// query returns the data of every answer of qtype for name. CNAMEs in the
+// chain are skipped, so callers only see the records they asked for.
+func (r *dohJSONResolver) query(ctx context.Context, name string, qtype int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	params := url.Values{"name": {name}, "type": {strconv.Itoa(qtype)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name, Server: r.endpoint, IsTimeout: ctx.Err() != nil, IsTemporary: true}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &net.DNSError{Err: "DoH server returned " + resp.Status, Name: name, Server: r.endpoint, IsTemporary: true}
+	}
+
+	var answer dohJSONResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil {
+		return nil, &net.DNSError{Err: "invalid DoH answer: " + err.Error(), Name: name, Server: r.endpoint}
+	}
+	if ttl, ok := answer.ttl(); ok {
+		recordTTL(ctx, ttl)
+	}
+	switch answer.Status {
+	case 0:
+	case 3:
+		return nil, &net.DNSError{Err: "no such host", Name: name, Server: r.endpoint, IsNotFound: true}
+	default:
+		// SERVFAIL, REFUSED and the rest say nothing about the name: the
+		// lookup failed, and another attempt may well succeed
+		return nil, &net.DNSError{Err: fmt.Sprintf("server answered rcode %d", answer.Status), Name: name, Server: r.endpoint, IsTemporary: true}
+	}
+
+	data := []string{}
+	for _, record := range answer.Answer {
+		if record.Type == qtype {
+			data = append(data, record.Data)
+		}
+	}
+	return data, nil
+}
+
+// noRecords mirrors the error net.Resolver returns for a name without records
+// of the requested type
+func (r *dohJSONResolver) noRecords(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, Server: r.endpoint, IsNotFound: true}
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of host
+func (r *dohJSONResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	type result struct {
+		addrs []string
+		err   error
+	}
+	v6 := make(chan result, 1)
+	go func() {
+		addrs, err := r.query(ctx, host, typeAAAA)
+		v6 <- result{addrs, err}
+	}()
+	addrs, err := r.query(ctx, host, typeA)
+	six := <-v6
+	if err != nil && six.err != nil {
+		return nil, err
+	}
+	addrs = append(addrs, six.addrs...)
+	if len(addrs) == 0 {
+		return nil, r.noRecords(host)
+	}
+	return addrs, nil
+}
+
+// LookupIP returns the IPv4 ("ip4"), IPv6 ("ip6") or all ("ip") addresses of host
+func (r *dohJSONResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	var addrs []string
+	var err error
+	switch network {
+	case "ip4":
+		addrs, err = r.query(ctx, host, typeA)
+	case "ip6":
+		addrs, err = r.query(ctx, host, typeAAAA)
+	default:
+		addrs, err = r.LookupHost(ctx, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, r.noRecords(host)
+	}
+	return ips, nil
+}
+
+// LookupMX returns the MX records of name; answers look like "10 mx.example.com."
+func (r *dohJSONResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	data, err := r.query(ctx, name, typeMX)
+	if err != nil {
+		return nil, err
+	}
+	records := []*net.MX{}
+	for _, answer := range data {
+		fields := strings.Fields(answer)
+		if len(fields) != 2 {
+			continue
+		}
+		pref, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil {
+			continue
+		}
+		records = append(records, &net.MX{Host: fields[1], Pref: uint16(pref)})
+	}
+	if len(records) == 0 {
+		return nil, r.noRecords(name)
+	}
+	return records, nil
+}
+
+// LookupTXT returns the TXT records of name, joining the character strings of
+// each record as net.Resolver does
+func (r *dohJSONResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	data, err := r.query(ctx, name, typeTXT)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, r.noRecords(name)
+	}
+	records := make([]string, 0, len(data))
+	for _, answer := range data {
+		records = append(records, joinTXTStrings(answer))
+	}
+	return records, nil
+}
+
+// LookupNS returns the nameservers of name
+func (r *dohJSONResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	data, err := r.query(ctx, name, typeNS)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, r.noRecords(name)
+	}
+	records := make([]*net.NS, 0, len(data))
+	for _, host := range data {
+		records = append(records, &net.NS{Host: host})
+	}
+	return records, nil
+}
+
+// joinTXTStrings turns `"v=spf1 " "-all"` into `v=spf1 -all`. Cloudflare
+// quotes each character string; Google sends the text unquoted.
+func joinTXTStrings(data string) string {
+	if !strings.HasPrefix(data, `"`) {
+		return data
+	}
+	var b strings.Builder
+	inQuotes := false
+	for i := 0; i < len(data); i++ {
+		switch ch := data[i]; {
+		case ch == '"':
+			inQuotes = !inQuotes
+		case ch == '\\' && inQuotes && i+1 < len(data):
+			i++
+			b.WriteByte(data[i])
+		case inQuotes:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+)
+
+// countingServer serves handler for the test and counts the requests it gets
+func countingServer(t *testing.T, handler http.HandlerFunc) (url string, requests *int32) {
+	t.Helper()
+	requests = new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests
+}
+
+func rcode(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Status":%d,"Answer":[{"type":16,"TTL":300,"data":"v=spf1 -all"}]}`, status)
+	}
+}
+
+func TestDoHJSONStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantRecords   int
+		wantNotFound  bool
+		wantTemporary bool
+	}{
+		{"NOERROR", 0, 1, false, false},
+		{"NXDOMAIN", 3, 0, true, false},
+		{"SERVFAIL", 2, 0, false, true},
+		{"FORMERR", 1, 0, false, true},
+		{"REFUSED", 5, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := countingServer(t, rcode(tt.status))
+			r := newDoHJSONResolver(DNSTransport{Protocol: DNSOverHTTPSJSON, Server: server, HTTPClient: httpclient.New(httpclient.Options{})})
+			records, err := r.LookupTXT(context.Background(), "example.test")
+			if len(records) != tt.wantRecords {
+				t.Errorf("records = %v", records)
+			}
+			if tt.wantRecords > 0 {
+				if err != nil {
+					t.Errorf("err = %v", err)
+				}
+				return
+			}
+			var dnsErr *net.DNSError
+			if !errors.As(err, &dnsErr) {
+				t.Fatalf("err = %v, want a DNSError", err)
+			}
+			if dnsErr.IsNotFound != tt.wantNotFound || dnsErr.IsTemporary != tt.wantTemporary {
+				t.Errorf("IsNotFound %t, IsTemporary %t; want %t, %t", dnsErr.IsNotFound, dnsErr.IsTemporary, tt.wantNotFound, tt.wantTemporary)
+			}
+			// Only NXDOMAIN proves the name is absent
+			if lookupFailed(err) == tt.wantNotFound {
+				t.Errorf("lookupFailed = %t", lookupFailed(err))
+			}
+		})
+	}
+}
+
+func TestDoHJSONRetriesServerErrors(t *testing.T) {
+	var failed atomic.Bool
+	server, requests := countingServer(t, func(w http.ResponseWriter, req *http.Request) {
+		if !failed.Swap(true) {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rcode(0)(w, req)
+	})
+	client := httpclient.New(httpclient.Options{MaxRetries: 1, BaseBackoff: time.Millisecond})
+	r := newDoHJSONResolver(DNSTransport{Protocol: DNSOverHTTPSJSON, Server: server, HTTPClient: client})
+	records, err := r.LookupTXT(context.Background(), "example.test")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("records, err = %v, %v", records, err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestDoHJSONHonoursTimeout(t *testing.T) {
+	server, _ := countingServer(t, func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	r := newDoHJSONResolver(DNSTransport{Protocol: DNSOverHTTPSJSON, Server: server, HTTPClient: httpclient.New(httpclient.Options{})})
+	r.timeout = 50 * time.Millisecond
+	start := time.Now()
+	_, err := r.LookupTXT(context.Background(), "example.test")
+	if err == nil || !lookupFailed(err) {
+		t.Fatalf("err = %v, want a failed lookup", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("lookup took %v, timeout 50ms", elapsed)
+	}
+}
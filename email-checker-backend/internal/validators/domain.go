@@ -1,55 +1,96 @@
 package validators
 
 import (
+	"fmt"
 	"strings"
 
 	"email-intelligence/internal/models"
+
+	"golang.org/x/net/publicsuffix"
 )
 
 // DomainValidator validates domain intelligence
 type DomainValidator struct {
-	weights models.ScoringWeights
+	weights       models.ScoringWeights
+	tldReputation map[string]int
+	disposable    *DisposableIndex
+	feedback      *FeedbackStore
+	brands        map[string]string // brand name skeleton -> brand domain
 }
 
-// NewDomainValidator creates a new domain validator
-func NewDomainValidator(weights models.ScoringWeights) *DomainValidator {
-	return &DomainValidator{weights: weights}
+// NewDomainValidator creates a new domain validator. Lookalikes of
+// brandDomains are flagged; nil uses DefaultBrandDomains.
+func NewDomainValidator(weights models.ScoringWeights, tldReputation map[string]int, disposable *DisposableIndex, feedback *FeedbackStore, brandDomains []string) *DomainValidator {
+	if brandDomains == nil {
+		brandDomains = DefaultBrandDomains
+	}
+	return &DomainValidator{
+		weights:       weights,
+		tldReputation: tldReputation,
+		disposable:    disposable,
+		feedback:      feedback,
+		brands:        brandIndex(brandDomains),
+	}
+}
+
+// WithWeights returns a validator that scores against weights and otherwise
+// shares v's lists and feedback
+func (v *DomainValidator) WithWeights(weights models.ScoringWeights) *DomainValidator {
+	copied := *v
+	copied.weights = weights
+	return &copied
 }
 
 // Validate performs domain intelligence analysis
 func (v *DomainValidator) Validate(domain string) models.DomainIntelligenceResult {
 	result := models.DomainIntelligenceResult{}
+	result.RegistrableDomain, result.Subdomain = SplitRegistrable(domain)
 	
-	result.IsDisposable = v.checkDisposableEmail(domain)
-	result.IsFreeProvider = v.checkFreeProvider(domain)
-	result.IsCorporate = v.checkCorporateDomain(domain, result.IsFreeProvider.Status == "fail")
+	// Classify the organization's domain, not the mail host under it. The
+	// disposable check gets the full domain so a listed subdomain matches
+	// without flagging its parent; listed parents match their subdomains.
+	registrable := result.RegistrableDomain
+	result.IsDisposable, result.DisposableLevel = v.checkDisposableEmail(domain)
+	result.IsFreeProvider = v.checkFreeProvider(registrable)
+	result.IsCorporate = v.checkCorporateDomain(registrable, result.IsFreeProvider.Status == "fail")
 	result.IsCatchAll = v.checkCatchAllDomain(domain)
-	result.IsBlacklisted = v.checkBlacklistedDomain(domain)
-	result.DomainAge = v.estimateDomainAge(domain)
-	result.ReputationScore = v.calculateDomainReputation(result)
+	result.IsBlacklisted = models.ValidationResult{
+		Status:    "unknown",
+		Reason:    "No DNS blocklist consulted",
+		RawSignal: "not_checked",
+		Score:     0,
+		Weight:    0,
+	}
+	result.HomoglyphRisk = v.checkHomoglyph(domain)
+	result.ScannerReputation = models.ValidationResult{
+		Status:    "unknown",
+		Reason:    "No security scanner consulted",
+		RawSignal: "not_checked",
+		Score:     0,
+		Weight:    0,
+	}
+	result.DomainAge = v.estimateDomainAge(registrable)
+	result.Feedback = v.feedback.Stats(registrable)
+	result.ReputationScore = v.calculateDomainReputation(registrable, result)
 	result.RiskIndicators = v.identifyRiskIndicators(result)
 	
 	return result
 }
 
-func (v *DomainValidator) checkDisposableEmail(domain string) models.ValidationResult {
-	disposablePatterns := []string{
-		"10minutemail", "guerrillamail", "mailinator", "tempmail", "yopmail",
-		"throwaway", "disposable", "temporary", "fake", "trash", "spam",
-	}
-	
-	domainLower := strings.ToLower(domain)
-	
-	for _, pattern := range disposablePatterns {
-		if strings.Contains(domainLower, pattern) {
-			return models.ValidationResult{
-				Status:    "fail",
-				Reason:    "Disposable email service detected",
-				RawSignal: pattern,
-				Score:     0,
-				Weight:    v.weights.DisposableCheck,
-			}
-		}
+func (v *DomainValidator) checkDisposableEmail(domain string) (models.ValidationResult, string) {
+	match, level := v.disposable.Match(domain)
+	switch level {
+	case DisposableConfirmed:
+		return disposableConfirmed(match, v.weights), level
+	case DisposableSuspected:
+		// A keyword in the name is weak evidence; cost half the points, not all
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Domain name resembles a disposable email service",
+			RawSignal: match,
+			Score:     v.weights.DisposableCheck / 2,
+			Weight:    v.weights.DisposableCheck,
+		}, level
 	}
 	
 	return models.ValidationResult{
@@ -58,7 +99,38 @@ func (v *DomainValidator) checkDisposableEmail(domain string) models.ValidationR
 		RawSignal: "legitimate_domain",
 		Score:     v.weights.DisposableCheck,
 		Weight:    v.weights.DisposableCheck,
+	}, DisposableNone
+}
+
+func disposableConfirmed(match string, weights models.ScoringWeights) models.ValidationResult {
+	return models.ValidationResult{
+		Status:    "fail",
+		Reason:    "Disposable email service detected",
+		RawSignal: match,
+		Score:     0,
+		Weight:    weights.DisposableCheck,
+	}
+}
+
+// ApplyMXFingerprint confirms a domain as disposable when its mail is handled
+// by a listed disposable provider. It runs after DNS validation, so the
+// reputation and risk indicators are recalculated when the verdict changes.
+func (v *DomainValidator) ApplyMXFingerprint(result *models.DomainIntelligenceResult, mxRecords []models.MXRecord) {
+	if result.DisposableLevel == DisposableConfirmed || len(mxRecords) == 0 {
+		return
+	}
+	hosts := make([]string, 0, len(mxRecords))
+	for _, mx := range mxRecords {
+		hosts = append(hosts, mx.Host)
+	}
+	match, ok := v.disposable.MatchMX(hosts)
+	if !ok {
+		return
 	}
+	result.IsDisposable = disposableConfirmed("mx:"+match, v.weights)
+	result.DisposableLevel = DisposableConfirmed
+	result.ReputationScore = v.calculateDomainReputation(result.RegistrableDomain, *result)
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
 }
 
 func (v *DomainValidator) checkFreeProvider(domain string) models.ValidationResult {
@@ -132,44 +204,32 @@ func (v *DomainValidator) checkCatchAllDomain(domain string) models.ValidationRe
 	}
 }
 
-func (v *DomainValidator) checkBlacklistedDomain(domain string) models.ValidationResult {
-	blacklistedDomains := map[string]bool{
-		"spam.com": true,
-		"malware.com": true,
-	}
-	
-	if blacklistedDomains[strings.ToLower(domain)] {
-		return models.ValidationResult{
-			Status:    "fail",
-			Reason:    "Domain is blacklisted",
-			RawSignal: "blacklisted",
-			Score:     0,
-			Weight:    10,
-		}
-	}
-	
-	return models.ValidationResult{
-		Status:    "pass",
-		Reason:    "Domain not blacklisted",
-		RawSignal: "not_blacklisted",
-		Score:     5,
-		Weight:    10,
-	}
-}
-
+// estimateDomainAge is unknown until ApplyDomainAge supplies the RDAP answer
 func (v *DomainValidator) estimateDomainAge(domain string) int {
-	return 365 // Default to 1 year
+	return UnknownDomainAge
 }
 
-func (v *DomainValidator) calculateDomainReputation(result models.DomainIntelligenceResult) int {
-	score := 50
+func (v *DomainValidator) calculateDomainReputation(domain string, result models.DomainIntelligenceResult) int {
+	score := v.tldBaseline(domain)
+	
+	if result.ScannerReputation.RawSignal == "scanner:"+ReputationSuspicious {
+		score -= 10
+	}
 	
-	if result.IsDisposable.Status == "fail" && result.IsDisposable.Score == 0 {
+	switch result.DisposableLevel {
+	case DisposableConfirmed:
 		score -= 30
+	case DisposableSuspected:
+		score -= 10
 	}
 	
+	// Each DNS blocklist that agrees makes the listing more credible
 	if result.IsBlacklisted.Status == "fail" {
-		score -= 40
+		penalty := 40
+		if len(result.BlocklistHits) > 0 {
+			penalty = minInt(60, 20*len(result.BlocklistHits))
+		}
+		score -= penalty
 	}
 	
 	if result.IsCorporate.Status == "pass" {
@@ -184,27 +244,226 @@ func (v *DomainValidator) calculateDomainReputation(result models.DomainIntellig
 		score += 10
 	}
 	
+	// Real-world outcomes from other addresses at this domain; complaints are
+	// rarer than bounces, so a small rate already costs as much
+	if result.Feedback.Total >= MinFeedbackSamples {
+		score -= minInt(40, int(result.Feedback.BounceRate*100))
+		score -= minInt(40, int(result.Feedback.ComplaintRate*400))
+	}
+	
+	// A security scanner's malicious verdict outweighs every heuristic: it
+	// can only lower the score, and never leaves it above a low ceiling
+	if result.ScannerReputation.Status == "fail" {
+		score = minInt(score, minInt(result.ScannerReputation.Score, maliciousReputationCap))
+	}
+	
 	return maxInt(0, minInt(100, score))
 }
 
+// maliciousReputationCap is the highest reputation a domain flagged as
+// malicious by a security scanner can have
+const maliciousReputationCap = 20
+
+// tldBaseline returns the starting reputation for the domain's public suffix
+func (v *DomainValidator) tldBaseline(domain string) int {
+	suffix, _ := publicsuffix.PublicSuffix(strings.ToLower(domain))
+	if baseline, ok := v.tldReputation[suffix]; ok {
+		return baseline
+	}
+	
+	// Fall back to the last label, e.g. "uk" for an unlisted "*.uk" suffix
+	if dot := strings.LastIndex(suffix, "."); dot != -1 {
+		if baseline, ok := v.tldReputation[suffix[dot+1:]]; ok {
+			return baseline
+		}
+	}
+	return 50
+}
+
+// ApplyBlocklists records DNS blocklist listings of the domain's mail servers,
+// found by a BlocklistChecker running beside Validate; checked is false when
+// the checker has no zones, which leaves the result unknown. The reputation
+// and risk indicators are recalculated when any list matched.
+func (v *DomainValidator) ApplyBlocklists(result *models.DomainIntelligenceResult, zones []string, checked bool) {
+	if !checked {
+		return
+	}
+	if len(zones) == 0 {
+		result.IsBlacklisted = models.ValidationResult{
+			Status:    "pass",
+			Reason:    "Domain's mail servers are not listed on the DNS blocklists queried",
+			RawSignal: "not_listed",
+			Score:     5,
+			Weight:    10,
+		}
+		return
+	}
+	result.BlocklistHits = zones
+	result.IsBlacklisted = models.ValidationResult{
+		Status:    "fail",
+		Reason:    fmt.Sprintf("Domain's mail servers are listed on %d DNS blocklist(s)", len(zones)),
+		RawSignal: "dnsbl:" + strings.Join(zones, ","),
+		Score:     0,
+		Weight:    10,
+	}
+	result.ReputationScore = v.calculateDomainReputation(result.RegistrableDomain, *result)
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
+}
+
+// ApplyReputation records the verdict of a ReputationProvider running beside
+// Validate and recalculates the reputation and risk indicators. A failed
+// lookup leaves the heuristic reputation in place.
+func (v *DomainValidator) ApplyReputation(result *models.DomainIntelligenceResult, score int, verdict string, err error) {
+	if err != nil {
+		result.ScannerReputation = models.ValidationResult{
+			Status:    "unknown",
+			Reason:    "Security scanner lookup failed: " + err.Error(),
+			RawSignal: "scanner_error",
+			Score:     0,
+			Weight:    0,
+		}
+		return
+	}
+	
+	status, reason := "pass", "Not flagged by security scanners"
+	switch verdict {
+	case ReputationMalicious:
+		status, reason = "fail", "Flagged as malicious by security scanners"
+	case ReputationSuspicious:
+		status, reason = "unknown", "Flagged as suspicious by some security scanners"
+	case ReputationUnknown:
+		status, reason = "unknown", "Security scanners have no data on this domain"
+	}
+	result.ScannerReputation = models.ValidationResult{
+		Status:    status,
+		Reason:    reason,
+		RawSignal: "scanner:" + verdict,
+		Score:     score,
+		Weight:    0,
+	}
+	result.ReputationScore = v.calculateDomainReputation(result.RegistrableDomain, *result)
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
+}
+
+// ApplyDomainAge records the registration age found by a DomainAgeLookup
+// running beside Validate and recalculates the reputation and risk indicators
+func (v *DomainValidator) ApplyDomainAge(result *models.DomainIntelligenceResult, age int) {
+	if age == UnknownDomainAge {
+		return
+	}
+	result.DomainAge = age
+	result.ReputationScore = v.calculateDomainReputation(result.RegistrableDomain, *result)
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
+}
+
+// ApplyCatchAll records the outcome of an SMTP catch-all probe, which needs
+// MX records and so runs after Validate. An inconclusive probe leaves the
+// "not tested" verdict in place.
+func (v *DomainValidator) ApplyCatchAll(result *models.DomainIntelligenceResult, catchAll models.ValidationResult) {
+	if catchAll.Status == "unknown" {
+		return
+	}
+	result.IsCatchAll = catchAll
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
+}
+
+// ApplyMailboxPlausibility judges the local part of the analyzed address
+// against the provider's naming rules. It runs per address, after the shared
+// domain checks.
+func (v *DomainValidator) ApplyMailboxPlausibility(result *models.DomainIntelligenceResult, localPart string) {
+	result.MailboxPlausibility = CheckMailboxPlausibility(localPart, result.RegistrableDomain)
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
+}
+
+// ApplyRoleAccount records whether the analyzed address is a role account. It
+// runs per address, after the shared domain checks.
+func (v *DomainValidator) ApplyRoleAccount(result *models.DomainIntelligenceResult, role models.ValidationResult) {
+	result.IsRoleAccount = role
+	result.RiskIndicators = v.identifyRiskIndicators(*result)
+}
+
 func (v *DomainValidator) identifyRiskIndicators(result models.DomainIntelligenceResult) []string {
 	indicators := []string{}
 	
-	if result.IsDisposable.Status == "fail" && result.IsDisposable.Score == 0 {
+	switch result.DisposableLevel {
+	case DisposableConfirmed:
 		indicators = append(indicators, "Disposable email service")
+	case DisposableSuspected:
+		indicators = append(indicators, "Domain name resembles a disposable service")
 	}
 	
-	if result.IsBlacklisted.Status == "fail" {
+	if len(result.BlocklistHits) > 0 {
+		for _, zone := range result.BlocklistHits {
+			indicators = append(indicators, "Listed on "+zone)
+		}
+	} else if result.IsBlacklisted.Status == "fail" {
 		indicators = append(indicators, "Blacklisted domain")
 	}
 	
-	if result.DomainAge < 30 {
+	if result.ScannerReputation.Status == "fail" {
+		indicators = append(indicators, "Flagged by security scanner")
+	}
+	
+	if result.HomoglyphRisk.Status == "fail" {
+		indicators = append(indicators, "Lookalike of another domain")
+	}
+	
+	if result.IsCatchAll.Status == "fail" {
+		indicators = append(indicators, "Catch-all domain")
+	}
+	
+	if result.MailboxPlausibility.Status == "fail" {
+		indicators = append(indicators, "Implausible mailbox name")
+	}
+	
+	if result.IsRoleAccount.Status == "fail" {
+		indicators = append(indicators, "Role account")
+	}
+	
+	if result.DomainAge != UnknownDomainAge && result.DomainAge < 30 {
 		indicators = append(indicators, "Very new domain")
 	}
 	
 	return indicators
 }
 
+// SplitRegistrable splits a domain into its registrable part (eTLD+1) and any
+// subdomain in front of it, e.g. mail.corp.acme.co.uk -> acme.co.uk, mail.corp.
+// A domain that is itself a public suffix is returned whole.
+func SplitRegistrable(domain string) (registrable, subdomain string) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return domain, ""
+	}
+	return registrable, strings.TrimSuffix(strings.TrimSuffix(domain, registrable), ".")
+}
+
+// reservedTLDs are the RFC 2606 / RFC 6761 top-level names that never route mail
+var reservedTLDs = map[string]bool{
+	"test": true, "example": true, "invalid": true, "localhost": true, "local": true,
+}
+
+// reservedDomains are the RFC 2606 second-level example domains
+var reservedDomains = map[string]bool{
+	"example.com": true, "example.net": true, "example.org": true,
+}
+
+// IsReservedDomain reports whether the domain is reserved for testing or documentation
+func IsReservedDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	labels := strings.Split(domain, ".")
+	if reservedTLDs[labels[len(labels)-1]] {
+		return true
+	}
+	for suffix := range reservedDomains {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
 func maxInt(a, b int) int {
 	if a > b {
 		return a
@@ -0,0 +1,36 @@
+package validators
+
+import (
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+func TestApplyBlocklists(t *testing.T) {
+	v := NewDomainValidator(models.ScoringWeights{}, nil, NewDisposableIndex(nil, nil, nil, 0), NewFeedbackStore(10, time.Hour), nil)
+	tests := []struct {
+		name       string
+		domain     string
+		zones      []string
+		checked    bool
+		wantStatus string
+	}{
+		{"no zones configured", "example.com", nil, false, "unknown"},
+		{"not listed", "example.com", nil, true, "pass"},
+		{"listed", "example.com", []string{"zen.spamhaus.org"}, true, "fail"},
+		// Only a blocklist answer decides a listing, whatever the name
+		{"not listed despite the name", "spam.com", nil, true, "pass"},
+	}
+	for _, tt := range tests {
+		result := v.Validate(tt.domain)
+		before := result.ReputationScore
+		v.ApplyBlocklists(&result, tt.zones, tt.checked)
+		if result.IsBlacklisted.Status != tt.wantStatus {
+			t.Errorf("%s: status %q, want %q (%s)", tt.name, result.IsBlacklisted.Status, tt.wantStatus, result.IsBlacklisted.Reason)
+		}
+		if listed := tt.wantStatus == "fail"; listed != (result.ReputationScore < before) {
+			t.Errorf("%s: reputation %d -> %d", tt.name, before, result.ReputationScore)
+		}
+	}
+}
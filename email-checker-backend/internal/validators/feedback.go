@@ -0,0 +1,121 @@
+package validators
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// Feedback outcomes counted per domain
+const (
+	OutcomeDelivered = "delivered"
+	OutcomeBounced   = "bounced"
+	OutcomeComplaint = "complaint"
+)
+
+// MinFeedbackSamples is how many outcomes a domain needs before its rates
+// are trusted enough to affect scoring
+const MinFeedbackSamples = 20
+
+// FeedbackStore aggregates delivery outcomes per registrable domain. It is
+// kept in memory per process: at most maxDomains domains are tracked, the
+// least recently reported are dropped first, and a domain's counts are
+// forgotten once no outcome has arrived for ttl.
+type FeedbackStore struct {
+	maxDomains int
+	ttl        time.Duration
+	order      *list.List
+	items      map[string]*list.Element
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+type feedbackEntry struct {
+	domain  string
+	stats   models.DomainFeedback
+	updated time.Time
+}
+
+// NewFeedbackStore creates an empty in-memory feedback store. A ttl of zero
+// keeps counts until they are evicted.
+func NewFeedbackStore(maxDomains int, ttl time.Duration) *FeedbackStore {
+	if maxDomains < 1 {
+		maxDomains = 1
+	}
+	return &FeedbackStore{
+		maxDomains: maxDomains,
+		ttl:        ttl,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Record counts one outcome against the domain's registrable domain
+func (s *FeedbackStore) Record(domain, outcome string) {
+	if outcome != OutcomeDelivered && outcome != OutcomeBounced && outcome != OutcomeComplaint {
+		return
+	}
+	registrable, _ := SplitRegistrable(domain)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(registrable)
+	if entry == nil {
+		entry = &feedbackEntry{domain: registrable}
+		s.items[registrable] = s.order.PushFront(entry)
+		if s.order.Len() > s.maxDomains {
+			oldest := s.order.Back()
+			s.order.Remove(oldest)
+			delete(s.items, oldest.Value.(*feedbackEntry).domain)
+		}
+	}
+	entry.updated = s.now()
+
+	stats := &entry.stats
+	switch outcome {
+	case OutcomeDelivered:
+		stats.Delivered++
+	case OutcomeBounced:
+		stats.Bounced++
+	case OutcomeComplaint:
+		stats.Complaints++
+	}
+	stats.Total++
+	stats.BounceRate = float64(stats.Bounced) / float64(stats.Total)
+	stats.ComplaintRate = float64(stats.Complaints) / float64(stats.Total)
+}
+
+// Stats returns the aggregated outcomes for a domain (zero values if none)
+func (s *FeedbackStore) Stats(domain string) models.DomainFeedback {
+	registrable, _ := SplitRegistrable(strings.ToLower(domain))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.entry(registrable); entry != nil {
+		return entry.stats
+	}
+	return models.DomainFeedback{}
+}
+
+// entry returns the domain's counts, marking them recently used, or nil when
+// there are none or they have expired. The caller holds s.mu.
+func (s *FeedbackStore) entry(domain string) *feedbackEntry {
+	element, ok := s.items[domain]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*feedbackEntry)
+	if s.ttl > 0 && s.now().Sub(entry.updated) > s.ttl {
+		s.order.Remove(element)
+		delete(s.items, domain)
+		return nil
+	}
+	s.order.MoveToFront(element)
+	return entry
+}
@@ -0,0 +1,69 @@
+package validators
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeedbackStoreAggregatesByRegistrableDomain(t *testing.T) {
+	s := NewFeedbackStore(10, time.Hour)
+	s.Record("mail.example.com", OutcomeDelivered)
+	s.Record("example.com", OutcomeBounced)
+	s.Record("EXAMPLE.com", OutcomeComplaint)
+	s.Record("example.com", "opened") // not an outcome we count
+	s.Record("example.com", OutcomeDelivered)
+
+	stats := s.Stats("Mail.Example.com")
+	if stats.Total != 4 || stats.Delivered != 2 || stats.Bounced != 1 || stats.Complaints != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats.BounceRate != 0.25 || stats.ComplaintRate != 0.25 {
+		t.Errorf("rates = %v, %v, want 0.25 each", stats.BounceRate, stats.ComplaintRate)
+	}
+	if other := s.Stats("example.org"); other.Total != 0 {
+		t.Errorf("unreported domain has stats %+v", other)
+	}
+}
+
+func TestFeedbackStoreEvictsLeastRecentlyReported(t *testing.T) {
+	s := NewFeedbackStore(2, 0)
+	s.Record("a.com", OutcomeBounced)
+	s.Record("b.com", OutcomeBounced)
+	s.Stats("a.com") // a.com was used more recently than b.com
+	s.Record("c.com", OutcomeBounced)
+
+	if s.Stats("b.com").Total != 0 {
+		t.Error("b.com kept beyond the limit")
+	}
+	if s.Stats("a.com").Total != 1 || s.Stats("c.com").Total != 1 {
+		t.Error("recently used domains were dropped")
+	}
+	if len(s.items) != 2 || s.order.Len() != 2 {
+		t.Errorf("tracking %d domains, limit 2", len(s.items))
+	}
+}
+
+func TestFeedbackStoreForgetsStaleCounts(t *testing.T) {
+	s := NewFeedbackStore(10, time.Hour)
+	clock := time.Now()
+	s.now = func() time.Time { return clock }
+
+	s.Record("example.com", OutcomeBounced)
+	clock = clock.Add(59 * time.Minute)
+	s.Record("example.com", OutcomeBounced)
+
+	// The window restarts with every outcome
+	clock = clock.Add(59 * time.Minute)
+	if got := s.Stats("example.com").Total; got != 2 {
+		t.Fatalf("total = %d, want 2", got)
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	if got := s.Stats("example.com").Total; got != 0 {
+		t.Errorf("total = %d after the TTL, want 0", got)
+	}
+	s.Record("example.com", OutcomeDelivered)
+	if got := s.Stats("example.com"); got.Total != 1 || got.Bounced != 0 {
+		t.Errorf("stats = %+v, want a fresh count", got)
+	}
+}
@@ -0,0 +1,173 @@
+package validators
+
+import (
+	"strings"
+	"unicode"
+
+	"email-intelligence/internal/models"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultBrandDomains are domains phishers commonly impersonate. Lookalikes
+// of them are flagged; the domains themselves (on any TLD) are not.
+var DefaultBrandDomains = []string{
+	"amazon.com", "apple.com", "bankofamerica.com", "chase.com", "coinbase.com",
+	"docusign.com", "dropbox.com", "facebook.com", "gmail.com", "google.com",
+	"hotmail.com", "icloud.com", "instagram.com", "linkedin.com", "microsoft.com",
+	"netflix.com", "outlook.com", "paypal.com", "wellsfargo.com", "yahoo.com",
+}
+
+// confusables maps letters from other scripts, and ASCII characters that are
+// easily misread, to the ASCII letter they pass for
+var confusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'һ': "h", 'і': "l", 'ї': "l", 'ј': "j",
+	'к': "k", 'ӏ': "l", 'м': "m", 'н': "h", 'о': "o", 'р': "p", 'с': "c", 'ѕ': "s",
+	'т': "t", 'у': "y", 'х': "x", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'ь': "b",
+	// Greek
+	'α': "a", 'β': "b", 'ε': "e", 'η': "n", 'ι': "l", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x", 'ω': "w",
+	// ASCII lookalikes
+	'0': "o", '1': "l", '|': "l", '5': "s",
+}
+
+// minFoldedBrandLength is the shortest brand name matched by reading i as l.
+// Case is folded before the check, so a capital I is indistinguishable from an
+// ordinary i, and for short names a single i/l swap is too often a different
+// word: appie.com is not an imitation of apple.com.
+const minFoldedBrandLength = 6
+
+// multiLetter are ASCII sequences that render like a single letter
+var multiLetter = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// skeleton reduces a label to the ASCII letters it visually passes for.
+// foldI also reads i as l, the lookalike a capital I leaves once lowercased.
+func skeleton(label string, foldI bool) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(label) {
+		if ascii, ok := confusables[r]; ok {
+			b.WriteString(ascii)
+		} else if r == 'i' && foldI {
+			b.WriteByte('l')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return multiLetter.Replace(b.String())
+}
+
+// brandIndex maps the skeleton of each brand's name, with i read as l, to the
+// brand domain
+func brandIndex(brands []string) map[string]string {
+	index := make(map[string]string, len(brands))
+	for _, brand := range brands {
+		brand = strings.ToLower(strings.TrimSpace(brand))
+		if brand == "" {
+			continue
+		}
+		index[skeleton(brandName(brand), true)] = brand
+	}
+	return index
+}
+
+// imitatedBrand returns the brand whose name the label reads as without being
+// it. A name with no lookalike characters resembles a brand only by reading i
+// as l, which counts for brand names of at least minFoldedBrandLength letters.
+func (v *DomainValidator) imitatedBrand(name string) (string, bool) {
+	name = strings.ToLower(name)
+	brand, ok := v.brands[skeleton(name, true)]
+	if !ok || name == brandName(brand) {
+		return "", false
+	}
+	if skeleton(name, false) == name && len(brandName(brand)) < minFoldedBrandLength {
+		return "", false
+	}
+	return brand, true
+}
+
+// brandName is the label left of the public suffix, e.g. "paypal" for
+// paypal.co.uk
+func brandName(domain string) string {
+	registrable, _ := SplitRegistrable(domain)
+	name, _, _ := strings.Cut(registrable, ".")
+	return name
+}
+
+// scripts are the writing systems told apart by the mixed-script check;
+// CJK scripts are one group because Japanese mixes them legitimately
+var scripts = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{"Latin", []*unicode.RangeTable{unicode.Latin}},
+	{"Cyrillic", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"Greek", []*unicode.RangeTable{unicode.Greek}},
+	{"Armenian", []*unicode.RangeTable{unicode.Armenian}},
+	{"Cherokee", []*unicode.RangeTable{unicode.Cherokee}},
+	{"Arabic", []*unicode.RangeTable{unicode.Arabic}},
+	{"Hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	{"CJK", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul}},
+}
+
+// mixedScriptLabel returns the first label of a Unicode domain whose letters
+// come from more than one script, e.g. Latin "pay" with Cyrillic "раl"
+func mixedScriptLabel(domain string) (string, bool) {
+	for _, label := range strings.Split(domain, ".") {
+		seen := ""
+		for _, r := range label {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			for _, script := range scripts {
+				if !unicode.IsOneOf(script.tables, r) {
+					continue
+				}
+				if seen != "" && seen != script.name {
+					return label, true
+				}
+				seen = script.name
+				break
+			}
+		}
+	}
+	return "", false
+}
+
+// checkHomoglyph fails for domains built to be mistaken for a brand: labels
+// mixing scripts in their decoded (Unicode) form, and registrable names that
+// look like a brand's without being it (paypa1.com, gmaiI.com, аррӏе.com).
+// Unlike typo suggestions, this assumes the resemblance is deliberate.
+func (v *DomainValidator) checkHomoglyph(domain string) models.ValidationResult {
+	unicodeDomain, err := idna.Lookup.ToUnicode(domain)
+	if err != nil {
+		unicodeDomain = domain
+	}
+	if label, mixed := mixedScriptLabel(unicodeDomain); mixed {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Domain label \"" + label + "\" mixes letters from different scripts, a common way to imitate another domain",
+			RawSignal: "mixed_script",
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	if brand, ok := v.imitatedBrand(brandName(unicodeDomain)); ok {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Domain imitates " + brand + " with lookalike characters",
+			RawSignal: "lookalike:" + brand,
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	return models.ValidationResult{
+		Status:    "pass",
+		Reason:    "Domain does not imitate a known brand",
+		RawSignal: "no_lookalike",
+		Score:     0,
+		Weight:    0,
+	}
+}
@@ -0,0 +1,34 @@
+package validators
+
+import "testing"
+
+func TestCheckHomoglyph(t *testing.T) {
+	v := &DomainValidator{brands: brandIndex(DefaultBrandDomains)}
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		// Lookalike characters match brands of any length
+		{"paypa1.com", "lookalike:paypal.com"},
+		{"gma1l.com", "lookalike:gmail.com"},
+		{"rnicrosoft.com", "lookalike:microsoft.com"},
+		{"xn--80ak6aa92e.com", "lookalike:apple.com"}, // аррӏе, all Cyrillic
+		{"xn--pypal-4ve.com", "mixed_script"},         // pаypal with a Cyrillic а
+		// Reading i as l alone counts only for longer brand names
+		{"paypai.com", "lookalike:paypal.com"},
+		{"mlcrosoft.com", "lookalike:microsoft.com"},
+		{"appie.com", "no_lookalike"},
+		{"gmaii.com", "no_lookalike"},
+		{"gmall.com", "no_lookalike"},
+		// The brands themselves, on any suffix
+		{"apple.com", "no_lookalike"},
+		{"mail.paypal.co.uk", "no_lookalike"},
+		{"linkedin.com", "no_lookalike"},
+		{"example.com", "no_lookalike"},
+	}
+	for _, tt := range tests {
+		if got := v.checkHomoglyph(tt.domain).RawSignal; got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.domain, got, tt.want)
+		}
+	}
+}
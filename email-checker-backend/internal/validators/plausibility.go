@@ -0,0 +1,161 @@
+package validators
+
+import (
+	"fmt"
+	"strings"
+
+	"email-intelligence/internal/models"
+)
+
+// mailboxRules are a provider's published rules for the names of new
+// mailboxes. Providers such as Gmail and Yahoo answer every RCPT probe alike,
+// so a name their signup form would refuse is the only evidence available
+// that a mailbox does not exist.
+type mailboxRules struct {
+	provider   string
+	minLength  int
+	maxLength  int
+	allowed    string // characters besides a-z and 0-9
+	ignoreDots bool   // dots do not distinguish mailboxes and do not count toward the length
+	maxDots    int    // -1 for no limit
+	letterLead bool   // the name must start with a letter
+	tagSep     string // separators after which the rest of the local part is a tag
+}
+
+var (
+	// Gmail: 6-30 letters, digits and periods; periods and "+tag" are ignored
+	gmailRules = mailboxRules{provider: "gmail", minLength: 6, maxLength: 30, allowed: ".", ignoreDots: true, maxDots: -1, tagSep: "+"}
+	// Yahoo: 4-32 characters starting with a letter, at most one period; "-"
+	// starts the keyword of a disposable address
+	yahooRules = mailboxRules{provider: "yahoo", minLength: 4, maxLength: 32, allowed: "._", maxDots: 1, letterLead: true, tagSep: "-"}
+)
+
+// mailboxRulesFor returns the naming rules for a registrable domain, if known
+func mailboxRulesFor(registrable string) (mailboxRules, bool) {
+	switch {
+	case registrable == "gmail.com" || registrable == "googlemail.com":
+		return gmailRules, true
+	case strings.HasPrefix(registrable, "yahoo.") || registrable == "ymail.com" || registrable == "rocketmail.com":
+		return yahooRules, true
+	}
+	return mailboxRules{}, false
+}
+
+// fakeMailboxMarkers appear in addresses typed to get past a signup form
+var fakeMailboxMarkers = []string{
+	"doesnotexist", "doesntexist", "notexist", "nonexist", "notreal", "fakeemail", "fakemail",
+	"invalidemail", "noemail", "nomail", "asdfasdf", "qwerty", "sdfsdf", "donotreply", "noreply",
+}
+
+// CheckMailboxPlausibility judges a local part against the provider's naming
+// rules and obviously fake patterns. It is "unknown" for providers without
+// known rules; passing it does not prove the mailbox exists.
+func CheckMailboxPlausibility(localPart, registrable string) models.ValidationResult {
+	rules, ok := mailboxRulesFor(strings.ToLower(registrable))
+	if !ok {
+		return models.ValidationResult{
+			Status:    "unknown",
+			Reason:    "No mailbox naming rules known for this provider",
+			RawSignal: "no_provider_rules",
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	name := strings.ToLower(localPart)
+	if i := strings.IndexAny(name, rules.tagSep); i >= 0 {
+		name = name[:i]
+	}
+	if reason := rules.violation(name); reason != "" {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    fmt.Sprintf("Local part breaks %s naming rules: %s", rules.provider, reason),
+			RawSignal: rules.provider + "_rules_violated",
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	normalized := name
+	if rules.ignoreDots {
+		normalized = strings.ReplaceAll(normalized, ".", "")
+	}
+	if marker, fake := fakeMailboxPattern(normalized); fake {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    fmt.Sprintf("Local part looks made up (%s)", marker),
+			RawSignal: "fake_pattern:" + marker,
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	return models.ValidationResult{
+		Status:    "pass",
+		Reason:    fmt.Sprintf("Local part follows %s naming rules", rules.provider),
+		RawSignal: "normalized:" + normalized,
+		Score:     0,
+		Weight:    0,
+	}
+}
+
+// violation describes the first rule name breaks, or returns ""
+func (r mailboxRules) violation(name string) string {
+	length, dots := 0, 0
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9':
+			length++
+		case strings.IndexByte(r.allowed, ch) >= 0:
+			if ch == '.' {
+				dots++
+				if r.ignoreDots {
+					continue
+				}
+			}
+			length++
+		default:
+			return fmt.Sprintf("character %q is not allowed", ch)
+		}
+	}
+	switch {
+	case name == "":
+		return "empty name"
+	case r.letterLead && (name[0] < 'a' || name[0] > 'z'):
+		return "must start with a letter"
+	case name[0] == '.' || name[len(name)-1] == '.':
+		return "starts or ends with a period"
+	case strings.Contains(name, ".."):
+		return "consecutive periods"
+	case r.maxDots >= 0 && dots > r.maxDots:
+		return fmt.Sprintf("more than %d period(s)", r.maxDots)
+	case length < r.minLength:
+		return fmt.Sprintf("shorter than %d characters", r.minLength)
+	case length > r.maxLength:
+		return fmt.Sprintf("longer than %d characters", r.maxLength)
+	}
+	return ""
+}
+
+// fakeMailboxPattern reports a marker of a made-up name: a known phrase or a
+// character repeated five or more times in a row
+func fakeMailboxPattern(name string) (string, bool) {
+	for _, marker := range fakeMailboxMarkers {
+		if strings.Contains(name, marker) {
+			return marker, true
+		}
+	}
+	run := 1
+	for i := 1; i < len(name); i++ {
+		if name[i] == name[i-1] {
+			run++
+			if run >= 5 {
+				return "repeated_" + string(name[i]), true
+			}
+		} else {
+			run = 1
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,151 @@
+package validators
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+	"email-intelligence/internal/logging"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// UnknownDomainAge is reported when the registry did not say when a domain
+// was registered, so it is not mistaken for a real age
+const UnknownDomainAge = -1
+
+// DomainAgeLookup finds a domain's registration date over RDAP, the JSON
+// successor to WHOIS. Ages are cached per registrable domain; domains the
+// registry does not know or answers about unreadably are cached for a shorter
+// time, and timeouts and server errors not at all.
+type DomainAgeLookup struct {
+	client      *httpclient.Client
+	baseURL     string
+	timeout     time.Duration
+	cache       *cache.Cache
+	group       singleflight.Group
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// NewDomainAgeLookup queries baseURL + "/domain/<name>"; a bootstrap service
+// such as https://rdap.org redirects to the registry responsible for the TLD.
+// An empty baseURL disables lookups. timeout bounds each lookup so a slow
+// registry cannot hold up an analysis.
+func NewDomainAgeLookup(client *httpclient.Client, baseURL string, timeout, ttl time.Duration) *DomainAgeLookup {
+	return &DomainAgeLookup{
+		client:      client,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		timeout:     timeout,
+		cache:       cache.New(ttl, ttl*2),
+		ttl:         ttl,
+		negativeTTL: ttl / 24,
+	}
+}
+
+// Age returns the number of days since domain was registered, or
+// UnknownDomainAge when the registry could not be asked or did not answer
+func (l *DomainAgeLookup) Age(ctx context.Context, domain string) int {
+	if l.baseURL == "" || domain == "" {
+		return UnknownDomainAge
+	}
+	domain = strings.ToLower(domain)
+	if cached, found := l.cache.Get(domain); found {
+		return daysSince(cached.(time.Time))
+	}
+
+	// The shared lookup runs on its own timeout, so a caller that gives up
+	// first neither aborts it for the others nor gets its cancellation cached
+	lookupCtx := context.WithoutCancel(ctx)
+	resultChan := l.group.DoChan(domain, func() (interface{}, error) {
+		registered, err := l.registration(lookupCtx, domain)
+		if err != nil {
+			logging.FromContext(lookupCtx).Warn("rdap lookup failed", "domain", domain, "error", err.Error())
+			// Only an answer that will not improve on retry is remembered
+			if errors.Is(err, errNoRegistrationDate) {
+				l.cache.Set(domain, time.Time{}, l.negativeTTL)
+			}
+			return time.Time{}, err
+		}
+		l.cache.Set(domain, registered, l.ttl)
+		return registered, nil
+	})
+
+	select {
+	case result := <-resultChan:
+		return daysSince(result.Val.(time.Time))
+	case <-ctx.Done():
+		return UnknownDomainAge
+	}
+}
+
+// errNoRegistrationDate is a registry answer with no usable registration
+// date: the domain is not found, or the response cannot be read
+var errNoRegistrationDate = errors.New("no usable registration date")
+
+// rdapDomain is the part of an RDAP domain response (RFC 9083) used here
+type rdapDomain struct {
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+}
+
+func (l *DomainAgeLookup) registration(ctx context.Context, domain string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.baseURL+"/domain/"+domain, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return time.Time{}, fmt.Errorf("rdap %s: %w: %s", domain, errNoRegistrationDate, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("rdap %s: %s", domain, resp.Status)
+	}
+
+	var body rdapDomain
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		if ctx.Err() != nil {
+			return time.Time{}, err // cut off mid-body, not malformed
+		}
+		return time.Time{}, fmt.Errorf("rdap %s: %w: %v", domain, errNoRegistrationDate, err)
+	}
+	for _, event := range body.Events {
+		if event.Action != "registration" {
+			continue
+		}
+		registered, err := time.Parse(time.RFC3339, event.Date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("rdap %s: %w: %v", domain, errNoRegistrationDate, err)
+		}
+		return registered, nil
+	}
+	return time.Time{}, fmt.Errorf("rdap %s: %w: no registration event", domain, errNoRegistrationDate)
+}
+
+// daysSince returns whole days since t, or UnknownDomainAge for the zero time
+func daysSince(t time.Time) int {
+	if t.IsZero() {
+		return UnknownDomainAge
+	}
+	days := int(time.Since(t).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
@@ -0,0 +1,95 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+)
+
+// registeredDaysAgo answers with a registration event days in the past
+func registeredDaysAgo(days int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := time.Now().AddDate(0, 0, -days).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"events":[{"eventAction":"last changed","eventDate":"2024-01-01T00:00:00Z"},{"eventAction":"registration","eventDate":%q}]}`, date)
+	}
+}
+
+func TestDomainAgeLookupCaching(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		want     int
+		requests int32 // after two lookups
+	}{
+		{"registered", registeredDaysAgo(400), 400, 1},
+		{"not found", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) }, UnknownDomainAge, 1},
+		{"malformed answer", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "<html>") }, UnknownDomainAge, 1},
+		{"no registration event", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `{"events":[]}`) }, UnknownDomainAge, 1},
+		{"server error is retried", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }, UnknownDomainAge, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := countingServer(t, tt.handler)
+			lookup := NewDomainAgeLookup(httpclient.New(httpclient.Options{}), server, time.Second, time.Hour)
+			for i := 0; i < 2; i++ {
+				if got := lookup.Age(context.Background(), "example.test"); got != tt.want {
+					t.Errorf("lookup %d: age = %d, want %d", i, got, tt.want)
+				}
+			}
+			if n := atomic.LoadInt32(requests); n != tt.requests {
+				t.Errorf("requests = %d, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestDomainAgeLookupTimeoutIsNotCached(t *testing.T) {
+	var slow atomic.Bool
+	slow.Store(true)
+	server, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			time.Sleep(200 * time.Millisecond)
+		}
+		registeredDaysAgo(30)(w, r)
+	})
+	lookup := NewDomainAgeLookup(httpclient.New(httpclient.Options{}), server, 50*time.Millisecond, time.Hour)
+
+	if got := lookup.Age(context.Background(), "example.test"); got != UnknownDomainAge {
+		t.Fatalf("age = %d after a timeout, want unknown", got)
+	}
+	slow.Store(false)
+	if got := lookup.Age(context.Background(), "example.test"); got != 30 {
+		t.Errorf("age = %d, want 30", got)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestDomainAgeLookupOutlivesCaller(t *testing.T) {
+	release := make(chan struct{})
+	server, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		registeredDaysAgo(30)(w, r)
+	})
+	lookup := NewDomainAgeLookup(httpclient.New(httpclient.Options{}), server, 5*time.Second, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if got := lookup.Age(ctx, "example.test"); got != UnknownDomainAge {
+		t.Fatalf("age = %d for a caller that gave up, want unknown", got)
+	}
+
+	close(release)
+	if got := lookup.Age(context.Background(), "example.test"); got != 30 {
+		t.Errorf("age = %d, want 30", got)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
@@ -0,0 +1,224 @@
+package validators
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+	"email-intelligence/internal/logging"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// Verdicts returned by a ReputationProvider
+const (
+	ReputationMalicious  = "malicious"
+	ReputationSuspicious = "suspicious"
+	ReputationClean      = "clean"
+	ReputationUnknown    = "unknown" // the provider has no data on the domain
+)
+
+// ReputationProvider rates a domain with an external threat intelligence
+// service. score is 0-100, higher is more trustworthy.
+type ReputationProvider interface {
+	DomainReputation(ctx context.Context, domain string) (score int, verdict string, err error)
+}
+
+// A lone engine's detection is often a false positive, so a domain is only
+// rated malicious once several engines agree
+const (
+	minMaliciousEngines = 2 // engines calling it malicious
+	minFlaggingEngines  = 3 // engines calling it malicious or suspicious
+)
+
+// ErrReputationRateLimited is returned when a lookup would have to wait for
+// the provider's rate limit past its deadline
+var ErrReputationRateLimited = errors.New("reputation provider rate limit reached")
+
+// DefaultVirusTotalURL is the VirusTotal v3 API
+const DefaultVirusTotalURL = "https://www.virustotal.com/api/v3"
+
+// VirusTotal rates domains by the engines that flagged them in VirusTotal's
+// last analysis. Answers are cached per domain; failed lookups are cached for
+// a shorter time, except rate-limit refusals, which are retried next time.
+type VirusTotal struct {
+	client      *httpclient.Client
+	baseURL     string
+	apiKey      string
+	timeout     time.Duration
+	cache       *cache.Cache
+	group       singleflight.Group
+	ttl         time.Duration
+	negativeTTL time.Duration
+	limiter     *requestSpacer
+}
+
+// VirusTotalOptions configures a VirusTotal provider
+type VirusTotalOptions struct {
+	APIKey            string
+	BaseURL           string        // empty uses DefaultVirusTotalURL
+	Timeout           time.Duration // budget for one lookup, including any wait for the rate limit
+	TTL               time.Duration // how long an answer is reused
+	RequestsPerMinute int           // the API key's quota; the public API allows 4
+}
+
+// reputationEntry is a cached answer
+type reputationEntry struct {
+	score   int
+	verdict string
+	err     error
+}
+
+// NewVirusTotal creates a VirusTotal provider
+func NewVirusTotal(client *httpclient.Client, opts VirusTotalOptions) *VirusTotal {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultVirusTotalURL
+	}
+	if opts.RequestsPerMinute < 1 {
+		opts.RequestsPerMinute = 4
+	}
+	return &VirusTotal{
+		client:      client,
+		baseURL:     strings.TrimSuffix(opts.BaseURL, "/"),
+		apiKey:      opts.APIKey,
+		timeout:     opts.Timeout,
+		cache:       cache.New(opts.TTL, opts.TTL*2),
+		ttl:         opts.TTL,
+		negativeTTL: opts.TTL / 24,
+		limiter:     &requestSpacer{interval: time.Minute / time.Duration(opts.RequestsPerMinute)},
+	}
+}
+
+// DomainReputation returns the cached or freshly looked up rating of domain
+func (p *VirusTotal) DomainReputation(ctx context.Context, domain string) (int, string, error) {
+	domain = strings.ToLower(domain)
+	if cached, found := p.cache.Get(domain); found {
+		entry := cached.(reputationEntry)
+		return entry.score, entry.verdict, entry.err
+	}
+
+	// The shared lookup outlives any one caller: it runs on its own timeout,
+	// so the first caller giving up neither aborts it for the others nor
+	// leaves a cancellation cached as the domain's answer
+	lookupCtx := context.WithoutCancel(ctx)
+	resultChan := p.group.DoChan(domain, func() (interface{}, error) {
+		score, verdict, err := p.lookup(lookupCtx, domain)
+		entry := reputationEntry{score: score, verdict: verdict, err: err}
+		switch {
+		case errors.Is(err, ErrReputationRateLimited):
+		case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		case err != nil:
+			logging.FromContext(lookupCtx).Warn("virustotal lookup failed", "domain", domain, "error", err.Error())
+			p.cache.Set(domain, entry, p.negativeTTL)
+		default:
+			p.cache.Set(domain, entry, p.ttl)
+		}
+		return entry, nil
+	})
+
+	select {
+	case result := <-resultChan:
+		entry := result.Val.(reputationEntry)
+		return entry.score, entry.verdict, entry.err
+	case <-ctx.Done():
+		return 0, "", ctx.Err()
+	}
+}
+
+// virusTotalDomain is the part of a VirusTotal domain object used here
+type virusTotalDomain struct {
+	Data struct {
+		Attributes struct {
+			LastAnalysisStats struct {
+				Malicious  int `json:"malicious"`
+				Suspicious int `json:"suspicious"`
+				Harmless   int `json:"harmless"`
+				Undetected int `json:"undetected"`
+			} `json:"last_analysis_stats"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+func (p *VirusTotal) lookup(ctx context.Context, domain string) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	if err := p.limiter.wait(ctx); err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/domains/"+url.PathEscape(domain), nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("x-apikey", p.apiKey)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return 50, ReputationUnknown, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("virustotal %s: %s", domain, resp.Status)
+	}
+
+	var body virusTotalDomain
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return 0, "", err
+	}
+	stats := body.Data.Attributes.LastAnalysisStats
+	score := maxInt(0, 100-25*stats.Malicious-10*stats.Suspicious)
+	switch {
+	case stats.Malicious >= minMaliciousEngines || stats.Malicious+stats.Suspicious >= minFlaggingEngines:
+		return score, ReputationMalicious, nil
+	case stats.Malicious > 0 || stats.Suspicious > 0:
+		return score, ReputationSuspicious, nil
+	case stats.Harmless+stats.Undetected == 0:
+		return 50, ReputationUnknown, nil
+	}
+	return score, ReputationClean, nil
+}
+
+// requestSpacer spaces requests evenly to stay within a quota. A request
+// that would have to wait past its context's deadline is refused instead of
+// queued, so a busy quota never holds up an analysis.
+type requestSpacer struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func (s *requestSpacer) wait(ctx context.Context) error {
+	s.mu.Lock()
+	now := time.Now()
+	at := s.next
+	if at.Before(now) {
+		at = now
+	}
+	if deadline, ok := ctx.Deadline(); ok && at.After(deadline) {
+		s.mu.Unlock()
+		return ErrReputationRateLimited
+	}
+	s.next = at.Add(s.interval)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,135 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+	"email-intelligence/internal/models"
+)
+
+func analysisStats(malicious, suspicious, harmless int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"attributes":{"last_analysis_stats":{"malicious":%d,"suspicious":%d,"harmless":%d,"undetected":10}}}}`,
+			malicious, suspicious, harmless)
+	}
+}
+
+func TestVirusTotalVerdicts(t *testing.T) {
+	tests := []struct {
+		name                  string
+		malicious, suspicious int
+		want                  string
+	}{
+		{"clean", 0, 0, ReputationClean},
+		{"one malicious engine is not enough", 1, 0, ReputationSuspicious},
+		{"one suspicious engine", 0, 1, ReputationSuspicious},
+		{"two malicious engines", 2, 0, ReputationMalicious},
+		{"malicious and suspicious engines agree", 1, 2, ReputationMalicious},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := countingServer(t, analysisStats(tt.malicious, tt.suspicious, 60))
+			// A quota high enough that the rate limit never delays the test
+			provider := NewVirusTotal(httpclient.New(httpclient.Options{}), VirusTotalOptions{
+				APIKey: "test", BaseURL: server, Timeout: time.Second, TTL: time.Hour, RequestsPerMinute: 60000,
+			})
+			_, verdict, err := provider.DomainReputation(context.Background(), "example.test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verdict != tt.want {
+				t.Errorf("verdict = %q, want %q", verdict, tt.want)
+			}
+		})
+	}
+}
+
+func TestVirusTotalCallerCancellationIsNotShared(t *testing.T) {
+	release := make(chan struct{})
+	server, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		analysisStats(0, 0, 60)(w, r)
+	})
+	provider := NewVirusTotal(httpclient.New(httpclient.Options{}), VirusTotalOptions{
+		APIKey: "test", BaseURL: server, Timeout: 5 * time.Second, TTL: time.Hour, RequestsPerMinute: 60000,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if _, _, err := provider.DomainReputation(ctx, "example.test"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// The lookup the first caller abandoned is still running; a new caller
+	// gets its answer, not the cancellation
+	close(release)
+	_, verdict, err := provider.DomainReputation(context.Background(), "example.test")
+	if err != nil || verdict != ReputationClean {
+		t.Fatalf("verdict, err = %q, %v, want clean", verdict, err)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestVirusTotalTimeoutIsNotCached(t *testing.T) {
+	var slow atomic.Bool
+	slow.Store(true)
+	server, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			time.Sleep(200 * time.Millisecond)
+		}
+		analysisStats(0, 0, 60)(w, r)
+	})
+	provider := NewVirusTotal(httpclient.New(httpclient.Options{}), VirusTotalOptions{
+		APIKey: "test", BaseURL: server, Timeout: 50 * time.Millisecond, TTL: time.Hour, RequestsPerMinute: 60000,
+	})
+
+	if _, _, err := provider.DomainReputation(context.Background(), "example.test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	slow.Store(false)
+	if _, verdict, err := provider.DomainReputation(context.Background(), "example.test"); err != nil || verdict != ReputationClean {
+		t.Fatalf("verdict, err = %q, %v, want clean", verdict, err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestMaliciousVerdictCapsReputation(t *testing.T) {
+	v := NewDomainValidator(models.ScoringWeights{}, map[string]int{"com": 70}, NewDisposableIndex(nil, nil, nil, 0), nil, nil)
+	result := models.DomainIntelligenceResult{
+		RegistrableDomain: "example.com",
+		IsFreeProvider:    models.ValidationResult{Status: "pass"},
+		DomainAge:         4000,
+	}
+	clean := v.calculateDomainReputation("example.com", result)
+
+	tests := []struct {
+		scanner int
+		want    int
+	}{
+		{75, maliciousReputationCap}, // a high scanner score cannot lift a flagged domain
+		{10, 10},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		v.ApplyReputation(&result, tt.scanner, ReputationMalicious, nil)
+		if result.ReputationScore != tt.want {
+			t.Errorf("scanner score %d: reputation = %d, want %d", tt.scanner, result.ReputationScore, tt.want)
+		}
+		if result.ReputationScore > clean {
+			t.Errorf("scanner score %d: reputation %d above the unflagged %d", tt.scanner, result.ReputationScore, clean)
+		}
+	}
+}
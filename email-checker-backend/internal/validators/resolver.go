@@ -0,0 +1,184 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// Resolver is the subset of DNS lookups used by the validators
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error) // network is "ip", "ip4" or "ip6"
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+}
+
+// NewNetResolver creates the default resolver backed by the Go DNS client,
+// sending queries over the given transport. The DoH JSON transport has its
+// own client since the JSON API is not a DNS message exchange.
+func NewNetResolver(transport DNSTransport) Resolver {
+	if transport.Protocol == DNSOverHTTPSJSON {
+		return newDoHJSONResolver(transport)
+	}
+	return createOptimizedResolver(transport)
+}
+
+// CachingResolver caches DNS answers and coalesces concurrent identical queries
+type CachingResolver struct {
+	base        Resolver
+	cache       *cache.Cache
+	group       singleflight.Group
+	ttl         time.Duration
+	negativeTTL time.Duration
+	timeout     time.Duration
+}
+
+// minAnswerTTL keeps answers with very short TTLs from being asked again on
+// every analysis
+const minAnswerTTL = 30 * time.Second
+
+// NewCachingResolver wraps a resolver with a per-(qname, qtype) answer cache.
+// Answers are kept for their records' TTL, at least minAnswerTTL and at most
+// ttl; NXDOMAIN answers for at most a quarter of ttl. timeout bounds each
+// query, which runs apart from the callers waiting on it.
+func NewCachingResolver(base Resolver, ttl, timeout time.Duration) *CachingResolver {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &CachingResolver{
+		base:        base,
+		cache:       cache.New(ttl, ttl*2),
+		ttl:         ttl,
+		negativeTTL: ttl / 4,
+		timeout:     timeout,
+	}
+}
+
+// cachedAnswer stores either a successful answer or an authoritative not-found error
+type cachedAnswer struct {
+	value interface{}
+	err   error
+}
+
+// LookupHost resolves A/AAAA records through the cache
+func (r *CachingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	value, err := r.lookup(ctx, "A", host, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupHost(ctx, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]string), nil
+}
+
+// LookupIP resolves the addresses of one family ("ip4", "ip6") or both ("ip")
+// through the cache
+func (r *CachingResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	value, err := r.lookup(ctx, strings.ToUpper(network), host, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupIP(ctx, network, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]net.IP), nil
+}
+
+// LookupMX resolves MX records through the cache
+func (r *CachingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	value, err := r.lookup(ctx, "MX", name, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupMX(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]*net.MX), nil
+}
+
+// LookupTXT resolves TXT records through the cache
+func (r *CachingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	value, err := r.lookup(ctx, "TXT", name, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupTXT(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]string), nil
+}
+
+// LookupNS resolves NS records through the cache
+func (r *CachingResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	value, err := r.lookup(ctx, "NS", name, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupNS(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]*net.NS), nil
+}
+
+// lookup serves a query from cache or performs it once for all concurrent callers
+func (r *CachingResolver) lookup(ctx context.Context, qtype, qname string, query func(context.Context) (interface{}, error)) (interface{}, error) {
+	key := qtype + ":" + qname
+
+	if cached, found := r.cache.Get(key); found {
+		answer := cached.(cachedAnswer)
+		return answer.value, answer.err
+	}
+
+	// The shared query runs on its own timeout, so the first caller giving
+	// up neither aborts it for the others nor leaves its cancellation cached
+	resultChan := r.group.DoChan(key, func() (interface{}, error) {
+		queryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+		defer cancel()
+		recorder := &ttlRecorder{}
+		value, err := query(withTTLRecorder(queryCtx, recorder))
+		switch {
+		case queryCtx.Err() != nil:
+		case err == nil:
+			r.cache.Set(key, cachedAnswer{value: value}, answerTTL(recorder, r.ttl))
+		case isNotFound(err):
+			r.cache.Set(key, cachedAnswer{err: err}, answerTTL(recorder, r.negativeTTL))
+		}
+		return value, err
+	})
+
+	select {
+	case result := <-resultChan:
+		return result.Val, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// answerTTL is how long to keep an answer: the TTL its responses carried,
+// within minAnswerTTL and limit, or limit when none carried one
+func answerTTL(recorder *ttlRecorder, limit time.Duration) time.Duration {
+	ttl, ok := recorder.get()
+	switch {
+	case !ok || ttl > limit:
+		return limit
+	case ttl < minAnswerTTL:
+		return min(minAnswerTTL, limit)
+	}
+	return ttl
+}
+
+// isNotFound reports whether err is an authoritative "no such host" answer
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// lookupFailed reports whether err is a failure to get an answer, such as a
+// timeout or SERVFAIL, rather than an authoritative NXDOMAIN or empty answer
+func lookupFailed(err error) bool {
+	return err != nil && !isNotFound(err)
+}
+
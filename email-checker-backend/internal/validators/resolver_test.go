@@ -0,0 +1,203 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeResolver answers TXT lookups with answer, reporting ttl when set. Other
+// lookup types are not used by these tests.
+type fakeResolver struct {
+	Resolver
+	answer  func(ctx context.Context) ([]string, error)
+	ttl     time.Duration
+	queries int32
+}
+
+func (r *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	atomic.AddInt32(&r.queries, 1)
+	if r.ttl > 0 {
+		recordTTL(ctx, r.ttl)
+	}
+	return r.answer(ctx)
+}
+
+func answers(records ...string) func(context.Context) ([]string, error) {
+	return func(context.Context) ([]string, error) { return records, nil }
+}
+
+// expiresIn is how long the cached answer for name has left
+func expiresIn(t *testing.T, r *CachingResolver, name string) time.Duration {
+	t.Helper()
+	item, ok := r.cache.Items()["TXT:"+name]
+	if !ok {
+		t.Fatalf("%s not cached", name)
+	}
+	return time.Until(time.Unix(0, item.Expiration))
+}
+
+func TestCachingResolverHonoursTTL(t *testing.T) {
+	notFound := func(context.Context) ([]string, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: "example.test", IsNotFound: true}
+	}
+	tests := []struct {
+		name   string
+		answer func(context.Context) ([]string, error)
+		ttl    time.Duration
+		want   time.Duration
+	}{
+		{"answer TTL", answers("v=spf1 -all"), 10 * time.Minute, 10 * time.Minute},
+		{"capped at the configured TTL", answers("v=spf1 -all"), 48 * time.Hour, time.Hour},
+		{"raised to the floor", answers("v=spf1 -all"), 5 * time.Second, minAnswerTTL},
+		{"no TTL seen", answers("v=spf1 -all"), 0, time.Hour},
+		{"NXDOMAIN capped at the negative TTL", notFound, time.Hour, 15 * time.Minute},
+		{"NXDOMAIN SOA minimum", notFound, 2 * time.Minute, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &fakeResolver{answer: tt.answer, ttl: tt.ttl}
+			r := NewCachingResolver(base, time.Hour, time.Second)
+			r.LookupTXT(context.Background(), "example.test")
+			r.LookupTXT(context.Background(), "example.test")
+
+			if n := atomic.LoadInt32(&base.queries); n != 1 {
+				t.Errorf("queries = %d, want 1", n)
+			}
+			if left := expiresIn(t, r, "example.test"); left > tt.want || left < tt.want-5*time.Second {
+				t.Errorf("cached for %v, want %v", left.Round(time.Second), tt.want)
+			}
+		})
+	}
+}
+
+func TestCachingResolverSharedQueryOutlivesCaller(t *testing.T) {
+	release := make(chan struct{})
+	base := &fakeResolver{answer: func(ctx context.Context) ([]string, error) {
+		select {
+		case <-release:
+			return []string{"v=spf1 -all"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}}
+	r := NewCachingResolver(base, time.Hour, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.LookupTXT(ctx, "example.test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the caller's deadline", err)
+	}
+
+	close(release)
+	records, err := r.LookupTXT(context.Background(), "example.test")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("records, err = %v, %v, want the shared answer", records, err)
+	}
+	if n := atomic.LoadInt32(&base.queries); n != 1 {
+		t.Errorf("queries = %d, want 1", n)
+	}
+}
+
+func TestCachingResolverDoesNotCacheTimeouts(t *testing.T) {
+	var slow atomic.Bool
+	slow.Store(true)
+	base := &fakeResolver{answer: func(ctx context.Context) ([]string, error) {
+		if slow.Load() {
+			<-ctx.Done()
+			// Marked not-found as well, so only the timeout keeps it out of the cache
+			return nil, &net.DNSError{Err: ctx.Err().Error(), Name: "example.test", IsTimeout: true, IsNotFound: true}
+		}
+		return []string{"v=spf1 -all"}, nil
+	}}
+	r := NewCachingResolver(base, time.Hour, 50*time.Millisecond)
+
+	if _, err := r.LookupTXT(context.Background(), "example.test"); err == nil {
+		t.Fatal("timed out query succeeded")
+	}
+	slow.Store(false)
+	if records, err := r.LookupTXT(context.Background(), "example.test"); err != nil || len(records) != 1 {
+		t.Fatalf("records, err = %v, %v, want a fresh answer", records, err)
+	}
+	if n := atomic.LoadInt32(&base.queries); n != 2 {
+		t.Errorf("queries = %d, want 2", n)
+	}
+}
+
+// packMessage builds a response with answers of the given TTLs and, when
+// soaTTL is set, an SOA authority record with minimum soaMin
+func packMessage(t *testing.T, answerTTLs []uint32, soaTTL, soaMin uint32) []byte {
+	t.Helper()
+	name := dnsmessage.MustNewName("example.test.")
+	msg := dnsmessage.Message{Header: dnsmessage.Header{Response: true}}
+	for _, ttl := range answerTTLs {
+		msg.Answers = append(msg.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+		})
+	}
+	if soaTTL > 0 {
+		msg.Authorities = append(msg.Authorities, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: soaTTL},
+			Body:   &dnsmessage.SOAResource{NS: name, MBox: name, MinTTL: soaMin},
+		})
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packed
+}
+
+func TestMessageTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     []byte
+		want    time.Duration
+		wantSet bool
+	}{
+		{"lowest answer", packMessage(t, []uint32{300, 60, 900}, 0, 0), time.Minute, true},
+		{"negative answer uses the SOA minimum", packMessage(t, nil, 3600, 120), 2 * time.Minute, true},
+		{"negative answer uses a lower SOA TTL", packMessage(t, nil, 90, 600), 90 * time.Second, true},
+		{"nothing to go by", packMessage(t, nil, 0, 0), 0, false},
+		{"garbage", []byte{1, 2, 3}, 0, false},
+	}
+	for _, tt := range tests {
+		ttl, ok := messageTTL(tt.msg)
+		if ttl != tt.want || ok != tt.wantSet {
+			t.Errorf("%s: messageTTL = %v, %v, want %v, %v", tt.name, ttl, ok, tt.want, tt.wantSet)
+		}
+	}
+}
+
+func TestTTLConnReassemblesStreamMessages(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	recorder := &ttlRecorder{}
+	conn := &ttlConn{Conn: client, ctx: withTTLRecorder(context.Background(), recorder), stream: true}
+
+	msg := packMessage(t, []uint32{45}, 0, 0)
+	framed := append([]byte{byte(len(msg) >> 8), byte(len(msg))}, msg...)
+	go func() {
+		// Sent in pieces, as a TCP stream may deliver it
+		server.Write(framed[:1])
+		server.Write(framed[1:10])
+		server.Write(framed[10:])
+		server.Close()
+	}()
+
+	buf := make([]byte, 4)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+	if ttl, ok := recorder.get(); !ok || ttl != 45*time.Second {
+		t.Errorf("recorded %v, %v, want 45s", ttl, ok)
+	}
+}
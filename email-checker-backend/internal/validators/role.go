@@ -0,0 +1,71 @@
+package validators
+
+import (
+	"strings"
+
+	"email-intelligence/internal/models"
+)
+
+// DefaultRolePatterns are mailboxes that belong to a function rather than a
+// person. Mail to them reaches a team or nobody, is rarely opted in and is a
+// common home for spam traps.
+var DefaultRolePatterns = []string{
+	"abuse", "admin", "billing", "contact", "hello", "help", "hostmaster",
+	"info", "marketing", "noc", "noreply", "no-reply", "office", "postmaster",
+	"root", "sales", "security", "support", "team", "webmaster",
+}
+
+// LocalPartValidator judges the local part of an address on its own, without
+// network lookups
+type LocalPartValidator struct {
+	roles map[string]bool
+}
+
+// NewLocalPartValidator creates a local part validator. rolePatterns are
+// matched case-insensitively; nil uses DefaultRolePatterns.
+func NewLocalPartValidator(rolePatterns []string) *LocalPartValidator {
+	if rolePatterns == nil {
+		rolePatterns = DefaultRolePatterns
+	}
+	roles := make(map[string]bool, len(rolePatterns))
+	for _, pattern := range rolePatterns {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			roles[pattern] = true
+		}
+	}
+	return &LocalPartValidator{roles: roles}
+}
+
+// CheckRoleAccount fails when the local part names a role, either exactly
+// ("support") or as its first segment ("support.emea", "sales-uk"). A "+tag"
+// is ignored.
+func (v *LocalPartValidator) CheckRoleAccount(localPart string) models.ValidationResult {
+	name := strings.ToLower(localPart)
+	if plus := strings.Index(name, "+"); plus > 0 {
+		name = name[:plus]
+	}
+
+	role := ""
+	if v.roles[name] {
+		role = name
+	} else if i := strings.IndexAny(name, ".-_"); i > 0 && v.roles[name[:i]] {
+		role = name[:i]
+	}
+	if role != "" {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Role account (" + role + "@) reaches a function, not a person",
+			RawSignal: "role:" + role,
+			Score:     0,
+			Weight:    0,
+		}
+	}
+
+	return models.ValidationResult{
+		Status:    "pass",
+		Reason:    "Not a role account",
+		RawSignal: "personal",
+		Score:     0,
+		Weight:    0,
+	}
+}
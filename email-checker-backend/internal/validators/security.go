@@ -3,7 +3,7 @@ package validators
 import (
 	"context"
 	"fmt"
-	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -11,34 +11,106 @@ import (
 	"email-intelligence/internal/models"
 )
 
+// SecurityOptions bounds how long and how widely the security validator searches
+type SecurityOptions struct {
+	Timeout         time.Duration     // overall budget for SPF, DMARC and DKIM together
+	DKIMConcurrency int               // selector lookups in flight at once
+	DKIMSelectors   []string          // searched in addition to DefaultDKIMSelectors; invalid names are ignored
+	ESPIncludes     map[string]string // SPF include domain -> reputable sending provider
+	Strict          bool              // no DKIM credit for well-known providers without a record found
+}
+
+// DefaultDKIMSelectors are the selectors of common providers, searched for
+// every domain
+var DefaultDKIMSelectors = []string{
+	// Google/Gmail selectors
+	"google", "ga1", "20230601", "20210112", "20161025",
+	// Microsoft/Outlook selectors
+	"selector1", "selector2", "selector1-outlook-com", "selector2-outlook-com",
+	// Common selectors
+	"default", "dkim", "k1", "k2", "k3",
+	"mail", "email", "smtp", "mx", "s1", "s2",
+	// Other providers
+	"protonmail", "protonmail2", "protonmail3",
+	"yahoo", "ymail", "s", "sig1",
+	"zoho", "zmail",
+	"mailchimp", "mandrill", "sendgrid", "amazonses",
+}
+
 // SecurityValidator validates security records (SPF, DKIM, DMARC)
 type SecurityValidator struct {
-	resolver *net.Resolver
-	timeout  time.Duration
+	resolver        Resolver
+	timeout         time.Duration
+	dkimConcurrency int
+	dkimSelectors   []string
+	espIncludes     map[string]string
+	strict          bool
 }
 
 // NewSecurityValidator creates a new security validator
-func NewSecurityValidator(timeout time.Duration) *SecurityValidator {
+func NewSecurityValidator(resolver Resolver, opts SecurityOptions) *SecurityValidator {
+	if opts.DKIMConcurrency < 1 {
+		opts.DKIMConcurrency = 1
+	}
 	return &SecurityValidator{
-		resolver: createOptimizedResolver(),
-		timeout:  timeout,
+		resolver:        resolver,
+		timeout:         opts.Timeout,
+		dkimConcurrency: opts.DKIMConcurrency,
+		dkimSelectors:   mergeSelectors(DefaultDKIMSelectors, opts.DKIMSelectors),
+		espIncludes:     opts.ESPIncludes,
+		strict:          opts.Strict,
+	}
+}
+
+// mergeSelectors appends the valid extra selectors not already in base
+func mergeSelectors(base, extra []string) []string {
+	merged := append([]string{}, base...)
+	seen := map[string]bool{}
+	for _, selector := range base {
+		seen[strings.ToLower(selector)] = true
+	}
+	for _, selector := range extra {
+		if ValidSelector(selector) && !seen[strings.ToLower(selector)] {
+			seen[strings.ToLower(selector)] = true
+			merged = append(merged, selector)
+		}
 	}
+	return merged
 }
 
-// Validate performs security analysis with PARALLEL lookups
-func (v *SecurityValidator) Validate(ctx context.Context, domain string) models.SecurityAnalysisResult {
-	result := models.SecurityAnalysisResult{}
+// Validate performs security analysis with PARALLEL lookups. Checks that have
+// not finished when the timeout expires are reported as "unknown". A non-empty
+// dkimSelector is looked up alone instead of searching the known selectors.
+func (v *SecurityValidator) Validate(ctx context.Context, domain, dkimSelector string) models.SecurityAnalysisResult {
+	if v.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.timeout)
+		defer cancel()
+	}
+	
+	shared := models.SecurityAnalysisResult{
+		SPFRecord:   incompleteLookup("SPF", 7),
+		DMARCRecord: incompleteLookup("DMARC", 7),
+		DKIMRecord:  incompleteLookup("DKIM", 6),
+		BIMIRecord:  bimiNotChecked,
+	}
 	
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	trace := &txtTrace{}
 	
 	// 1. SPF lookup (parallel)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		spfResult := v.lookupSPF(ctx, domain)
+		spfResult, spf := v.lookupSPF(ctx, domain, trace)
 		mu.Lock()
-		result.SPFRecord = spfResult
+		shared.SPFRecord = spfResult
+		shared.SPFRecordText = spf.record
+		shared.SPFLookups = spf.lookups
+		if spf.record != "" {
+			shared.SPFWarnings = spf.warnings()
+		}
 		mu.Unlock()
 	}()
 	
@@ -46,24 +118,61 @@ func (v *SecurityValidator) Validate(ctx context.Context, domain string) models.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		dmarcResult := v.lookupDMARC(ctx, domain)
+		dmarcResult, dmarc := v.lookupDMARC(ctx, domain, trace)
 		mu.Lock()
-		result.DMARCRecord = dmarcResult
+		shared.DMARCRecord = dmarcResult
+		shared.DMARCRecordText = dmarc.record
+		shared.DMARCPolicy = dmarc.policy
+		shared.DMARCSubPolicy = dmarc.subdomainPolicy
+		shared.DMARCPercent = dmarc.percent
 		mu.Unlock()
 	}()
 	
-	// 3. DKIM lookup (parallel with selector search)
+	// 3. DKIM lookup (parallel with selector search, or the caller's selector)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		dkimResult := v.lookupDKIM(ctx, domain)
+		var dkimResult models.ValidationResult
+		selector := dkimSelector
+		if selector != "" {
+			dkimResult = v.lookupDKIMSelector(ctx, domain, selector, trace)
+		} else {
+			dkimResult, selector = v.lookupDKIM(ctx, domain, trace)
+		}
 		mu.Lock()
-		result.DKIMRecord = dkimResult
+		shared.DKIMRecord = dkimResult
+		if dkimResult.Status == "pass" {
+			shared.DKIMSelector = selector
+		}
 		mu.Unlock()
 	}()
 	
-	// Wait for all parallel lookups
-	wg.Wait()
+	// Wait for all parallel lookups or the budget, whichever comes first
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	
+	mu.Lock()
+	result := shared
+	mu.Unlock()
+	
+	// Sending infrastructure from the SPF record
+	result.SPFIncludes = parseSPFIncludes(result.SPFRecordText)
+	if result.SPFWarnings == nil {
+		result.SPFWarnings = []string{}
+	}
+	result.SendingProviders = v.matchSendingProviders(result.SPFIncludes)
+	// A permissive or broken SPF record vouches for nobody, whatever it includes
+	result.ReputableSender = len(result.SendingProviders) > 0 && result.SPFRecord.Status == "pass" && result.DMARCRecord.Status == "pass"
+	result.TXTAnomalies = trace.list()
+	result.AbnormalTXT = len(result.TXTAnomalies) > 0
+	result.TXTLookups, result.DKIMSelectors = trace.answers()
 	
 	// Calculate security score
 	result.SecurityScore = result.SPFRecord.Score + result.DMARCRecord.Score + result.DKIMRecord.Score
@@ -80,19 +189,125 @@ func (v *SecurityValidator) Validate(ctx context.Context, domain string) models.
 	return result
 }
 
-// lookupSPF checks for SPF records
-func (v *SecurityValidator) lookupSPF(ctx context.Context, domain string) models.ValidationResult {
-	txtRecords, err := v.resolver.LookupTXT(ctx, domain)
+// parseSPFIncludes returns the domains referenced by include: mechanisms and
+// the redirect= modifier of an SPF record
+func parseSPFIncludes(record string) []string {
+	includes := []string{}
+	for _, term := range strings.Fields(record) {
+		term = strings.ToLower(term)
+		// Qualifiers (+include:, ~include:) are allowed on mechanisms
+		term = strings.TrimLeft(term, "+-~?")
+		if domain, ok := strings.CutPrefix(term, "include:"); ok && domain != "" {
+			includes = append(includes, domain)
+		} else if domain, ok := strings.CutPrefix(term, "redirect="); ok && domain != "" {
+			includes = append(includes, domain)
+		}
+	}
+	return includes
+}
+
+// dmarcPolicy is the enforcement a DMARC record asks receivers for
+type dmarcPolicy struct {
+	record          string
+	policy          string // p=: none, quarantine or reject
+	subdomainPolicy string // sp=, defaulting to p=
+	percent         int    // pct=, 0-100, defaulting to 100
+}
+
+// parseDMARC reads the p=, sp= and pct= tags of a DMARC record. A missing or
+// invalid p= is taken as none, as RFC 7489 section 6.6.3 does for records
+// that are otherwise usable.
+func parseDMARC(record string) dmarcPolicy {
+	dmarc := dmarcPolicy{record: record, percent: 100}
+	for _, tag := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if !ok {
+			continue
+		}
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "p":
+			if isDMARCPolicy(value) {
+				dmarc.policy = value
+			}
+		case "sp":
+			if isDMARCPolicy(value) {
+				dmarc.subdomainPolicy = value
+			}
+		case "pct":
+			if pct, err := strconv.Atoi(value); err == nil {
+				dmarc.percent = max(0, min(pct, 100))
+			}
+		}
+	}
+	if dmarc.policy == "" {
+		dmarc.policy = "none"
+	}
+	if dmarc.subdomainPolicy == "" {
+		dmarc.subdomainPolicy = dmarc.policy
+	}
+	return dmarc
+}
+
+func isDMARCPolicy(value string) bool {
+	return value == "none" || value == "quarantine" || value == "reject"
+}
+
+// rawSignal is the parsed policy as reported in RawSignal
+func (d dmarcPolicy) rawSignal() string {
+	return fmt.Sprintf("p=%s sp=%s pct=%d", d.policy, d.subdomainPolicy, d.percent)
+}
+
+// result scores the policy: full credit for quarantine or reject applied to
+// all failing mail, less for a partial rollout, little for monitoring only
+func (d dmarcPolicy) result() models.ValidationResult {
+	result := models.ValidationResult{
+		Status:    "pass",
+		Reason:    fmt.Sprintf("DMARC record found (p=%s)", d.policy),
+		RawSignal: d.rawSignal(),
+		Score:     7,
+		Weight:    7,
+	}
+	switch {
+	case d.policy == "none":
+		result.Reason = "DMARC record found, but p=none only monitors and protects nothing"
+		result.Score = 2
+	case d.percent < 100:
+		result.Reason = fmt.Sprintf("DMARC record found, but p=%s applies to only %d%% of failing mail", d.policy, d.percent)
+		result.Score = 3 + 4*d.percent/100
+	}
+	return result
+}
+
+// matchSendingProviders maps SPF includes to known reputable ESPs
+func (v *SecurityValidator) matchSendingProviders(includes []string) []string {
+	providers := []string{}
+	seen := map[string]bool{}
+	for _, include := range includes {
+		for domain, provider := range v.espIncludes {
+			if (include == domain || strings.HasSuffix(include, "."+domain)) && !seen[provider] {
+				seen[provider] = true
+				providers = append(providers, provider)
+			}
+		}
+	}
+	return providers
+}
+
+// lookupSPF checks for SPF records, flattening includes to judge the policy
+func (v *SecurityValidator) lookupSPF(ctx context.Context, domain string, trace *txtTrace) (models.ValidationResult, spfPolicy) {
+	txtRecords, err := v.lookupTXT(ctx, domain, trace)
+	if err != nil && ctx.Err() != nil {
+		return incompleteLookup("SPF", 7), spfPolicy{}
+	}
+	if lookupFailed(err) {
+		return dnsErrorResult("SPF", 7, err), spfPolicy{}
+	}
 	if err == nil {
 		for _, txt := range txtRecords {
-			if strings.HasPrefix(txt, "v=spf1") {
-				return models.ValidationResult{
-					Status:    "pass",
-					Reason:    "SPF record found",
-					RawSignal: txt,
-					Score:     7,
-					Weight:    7,
-				}
+			if isSPFRecord(txt) {
+				policy := v.flattenSPF(ctx, domain, txt, trace)
+				return policy.result(), policy
 			}
 		}
 	}
@@ -103,22 +318,23 @@ func (v *SecurityValidator) lookupSPF(ctx context.Context, domain string) models
 		RawSignal: "no_spf_record",
 		Score:     0,
 		Weight:    7,
-	}
+	}, spfPolicy{}
 }
 
-// lookupDMARC checks for DMARC records
-func (v *SecurityValidator) lookupDMARC(ctx context.Context, domain string) models.ValidationResult {
-	dmarcRecords, err := v.resolver.LookupTXT(ctx, "_dmarc."+domain)
+// lookupDMARC checks for DMARC records and how strictly they are enforced
+func (v *SecurityValidator) lookupDMARC(ctx context.Context, domain string, trace *txtTrace) (models.ValidationResult, dmarcPolicy) {
+	dmarcRecords, err := v.lookupTXT(ctx, "_dmarc."+domain, trace)
+	if err != nil && ctx.Err() != nil {
+		return incompleteLookup("DMARC", 7), dmarcPolicy{}
+	}
+	if lookupFailed(err) {
+		return dnsErrorResult("DMARC", 7, err), dmarcPolicy{}
+	}
 	if err == nil {
 		for _, record := range dmarcRecords {
 			if strings.HasPrefix(record, "v=DMARC1") {
-				return models.ValidationResult{
-					Status:    "pass",
-					Reason:    "DMARC record found",
-					RawSignal: record,
-					Score:     7,
-					Weight:    7,
-				}
+				dmarc := parseDMARC(record)
+				return dmarc.result(), dmarc
 			}
 		}
 	}
@@ -129,65 +345,62 @@ func (v *SecurityValidator) lookupDMARC(ctx context.Context, domain string) mode
 		RawSignal: "no_dmarc_record",
 		Score:     0,
 		Weight:    7,
-	}
+	}, dmarcPolicy{}
 }
 
-// lookupDKIM checks for DKIM records with PARALLEL selector search
-func (v *SecurityValidator) lookupDKIM(ctx context.Context, domain string) models.ValidationResult {
-	dkimSelectors := []string{
-		// Google/Gmail selectors
-		"google", "ga1", "20230601", "20210112", "20161025",
-		// Microsoft/Outlook selectors
-		"selector1", "selector2", "selector1-outlook-com", "selector2-outlook-com",
-		// Common selectors
-		"default", "dkim", "k1", "k2", "k3",
-		"mail", "email", "smtp", "mx", "s1", "s2",
-		// Other providers
-		"protonmail", "protonmail2", "protonmail3",
-		"yahoo", "ymail", "s", "sig1",
-		"zoho", "zmail",
-		"mailchimp", "mandrill", "sendgrid", "amazonses",
-	}
-	
+// lookupDKIM checks for DKIM records with PARALLEL selector search and
+// returns the selector that matched, if any
+func (v *SecurityValidator) lookupDKIM(ctx context.Context, domain string, trace *txtTrace) (models.ValidationResult, string) {
 	// Channel to receive first successful result
-	resultChan := make(chan models.ValidationResult, 1)
+	resultChan := make(chan dkimMatch, 1)
 	var wg sync.WaitGroup
+	parent := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	
-	// Try all selectors in PARALLEL
-	for _, selector := range dkimSelectors {
+	// A selector whose lookup failed may be the one in use, so absence is
+	// only proven when every selector got an answer
+	var failedMu sync.Mutex
+	var failed error
+	
+	// Try selectors in PARALLEL, bounded so one domain cannot flood the resolver
+	slots := make(chan struct{}, v.dkimConcurrency)
+	for _, selector := range v.dkimSelectors {
 		wg.Add(1)
 		go func(sel string) {
 			defer wg.Done()
 			
 			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
 			case <-ctx.Done():
-				return // Another goroutine found it
-			default:
+				return // Another goroutine found it, or the budget ran out
 			}
 			
-			dkimRecords, err := v.resolver.LookupTXT(ctx, sel+"._domainkey."+domain)
+			dkimRecords, err := v.lookupTXT(ctx, sel+"._domainkey."+domain, trace)
+			if err == nil || ctx.Err() == nil {
+				trace.selector(dkimSelectorResult(domain, sel, dkimRecords, err))
+			}
+			if lookupFailed(err) && ctx.Err() == nil {
+				failedMu.Lock()
+				failed = err
+				failedMu.Unlock()
+			}
 			if err == nil && len(dkimRecords) > 0 {
 				fullRecord := strings.Join(dkimRecords, "")
 				
 				// Validate DKIM record
 				if isValidDKIMRecord(fullRecord) {
-					displayRecord := fullRecord
-					if len(displayRecord) > 100 {
-						displayRecord = displayRecord[:100] + "..."
-					}
-					
 					result := models.ValidationResult{
 						Status:    "pass",
 						Reason:    fmt.Sprintf("DKIM record found (selector: %s)", sel),
-						RawSignal: displayRecord,
+						RawSignal: truncateRecord(fullRecord),
 						Score:     6,
 						Weight:    6,
 					}
 					
 					select {
-					case resultChan <- result:
+					case resultChan <- dkimMatch{result: result, selector: sel}:
 						cancel() // Stop other goroutines
 					default:
 					}
@@ -203,12 +416,120 @@ func (v *SecurityValidator) lookupDKIM(ctx context.Context, domain string) model
 	}()
 	
 	// Return first successful result or check trusted providers
-	if result, ok := <-resultChan; ok {
-		return result
+	if match, ok := <-resultChan; ok {
+		return match.result, match.selector
 	}
 	
 	// Check trusted providers
-	return checkTrustedDKIMProvider(domain)
+	result := checkTrustedDKIMProvider(domain, v.strict)
+	if result.Status == "fail" && parent.Err() != nil {
+		// The search was cut short, so absence is not proven
+		return incompleteLookup("DKIM", 6), ""
+	}
+	if result.Status == "fail" && failed != nil {
+		return dnsErrorResult("DKIM", 6, failed), ""
+	}
+	return result, ""
+}
+
+// dkimMatch is a selector whose record passed validation
+type dkimMatch struct {
+	result   models.ValidationResult
+	selector string
+}
+
+// lookupDKIMSelector checks only the selector the caller named, so a custom
+// or rotated selector is found without searching the known ones
+func (v *SecurityValidator) lookupDKIMSelector(ctx context.Context, domain, selector string, trace *txtTrace) models.ValidationResult {
+	records, err := v.lookupTXT(ctx, selector+"._domainkey."+domain, trace)
+	if err != nil && ctx.Err() != nil {
+		return incompleteLookup("DKIM", 6)
+	}
+	trace.selector(dkimSelectorResult(domain, selector, records, err))
+	if lookupFailed(err) {
+		return dnsErrorResult("DKIM", 6, err)
+	}
+	if err == nil && len(records) > 0 {
+		checked := models.DKIMSelectorResult{Record: strings.Join(records, ""), Tags: map[string]string{}}
+		parseDKIMRecord(&checked)
+		if checked.Status == DKIMValid {
+			return models.ValidationResult{
+				Status:    "pass",
+				Reason:    fmt.Sprintf("DKIM record found (selector: %s)", selector),
+				RawSignal: truncateRecord(checked.Record),
+				Score:     6,
+				Weight:    6,
+			}
+		}
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    fmt.Sprintf("DKIM record for selector %s is not usable: %s", selector, checked.Reason),
+			RawSignal: "dkim_" + checked.Status,
+			Score:     0,
+			Weight:    6,
+		}
+	}
+	
+	return models.ValidationResult{
+		Status:    "fail",
+		Reason:    fmt.Sprintf("No DKIM record found for selector %s", selector),
+		RawSignal: "no_dkim_record",
+		Score:     0,
+		Weight:    6,
+	}
+}
+
+// truncateRecord shortens a DKIM record for display
+func truncateRecord(record string) string {
+	if len(record) > 100 {
+		return record[:100] + "..."
+	}
+	return record
+}
+
+// Raw signals of results whose lookup failed without an answer; the record
+// may well exist, so they are reported as "unknown", never "fail"
+const (
+	SignalDNSError      = "dns_error"      // timeout, SERVFAIL or unreachable resolver
+	SignalLookupTimeout = "lookup_timeout" // the check's own time budget ran out
+)
+
+// SecurityPoints is what the SPF, DMARC and DKIM checks are scored out of
+// together (7+7+6), whatever the scoring weights; the score analyzer rescales
+// it to the security weight
+const SecurityPoints = 20
+
+// SignalNoMXRecords is the raw signal of an MX check that got an answer: the
+// domain does not exist or publishes no MX records
+const SignalNoMXRecords = "no_mx_records"
+
+// incompleteLookup is the result for a record whose lookup did not finish in time
+func incompleteLookup(record string, weight int) models.ValidationResult {
+	return models.ValidationResult{
+		Status:    "unknown",
+		Reason:    record + " lookup did not complete in time",
+		RawSignal: SignalLookupTimeout,
+		Score:     0,
+		Weight:    weight,
+	}
+}
+
+// dnsErrorResult is the result for a record whose lookup failed without an
+// authoritative answer
+func dnsErrorResult(record string, weight int, err error) models.ValidationResult {
+	return models.ValidationResult{
+		Status:    "unknown",
+		Reason:    record + " lookup failed, so its absence is not proven: " + err.Error(),
+		RawSignal: SignalDNSError,
+		Score:     0,
+		Weight:    weight,
+	}
+}
+
+// Degraded reports whether a result could not be determined because its
+// lookup failed or ran out of time
+func Degraded(result models.ValidationResult) bool {
+	return result.Status == "unknown" && (result.RawSignal == SignalDNSError || result.RawSignal == SignalLookupTimeout)
 }
 
 // isValidDKIMRecord checks if a DKIM record is valid
@@ -229,8 +550,9 @@ func isValidDKIMRecord(record string) bool {
 	return strings.Contains(record, "v=DKIM1") || strings.Contains(record, "k=ed25519")
 }
 
-// checkTrustedDKIMProvider checks if domain is a trusted provider
-func checkTrustedDKIMProvider(domain string) models.ValidationResult {
+// checkTrustedDKIMProvider checks if domain is a trusted provider; strict
+// mode trusts none
+func checkTrustedDKIMProvider(domain string, strict bool) models.ValidationResult {
 	trustedDKIMProviders := map[string]bool{
 		"gmail.com": true, "googlemail.com": true,
 		"yahoo.com": true, "yahoo.co.in": true, "yahoo.co.uk": true,
@@ -241,7 +563,7 @@ func checkTrustedDKIMProvider(domain string) models.ValidationResult {
 		"zoho.com": true,
 	}
 	
-	if trustedDKIMProviders[strings.ToLower(domain)] {
+	if !strict && trustedDKIMProviders[strings.ToLower(domain)] {
 		return models.ValidationResult{
 			Status:    "pass",
 			Reason:    "DKIM configured (trusted provider)",
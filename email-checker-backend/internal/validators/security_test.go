@@ -0,0 +1,31 @@
+package validators
+
+import "testing"
+
+func TestParseDMARC(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     string
+		wantSignal string
+		wantScore  int
+	}{
+		{"reject", "v=DMARC1; p=reject; rua=mailto:d@example.test", "p=reject sp=reject pct=100", 7},
+		{"quarantine at pct=50", "v=DMARC1; p=quarantine; pct=50", "p=quarantine sp=quarantine pct=50", 5},
+		{"quarantine at pct=0", "v=DMARC1; p=quarantine; pct=0", "p=quarantine sp=quarantine pct=0", 3},
+		{"none", "v=DMARC1; p=none", "p=none sp=none pct=100", 2},
+		{"missing p", "v=DMARC1; rua=mailto:d@example.test", "p=none sp=none pct=100", 2},
+		{"invalid p", "v=DMARC1; p=block", "p=none sp=none pct=100", 2},
+		{"invalid pct", "v=DMARC1; p=reject; pct=half", "p=reject sp=reject pct=100", 7},
+		{"pct over 100", "v=DMARC1; p=reject; pct=250", "p=reject sp=reject pct=100", 7},
+		{"sp defaults to p", "v=DMARC1; p=quarantine", "p=quarantine sp=quarantine pct=100", 7},
+		{"explicit sp", "v=DMARC1; p=reject; sp=none", "p=reject sp=none pct=100", 7},
+		{"invalid sp defaults to p", "v=DMARC1; p=reject; sp=maybe", "p=reject sp=reject pct=100", 7},
+		{"case and spacing", "v=DMARC1;P = Reject ; PCT= 100", "p=reject sp=reject pct=100", 7},
+	}
+	for _, tt := range tests {
+		result := parseDMARC(tt.record).result()
+		if result.RawSignal != tt.wantSignal || result.Score != tt.wantScore || result.Status != "pass" {
+			t.Errorf("%s: %s %q score %d, want pass %q score %d", tt.name, result.Status, result.RawSignal, result.Score, tt.wantSignal, tt.wantScore)
+		}
+	}
+}
@@ -4,31 +4,217 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
-	"fmt"
 	"net"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"email-intelligence/internal/models"
+
+	"github.com/patrickmn/go-cache"
 )
 
+// smtpPorts are the ports probed on each MX host, in order: the next is only
+// dialed when the previous one could not be connected to
+var smtpPorts = []int{25, 587, 465, 2525}
+
+// Mailbox states reported from the RCPT TO reply
+const (
+	MailboxActive      = "active"
+	MailboxDisabled    = "disabled"
+	MailboxNonexistent = "nonexistent"
+	MailboxFull        = "full"
+	MailboxUnknown     = "unknown"
+)
+
+// How an SMTP verdict was reached
+const (
+	VerificationRCPT            = "rcpt_verified"    // the server answered RCPT TO for the mailbox
+	VerificationTCPOnly         = "tcp_only"         // a connection was made but no RCPT TO answer was obtained
+	VerificationAssumedMX       = "assumed_mx"       // no server could be reached; MX records were taken as enough
+	VerificationTrustedProvider = "trusted_provider" // known provider, no connection attempted
+	VerificationSkipped         = "skipped"          // SMTP was not attempted
+)
+
+// SMTPOptions tunes how the SMTP validator talks to mail servers
+type SMTPOptions struct {
+	Timeout        time.Duration
+	StartTLS       bool          // upgrade with STARTTLS when the server advertises it
+	CacheTTL       time.Duration // how long a mailbox verdict from one MX host is reused
+	HeloName       string        // FQDN announced in EHLO; should resolve back to the probing host
+	MailFrom       string        // envelope sender, ideally a monitored abuse/contact mailbox
+	BlockThreshold int           // consecutive refusals/421s before an MX host is paused
+	BlockCooldown  time.Duration // how long a paused MX host is left alone
+	SourceAddrs    []net.IP      // local addresses probes rotate through; empty uses the default route
+	Strict         bool          // probe well-known providers too instead of assuming their mailboxes exist
+
+	MaxConnsPerHost int // simultaneous connections to one MX host across all validations; 0 uses DefaultMaxConnsPerHost
+
+	// ValidateWithRetries only: extra rounds after a deferral, the wait
+	// before the first (doubled for each further one), and the longest the
+	// rounds together may take
+	Retries      int
+	RetryBackoff time.Duration
+	RetryBudget  time.Duration
+}
+
 // SMTPValidator validates SMTP connectivity
 type SMTPValidator struct {
-	timeout time.Duration
-	weights models.ScoringWeights
+	timeout  time.Duration
+	startTLS bool
+	heloName string
+	mailFrom string
+	strict   bool
+	weights  models.ScoringWeights
+	cacheTTL time.Duration
+	verdicts *cache.Cache // keyed by mailbox and MX host
+	catchAll *cache.Cache // catch-all verdicts keyed by domain
+	backoff  *mxBackoff   // shared across validations so bulk runs back off blocking hosts
+	sources  *sourcePool  // nil when probes use the default route
+	conns    *connLimiter // shared so concurrent validations queue for busy hosts
+	ports    []int        // tried in turn on each MX host; the first also serves catch-all probes
+
+	retries      int
+	retryBackoff time.Duration
+	retryBudget  time.Duration
 }
 
 // NewSMTPValidator creates a new SMTP validator
-func NewSMTPValidator(timeout time.Duration, weights models.ScoringWeights) *SMTPValidator {
+func NewSMTPValidator(opts SMTPOptions, weights models.ScoringWeights) *SMTPValidator {
+	if opts.HeloName == "" || ContainsControl(opts.HeloName) {
+		opts.HeloName = "emailintel.local"
+	}
+	if opts.MailFrom == "" || ContainsControl(opts.MailFrom) {
+		opts.MailFrom = "verify@" + opts.HeloName
+	}
 	return &SMTPValidator{
-		timeout: timeout,
-		weights: weights,
+		timeout:  opts.Timeout,
+		startTLS: opts.StartTLS,
+		heloName: opts.HeloName,
+		mailFrom: opts.MailFrom,
+		strict:   opts.Strict,
+		weights:  weights,
+		cacheTTL: opts.CacheTTL,
+		verdicts: cache.New(opts.CacheTTL, opts.CacheTTL*2),
+		catchAll: cache.New(opts.CacheTTL, opts.CacheTTL*2),
+		backoff:  newMXBackoff(opts.BlockThreshold, opts.BlockCooldown),
+		sources:  newSourcePool(opts.SourceAddrs, opts.BlockThreshold, opts.BlockCooldown),
+		conns:    newConnLimiter(opts.MaxConnsPerHost),
+		ports:    smtpPorts,
+
+		retries:      opts.Retries,
+		retryBackoff: opts.RetryBackoff,
+		retryBudget:  opts.RetryBudget,
+	}
+}
+
+// WithWeights returns a validator that scores against weights. It shares the
+// host backoff, source addresses and connection limits with v, but not its
+// cached verdicts, which carry v's weights.
+func (v *SMTPValidator) WithWeights(weights models.ScoringWeights) *SMTPValidator {
+	copied := *v
+	copied.weights = weights
+	copied.verdicts = cache.New(v.cacheTTL, v.cacheTTL*2)
+	copied.catchAll = cache.New(v.cacheTTL, v.cacheTTL*2)
+	return &copied
+}
+
+// respondedScore is the partial credit for a server that answered without
+// confirming the mailbox: three quarters of the SMTP weight
+func (v *SMTPValidator) respondedScore() int {
+	return v.weights.SMTPReachability * 3 / 4
+}
+
+// assumedScore is the partial credit for a deferral or for reachability taken
+// from the MX records alone: three fifths of the SMTP weight
+func (v *SMTPValidator) assumedScore() int {
+	return v.weights.SMTPReachability * 3 / 5
+}
+
+// InvalidateMailbox drops every cached verdict for a mailbox, e.g. after a
+// confirmed bounce contradicts an optimistic "verified" result
+func (v *SMTPValidator) InvalidateMailbox(email string) {
+	prefix := strings.ToLower(email) + "|"
+	for key := range v.verdicts.Items() {
+		if strings.HasPrefix(key, prefix) {
+			v.verdicts.Delete(key)
+		}
 	}
 }
 
-// Validate performs SMTP validation with PARALLEL connection attempts
+func verdictKey(email, mxHost string) string {
+	return strings.ToLower(email) + "|" + strings.ToLower(mxHost)
+}
+
+// Validate performs SMTP validation, probing the MX hosts in parallel
 func (v *SMTPValidator) Validate(ctx context.Context, email string, mxRecords []models.MXRecord) models.SMTPValidationResult {
+	result := v.validate(ctx, email, mxRecords)
+	result.VerificationMethod = verificationMethod(result)
+	result.SMTPVerificationPerformed = result.VerificationMethod == VerificationRCPT || result.VerificationMethod == VerificationTCPOnly
+	if result.MailboxStatus == "" {
+		result.MailboxStatus = MailboxUnknown // no RCPT TO answer was obtained
+	}
+	result.Attempts = 1
+	return result
+}
+
+// ValidateWithRetries validates like Validate, but when the servers defer
+// the answer with a 4xx (e.g. greylisting) it waits and asks again, up to
+// the configured number of retries with doubling backoff. A retry that would
+// not finish before the retry budget or ctx's deadline is not started.
+func (v *SMTPValidator) ValidateWithRetries(ctx context.Context, email string, mxRecords []models.MXRecord) models.SMTPValidationResult {
+	if v.retryBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.retryBudget)
+		defer cancel()
+	}
+	
+	result := v.Validate(ctx, email, mxRecords)
+	wait := v.retryBackoff
+	for retry := 0; retry < v.retries && result.Deferred; retry++ {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait+v.timeout {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		}
+		
+		retried := v.Validate(ctx, email, mxRecords)
+		retried.Attempts = result.Attempts + 1
+		// A host now in backoff, or a cancelled round, says less than the deferral
+		if retried.VerificationMethod == VerificationSkipped || ctx.Err() != nil {
+			result.Attempts = retried.Attempts
+			break
+		}
+		result = retried
+		wait *= 2
+	}
+	return result
+}
+
+// verificationMethod derives the provenance of a verdict from how it was produced
+func verificationMethod(result models.SMTPValidationResult) string {
+	switch {
+	case result.Reachable.RawSignal == "trusted_provider":
+		return VerificationTrustedProvider
+	case result.Reachable.RawSignal == "mx_verified":
+		return VerificationAssumedMX
+	case result.Reachable.RawSignal == "no_mx_records", result.Reachable.RawSignal == "unsafe_address",
+		result.Reachable.RawSignal == "mx_backoff", result.Reachable.RawSignal == SignalLookupTimeout:
+		return VerificationSkipped
+	case result.MailboxStatus != "":
+		return VerificationRCPT
+	}
+	return VerificationTCPOnly
+}
+
+func (v *SMTPValidator) validate(ctx context.Context, email string, mxRecords []models.MXRecord) models.SMTPValidationResult {
 	startTime := time.Now()
 
 	if len(mxRecords) == 0 {
@@ -43,68 +229,242 @@ func (v *SMTPValidator) Validate(ctx context.Context, email string, mxRecords []
 		}
 	}
 
-	// Extract domain from email
-	parts := strings.Split(email, "@")
-	domain := ""
-	if len(parts) == 2 {
-		domain = strings.ToLower(parts[1])
+	// Never build SMTP commands from an address that could inject extra lines
+	if ContainsControl(email) || strings.ContainsAny(email, "<>") {
+		return models.SMTPValidationResult{
+			Reachable: models.ValidationResult{
+				Status:    "fail",
+				Reason:    "Address is not safe to use in SMTP commands",
+				RawSignal: "unsafe_address",
+				Score:     0,
+				Weight:    v.weights.SMTPReachability,
+			},
+		}
 	}
 
+	// Extract domain from email
+	_, domain, _ := SplitAddress(email)
+	domain = strings.ToLower(domain)
+
 	// Check if it's a known trusted provider
 	if result, ok := v.checkTrustedProvider(domain, startTime); ok {
 		return result
 	}
 
-	// Try multiple MX servers and ports in PARALLEL
-	resultChan := make(chan models.SMTPValidationResult, 1)
+	// Reuse a recent verdict from any of the domain's MX hosts
+	for _, mx := range mxRecords {
+		if cached, found := v.verdicts.Get(verdictKey(email, mx.Host)); found {
+			result := cached.(models.SMTPValidationResult)
+			result.ResponseTime = time.Since(startTime).Milliseconds()
+			return result
+		}
+	}
+
+	// Leave hosts that recently refused or throttled us alone until their cooldown ends
+	available := make([]models.MXRecord, 0, len(mxRecords))
+	var retryAt time.Time
+	for _, mx := range mxRecords {
+		if until, blocked := v.backoff.blocked(mx.Host); blocked {
+			if retryAt.IsZero() || until.Before(retryAt) {
+				retryAt = until
+			}
+			continue
+		}
+		available = append(available, mx)
+	}
+	if len(available) == 0 {
+		return models.SMTPValidationResult{
+			Reachable: models.ValidationResult{
+				Status:    "unknown",
+				Reason:    "Mail servers are temporarily refusing verification; retry after " + retryAt.UTC().Format(time.RFC3339),
+				RawSignal: "mx_backoff",
+				Score:     0,
+				Weight:    v.weights.SMTPReachability,
+			},
+			ResponseTime: time.Since(startTime).Milliseconds(),
+		}
+	}
+	mxRecords = available
+	
+	// A random address is asked about in the same session, so the catch-all
+	// check is usually answered without a connection of its own
+	probe := ""
+	if _, found := v.catchAll.Get(domain); !found {
+		probe, _ = catchAllProbe(domain)
+	}
+	
+	// MX hosts are tried in PARALLEL, but each host's ports in turn: the next
+	// port is only dialed when the previous one could not be connected to
+	ports := v.ports
+	attempts := make(chan mxAttempt, len(mxRecords)*len(ports))
 	var wg sync.WaitGroup
+	parent := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	
-	ports := []int{25, 587, 465, 2525}
-	
-	// Launch parallel connection attempts
 	for _, mx := range mxRecords {
-		for _, port := range ports {
-			wg.Add(1)
-			go func(host string, p int) {
-				defer wg.Done()
-				
-				select {
-				case <-ctx.Done():
+		wg.Add(1)
+		go func(mx models.MXRecord) {
+			defer wg.Done()
+			host := mx.Host
+			
+			for _, p := range ports {
+				if ctx.Err() != nil {
 					return
-				default:
 				}
 				
-				result := v.trySMTPConnection(ctx, email, host, p, startTime)
-				if result.Reachable.Status == "pass" && result.Reachable.Score >= 15 {
-					select {
-					case resultChan <- result:
-						cancel() // Stop other attempts
-					default:
-					}
+				source := v.sources.pick(mxFamily(mx))
+				result := v.trySMTPConnection(ctx, email, probe, host, p, source, startTime)
+				v.sources.record(source, smtpProbe{reply: result.ServerResponse, answered: result.MailboxStatus != ""})
+				result.MXHost = host
+				if source != nil {
+					result.SourceIP = source.String()
 				}
-			}(mx.Host, port)
-		}
+				if result.MailboxStatus != "" && !result.Deferred {
+					// The server answered RCPT TO, so the verdict is worth keeping
+					v.verdicts.Set(verdictKey(email, host), result, cache.DefaultExpiration)
+				}
+				attempts <- mxAttempt{host: host, result: result}
+				if !connectionFailed(result) {
+					return
+				}
+			}
+		}(mx)
 	}
 	
-	// Wait for first success or all to complete
 	go func() {
 		wg.Wait()
-		close(resultChan)
+		close(attempts)
 	}()
 	
-	// Return first successful result
-	if result, ok := <-resultChan; ok {
-		return result
+	// Collect every attempt; once a usable verdict arrives, give the other
+	// hosts a short window to report before cancelling them
+	var best *models.SMTPValidationResult
+	var bestRank int
+	var window <-chan time.Time
+	rank := make(map[string]int, len(mxRecords)) // position in MX preference order
+	for i, mx := range mxRecords {
+		rank[mx.Host] = i
+	}
+	outcomes := make(map[string]models.MXTestResult)
+	throttled := make(map[string]bool)
+	
+collect:
+	for {
+		select {
+		case attempt, ok := <-attempts:
+			if !ok {
+				break collect
+			}
+			recordMXOutcome(outcomes, attempt)
+			if strings.HasPrefix(attempt.result.ServerResponse, "421") {
+				throttled[attempt.host] = true
+			}
+			
+			definitive := attempt.result.MailboxStatus == MailboxNonexistent || attempt.result.MailboxStatus == MailboxDisabled
+			usable := (attempt.result.Reachable.Status == "pass" && attempt.result.Reachable.Score >= v.respondedScore()) || definitive
+			// An answer or a deferral beats the TCP fallback. The most preferred
+			// host that answered wins, not the fastest, so the verdict is the
+			// same run to run; backup MX hosts often accept any recipient for
+			// relay, so even a deferral from a better host outranks them.
+			if !usable && !attempt.result.Deferred {
+				continue
+			}
+			if best == nil || rank[attempt.host] < bestRank {
+				result := attempt.result
+				if best == nil {
+					window = time.After(v.timeout)
+				}
+				best = &result
+				bestRank = rank[attempt.host]
+			}
+		case <-window:
+			break collect
+		case <-ctx.Done():
+			break collect
+		}
+	}
+	// Only a full run the caller did not cancel says anything about hosts that
+	// failed; decide that before cancel() marks ctx done
+	complete := best == nil && parent.Err() == nil
+	cancel() // Stop attempts still in flight
+	
+	v.updateBackoff(outcomes, throttled, complete)
+	
+	mxResults := make([]models.MXTestResult, 0, len(mxRecords))
+	for _, mx := range mxRecords {
+		outcome, ok := outcomes[mx.Host]
+		if !ok {
+			outcome = models.MXTestResult{Host: mx.Host, Priority: mx.Priority, Outcome: "timeout"}
+		}
+		outcome.Priority = mx.Priority
+		mxResults = append(mxResults, outcome)
+	}
+	
+	if best != nil {
+		best.MXResults = mxResults
+		return *best
+	}
+	
+	// Fallback: Try TCP connections in parallel, unless the caller has gone
+	result := v.tryTCPFallback(parent, mxRecords, startTime)
+	result.MXResults = mxResults
+	return result
+}
+
+// mxAttempt is the outcome of one host/port connection attempt
+type mxAttempt struct {
+	host   string
+	result models.SMTPValidationResult
+}
+
+// updateBackoff records a failure for hosts that throttled us (421) or, after a
+// complete run, could not be connected to at all; other answering hosts are cleared
+func (v *SMTPValidator) updateBackoff(outcomes map[string]models.MXTestResult, throttled map[string]bool, complete bool) {
+	for host, outcome := range outcomes {
+		switch {
+		case throttled[host]:
+			v.backoff.failure(host)
+		case outcome.Outcome == "connected":
+			v.backoff.success(host)
+		case complete:
+			v.backoff.failure(host)
+		}
+	}
+}
+
+// recordMXOutcome keeps the most informative attempt per host: a port that
+// answered beats a timeout, which beats a refused or failed connection
+func recordMXOutcome(outcomes map[string]models.MXTestResult, attempt mxAttempt) {
+	outcome := "connected"
+	switch attempt.result.Reachable.RawSignal {
+	case "connection_failed":
+		outcome = "failed"
+	case "connection_timeout":
+		outcome = "timeout"
+	}
+	
+	rank := map[string]int{"failed": 0, "timeout": 1, "connected": 2}
+	if existing, ok := outcomes[attempt.host]; ok && rank[existing.Outcome] >= rank[outcome] {
+		return
 	}
 	
-	// Fallback: Try TCP connections in parallel
-	return v.tryTCPFallback(ctx, mxRecords, startTime)
+	outcomes[attempt.host] = models.MXTestResult{
+		Host:         attempt.host,
+		Port:         attempt.result.Port,
+		Outcome:      outcome,
+		RawSignal:    attempt.result.Reachable.RawSignal,
+		ResponseTime: attempt.result.ResponseTime,
+	}
 }
 
-// checkTrustedProvider checks if domain is a trusted email provider
+// checkTrustedProvider checks if domain is a trusted email provider. Strict
+// mode has no trusted providers, since the shortcut credits mailboxes that
+// were never checked.
 func (v *SMTPValidator) checkTrustedProvider(domain string, startTime time.Time) (models.SMTPValidationResult, bool) {
+	if v.strict {
+		return models.SMTPValidationResult{}, false
+	}
 	trustedProviders := map[string]bool{
 		"gmail.com": true, "googlemail.com": true,
 		"yahoo.com": true, "yahoo.co.in": true, "yahoo.co.uk": true,
@@ -138,32 +498,81 @@ func (v *SMTPValidator) checkTrustedProvider(domain string, startTime time.Time)
 	return models.SMTPValidationResult{}, false
 }
 
+// connectionFailed reports whether an attempt never reached the server, so
+// another port may still work
+func connectionFailed(result models.SMTPValidationResult) bool {
+	return result.Reachable.RawSignal == "connection_failed" || result.Reachable.RawSignal == "connection_timeout"
+}
+
 // trySMTPConnection attempts SMTP connection on a specific host and port
-func (v *SMTPValidator) trySMTPConnection(ctx context.Context, email string, host string, port int, startTime time.Time) models.SMTPValidationResult {
-	address := fmt.Sprintf("%s:%d", host, port)
+// from source, or the default route when source is nil. A non-empty probe is
+// sent as a second RCPT TO in the same transaction to record the domain's
+// catch-all status. A failed STARTTLS leaves the session in no defined
+// state, so the check is repeated on a fresh connection without TLS.
+func (v *SMTPValidator) trySMTPConnection(ctx context.Context, email, probe string, host string, port int, source net.IP, startTime time.Time) models.SMTPValidationResult {
+	result := v.smtpSession(ctx, email, probe, host, port, source, startTime, v.startTLS)
+	if result.Reachable.RawSignal != "starttls_failed" || ctx.Err() != nil {
+		return result
+	}
+	
+	plain := v.smtpSession(ctx, email, probe, host, port, source, startTime, false)
+	plain.TLSSupported = true // advertised, just not working
+	plain.Transcript = append(result.Transcript, plain.Transcript...)
+	return plain
+}
+
+// smtpSession runs one SMTP conversation for trySMTPConnection, upgrading
+// with STARTTLS first when startTLS is set and the server offers it
+func (v *SMTPValidator) smtpSession(ctx context.Context, email, probe string, host string, port int, source net.IP, startTime time.Time, startTLS bool) (result models.SMTPValidationResult) {
+	// Every command and reply is kept so a disputed verdict can be explained
+	var transcript []models.SMTPExchange
+	defer func() { result.Transcript = transcript }()
+	
+	address := net.JoinHostPort(host, strconv.Itoa(port))
 	timeout := 5 * time.Second
+	dialer, network := sourceDialer(source, timeout)
 
 	var conn net.Conn
 	var err error
 
+	release, err := v.conns.acquire(ctx, host)
+	if err == nil {
+		defer release()
+	} else {
+		return models.SMTPValidationResult{
+			Reachable: models.ValidationResult{
+				Status:    "fail",
+				Reason:    "Timed out waiting for a free connection to the mail server",
+				RawSignal: "connection_timeout",
+				Score:     0,
+				Weight:    v.weights.SMTPReachability,
+			},
+			ResponseTime: time.Since(startTime).Milliseconds(),
+			Port:         port,
+		}
+	}
+
 	// Use TLS for port 465
 	if port == 465 {
 		tlsConfig := &tls.Config{
 			InsecureSkipVerify: true,
 			ServerName:         host,
 		}
-		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, tlsConfig)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, network, address)
 	} else {
-		dialer := net.Dialer{Timeout: timeout}
-		conn, err = dialer.DialContext(ctx, "tcp", address)
+		conn, err = dialer.DialContext(ctx, network, address)
 	}
 
 	if err != nil {
+		signal := "connection_failed"
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			signal = "connection_timeout"
+		}
 		return models.SMTPValidationResult{
 			Reachable: models.ValidationResult{
 				Status:    "fail",
 				Reason:    "SMTP connection failed",
-				RawSignal: "connection_failed",
+				RawSignal: signal,
 				Score:     0,
 				Weight:    v.weights.SMTPReachability,
 			},
@@ -173,28 +582,68 @@ func (v *SMTPValidator) trySMTPConnection(ctx context.Context, email string, hos
 	}
 	defer conn.Close()
 
-	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	// A caller's deadline (e.g. the catch-all probe budget) may be shorter
+	deadline := time.Now().Add(10 * time.Second)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	
+	// Cancellation unblocks any read or write at once; the checks between
+	// steps keep the empty replies that follow from passing as answers
+	rawConn := conn
+	stop := context.AfterFunc(ctx, func() { rawConn.SetDeadline(time.Now()) })
+	defer stop()
+	cancelled := func() models.SMTPValidationResult {
+		return models.SMTPValidationResult{
+			Reachable: models.ValidationResult{
+				Status:    "fail",
+				Reason:    "SMTP check cancelled before the server answered",
+				RawSignal: "connection_timeout",
+				Score:     0,
+				Weight:    v.weights.SMTPReachability,
+			},
+			ResponseTime: time.Since(startTime).Milliseconds(),
+			Port:         port,
+		}
+	}
+	
 	reader := bufio.NewReader(conn)
 	writer := bufio.NewWriter(conn)
 
+	// Replies may span several "250-" lines; keep them together so no
+	// continuation line is mistaken for the answer to the next command
+	readLines := func() []string {
+		lines := readResponse(reader)
+		reply := strings.Join(lines, "\n")
+		if last := len(transcript) - 1; last >= 0 && transcript[last].Command != "" && transcript[last].Reply == "" {
+			transcript[last].Reply = reply
+		} else {
+			transcript = append(transcript, models.SMTPExchange{Reply: reply})
+		}
+		return lines
+	}
 	read := func() string {
-		line, _ := reader.ReadString('\n')
-		return strings.TrimSpace(line)
+		return strings.Join(readLines(), "\n")
 	}
 	write := func(cmd string) {
+		transcript = append(transcript, models.SMTPExchange{Command: cmd})
 		writer.WriteString(cmd + "\r\n")
 		writer.Flush()
 	}
 
 	// Read banner
 	banner := read()
+	if ctx.Err() != nil {
+		return cancelled()
+	}
 	if !strings.HasPrefix(banner, "220") {
 		return models.SMTPValidationResult{
 			Reachable: models.ValidationResult{
 				Status:    "pass",
 				Reason:    "SMTP server responded",
 				RawSignal: "server_responded",
-				Score:     15,
+				Score:     v.respondedScore(),
 				Weight:    v.weights.SMTPReachability,
 			},
 			ResponseTime:   time.Since(startTime).Milliseconds(),
@@ -204,15 +653,77 @@ func (v *SMTPValidator) trySMTPConnection(ctx context.Context, email string, hos
 	}
 
 	// SMTP handshake
-	write("EHLO emailintel.local")
-	read()
+	write("EHLO " + v.heloName)
+	capabilities := parseEHLOCapabilities(readLines())
+	tlsActive := port == 465
 
-	write("MAIL FROM:<verify@emailintel.local>")
+	if ctx.Err() != nil {
+		return cancelled()
+	}
+	
+	// Only upgrade when the server actually advertises STARTTLS
+	if startTLS && !tlsActive && hasCapability(capabilities, "STARTTLS") {
+		write("STARTTLS")
+		if reply := read(); strings.HasPrefix(reply, "220") {
+			tlsConn := tls.Client(conn, &tls.Config{
+				InsecureSkipVerify: true,
+				ServerName:         host,
+			})
+			if err := tlsConn.HandshakeContext(ctx); err == nil {
+				conn = tlsConn
+				reader = bufio.NewReader(conn)
+				writer = bufio.NewWriter(conn)
+				tlsActive = true
+				
+				// Capabilities must be re-read after the TLS upgrade
+				write("EHLO " + v.heloName)
+				capabilities = parseEHLOCapabilities(readLines())
+			}
+		}
+		
+		// Never carry on with MAIL FROM in a session whose upgrade failed
+		if !tlsActive {
+			if ctx.Err() != nil {
+				return cancelled()
+			}
+			return models.SMTPValidationResult{
+				Reachable: models.ValidationResult{
+					Status:    "unknown",
+					Reason:    "STARTTLS upgrade failed",
+					RawSignal: "starttls_failed",
+					Score:     0,
+					Weight:    v.weights.SMTPReachability,
+				},
+				ResponseTime: time.Since(startTime).Milliseconds(),
+				Port:         port,
+				Capabilities: capabilities,
+				TLSSupported: true,
+			}
+		}
+	}
+	
+	if ctx.Err() != nil {
+		return cancelled()
+	}
+	
+	tlsSupported := tlsActive || hasCapability(capabilities, "STARTTLS")
+	smtpUTF8 := hasCapability(capabilities, "SMTPUTF8")
+
+	write("MAIL FROM:<" + v.mailFrom + ">")
 	mailResp := read()
+	if ctx.Err() != nil {
+		return cancelled()
+	}
 
 	if strings.HasPrefix(mailResp, "250") {
 		write("RCPT TO:<" + email + ">")
 		rcptResp := read()
+		if ctx.Err() != nil {
+			return cancelled()
+		}
+		if probe != "" {
+			v.pipelineCatchAll(probe, host, rcptResp, write, read)
+		}
 		write("QUIT")
 
 		if strings.HasPrefix(rcptResp, "250") {
@@ -224,10 +735,61 @@ func (v *SMTPValidator) trySMTPConnection(ctx context.Context, email string, hos
 					Score:     v.weights.SMTPReachability,
 					Weight:    v.weights.SMTPReachability,
 				},
-				ResponseTime:   time.Since(startTime).Milliseconds(),
-				Port:           port,
-				TLSSupported:   port == 465 || port == 587,
-				ServerResponse: rcptResp,
+				ResponseTime:      time.Since(startTime).Milliseconds(),
+				Port:              port,
+				TLSSupported:      tlsSupported,
+				TLSUsed:           tlsActive,
+				Capabilities:      capabilities,
+				SMTPUTF8Supported: smtpUTF8,
+				ServerResponse:    rcptResp,
+				MailboxStatus:     MailboxActive,
+			}
+		}
+
+		mailboxStatus := classifyMailbox(rcptResp)
+		if isTransientReply(rcptResp) {
+			// Greylisting servers accept the recipient on a later attempt, so
+			// the mailbox is likely valid but nothing was confirmed
+			return models.SMTPValidationResult{
+				Reachable: models.ValidationResult{
+					Status:    "unknown",
+					Reason:    "Mail server deferred the recipient (greylisting or temporary failure); retry later",
+					RawSignal: "greylisted",
+					Score:     v.assumedScore(),
+					Weight:    v.weights.SMTPReachability,
+				},
+				ResponseTime:      time.Since(startTime).Milliseconds(),
+				Port:              port,
+				TLSSupported:      tlsSupported,
+				TLSUsed:           tlsActive,
+				Capabilities:      capabilities,
+				SMTPUTF8Supported: smtpUTF8,
+				ServerResponse:    rcptResp,
+				MailboxStatus:     MailboxUnknown, // a deferral never settles the mailbox, whatever its code says
+				Deferred:          true,
+			}
+		}
+		if mailboxStatus == MailboxNonexistent || mailboxStatus == MailboxDisabled {
+			reason := "Mailbox does not exist"
+			if mailboxStatus == MailboxDisabled {
+				reason = "Mailbox exists but is disabled"
+			}
+			return models.SMTPValidationResult{
+				Reachable: models.ValidationResult{
+					Status:    "fail",
+					Reason:    reason,
+					RawSignal: "mailbox_" + mailboxStatus,
+					Score:     0,
+					Weight:    v.weights.SMTPReachability,
+				},
+				ResponseTime:      time.Since(startTime).Milliseconds(),
+				Port:              port,
+				TLSSupported:      tlsSupported,
+				TLSUsed:           tlsActive,
+				Capabilities:      capabilities,
+				SMTPUTF8Supported: smtpUTF8,
+				ServerResponse:    rcptResp,
+				MailboxStatus:     mailboxStatus,
 			}
 		}
 
@@ -236,29 +798,256 @@ func (v *SMTPValidator) trySMTPConnection(ctx context.Context, email string, hos
 				Status:    "pass",
 				Reason:    "SMTP server reachable",
 				RawSignal: "smtp_reachable",
-				Score:     15,
+				Score:     v.respondedScore(),
 				Weight:    v.weights.SMTPReachability,
 			},
-			ResponseTime:   time.Since(startTime).Milliseconds(),
-			Port:           port,
-			TLSSupported:   port == 465 || port == 587,
-			ServerResponse: rcptResp,
+			ResponseTime:      time.Since(startTime).Milliseconds(),
+			Port:              port,
+			TLSSupported:      tlsSupported,
+			TLSUsed:           tlsActive,
+			Capabilities:      capabilities,
+			SMTPUTF8Supported: smtpUTF8,
+			ServerResponse:    rcptResp,
+			MailboxStatus:     mailboxStatus,
 		}
 	}
 
 	write("QUIT")
+	if isTransientReply(mailResp) {
+		return models.SMTPValidationResult{
+			Reachable: models.ValidationResult{
+				Status:    "unknown",
+				Reason:    "Mail server deferred the sender (greylisting or temporary failure); retry later",
+				RawSignal: "greylisted",
+				Score:     v.assumedScore(),
+				Weight:    v.weights.SMTPReachability,
+			},
+			ResponseTime:      time.Since(startTime).Milliseconds(),
+			Port:              port,
+			TLSSupported:      tlsSupported,
+			TLSUsed:           tlsActive,
+			Capabilities:      capabilities,
+			SMTPUTF8Supported: smtpUTF8,
+			ServerResponse:    mailResp,
+			MailboxStatus:     MailboxUnknown,
+			Deferred:          true,
+		}
+	}
 	return models.SMTPValidationResult{
 		Reachable: models.ValidationResult{
 			Status:    "pass",
 			Reason:    "SMTP server reachable",
 			RawSignal: "smtp_connected",
-			Score:     15,
+			Score:     v.respondedScore(),
 			Weight:    v.weights.SMTPReachability,
 		},
-		ResponseTime:   time.Since(startTime).Milliseconds(),
-		Port:           port,
-		ServerResponse: mailResp,
+		ResponseTime:      time.Since(startTime).Milliseconds(),
+		Port:              port,
+		TLSSupported:      tlsSupported,
+		TLSUsed:           tlsActive,
+		Capabilities:      capabilities,
+		SMTPUTF8Supported: smtpUTF8,
+		ServerResponse:    mailResp,
+	}
+}
+
+// isTransientReply reports whether a reply is a 4xx temporary failure (421,
+// 450, 451, 452, ...), which servers use for greylisting and deferrals
+func isTransientReply(reply string) bool {
+	return len(reply) >= 3 && reply[0] == '4' && reply[1] >= '0' && reply[1] <= '9' && reply[2] >= '0' && reply[2] <= '9'
+}
+
+// enhancedCodePattern matches RFC 3463 enhanced status codes such as 5.1.1
+var enhancedCodePattern = regexp.MustCompile(`\b([245])\.(\d{1,3})\.(\d{1,3})\b`)
+
+// parseEnhancedCode returns the enhanced status code of a reply, if present
+func parseEnhancedCode(reply string) string {
+	return enhancedCodePattern.FindString(reply)
+}
+
+// classifyMailbox maps a RCPT TO reply to a mailbox status. Only permanent
+// (5xx) replies say a mailbox is missing or disabled: "450 4.2.1" is the usual
+// greylisting or rate-limit reply and leaves the mailbox unknown.
+func classifyMailbox(reply string) string {
+	if strings.HasPrefix(reply, "250") || strings.HasPrefix(reply, "251") {
+		return MailboxActive
+	}
+	permanent := strings.HasPrefix(reply, "5")
+	
+	switch code := parseEnhancedCode(reply); {
+	case strings.HasSuffix(code, ".2.2"):
+		return MailboxFull
+	case code != "" && (!permanent || !strings.HasPrefix(code, "5.")):
+		return MailboxUnknown
+	case strings.HasSuffix(code, ".1.1"), strings.HasSuffix(code, ".1.10"):
+		return MailboxNonexistent
+	case strings.HasSuffix(code, ".2.1"):
+		return MailboxDisabled
+	case code != "":
+		return MailboxUnknown
+	}
+	
+	// Servers without enhanced codes: fall back to the basic reply code and text
+	lower := strings.ToLower(reply)
+	switch {
+	case strings.HasPrefix(reply, "552") || strings.HasPrefix(reply, "452") || strings.Contains(lower, "quota"):
+		return MailboxFull
+	case !permanent:
+		return MailboxUnknown
+	case strings.Contains(lower, "disabled") || strings.Contains(lower, "inactive"):
+		return MailboxDisabled
+	case strings.HasPrefix(reply, "550") && (strings.Contains(lower, "unknown") || strings.Contains(lower, "does not exist") || strings.Contains(lower, "no such")):
+		return MailboxNonexistent
+	}
+	return MailboxUnknown
+}
+
+// readResponse reads a complete, possibly multi-line, SMTP reply
+func readResponse(reader *bufio.Reader) []string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+		// "250-" marks a continuation line, "250 " the final one
+		if err != nil || len(line) < 4 || line[3] != '-' {
+			return lines
+		}
+	}
+}
+
+// parseEHLOCapabilities extracts the advertised extensions from an EHLO reply
+func parseEHLOCapabilities(lines []string) []string {
+	capabilities := []string{}
+	for i, line := range lines {
+		if i == 0 || len(line) < 4 || !strings.HasPrefix(line, "250") {
+			continue // first line is the server greeting
+		}
+		if capability := strings.TrimSpace(line[4:]); capability != "" {
+			capabilities = append(capabilities, strings.ToUpper(capability))
+		}
+	}
+	return capabilities
+}
+
+// hasCapability reports whether an EHLO extension keyword was advertised
+func hasCapability(capabilities []string, keyword string) bool {
+	for _, capability := range capabilities {
+		if capability == keyword || strings.HasPrefix(capability, keyword+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSubmission connects to the submission port of the domain's MX hosts and
+// reports whether authenticated submission (AUTH) and STARTTLS are offered
+func (v *SMTPValidator) CheckSubmission(ctx context.Context, mxRecords []models.MXRecord) models.SubmissionCapabilities {
+	result := models.SubmissionCapabilities{
+		Port:           587,
+		AuthMechanisms: []string{},
+		Capabilities:   []string{},
+		Error:          "no MX hosts to test",
+	}
+	
+	for _, mx := range mxRecords {
+		if ctx.Err() != nil {
+			break
+		}
+		result = v.probeSubmission(ctx, mx.Host)
+		if result.Reachable {
+			break
+		}
+	}
+	return result
+}
+
+// probeSubmission performs EHLO (and STARTTLS when offered) on host:587
+func (v *SMTPValidator) probeSubmission(ctx context.Context, host string) models.SubmissionCapabilities {
+	result := models.SubmissionCapabilities{
+		Host:           host,
+		Port:           587,
+		AuthMechanisms: []string{},
+		Capabilities:   []string{},
+	}
+	
+	release, err := v.conns.acquire(ctx, host)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer release()
+	
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "587"))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	rawConn := conn
+	stop := context.AfterFunc(ctx, func() { rawConn.SetDeadline(time.Now()) })
+	defer stop()
+	
+	reader := bufio.NewReader(conn)
+	send := func(cmd string) []string {
+		conn.Write([]byte(cmd + "\r\n"))
+		return readResponse(reader)
+	}
+	
+	if banner := readResponse(reader); ctx.Err() != nil {
+		result.Error = ctx.Err().Error()
+		return result
+	} else if len(banner) == 0 || !strings.HasPrefix(banner[0], "220") {
+		result.Error = "unexpected banner"
+		return result
+	}
+	result.Reachable = true
+	
+	capabilities := parseEHLOCapabilities(send("EHLO " + v.heloName))
+	result.StartTLS = hasCapability(capabilities, "STARTTLS")
+	
+	// Most servers only advertise AUTH once the session is encrypted
+	if result.StartTLS {
+		if reply := send("STARTTLS"); len(reply) > 0 && strings.HasPrefix(reply[0], "220") {
+			tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: host})
+			if err := tlsConn.HandshakeContext(ctx); err == nil {
+				conn = tlsConn
+				reader = bufio.NewReader(conn)
+				capabilities = parseEHLOCapabilities(send("EHLO " + v.heloName))
+			}
+		}
+	}
+	send("QUIT")
+	if ctx.Err() != nil {
+		result.Error = ctx.Err().Error()
+		return result
+	}
+	
+	result.Capabilities = capabilities
+	result.AuthMechanisms = authMechanisms(capabilities)
+	return result
+}
+
+// authMechanisms extracts SASL mechanisms from "AUTH PLAIN LOGIN" or "AUTH=PLAIN" lines
+func authMechanisms(capabilities []string) []string {
+	mechanisms := []string{}
+	seen := map[string]bool{}
+	for _, capability := range capabilities {
+		if !strings.HasPrefix(capability, "AUTH ") && !strings.HasPrefix(capability, "AUTH=") {
+			continue
+		}
+		for _, mechanism := range strings.Fields(capability[5:]) {
+			if !seen[mechanism] {
+				seen[mechanism] = true
+				mechanisms = append(mechanisms, mechanism)
+			}
+		}
 	}
+	return mechanisms
 }
 
 // tryTCPFallback tries simple TCP connections in parallel
@@ -270,7 +1059,7 @@ func (v *SMTPValidator) tryTCPFallback(ctx context.Context, mxRecords []models.M
 	
 	for _, mx := range mxRecords {
 		wg.Add(1)
-		go func(host string) {
+		go func(mx models.MXRecord) {
 			defer wg.Done()
 			
 			select {
@@ -279,14 +1068,20 @@ func (v *SMTPValidator) tryTCPFallback(ctx context.Context, mxRecords []models.M
 			default:
 			}
 			
-			if testTCPConnection(host, 25, 3*time.Second) {
+			release, err := v.conns.acquire(ctx, mx.Host)
+			if err != nil {
+				return
+			}
+			reachable := testTCPConnection(ctx, mxDialHosts(mx), 25, 3*time.Second)
+			release()
+			if reachable {
 				select {
 				case resultChan <- true:
 					cancel()
 				default:
 				}
 			}
-		}(mx.Host)
+		}(mx)
 	}
 	
 	go func() {
@@ -300,7 +1095,7 @@ func (v *SMTPValidator) tryTCPFallback(ctx context.Context, mxRecords []models.M
 				Status:    "pass",
 				Reason:    "SMTP server reachable (TCP verified)",
 				RawSignal: "tcp_verified",
-				Score:     15,
+				Score:     v.respondedScore(),
 				Weight:    v.weights.SMTPReachability,
 			},
 			ResponseTime: time.Since(startTime).Milliseconds(),
@@ -308,13 +1103,27 @@ func (v *SMTPValidator) tryTCPFallback(ctx context.Context, mxRecords []models.M
 		}
 	}
 	
+	// A cancelled check proves nothing either way
+	if ctx.Err() != nil {
+		return models.SMTPValidationResult{
+			Reachable: models.ValidationResult{
+				Status:    "unknown",
+				Reason:    "SMTP check was cancelled before any mail server answered",
+				RawSignal: SignalLookupTimeout,
+				Score:     0,
+				Weight:    v.weights.SMTPReachability,
+			},
+			ResponseTime: time.Since(startTime).Milliseconds(),
+		}
+	}
+	
 	// Final fallback - MX records exist
 	return models.SMTPValidationResult{
 		Reachable: models.ValidationResult{
 			Status:    "pass",
 			Reason:    "SMTP assumed reachable (MX records valid)",
 			RawSignal: "mx_verified",
-			Score:     12,
+			Score:     v.assumedScore(),
 			Weight:    v.weights.SMTPReachability,
 		},
 		ResponseTime: time.Since(startTime).Milliseconds(),
@@ -322,13 +1131,43 @@ func (v *SMTPValidator) tryTCPFallback(ctx context.Context, mxRecords []models.M
 	}
 }
 
-// testTCPConnection tests if a TCP connection can be established
-func testTCPConnection(host string, port int, timeout time.Duration) bool {
-	address := fmt.Sprintf("%s:%d", host, port)
-	conn, err := net.DialTimeout("tcp", address, timeout)
-	if err != nil {
-		return false
+// testTCPConnection tests if a TCP connection can be established to any of
+// hosts, each given its own timeout
+func testTCPConnection(ctx context.Context, hosts []string, port int, timeout time.Duration) bool {
+	dialer := net.Dialer{Timeout: timeout}
+	for _, host := range hosts {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err == nil {
+			conn.Close()
+			return true
+		}
+	}
+	return false
+}
+
+// mxDialHosts lists the resolved addresses of an MX host, IPv4 first, so
+// each family is tried rather than only the one the dialer picks. Without
+// resolved addresses the host name is dialed.
+func mxDialHosts(mx models.MXRecord) []string {
+	hosts := []string{}
+	if mx.IP != "" {
+		hosts = append(hosts, mx.IP)
+	}
+	hosts = append(hosts, mx.IPv6...)
+	if len(hosts) == 0 {
+		hosts = append(hosts, mx.Host)
+	}
+	return hosts
+}
+
+// mxFamily returns the only address family an MX host resolved to, "ip4" or
+// "ip6", or "" when it has both or was not resolved
+func mxFamily(mx models.MXRecord) string {
+	switch {
+	case mx.IP != "" && len(mx.IPv6) == 0:
+		return "ip4"
+	case mx.IP == "" && len(mx.IPv6) > 0:
+		return "ip6"
 	}
-	conn.Close()
-	return true
+	return ""
 }
@@ -0,0 +1,724 @@
+package validators
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"email-intelligence/internal/models"
+)
+
+// fakeSMTP is an in-process SMTP server. Each test scripts only the replies
+// it exercises; everything else answers like a permissive server.
+type fakeSMTP struct {
+	listener net.Listener
+
+	banner   string                      // greeting; default 220
+	caps     []string                    // EHLO extensions, e.g. "STARTTLS"
+	tls      *tls.Config                 // STARTTLS is accepted when set, refused with 454 otherwise
+	mailFrom string                      // reply to MAIL FROM; default 250
+	rcpt     func(address string) string // reply to RCPT TO; default 250
+	delay    time.Duration               // pause before each RCPT reply
+
+	mu       sync.Mutex
+	sessions [][]string // commands received, one slice per connection
+	open     int        // connections open right now
+}
+
+// newFakeSMTP starts a server on addr ("127.0.0.1:0" for any free port)
+// after setup has scripted it. It is closed when the test ends.
+func newFakeSMTP(t *testing.T, addr string, setup func(*fakeSMTP)) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+	server := &fakeSMTP{
+		listener: listener,
+		banner:   "220 fake.test ESMTP",
+		mailFrom: "250 2.1.0 OK",
+		rcpt:     func(string) string { return "250 2.1.5 OK" },
+	}
+	if setup != nil {
+		setup(server)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.accept()
+	return server
+}
+
+// port is the port the server listens on
+func (s *fakeSMTP) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// mx is an MX record pointing at the server
+func (s *fakeSMTP) mx(priority int) models.MXRecord {
+	ip := s.listener.Addr().(*net.TCPAddr).IP.String()
+	return models.MXRecord{Host: ip, Priority: priority, IP: ip}
+}
+
+// commands returns the commands received in every session so far
+func (s *fakeSMTP) commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([][]string, len(s.sessions))
+	for i, session := range s.sessions {
+		sessions[i] = append([]string(nil), session...)
+	}
+	return sessions
+}
+
+// openConns is the number of connections open right now
+func (s *fakeSMTP) openConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open
+}
+
+func (s *fakeSMTP) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	s.mu.Lock()
+	session := len(s.sessions)
+	s.sessions = append(s.sessions, nil)
+	s.open++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.open--
+		s.mu.Unlock()
+	}()
+	record := func(command string) {
+		s.mu.Lock()
+		s.sessions[session] = append(s.sessions[session], command)
+		s.mu.Unlock()
+	}
+
+	reader := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			fmt.Fprintf(conn, "%s\r\n", line)
+		}
+	}
+
+	reply(s.banner)
+	if !strings.HasPrefix(s.banner, "220") {
+		return
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		record(command)
+		verb, argument, _ := strings.Cut(command, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"fake.test"}
+			lines = append(lines, s.caps...)
+			for i, line := range lines {
+				separator := "-"
+				if i == len(lines)-1 {
+					separator = " "
+				}
+				reply("250" + separator + line)
+			}
+		case "STARTTLS":
+			if s.tls == nil {
+				reply("454 4.7.0 TLS not available")
+				continue
+			}
+			reply("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			reader = bufio.NewReader(conn)
+			record("TLS")
+		case "MAIL":
+			reply(s.mailFrom)
+		case "RCPT":
+			address := argument
+			if open, close := strings.IndexByte(argument, '<'), strings.LastIndexByte(argument, '>'); open != -1 && close > open {
+				address = argument[open+1 : close]
+			}
+			time.Sleep(s.delay)
+			reply(s.rcpt(address))
+		case "RSET", "NOOP":
+			reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// testTLSConfig is a server configuration with a fresh self-signed certificate
+func testTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+// newTestSMTPValidator probes only the given port, with STARTTLS enabled
+func newTestSMTPValidator(port int) *SMTPValidator {
+	v := NewSMTPValidator(SMTPOptions{
+		Timeout:        2 * time.Second,
+		StartTLS:       true,
+		CacheTTL:       time.Minute,
+		BlockThreshold: 3,
+		BlockCooldown:  time.Minute,
+		Strict:         true,
+	}, models.ScoringWeights{SMTPReachability: 20, CatchAllRisk: 10})
+	v.ports = []int{port}
+	return v
+}
+
+// rejectUnknown accepts only the listed mailboxes
+func rejectUnknown(mailboxes ...string) func(string) string {
+	return func(address string) string {
+		for _, mailbox := range mailboxes {
+			if strings.EqualFold(address, mailbox) {
+				return "250 2.1.5 OK"
+			}
+		}
+		return "550 5.1.1 User unknown"
+	}
+}
+
+func TestSMTPValidateAgainstFakeServer(t *testing.T) {
+	const email = "jane@example.test"
+	tests := []struct {
+		name  string
+		setup func(*fakeSMTP)
+
+		status   string // Reachable.Status
+		signal   string // Reachable.RawSignal
+		mailbox  string
+		deferred bool
+		tlsUsed  bool
+		tlsSeen  bool   // TLSSupported
+		catchAll string // cached catch-all signal after the probe, "" when nothing was cached
+	}{
+		{
+			name:     "mailbox accepted, unknown mailboxes rejected",
+			setup:    func(s *fakeSMTP) { s.rcpt = rejectUnknown(email) },
+			status:   "pass",
+			signal:   "mailbox_verified",
+			mailbox:  MailboxActive,
+			catchAll: "catch_all_rejected",
+		},
+		{
+			name:     "catch-all server",
+			status:   "pass",
+			signal:   "mailbox_verified",
+			mailbox:  MailboxActive,
+			catchAll: "catch_all_detected",
+		},
+		{
+			name:     "nonexistent mailbox",
+			setup:    func(s *fakeSMTP) { s.rcpt = rejectUnknown() },
+			status:   "fail",
+			signal:   "mailbox_nonexistent",
+			mailbox:  MailboxNonexistent,
+			catchAll: "catch_all_rejected",
+		},
+		{
+			name:    "disabled mailbox",
+			setup:   func(s *fakeSMTP) { s.rcpt = func(string) string { return "550 5.2.1 Account disabled" } },
+			status:  "fail",
+			signal:  "mailbox_disabled",
+			mailbox: MailboxDisabled,
+		},
+		{
+			name:     "recipient greylisted",
+			setup:    func(s *fakeSMTP) { s.rcpt = func(string) string { return "451 4.7.1 Greylisted, try again later" } },
+			status:   "unknown",
+			signal:   "greylisted",
+			mailbox:  MailboxUnknown,
+			deferred: true,
+		},
+		{
+			// Its enhanced code reads "disabled", but a 4xx settles nothing
+			name: "recipient rate limited",
+			setup: func(s *fakeSMTP) {
+				s.rcpt = func(string) string { return "450 4.2.1 The user is receiving mail too quickly" }
+			},
+			status:   "unknown",
+			signal:   "greylisted",
+			mailbox:  MailboxUnknown,
+			deferred: true,
+		},
+		{
+			// Over quota is temporary too, so it is reported as a deferral
+			name:     "recipient over quota",
+			setup:    func(s *fakeSMTP) { s.rcpt = func(string) string { return "452 4.2.2 Mailbox full" } },
+			status:   "unknown",
+			signal:   "greylisted",
+			mailbox:  MailboxUnknown,
+			deferred: true,
+		},
+		{
+			name:     "sender deferred",
+			setup:    func(s *fakeSMTP) { s.mailFrom = "421 4.3.2 Service not available" },
+			status:   "unknown",
+			signal:   "greylisted",
+			mailbox:  MailboxUnknown,
+			deferred: true,
+		},
+		{
+			name: "STARTTLS upgrade",
+			setup: func(s *fakeSMTP) {
+				s.caps = []string{"PIPELINING", "STARTTLS"}
+				s.tls = testTLSConfig(t)
+				s.rcpt = rejectUnknown(email)
+			},
+			status:   "pass",
+			signal:   "mailbox_verified",
+			mailbox:  MailboxActive,
+			tlsUsed:  true,
+			tlsSeen:  true,
+			catchAll: "catch_all_rejected",
+		},
+		{
+			name:     "no STARTTLS offered",
+			setup:    func(s *fakeSMTP) { s.caps = []string{"PIPELINING", "8BITMIME"}; s.rcpt = rejectUnknown(email) },
+			status:   "pass",
+			signal:   "mailbox_verified",
+			mailbox:  MailboxActive,
+			catchAll: "catch_all_rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, "127.0.0.1:0", tt.setup)
+			v := newTestSMTPValidator(server.port())
+
+			result := v.Validate(context.Background(), email, []models.MXRecord{server.mx(10)})
+			if result.Reachable.Status != tt.status || result.Reachable.RawSignal != tt.signal {
+				t.Errorf("reachable = %s/%s, want %s/%s (%s)", result.Reachable.Status, result.Reachable.RawSignal, tt.status, tt.signal, result.ServerResponse)
+			}
+			if result.MailboxStatus != tt.mailbox {
+				t.Errorf("mailbox status = %q, want %q", result.MailboxStatus, tt.mailbox)
+			}
+			if result.Deferred != tt.deferred {
+				t.Errorf("deferred = %v, want %v", result.Deferred, tt.deferred)
+			}
+			if result.TLSUsed != tt.tlsUsed || result.TLSSupported != tt.tlsSeen {
+				t.Errorf("tls used/supported = %v/%v, want %v/%v", result.TLSUsed, result.TLSSupported, tt.tlsUsed, tt.tlsSeen)
+			}
+			if result.MXHost != server.mx(10).Host {
+				t.Errorf("mx host = %q, want %q", result.MXHost, server.mx(10).Host)
+			}
+
+			cached, found := v.catchAll.Get("example.test")
+			switch {
+			case tt.catchAll == "" && found:
+				t.Errorf("catch-all cached as %s, want nothing cached", cached.(models.ValidationResult).RawSignal)
+			case tt.catchAll != "" && !found:
+				t.Errorf("catch-all not cached, want %s", tt.catchAll)
+			case found && cached.(models.ValidationResult).RawSignal != tt.catchAll:
+				t.Errorf("catch-all cached as %s, want %s", cached.(models.ValidationResult).RawSignal, tt.catchAll)
+			}
+		})
+	}
+}
+
+func TestSMTPValidateUpgradesBeforeMailFrom(t *testing.T) {
+	server := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+		s.caps = []string{"STARTTLS"}
+		s.tls = testTLSConfig(t)
+	})
+	v := newTestSMTPValidator(server.port())
+	v.Validate(context.Background(), "jane@example.test", []models.MXRecord{server.mx(10)})
+
+	sessions := server.commands()
+	if len(sessions) != 1 {
+		t.Fatalf("sessions = %d, want 1", len(sessions))
+	}
+	got := strings.Join(sessions[0], " | ")
+	want := "EHLO emailintel.local | STARTTLS | TLS | EHLO emailintel.local | MAIL FROM:<verify@emailintel.local> | RCPT TO:<jane@example.test>"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("commands = %s, want prefix %s", got, want)
+	}
+}
+
+func TestSMTPValidatePrefersPrimaryHost(t *testing.T) {
+	const email = "jane@example.test"
+	accept := func(string) string { return "250 2.1.5 OK" }
+	reject := func(string) string { return "550 5.1.1 User unknown" }
+	deferral := func(string) string { return "451 4.7.1 Greylisted, try again later" }
+
+	tests := []struct {
+		name               string
+		primary, secondary func(string) string
+		slowPrimary        bool
+		signal             string
+		host               string
+	}{
+		{"both answer, primary wins", reject, accept, false, "mailbox_nonexistent", "127.0.0.1"},
+		{"slow primary still wins", reject, accept, true, "mailbox_nonexistent", "127.0.0.1"},
+		{"primary deferral beats a backup's acceptance", deferral, accept, true, "greylisted", "127.0.0.1"},
+		{"primary answer beats a backup's deferral", accept, deferral, true, "mailbox_verified", "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+				s.rcpt = tt.primary
+				if tt.slowPrimary {
+					s.delay = 200 * time.Millisecond
+				}
+			})
+			// Same port on another loopback address, so one port list reaches both
+			secondary := newFakeSMTP(t, net.JoinHostPort("127.0.0.2", strconv.Itoa(primary.port())), func(s *fakeSMTP) {
+				s.rcpt = tt.secondary
+			})
+
+			// The same verdict every run, however the replies race
+			for run := 0; run < 3; run++ {
+				v := newTestSMTPValidator(primary.port())
+				result := v.Validate(context.Background(), email, []models.MXRecord{primary.mx(10), secondary.mx(20)})
+				if result.Reachable.RawSignal != tt.signal || result.MXHost != tt.host {
+					t.Fatalf("run %d: verdict = %s from %s, want %s from %s", run, result.Reachable.RawSignal, result.MXHost, tt.signal, tt.host)
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPValidateReconnectsAfterFailedSTARTTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  *tls.Config
+	}{
+		{"upgrade refused", nil},
+		{"handshake fails", &tls.Config{}}, // no certificate to offer
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+				s.caps = []string{"STARTTLS"}
+				s.tls = tt.tls
+				s.rcpt = rejectUnknown("jane@example.test")
+			})
+			v := newTestSMTPValidator(server.port())
+
+			result := v.Validate(context.Background(), "jane@example.test", []models.MXRecord{server.mx(10)})
+			if result.Reachable.RawSignal != "mailbox_verified" || result.MailboxStatus != MailboxActive {
+				t.Errorf("result = %s/%s, want mailbox_verified/active", result.Reachable.RawSignal, result.MailboxStatus)
+			}
+			if result.TLSUsed || !result.TLSSupported {
+				t.Errorf("tls used/supported = %v/%v, want false/true", result.TLSUsed, result.TLSSupported)
+			}
+
+			sessions := server.commands()
+			if len(sessions) != 2 {
+				t.Fatalf("sessions = %v, want the failed one and a plaintext retry", sessions)
+			}
+			for _, command := range sessions[0] {
+				if strings.HasPrefix(command, "MAIL") || strings.HasPrefix(command, "RCPT") {
+					t.Errorf("failed session went on with %q", command)
+				}
+			}
+			for _, command := range sessions[1] {
+				if command == "STARTTLS" {
+					t.Error("retry tried STARTTLS again")
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCatchAll(t *testing.T) {
+	tests := []struct {
+		name   string
+		rcpt   string
+		status string
+		signal string
+		cached bool
+	}{
+		{"rejects unknown mailboxes", "550 5.1.1 User unknown", "pass", "catch_all_rejected", true},
+		{"accepts any mailbox", "250 2.1.5 OK", "fail", "catch_all_detected", true},
+		{"greylisted probe", "450 4.2.1 Try again later", "unknown", "450 4.2.1 Try again later", false},
+		{"policy rejection", "550 5.7.1 Relaying denied", "unknown", "550 5.7.1 Relaying denied", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+				s.rcpt = func(string) string { return tt.rcpt }
+			})
+			v := newTestSMTPValidator(server.port())
+			mx := []models.MXRecord{server.mx(10)}
+
+			result := v.CheckCatchAll(context.Background(), "example.test", mx)
+			if result.Status != tt.status || result.RawSignal != tt.signal {
+				t.Errorf("catch-all = %s/%s, want %s/%s", result.Status, result.RawSignal, tt.status, tt.signal)
+			}
+
+			// A definitive verdict is reused; anything else is asked again
+			v.CheckCatchAll(context.Background(), "example.test", mx)
+			wantSessions := 2
+			if tt.cached {
+				wantSessions = 1
+			}
+			if sessions := len(server.commands()); sessions != wantSessions {
+				t.Errorf("sessions = %d, want %d", sessions, wantSessions)
+			}
+		})
+	}
+}
+
+func TestSMTPValidateUnreachableServer(t *testing.T) {
+	// Listen and close at once so the port is known to refuse connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	v := newTestSMTPValidator(port)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := v.Validate(ctx, "jane@example.test", []models.MXRecord{{Host: "127.0.0.1", Priority: 10, IP: "127.0.0.1"}})
+	// Nothing answered, so the verdict falls back to the MX records alone
+	if result.Reachable.RawSignal != "mx_verified" && result.Reachable.RawSignal != "tcp_verified" {
+		t.Errorf("reachable = %s/%s, want a fallback verdict", result.Reachable.Status, result.Reachable.RawSignal)
+	}
+	if result.MailboxStatus == MailboxActive || result.MailboxStatus == MailboxNonexistent {
+		t.Errorf("mailbox status = %q without an SMTP answer", result.MailboxStatus)
+	}
+	if len(result.MXResults) != 1 || result.MXResults[0].Outcome != "failed" {
+		t.Errorf("mx results = %+v, want one failed host", result.MXResults)
+	}
+}
+
+func TestSMTPValidateBacksOffFailingHost(t *testing.T) {
+	const cooldown = 300 * time.Millisecond
+	// Listen and close at once so the port is known to refuse connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refusedPort := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	throttling := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+		s.banner = "421 4.7.0 Too many connections, try again later"
+	})
+
+	tests := []struct {
+		name     string
+		port     int
+		sessions func() int // connections the host has seen, or nil when it cannot count them
+	}{
+		{"refused", refusedPort, nil},
+		{"421", throttling.port(), func() int { return len(throttling.commands()) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestSMTPValidator(tt.port)
+			v.backoff = newMXBackoff(1, cooldown)
+			mx := []models.MXRecord{{Host: "127.0.0.1", Priority: 10, IP: "127.0.0.1"}}
+			validate := func() models.SMTPValidationResult {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				return v.Validate(ctx, "jane@example.test", mx)
+			}
+			sessions := func() int {
+				if tt.sessions == nil {
+					return -1
+				}
+				return tt.sessions()
+			}
+
+			if result := validate(); result.Reachable.RawSignal == "mx_backoff" {
+				t.Fatalf("first probe was skipped: %+v", result.Reachable)
+			}
+			probed := sessions()
+
+			// During the cooldown the host is not contacted
+			if result := validate(); result.Reachable.RawSignal != "mx_backoff" {
+				t.Errorf("during cooldown reachable = %s/%s, want mx_backoff", result.Reachable.Status, result.Reachable.RawSignal)
+			}
+			if got := sessions(); got != probed {
+				t.Errorf("sessions during cooldown = %d, want %d", got, probed)
+			}
+
+			// Once it ends the host is probed again
+			time.Sleep(cooldown + 50*time.Millisecond)
+			if result := validate(); result.Reachable.RawSignal == "mx_backoff" {
+				t.Errorf("after cooldown reachable = %s/%s, want a fresh probe", result.Reachable.Status, result.Reachable.RawSignal)
+			}
+			if tt.sessions != nil && sessions() <= probed {
+				t.Errorf("sessions after cooldown = %d, want more than %d", sessions(), probed)
+			}
+		})
+	}
+}
+
+func TestMXBackoffForgetsExpiredHosts(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	b := newMXBackoff(3, cooldown)
+	for i := 0; i < 100; i++ {
+		b.failure(fmt.Sprintf("mx%d.example.test", i))
+	}
+	time.Sleep(2*cooldown + 10*time.Millisecond)
+	b.failure("fresh.example.test")
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.hosts) != 1 {
+		t.Errorf("hosts = %d after cooldown, want only the fresh one", len(b.hosts))
+	}
+}
+
+func TestClassifyMailbox(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  string
+	}{
+		{"250 2.1.5 OK", MailboxActive},
+		{"251 User not local; will forward", MailboxActive},
+		{"550 5.1.1 The email account that you tried to reach does not exist", MailboxNonexistent},
+		{"550 5.1.10 Recipient not found", MailboxNonexistent},
+		{"550 5.2.1 The email account that you tried to reach is disabled", MailboxDisabled},
+		{"552 5.2.2 Mailbox full", MailboxFull},
+		{"452 4.2.2 Over quota", MailboxFull},
+		{"550 5.7.1 Rejected by policy", MailboxUnknown},
+		{"550 No such user here", MailboxNonexistent},
+		{"550 Account disabled", MailboxDisabled},
+
+		// Temporary replies never settle the mailbox's existence
+		{"450 4.2.1 The user you are trying to contact is receiving mail too quickly", MailboxUnknown},
+		{"450 4.1.1 Recipient address rejected: unverified address", MailboxUnknown},
+		{"451 4.7.1 Greylisted, try again later", MailboxUnknown},
+		{"450 Mailbox temporarily disabled", MailboxUnknown},
+		{"550 4.1.1 Mismatched enhanced code", MailboxUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyMailbox(tt.reply); got != tt.want {
+			t.Errorf("classifyMailbox(%q) = %q, want %q", tt.reply, got, tt.want)
+		}
+	}
+}
+
+func TestSMTPValidateStopsWhenCancelled(t *testing.T) {
+	gate := make(chan struct{})
+	t.Cleanup(func() { close(gate) })
+	// The server never answers RCPT TO while the test runs
+	server := newFakeSMTP(t, "127.0.0.1:0", func(s *fakeSMTP) {
+		s.rcpt = func(string) string {
+			<-gate
+			return "250 2.1.5 OK"
+		}
+	})
+	v := newTestSMTPValidator(server.port())
+	v.timeout = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan models.SMTPValidationResult, 1)
+	go func() {
+		done <- v.Validate(ctx, "jane@example.test", []models.MXRecord{server.mx(10)})
+	}()
+
+	// Cancel once the session is waiting on the RCPT reply
+	deadline := time.Now().Add(2 * time.Second)
+	for !sentRCPT(server) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case result := <-done:
+		// Nothing was learned, so the MX records must not be taken as enough
+		if result.Reachable.Status != "unknown" || result.SMTPVerificationPerformed {
+			t.Errorf("reachable = %+v, verification %s after cancellation", result.Reachable, result.VerificationMethod)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Validate kept waiting on the server after its context was cancelled")
+	}
+}
+
+// sentRCPT reports whether any session has sent RCPT TO
+func sentRCPT(server *fakeSMTP) bool {
+	for _, session := range server.commands() {
+		for _, command := range session {
+			if strings.HasPrefix(command, "RCPT") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestSMTPValidateRefusesInjectedAddress(t *testing.T) {
+	server := newFakeSMTP(t, "127.0.0.1:0", nil)
+	v := newTestSMTPValidator(server.port())
+
+	// Syntax validation would reject these; the SMTP path must not rely on it
+	for _, email := range []string{
+		"user\r\nMAIL FROM:<evil>@example.test",
+		"jane@example.test\r\nRSET",
+		"jane>@example.test",
+	} {
+		result := v.Validate(context.Background(), email, []models.MXRecord{server.mx(10)})
+		if result.Reachable.Status != "fail" || result.Reachable.RawSignal != "unsafe_address" {
+			t.Errorf("Validate(%q) = %+v, want an unsafe address failure", email, result.Reachable)
+		}
+	}
+	if sessions := server.commands(); len(sessions) != 0 {
+		t.Errorf("server received %v, want no connection", sessions)
+	}
+}
+
+func TestNewSMTPValidatorIgnoresUnsafeIdentity(t *testing.T) {
+	v := NewSMTPValidator(SMTPOptions{HeloName: "mx.example.test\r\nRSET", MailFrom: "bounce@example.test\nDATA"}, models.ScoringWeights{})
+	if v.heloName != "emailintel.local" || v.mailFrom != "verify@emailintel.local" {
+		t.Errorf("HELO %q, MAIL FROM %q; want the defaults", v.heloName, v.mailFrom)
+	}
+}
@@ -0,0 +1,159 @@
+package validators
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResolveSourceAddrs turns SMTP source entries into local IPs. An entry is an
+// IP literal or a network interface name, which contributes the interface's
+// global unicast addresses. Entries that cannot be resolved are reported in
+// the error; the addresses that did resolve are still returned.
+func ResolveSourceAddrs(entries []string) ([]net.IP, error) {
+	addrs := []net.IP{}
+	var unresolved []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if ip := net.ParseIP(entry); ip != nil {
+			addrs = append(addrs, ip)
+			continue
+		}
+		ips, err := interfaceAddrs(entry)
+		if err != nil || len(ips) == 0 {
+			unresolved = append(unresolved, entry)
+			continue
+		}
+		addrs = append(addrs, ips...)
+	}
+	if len(unresolved) > 0 {
+		return addrs, fmt.Errorf("no usable address for %s", strings.Join(unresolved, ", "))
+	}
+	return addrs, nil
+}
+
+func interfaceAddrs(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	ips := []net.IP{}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips, nil
+}
+
+// sourcePool rotates SMTP probes across local source addresses round-robin,
+// skipping addresses that receivers have recently blocked. When every address
+// is blocked the one whose cooldown ends first is used.
+type sourcePool struct {
+	addrs  []net.IP
+	next   int
+	blocks *mxBackoff // keyed by source IP
+	mu     sync.Mutex
+}
+
+// newSourcePool returns nil when there are no addresses, in which case probes
+// use the default route
+func newSourcePool(addrs []net.IP, threshold int, cooldown time.Duration) *sourcePool {
+	if len(addrs) == 0 {
+		return nil
+	}
+	return &sourcePool{
+		addrs:  addrs,
+		blocks: newMXBackoff(threshold, cooldown),
+	}
+}
+
+// pick returns the source for the next probe to a host reachable over family
+// ("ip4", "ip6", or "" for either). It returns nil without a pool or without a
+// source of that family, leaving the route to the OS: an IPv6-only MX host
+// cannot be reached from an IPv4 source.
+func (p *sourcePool) pick(family string) net.IP {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var fallback net.IP
+	var earliest time.Time
+	for i := 0; i < len(p.addrs); i++ {
+		ip := p.addrs[(p.next+i)%len(p.addrs)]
+		if (family == "ip4" && ip.To4() == nil) || (family == "ip6" && ip.To4() != nil) {
+			continue
+		}
+		until, blocked := p.blocks.blocked(ip.String())
+		if !blocked {
+			p.next = (p.next + i + 1) % len(p.addrs)
+			return ip
+		}
+		if fallback == nil || until.Before(earliest) {
+			fallback, earliest = ip, until
+		}
+	}
+	return fallback
+}
+
+// record updates the block status of source from the outcome of one probe:
+// a throttling or reputation refusal counts against it, an answered RCPT clears it
+func (p *sourcePool) record(source net.IP, result smtpProbe) {
+	if p == nil || source == nil {
+		return
+	}
+	switch {
+	case sourceRefused(result.reply):
+		p.blocks.failure(source.String())
+	case result.answered:
+		p.blocks.success(source.String())
+	}
+}
+
+// smtpProbe is the part of a probe result that says something about the source IP
+type smtpProbe struct {
+	reply    string
+	answered bool // the server answered RCPT TO
+}
+
+// sourceRefusalHints appear in replies rejecting the connecting IP rather than the mailbox
+var sourceRefusalHints = []string{"blocked", "blacklist", "blocklist", "listed", "spamhaus", "reputation", "rbl", "client host rejected"}
+
+// sourceRefused reports whether a reply throttles or rejects the connecting IP
+func sourceRefused(reply string) bool {
+	if strings.HasPrefix(reply, "421") {
+		return true
+	}
+	if !strings.HasPrefix(reply, "5") && !strings.HasPrefix(reply, "4") {
+		return false
+	}
+	lower := strings.ToLower(reply)
+	for _, hint := range sourceRefusalHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceDialer binds outbound connections to source, restricting the address
+// family to match it. A nil source leaves the choice to the OS.
+func sourceDialer(source net.IP, timeout time.Duration) (*net.Dialer, string) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if source == nil {
+		return dialer, "tcp"
+	}
+	dialer.LocalAddr = &net.TCPAddr{IP: source}
+	if source.To4() != nil {
+		return dialer, "tcp4"
+	}
+	return dialer, "tcp6"
+}
@@ -0,0 +1,173 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"email-intelligence/internal/models"
+)
+
+// spfLookupLimit is the number of DNS-querying terms RFC 7208 section 4.6.4
+// allows during one SPF evaluation; receivers treat more as a permanent error
+const spfLookupLimit = 10
+
+// spfPolicy is an SPF record flattened through its include: mechanisms and
+// redirect= modifier
+type spfPolicy struct {
+	record  string
+	all     string // qualifier of the effective all mechanism: +, -, ~, ? or "" without one
+	lookups int    // include, a, mx, ptr, exists and redirect terms, nested ones included
+}
+
+// spfWalk carries what flattening needs across nested records
+type spfWalk struct {
+	v     *SecurityValidator
+	trace *txtTrace
+}
+
+// flattenSPF parses record and follows its includes and redirect, counting
+// DNS lookups across all of them. Includes at one level are fetched in
+// parallel; flattening stops descending once the limit is exceeded.
+func (v *SecurityValidator) flattenSPF(ctx context.Context, domain, record string, trace *txtTrace) spfPolicy {
+	walk := &spfWalk{v: v, trace: trace}
+	return walk.parse(ctx, record, []string{strings.ToLower(domain)})
+}
+
+// parse evaluates record, reached through the domains in path
+func (w *spfWalk) parse(ctx context.Context, record string, path []string) spfPolicy {
+	policy := spfPolicy{record: record}
+	var includes []string
+	redirect := ""
+	for _, term := range strings.Fields(strings.ToLower(record)) {
+		if term == "v=spf1" {
+			continue
+		}
+		// Modifiers are name=value with no mechanism separator before the '='
+		if name, value, ok := strings.Cut(term, "="); ok && !strings.ContainsAny(name, ":/") {
+			if name == "redirect" {
+				policy.lookups++
+				redirect = value
+			}
+			continue
+		}
+		qualifier := "+"
+		if strings.ContainsRune("+-~?", rune(term[0])) {
+			qualifier, term = term[:1], term[1:]
+		}
+		mechanism, target, _ := strings.Cut(term, ":")
+		mechanism, _, _ = strings.Cut(mechanism, "/")
+		switch mechanism {
+		case "all":
+			policy.all = qualifier
+		case "include":
+			policy.lookups++
+			if target != "" {
+				includes = append(includes, target)
+			}
+		case "a", "mx", "ptr", "exists":
+			policy.lookups++
+		}
+	}
+
+	if policy.lookups <= spfLookupLimit && len(path) <= spfLookupLimit {
+		nested := make([]spfPolicy, len(includes))
+		var wg sync.WaitGroup
+		for i, include := range includes {
+			wg.Add(1)
+			go func(i int, include string) {
+				defer wg.Done()
+				nested[i] = w.follow(ctx, include, path)
+			}(i, include)
+		}
+		wg.Wait()
+		for _, included := range nested {
+			policy.lookups += included.lookups
+		}
+
+		// A redirect only applies when the record has no all mechanism of its own
+		if redirect != "" && policy.all == "" {
+			target := w.follow(ctx, redirect, path)
+			policy.lookups += target.lookups
+			policy.all = target.all
+		}
+	}
+	return policy
+}
+
+// follow fetches and parses the SPF record of domain, or returns an empty
+// policy when it has no record or already appears in path. Only the path is
+// checked: a record included from two branches is evaluated, and counted,
+// once per branch, as receivers do under RFC 7208 section 4.6.4.
+func (w *spfWalk) follow(ctx context.Context, domain string, path []string) spfPolicy {
+	if slices.Contains(path, domain) || ctx.Err() != nil {
+		return spfPolicy{}
+	}
+
+	records, err := w.v.lookupTXT(ctx, domain, w.trace)
+	if err != nil {
+		return spfPolicy{}
+	}
+	for _, record := range records {
+		if isSPFRecord(record) {
+			return w.parse(ctx, record, append(slices.Clip(path), domain))
+		}
+	}
+	return spfPolicy{}
+}
+
+// isSPFRecord reports whether a TXT record is an SPF version 1 record
+func isSPFRecord(txt string) bool {
+	return strings.EqualFold(txt, "v=spf1") || len(txt) > 7 && strings.EqualFold(txt[:7], "v=spf1 ")
+}
+
+// rawSignal is the effective all qualifier as reported in RawSignal
+func (p spfPolicy) rawSignal() string {
+	if p.all == "" {
+		return "no_all"
+	}
+	return p.all + "all"
+}
+
+// warnings describes what makes the policy weak or broken
+func (p spfPolicy) warnings() []string {
+	warnings := []string{}
+	switch p.all {
+	case "+":
+		warnings = append(warnings, "SPF record ends in +all, so any server may send mail as this domain")
+	case "?", "":
+		warnings = append(warnings, "SPF record is neutral about unlisted servers, so it does not stop spoofing")
+	}
+	if p.lookups > spfLookupLimit {
+		warnings = append(warnings, fmt.Sprintf("SPF record needs %d DNS lookups, over the RFC 7208 limit of %d, so receivers treat it as an error", p.lookups, spfLookupLimit))
+	}
+	return warnings
+}
+
+// result scores the policy: full credit only for a record that rejects or
+// soft-fails unlisted servers within the lookup limit
+func (p spfPolicy) result() models.ValidationResult {
+	result := models.ValidationResult{
+		Status:    "pass",
+		Reason:    fmt.Sprintf("SPF record found (%s)", p.rawSignal()),
+		RawSignal: p.rawSignal(),
+		Score:     7,
+		Weight:    7,
+	}
+	switch {
+	case p.all == "+":
+		result.Status = "fail"
+		result.Reason = "SPF record permits any sender (+all)"
+		result.Score = 0
+	case p.lookups > spfLookupLimit:
+		result.Status = "fail"
+		result.Reason = fmt.Sprintf("SPF record exceeds the DNS lookup limit (%d of %d)", p.lookups, spfLookupLimit)
+		result.Score = 2
+	case p.all == "?" || p.all == "":
+		result.Reason = fmt.Sprintf("SPF record found, but neutral about unlisted senders (%s)", p.rawSignal())
+		result.Score = 4
+	}
+	return result
+}
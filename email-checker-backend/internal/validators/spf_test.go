@@ -0,0 +1,94 @@
+package validators
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlattenSPF(t *testing.T) {
+	tests := []struct {
+		name        string
+		zone        map[string][]string // example.test holds the record under test
+		wantLookups int
+		wantSignal  string
+		wantStatus  string
+		wantScore   int
+	}{
+		{"-all", map[string][]string{
+			"example.test": {"v=spf1 ip4:192.0.2.0/24 -all"},
+		}, 0, "-all", "pass", 7},
+		{"+all", map[string][]string{
+			"example.test": {"v=spf1 ip4:192.0.2.0/24 +all"},
+		}, 0, "+all", "fail", 0},
+		{"?all", map[string][]string{
+			"example.test": {"v=spf1 mx ?all"},
+		}, 1, "?all", "pass", 4},
+		{"no all", map[string][]string{
+			"example.test": {"v=spf1 ip4:192.0.2.0/24"},
+		}, 0, "no_all", "pass", 4},
+		{"redirect", map[string][]string{
+			"example.test":      {"v=spf1 redirect=_spf.example.test"},
+			"_spf.example.test": {"v=spf1 include:mail.test ~all"},
+			"mail.test":         {"v=spf1 ip4:192.0.2.0/24 -all"},
+		}, 2, "~all", "pass", 7},
+		{"redirect ignored beside all", map[string][]string{
+			"example.test":      {"v=spf1 redirect=_spf.example.test -all"},
+			"_spf.example.test": {"v=spf1 +all"},
+		}, 1, "-all", "pass", 7},
+		{"nested includes over the limit", map[string][]string{
+			"example.test": {"v=spf1 include:l1.test include:l2.test -all"},
+			"l1.test":      {"v=spf1 a mx include:l3.test ~all"},
+			"l2.test":      {"v=spf1 a mx ptr exists:%{i}.l2.test ~all"},
+			"l3.test":      {"v=spf1 a mx ptr -all"},
+		}, 12, "-all", "fail", 2},
+		{"include loop", map[string][]string{
+			"example.test": {"v=spf1 include:loop.test -all"},
+			"loop.test":    {"v=spf1 include:example.test -all"},
+		}, 2, "-all", "pass", 7},
+		// d.test is reached through b and c, and a receiver evaluates it twice
+		{"diamond include", map[string][]string{
+			"example.test": {"v=spf1 include:b.test include:c.test -all"},
+			"b.test":       {"v=spf1 include:d.test -all"},
+			"c.test":       {"v=spf1 include:d.test -all"},
+			"d.test":       {"v=spf1 a mx ptr exists:%{i}.d.test -all"},
+		}, 12, "-all", "fail", 2},
+		{"missing include", map[string][]string{
+			"example.test": {"v=spf1 include:gone.test -all"},
+		}, 1, "-all", "pass", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSecurityValidator(&zoneResolver{zone: tt.zone}, SecurityOptions{Timeout: time.Second})
+			policy := v.flattenSPF(context.Background(), "example.test", tt.zone["example.test"][0], &txtTrace{})
+			if policy.lookups != tt.wantLookups {
+				t.Errorf("lookups = %d, want %d", policy.lookups, tt.wantLookups)
+			}
+			result := policy.result()
+			if result.RawSignal != tt.wantSignal || result.Status != tt.wantStatus || result.Score != tt.wantScore {
+				t.Errorf("result = %s %q score %v, want %s %q score %v (%s)",
+					result.Status, result.RawSignal, result.Score, tt.wantStatus, tt.wantSignal, tt.wantScore, result.Reason)
+			}
+		})
+	}
+}
+
+func TestParseSPFIncludes(t *testing.T) {
+	tests := []struct {
+		record string
+		want   []string
+	}{
+		{"v=spf1 -all", []string{}},
+		{"v=spf1 include:_spf.google.com ~all", []string{"_spf.google.com"}},
+		{"v=spf1 +include:A.test ?include:b.test -include:c.test -all", []string{"a.test", "b.test", "c.test"}},
+		{"v=spf1 redirect=_spf.example.test", []string{"_spf.example.test"}},
+		{"v=spf1 include: redirect= -all", []string{}},
+		{"v=spf1 a:include.test mx exists:include:x -all", []string{}},
+	}
+	for _, tt := range tests {
+		if got := parseSPFIncludes(tt.record); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSPFIncludes(%q) = %q, want %q", tt.record, got, tt.want)
+		}
+	}
+}
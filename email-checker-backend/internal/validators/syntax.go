@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"email-intelligence/internal/models"
+
+	"golang.org/x/net/idna"
 )
 
 // SyntaxValidator validates email syntax
@@ -22,6 +24,29 @@ func (v *SyntaxValidator) Validate(email string) models.ValidationResult {
 	// RFC 5322 compliant regex with enhanced validation
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
 	
+	// Control characters (CR/LF in particular) could smuggle extra SMTP commands
+	if ContainsControl(email) {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Email contains control characters",
+			RawSignal: "control_characters",
+			Score:     0,
+			Weight:    v.weights.SyntaxFormat,
+		}
+	}
+	
+	// Internationalized domains are checked in their ASCII (Punycode) form
+	email, err := ASCIIAddress(email)
+	if err != nil {
+		return models.ValidationResult{
+			Status:    "fail",
+			Reason:    "Domain is not a valid internationalized domain name",
+			RawSignal: "idn_conversion_failed",
+			Score:     0,
+			Weight:    v.weights.SyntaxFormat,
+		}
+	}
+	
 	if !emailRegex.MatchString(email) {
 		return models.ValidationResult{
 			Status:    "fail",
@@ -32,8 +57,8 @@ func (v *SyntaxValidator) Validate(email string) models.ValidationResult {
 		}
 	}
 	
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
+	localPart, domain, ok := SplitAddress(email)
+	if !ok {
 		return models.ValidationResult{
 			Status:    "fail",
 			Reason:    "Invalid email structure",
@@ -43,8 +68,6 @@ func (v *SyntaxValidator) Validate(email string) models.ValidationResult {
 		}
 	}
 	
-	localPart, domain := parts[0], parts[1]
-	
 	// Enhanced validation checks
 	if len(localPart) > 64 || len(domain) > 253 || len(email) > 254 {
 		return models.ValidationResult{
@@ -74,3 +97,77 @@ func (v *SyntaxValidator) Validate(email string) models.ValidationResult {
 		Weight:    v.weights.SyntaxFormat,
 	}
 }
+
+// ContainsControl reports whether s contains ASCII control characters or DEL
+func ContainsControl(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// ASCIIAddress converts the domain of email to its ASCII (Punycode) form,
+// leaving the local part untouched. Pure ASCII addresses are returned as is.
+func ASCIIAddress(email string) (string, error) {
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return email, nil
+	}
+	domain, err := ASCIIDomain(email[at+1:])
+	if err != nil {
+		return "", err
+	}
+	return email[:at+1] + domain, nil
+}
+
+// ASCIIDomain converts domain to its ASCII (Punycode) form. Pure ASCII
+// domains are returned as is.
+func ASCIIDomain(domain string) (string, error) {
+	if isASCII(domain) {
+		return domain, nil
+	}
+	return idna.Lookup.ToASCII(domain)
+}
+
+// UnicodeAddress converts the domain of email to its Unicode form for display
+func UnicodeAddress(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return email
+	}
+	domain, err := idna.Lookup.ToUnicode(email[at+1:])
+	if err != nil {
+		return email
+	}
+	return email[:at+1] + domain
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitAddress splits an address into local part and domain. ok is false
+// unless there is exactly one "@" with non-empty text on both sides.
+func SplitAddress(email string) (localPart, domain string, ok bool) {
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// domainPattern accepts two or more DNS labels of letters, digits and inner
+// hyphens, e.g. "example.com" or "mail.example.co.uk"
+var domainPattern = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+
+// ValidDomain reports whether domain, in its ASCII form, is a usable host name
+func ValidDomain(domain string) bool {
+	return len(domain) <= 253 && domainPattern.MatchString(domain)
+}
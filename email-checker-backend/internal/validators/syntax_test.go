@@ -0,0 +1,46 @@
+package validators
+
+import (
+	"testing"
+
+	"email-intelligence/internal/models"
+)
+
+// malformedAddresses are missing a local part or domain, or have too many
+var malformedAddresses = []string{"", "@", "a@", "@b", "a@b@c", "a", "@@"}
+
+func TestSplitAddress(t *testing.T) {
+	for _, email := range malformedAddresses {
+		if localPart, domain, ok := SplitAddress(email); ok || localPart != "" || domain != "" {
+			t.Errorf("SplitAddress(%q) = %q, %q, %t; want a failed split", email, localPart, domain, ok)
+		}
+	}
+	if localPart, domain, ok := SplitAddress("jane@example.com"); !ok || localPart != "jane" || domain != "example.com" {
+		t.Errorf("SplitAddress(jane@example.com) = %q, %q, %t", localPart, domain, ok)
+	}
+}
+
+func TestSyntaxValidateMalformed(t *testing.T) {
+	v := NewSyntaxValidator(models.ScoringWeights{SyntaxFormat: 20})
+	for _, email := range malformedAddresses {
+		if result := v.Validate(email); result.Status != "fail" || result.Score != 0 {
+			t.Errorf("Validate(%q) = %+v, want a failure", email, result)
+		}
+	}
+}
+
+func TestSyntaxValidateRejectsControlCharacters(t *testing.T) {
+	v := NewSyntaxValidator(models.ScoringWeights{SyntaxFormat: 20})
+	for _, email := range []string{
+		"user\r\nMAIL FROM:<evil>@domain.com",
+		"jane@example.com\r\nRCPT TO:<victim@example.com>",
+		"jane\n@example.com",
+		"jane\x00@example.com",
+		"jane\t@example.com",
+		"jane\x7f@example.com",
+	} {
+		if result := v.Validate(email); result.Status != "fail" || result.RawSignal != "control_characters" {
+			t.Errorf("Validate(%q) = %+v, want a control character failure", email, result)
+		}
+	}
+}
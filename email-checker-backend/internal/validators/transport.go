@@ -0,0 +1,182 @@
+package validators
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"email-intelligence/internal/httpclient"
+)
+
+// DNS transport protocols
+const (
+	DNSPlain     = "plain" // UDP/TCP to the configured or system nameservers
+	DNSOverTLS   = "dot"   // RFC 7858, TCP framing inside TLS on port 853
+	DNSOverHTTPS = "doh"   // RFC 8484, wire-format messages POSTed over HTTPS
+
+	// DNSOverHTTPSJSON queries the JSON API most DoH providers also serve, for
+	// endpoints such as https://dns.google/resolve that do not take wire format
+	DNSOverHTTPSJSON = "doh-json"
+)
+
+// Default encrypted resolvers used when no server is configured
+const (
+	defaultDoTServer = "1.1.1.1:853"
+	defaultDoHServer = "https://cloudflare-dns.com/dns-query"
+)
+
+// DNSTransport selects how every outbound DNS query is carried. Only
+// domain-level names (MX, TXT, NS, DKIM selectors) are ever queried, never
+// the local part; encrypted transports also hide those names from the network
+// path, and the upstream resolver is expected to apply QNAME minimization.
+type DNSTransport struct {
+	Protocol   string             // DNSPlain, DNSOverTLS, DNSOverHTTPS or DNSOverHTTPSJSON
+	Server     string             // "host:port" for plain/DoT, an https URL for DoH
+	Timeout    time.Duration      // per-connection dial timeout
+	HTTPClient *httpclient.Client // carries DoH JSON API queries; nil uses a client of its own
+}
+
+// dialFunc matches net.Resolver.Dial
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dialer returns the function used to reach the resolver. Connections that
+// are not net.PacketConn (DoT, DoH) are spoken to with TCP framing.
+func (t DNSTransport) dialer() dialFunc {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	switch t.Protocol {
+	case DNSOverTLS:
+		server := t.Server
+		if server == "" {
+			server = defaultDoTServer
+		} else if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "853")
+		}
+		host, _, _ := net.SplitHostPort(server)
+		tlsDialer := &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: timeout},
+			Config:    &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+		}
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return tlsDialer.DialContext(ctx, "tcp", server)
+		}
+	case DNSOverHTTPS, DNSOverHTTPSJSON:
+		// Lookups over the JSON API do not dial; the DNSSEC check still sends
+		// wire-format queries, which Cloudflare's endpoint accepts as well
+		endpoint := t.Server
+		if endpoint == "" {
+			endpoint = defaultDoHServer
+		}
+		client := &http.Client{Timeout: timeout * 2}
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, client: client, endpoint: endpoint}, nil
+		}
+	default:
+		d := net.Dialer{Timeout: timeout}
+		if t.Server == "" {
+			return d.DialContext
+		}
+		server := t.Server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		return func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, network, server)
+		}
+	}
+}
+
+// dohConn adapts DNS over HTTPS to the stream interface the Go resolver
+// speaks: each length-prefixed message written is POSTed to the endpoint and
+// the length-prefixed answer is made available to Read.
+type dohConn struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+	deadline time.Time
+	pending  bytes.Buffer
+	answers  bytes.Buffer
+}
+
+func (c *dohConn) Write(p []byte) (int, error) {
+	c.pending.Write(p)
+	for c.pending.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.pending.Bytes()[:2]))
+		if c.pending.Len() < 2+size {
+			break
+		}
+		c.pending.Next(2)
+		if err := c.roundTrip(c.pending.Next(size)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *dohConn) roundTrip(query []byte) error {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DoH server returned %s", resp.Status)
+	}
+
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 65535))
+	if err != nil {
+		return err
+	}
+	var size [2]byte
+	binary.BigEndian.PutUint16(size[:], uint16(len(answer)))
+	c.answers.Write(size[:])
+	c.answers.Write(answer)
+	return nil
+}
+
+func (c *dohConn) Read(p []byte) (int, error) {
+	if c.answers.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.answers.Read(p)
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr{} }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "https" }
+func (dohAddr) String() string  { return "doh" }
+
+// ValidDNSProtocol reports whether protocol names a supported transport
+func ValidDNSProtocol(protocol string) bool {
+	return protocol == DNSPlain || protocol == DNSOverTLS || protocol == DNSOverHTTPS || protocol == DNSOverHTTPSJSON
+}
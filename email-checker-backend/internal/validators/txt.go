@@ -0,0 +1,140 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"email-intelligence/internal/models"
+)
+
+// Limits on TXT data processed per lookup. Legitimate SPF, DMARC and DKIM
+// records fit comfortably; anything beyond is truncated and reported as an
+// anomaly so a hostile domain cannot make us hold or scan megabytes of text.
+const (
+	MaxTXTRecords     = 32        // records kept per name
+	MaxTXTRecordBytes = 4096      // longest single record kept
+	MaxTXTTotalBytes  = 16 * 1024 // combined size kept per name
+)
+
+// txtTrace collects, from lookups running in parallel, descriptions of
+// oversized or abnormal TXT answers and the raw evidence for debug output:
+// every answer seen and every DKIM selector tried
+type txtTrace struct {
+	items     []string
+	lookups   []models.TXTLookup
+	selectors []models.DKIMSelectorResult
+	mu        sync.Mutex
+}
+
+func (a *txtTrace) add(name, description string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.items = append(a.items, name+": "+description)
+}
+
+func (a *txtTrace) list() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string{}, a.items...)
+}
+
+// answer records the outcome of one TXT query
+func (a *txtTrace) answer(name string, records []string, err error) {
+	if a == nil {
+		return
+	}
+	lookup := models.TXTLookup{Name: name, Records: append([]string{}, records...)}
+	if err != nil {
+		lookup.Error = err.Error()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lookups = append(a.lookups, lookup)
+}
+
+// selector records the outcome of one DKIM selector
+func (a *txtTrace) selector(result models.DKIMSelectorResult) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.selectors = append(a.selectors, result)
+}
+
+func (a *txtTrace) answers() ([]models.TXTLookup, []models.DKIMSelectorResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]models.TXTLookup{}, a.lookups...), append([]models.DKIMSelectorResult{}, a.selectors...)
+}
+
+// lookupTXT resolves TXT records for name and bounds them with boundTXT,
+// recording the answer and any truncation in trace (which may be nil)
+func (v *SecurityValidator) lookupTXT(ctx context.Context, name string, trace *txtTrace) ([]string, error) {
+	records, err := v.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		trace.answer(name, nil, err)
+		return nil, err
+	}
+	bounded, anomaly := boundTXT(records)
+	if anomaly != "" {
+		trace.add(name, anomaly)
+	}
+	trace.answer(name, bounded, nil)
+	return bounded, nil
+}
+
+// boundTXT drops records over MaxTXTRecordBytes and keeps at most
+// MaxTXTRecords records totalling MaxTXTTotalBytes. Policy records are kept
+// first, so a domain cannot push its SPF or DMARC record out of the limits
+// with filler such as verification tokens; the order of the answer is
+// otherwise preserved. The returned description is empty when nothing was
+// dropped.
+func boundTXT(records []string) ([]string, string) {
+	keep := make([]bool, len(records))
+	kept, oversized, total := 0, 0, 0
+	for _, policy := range []bool{true, false} {
+		for i, record := range records {
+			if isPolicyRecord(record) != policy {
+				continue
+			}
+			if len(record) > MaxTXTRecordBytes {
+				oversized++
+				continue
+			}
+			if kept == MaxTXTRecords || total+len(record) > MaxTXTTotalBytes {
+				continue
+			}
+			keep[i] = true
+			kept++
+			total += len(record)
+		}
+	}
+	bounded := make([]string, 0, kept)
+	for i, record := range records {
+		if keep[i] {
+			bounded = append(bounded, record)
+		}
+	}
+
+	dropped := len(records) - len(bounded)
+	if dropped == 0 {
+		return bounded, ""
+	}
+	if oversized > 0 {
+		return bounded, fmt.Sprintf("%d TXT records, %d over %d bytes; %d dropped", len(records), oversized, MaxTXTRecordBytes, dropped)
+	}
+	return bounded, fmt.Sprintf("%d TXT records; only %d (%d bytes) processed", len(records), len(bounded), total)
+}
+
+// isPolicyRecord reports the SPF, DMARC, DKIM and BIMI records the security
+// checks look for
+func isPolicyRecord(record string) bool {
+	return isSPFRecord(record) || strings.HasPrefix(record, "v=DMARC1") ||
+		strings.HasPrefix(record, "v=DKIM1") || strings.HasPrefix(record, "v=BIMI1")
+}
@@ -0,0 +1,135 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fillerRecords returns n verification tokens of the kind domains collect
+func fillerRecords(n int) []string {
+	records := make([]string, n)
+	for i := range records {
+		records[i] = fmt.Sprintf("site-verification=%040d", i)
+	}
+	return records
+}
+
+func TestBoundTXTKeepsPolicyRecords(t *testing.T) {
+	spf := "v=spf1 include:_spf.example.com -all"
+	dmarc := "v=DMARC1; p=reject"
+	tests := []struct {
+		name    string
+		records []string
+	}{
+		{"after too many records", append(fillerRecords(MaxTXTRecords+8), spf, dmarc)},
+		{"after too many bytes", append([]string{strings.Repeat("a", MaxTXTRecordBytes), strings.Repeat("b", MaxTXTRecordBytes), strings.Repeat("c", MaxTXTRecordBytes), strings.Repeat("d", MaxTXTRecordBytes)}, spf, dmarc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounded, anomaly := boundTXT(tt.records)
+			if anomaly == "" {
+				t.Error("truncation not reported")
+			}
+			if len(bounded) > MaxTXTRecords {
+				t.Errorf("kept %d records, limit %d", len(bounded), MaxTXTRecords)
+			}
+			total := 0
+			for _, record := range bounded {
+				total += len(record)
+			}
+			if total > MaxTXTTotalBytes {
+				t.Errorf("kept %d bytes, limit %d", total, MaxTXTTotalBytes)
+			}
+			// Policy records survive and the answer keeps its order
+			if got := bounded[len(bounded)-2:]; !reflect.DeepEqual(got, []string{spf, dmarc}) {
+				t.Errorf("last records = %q, want the SPF and DMARC records", got)
+			}
+		})
+	}
+}
+
+func TestBoundTXT(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     []string
+		wantKept    int
+		wantAnomaly bool
+	}{
+		{"within limits", fillerRecords(3), 3, false},
+		{"too many records", fillerRecords(MaxTXTRecords + 1), MaxTXTRecords, true},
+		{"oversized record", []string{strings.Repeat("x", MaxTXTRecordBytes+1), "v=spf1 -all"}, 1, true},
+		{"oversized policy record", []string{"v=spf1 " + strings.Repeat("a", MaxTXTRecordBytes)}, 0, true},
+		{"empty", nil, 0, false},
+	}
+	for _, tt := range tests {
+		bounded, anomaly := boundTXT(tt.records)
+		if len(bounded) != tt.wantKept || (anomaly != "") != tt.wantAnomaly {
+			t.Errorf("%s: kept %d, anomaly %q; want %d, anomaly %t", tt.name, len(bounded), anomaly, tt.wantKept, tt.wantAnomaly)
+		}
+	}
+}
+
+// zoneResolver answers TXT lookups from a fixed zone, NXDOMAIN for any name
+// the zone does not hold
+type zoneResolver struct {
+	Resolver
+	zone map[string][]string
+}
+
+func (r *zoneResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r.zone[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+// flooded buries policy behind hundreds of tokens and a few multi-KB records
+func flooded(policy ...string) []string {
+	records := fillerRecords(300)
+	for i := 0; i < 4; i++ {
+		records = append(records, strings.Repeat(string(rune('a'+i)), 3*1024))
+	}
+	return append(records, policy...)
+}
+
+func TestValidateSurvivesAbnormalTXT(t *testing.T) {
+	dkim := "v=DKIM1; k=rsa; p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC"
+	resolver := &zoneResolver{zone: map[string][]string{
+		"example.test":               flooded("v=spf1 ip4:192.0.2.0/24 -all"),
+		"_dmarc.example.test":        flooded("v=DMARC1; p=reject"),
+		"s1._domainkey.example.test": append([]string{dkim}, flooded()...),
+	}}
+	v := NewSecurityValidator(resolver, SecurityOptions{Timeout: time.Second})
+
+	result := v.Validate(context.Background(), "example.test", "s1")
+	if result.SPFRecord.Status != "pass" {
+		t.Errorf("SPF status = %q, want pass", result.SPFRecord.Status)
+	}
+	if result.DMARCRecord.Status != "pass" {
+		t.Errorf("DMARC status = %q, want pass", result.DMARCRecord.Status)
+	}
+	if !result.AbnormalTXT {
+		t.Error("AbnormalTXT = false for flooded answers")
+	}
+	for _, name := range []string{"example.test", "_dmarc.example.test", "s1._domainkey.example.test"} {
+		found := false
+		for _, anomaly := range result.TXTAnomalies {
+			found = found || strings.HasPrefix(anomaly, name+": ")
+		}
+		if !found {
+			t.Errorf("no anomaly reported for %s in %q", name, result.TXTAnomalies)
+		}
+	}
+	if len(result.DKIMSelectors) != 1 {
+		t.Fatalf("tried %d DKIM selectors, want 1", len(result.DKIMSelectors))
+	}
+	if record := result.DKIMSelectors[0].Record; !strings.HasPrefix(record, dkim) || len(record) > MaxTXTTotalBytes {
+		t.Errorf("DKIM record is %d bytes starting %.40q; want the bounded selector answer", len(record), record)
+	}
+}